@@ -1,6 +1,8 @@
 package gorumsfd
 
 import (
+	"time"
+
 	pb "dat520/lab3/gorumsfd/proto"
 )
 
@@ -31,6 +33,13 @@ type SuspectRestorer interface {
 	NodeIDs() []uint32
 }
 
+// LatencyObserver is the interface that wraps the ObserveRTT method.
+// ObserveRTT indicates that a round-trip time of rtt was measured from the
+// node with identifier from to the node with identifier to.
+type LatencyObserver interface {
+	ObserveRTT(from, to int, rtt time.Duration)
+}
+
 type mockLD struct {
 	nodes     []int
 	suspected []int
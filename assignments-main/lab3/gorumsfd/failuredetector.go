@@ -1,6 +1,8 @@
 package gorumsfd
 
 import (
+	"slices"
+	"sync"
 	"time"
 
 	pb "dat520/lab3/gorumsfd/proto"
@@ -10,15 +12,27 @@ import (
 
 // GorumsFailureDetector is a variant of the Eventually Perfect Failure Detector.
 type GorumsFailureDetector struct {
-	myID      uint32          // the id of this node
-	nodeIDs   []uint32        // list of node ids
-	alive     map[uint32]bool // map of node ids considered alive
-	suspected map[uint32]bool // map of node ids considered suspected
-	sr        SuspectRestorer // Provided SuspectRestorer implementation
-	delay     time.Duration   // the current delay for the timeout procedure
-	delta     time.Duration   // the delta value to be used when increasing delay
-	stop      chan struct{}   // channel for signaling a stop request to the main run loop
-	// TODO(student) add more fields if needed
+	myID      uint32                       // the id of this node
+	nodeIDs   []uint32                     // list of node ids
+	alive     map[uint32]bool              // map of node ids considered alive
+	suspected map[uint32]bool              // map of node ids considered suspected
+	sr        SuspectRestorer              // Provided SuspectRestorer implementation
+	delay     time.Duration                // the current delay for the timeout procedure
+	delta     time.Duration                // the delta value to be used when increasing delay
+	stop      chan struct{}                // channel for signaling a stop request to the main run loop
+	mu        sync.Mutex                   // protects alive, suspected, delay, received and rtt
+	stopOnce  sync.Once                    // ensures that the stop channel is only closed once
+	received  map[uint32]receivedHeartBeat // latest heartbeat received from each node
+	rtt       map[uint32]time.Duration     // smoothed RTT measured to each node
+	observer  LatencyObserver              // receives the measured RTTs; may be nil
+	now       func() time.Time             // returns the current time; replaced in tests
+}
+
+// receivedHeartBeat records when a heartbeat was received, so that it can be
+// echoed to its sender.
+type receivedHeartBeat struct {
+	sentAt     int64
+	receivedAt time.Time
 }
 
 // NewGorumsFailureDetector returns a new Eventual Failure Detector. It takes the
@@ -36,9 +50,19 @@ func NewGorumsFailureDetector(myID uint32, sr SuspectRestorer, delta time.Durati
 		delay:     delta,
 		delta:     delta,
 		stop:      make(chan struct{}),
+		received:  make(map[uint32]receivedHeartBeat),
+		rtt:       make(map[uint32]time.Duration),
+		now:       time.Now,
 	}
 }
 
+// SetLatencyObserver sets the observer of the RTTs measured by the heartbeats
+// of this node and reported by the heartbeats of other nodes.
+// It must be called before Start.
+func (e *GorumsFailureDetector) SetLatencyObserver(observer LatencyObserver) {
+	e.observer = observer
+}
+
 // Start starts the failure detector's run loop in a separate goroutine.
 // This function should perform the following functionalities:
 //  1. Periodically send heartbeats to all nodes in the configuration;
@@ -47,14 +71,40 @@ func NewGorumsFailureDetector(myID uint32, sr SuspectRestorer, delta time.Durati
 //  3. Wait to receive the signal to stop.
 func (e *GorumsFailureDetector) Start(hbSender func(*pb.HeartBeat)) {
 	go func() {
-		// TODO(student) complete Start method
+		// heartbeats are sent more often than the timeout procedure runs,
+		// so that a correct node's heartbeat is not missed due to timing jitter.
+		heartbeat := time.NewTicker(e.delta / 2)
+		defer heartbeat.Stop()
+		timer := time.NewTimer(e.currentDelay())
+		defer timer.Stop()
+		hbSender(e.heartbeat())
+		for {
+			select {
+			case <-heartbeat.C:
+				hbSender(e.heartbeat())
+			case <-timer.C:
+				e.timeout()
+				timer.Reset(e.currentDelay())
+			case <-e.stop:
+				return
+			}
+		}
 	}()
 }
 
+// currentDelay returns the current delay for the timeout procedure.
+func (e *GorumsFailureDetector) currentDelay() time.Duration {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+	return e.delay
+}
+
 // Stop stops the failure detector.
 // If the failure detector has already stopped, we return immediately.
 func (e *GorumsFailureDetector) Stop() {
-	// TODO(student) complete Stop method
+	e.stopOnce.Do(func() {
+		close(e.stop)
+	})
 }
 
 // timeout updates the status of the nodes to the SuspectRestorer.
@@ -66,10 +116,99 @@ func (e *GorumsFailureDetector) Stop() {
 // Christian Cachin, Rachid Guerraoui, and Luís Rodrigues: "Introduction to
 // Reliable and Secure Distributed Programming" Springer, 2nd edition, 2011.
 func (e *GorumsFailureDetector) timeout() {
-	// TODO(student) complete timeout method
+	e.mu.Lock()
+	var suspect, restore []int
+	for id := range e.alive {
+		if e.suspected[id] {
+			e.delay += e.delta
+			break
+		}
+	}
+	nodeIDs := slices.Clone(e.nodeIDs)
+	slices.Sort(nodeIDs)
+	for _, id := range nodeIDs {
+		switch {
+		case !e.alive[id] && !e.suspected[id]:
+			e.suspected[id] = true
+			suspect = append(suspect, int(id))
+		case e.alive[id] && e.suspected[id]:
+			delete(e.suspected, id)
+			restore = append(restore, int(id))
+		}
+	}
+	e.alive = make(map[uint32]bool)
+	e.mu.Unlock()
+
+	// report outside the lock, since the SuspectRestorer may block
+	for _, id := range suspect {
+		e.sr.Suspect(id)
+	}
+	for _, id := range restore {
+		e.sr.Restore(id)
+	}
 }
 
+// heartbeat returns the next heartbeat to send, echoing the latest heartbeat
+// received from each other node and carrying the latest measured RTTs.
+func (e *GorumsFailureDetector) heartbeat() *pb.HeartBeat {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+	now := e.now()
+	hb := &pb.HeartBeat{ID: e.myID, SentAt: now.UnixNano()}
+	for id, recv := range e.received {
+		hb.Echo = append(hb.Echo, &pb.Echo{ID: id, SentAt: recv.sentAt, Held: int64(now.Sub(recv.receivedAt))})
+	}
+	for id, rtt := range e.rtt {
+		hb.RTT = append(hb.RTT, &pb.RTT{ID: id, Nanos: int64(rtt)})
+	}
+	return hb
+}
+
+// rttSmoothing is the weight of a new sample in the smoothed RTT to a node.
+const rttSmoothing = 0.25
+
 // Heartbeat is a multicast call invoked on all nodes in the configuration.
+// If the heartbeat echoes a heartbeat sent by this node, the RTT to the sender
+// is the time since the echoed heartbeat was sent, less the time it was held.
+// The RTT is smoothed with an exponentially weighted moving average before it
+// is observed and reported to the other nodes, so that all nodes observe the
+// same smoothed RTTs.
 func (e *GorumsFailureDetector) Heartbeat(ctx gorums.ServerCtx, in *pb.HeartBeat) {
-	// TODO(student) complete Heartbeat method
+	e.mu.Lock()
+	e.alive[in.GetID()] = true
+	if in.GetID() == e.myID {
+		e.mu.Unlock()
+		return
+	}
+	now := e.now()
+	e.received[in.GetID()] = receivedHeartBeat{sentAt: in.GetSentAt(), receivedAt: now}
+	var measured time.Duration
+	for _, echo := range in.GetEcho() {
+		if echo.GetID() != e.myID || echo.GetSentAt() == 0 {
+			continue
+		}
+		rtt := now.Sub(time.Unix(0, echo.GetSentAt())) - time.Duration(echo.GetHeld())
+		if rtt <= 0 {
+			continue
+		}
+		if old, ok := e.rtt[in.GetID()]; ok {
+			rtt = old + time.Duration(rttSmoothing*float64(rtt-old))
+		}
+		e.rtt[in.GetID()] = rtt
+		measured = rtt
+	}
+	e.mu.Unlock()
+
+	// report outside the lock, since the observer may block
+	if e.observer == nil {
+		return
+	}
+	if measured > 0 {
+		e.observer.ObserveRTT(int(e.myID), int(in.GetID()), measured)
+	}
+	for _, rtt := range in.GetRTT() {
+		if rtt.GetID() != in.GetID() {
+			e.observer.ObserveRTT(int(in.GetID()), int(rtt.GetID()), time.Duration(rtt.GetNanos()))
+		}
+	}
 }
@@ -0,0 +1,133 @@
+package gorumsfd
+
+import (
+	"sync"
+	"testing"
+	"time"
+
+	pb "dat520/lab3/gorumsfd/proto"
+	"dat520/lab3/leaderdetector"
+
+	"github.com/relab/gorums"
+)
+
+// rttRecorder records the observed RTTs.
+type rttRecorder struct {
+	mockLD
+	mu   sync.Mutex
+	rtts map[[2]int]time.Duration
+}
+
+func (r *rttRecorder) ObserveRTT(from, to int, rtt time.Duration) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.rtts[[2]int{from, to}] = rtt
+}
+
+func TestHeartbeatRTT(t *testing.T) {
+	start := time.Unix(100, 0)
+	clock := func(d time.Duration) func() time.Time {
+		return func() time.Time { return start.Add(d) }
+	}
+	nodes := []int{0, 1, 2}
+	rec := &rttRecorder{mockLD: mockLD{nodes: nodes}, rtts: make(map[[2]int]time.Duration)}
+	fd0 := NewGorumsFailureDetector(0, rec, time.Second)
+	fd0.SetLatencyObserver(rec)
+	fd1 := NewGorumsFailureDetector(1, &mockLD{nodes: nodes}, time.Second)
+
+	// node 0 sends at 0ms; node 1 receives at 10ms and echoes at 15ms,
+	// reporting its RTT to node 2; node 0 receives the echo at 30ms.
+	fd0.now = clock(0)
+	hb0 := fd0.heartbeat()
+	fd1.now = clock(10 * time.Millisecond)
+	fd1.Heartbeat(gorums.ServerCtx{}, hb0)
+	fd1.rtt[2] = 42 * time.Millisecond
+	fd1.now = clock(15 * time.Millisecond)
+	hb1 := fd1.heartbeat()
+	fd0.now = clock(30 * time.Millisecond)
+	fd0.Heartbeat(gorums.ServerCtx{}, hb1)
+
+	want := map[[2]int]time.Duration{
+		{0, 1}: 25 * time.Millisecond,
+		{1, 2}: 42 * time.Millisecond,
+	}
+	if len(rec.rtts) != len(want) {
+		t.Errorf("observed RTTs = %v, want %v", rec.rtts, want)
+	}
+	for pair, rtt := range want {
+		if got := rec.rtts[pair]; got != rtt {
+			t.Errorf("RTT %d->%d = %v, want %v", pair[0], pair[1], got, rtt)
+		}
+	}
+	// node 0 reports its RTT in its next heartbeat
+	hb := fd0.heartbeat()
+	if len(hb.GetRTT()) != 1 || hb.GetRTT()[0].GetID() != 1 || hb.GetRTT()[0].GetNanos() != int64(25*time.Millisecond) {
+		t.Errorf("heartbeat RTTs = %v, want [ID:1 Nanos:25ms]", hb.GetRTT())
+	}
+}
+
+// TestLatencyLeaderPlacement runs failure detectors whose heartbeats are
+// delayed by simulated network latencies, and checks that the latency leader
+// detectors elect the node closest to a majority, rather than the highest id.
+func TestLatencyLeaderPlacement(t *testing.T) {
+	const delta = 200 * time.Millisecond
+	// nodes are placed on a line; the RTT between two nodes is their distance
+	// in milliseconds. Quorum latencies: 0: 20ms, 1: 10ms, 2: 20ms, 3: 30ms, 4: 80ms.
+	positions := []int{0, 10, 20, 40, 100}
+	nodeIDs := []int{0, 1, 2, 3, 4}
+	oneWay := func(from, to int) time.Duration {
+		return time.Duration(max(positions[from]-positions[to], positions[to]-positions[from])) * time.Millisecond / 2
+	}
+
+	fds := make([]*GorumsFailureDetector, len(nodeIDs))
+	lds := make([]*leaderdetector.LatencyLeaderDetector, len(nodeIDs))
+	for _, id := range nodeIDs {
+		lds[id] = leaderdetector.NewLatencyLeaderDetector(nodeIDs, leaderdetector.LatencyConfig{})
+		fds[id] = NewGorumsFailureDetector(uint32(id), lds[id], delta)
+		fds[id].SetLatencyObserver(lds[id])
+	}
+	for _, id := range nodeIDs {
+		fds[id].Start(func(hb *pb.HeartBeat) {
+			for to, fd := range fds {
+				time.AfterFunc(oneWay(id, to), func() { fd.Heartbeat(gorums.ServerCtx{}, hb) })
+			}
+		})
+	}
+	defer func() {
+		for _, fd := range fds {
+			fd.Stop()
+		}
+	}()
+
+	const wantLeader = 1
+	deadline := time.Now().Add(10 * time.Second)
+	for {
+		converged := true
+		for _, ld := range lds {
+			converged = converged && ld.Leader() == wantLeader
+		}
+		if converged {
+			break
+		}
+		if time.Now().After(deadline) {
+			for id, ld := range lds {
+				t.Errorf("node %d: Leader() = %d, want %d", id, ld.Leader(), wantLeader)
+			}
+			return
+		}
+		time.Sleep(10 * time.Millisecond)
+	}
+	// the measured RTTs match the simulated latencies
+	for _, from := range nodeIDs {
+		for _, to := range nodeIDs {
+			if from == to {
+				continue
+			}
+			got, ok := lds[0].RTT(from, to)
+			want := 2 * oneWay(from, to)
+			if !ok || got < want || got > want+30*time.Millisecond {
+				t.Errorf("RTT(%d, %d) = %v, %t; want about %v", from, to, got, ok, want)
+			}
+		}
+	}
+}
@@ -1,7 +1,7 @@
 // Code generated by protoc-gen-go. DO NOT EDIT.
 // versions:
-// 	protoc-gen-go v1.32.0
-// 	protoc        v4.25.2
+// 	protoc-gen-go v1.33.0
+// 	protoc        v4.25.3
 // source: fd.proto
 
 package proto
@@ -22,12 +22,19 @@ const (
 	_ = protoimpl.EnforceVersion(protoimpl.MaxVersion - 20)
 )
 
+// HeartBeat is sent periodically by every node. Heartbeats also measure the
+// round-trip time between nodes: each heartbeat echoes the send time of the
+// latest heartbeat received from each node, together with the time it was
+// held before the echo, and carries the sender's latest round-trip times.
 type HeartBeat struct {
 	state         protoimpl.MessageState
 	sizeCache     protoimpl.SizeCache
 	unknownFields protoimpl.UnknownFields
 
-	ID uint32 `protobuf:"varint,1,opt,name=ID,proto3" json:"ID,omitempty"`
+	ID     uint32  `protobuf:"varint,1,opt,name=ID,proto3" json:"ID,omitempty"`
+	SentAt int64   `protobuf:"varint,2,opt,name=SentAt,proto3" json:"SentAt,omitempty"` // send time in Unix nanoseconds on the sender's clock
+	Echo   []*Echo `protobuf:"bytes,3,rep,name=Echo,proto3" json:"Echo,omitempty"`
+	RTT    []*RTT  `protobuf:"bytes,4,rep,name=RTT,proto3" json:"RTT,omitempty"`
 }
 
 func (x *HeartBeat) Reset() {
@@ -69,22 +76,176 @@ func (x *HeartBeat) GetID() uint32 {
 	return 0
 }
 
+func (x *HeartBeat) GetSentAt() int64 {
+	if x != nil {
+		return x.SentAt
+	}
+	return 0
+}
+
+func (x *HeartBeat) GetEcho() []*Echo {
+	if x != nil {
+		return x.Echo
+	}
+	return nil
+}
+
+func (x *HeartBeat) GetRTT() []*RTT {
+	if x != nil {
+		return x.RTT
+	}
+	return nil
+}
+
+// Echo returns the send time of a heartbeat from node ID to that node.
+type Echo struct {
+	state         protoimpl.MessageState
+	sizeCache     protoimpl.SizeCache
+	unknownFields protoimpl.UnknownFields
+
+	ID     uint32 `protobuf:"varint,1,opt,name=ID,proto3" json:"ID,omitempty"`
+	SentAt int64  `protobuf:"varint,2,opt,name=SentAt,proto3" json:"SentAt,omitempty"` // the echoed heartbeat's SentAt
+	Held   int64  `protobuf:"varint,3,opt,name=Held,proto3" json:"Held,omitempty"`     // nanoseconds between receiving the heartbeat and sending the echo
+}
+
+func (x *Echo) Reset() {
+	*x = Echo{}
+	if protoimpl.UnsafeEnabled {
+		mi := &file_fd_proto_msgTypes[1]
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		ms.StoreMessageInfo(mi)
+	}
+}
+
+func (x *Echo) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*Echo) ProtoMessage() {}
+
+func (x *Echo) ProtoReflect() protoreflect.Message {
+	mi := &file_fd_proto_msgTypes[1]
+	if protoimpl.UnsafeEnabled && x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use Echo.ProtoReflect.Descriptor instead.
+func (*Echo) Descriptor() ([]byte, []int) {
+	return file_fd_proto_rawDescGZIP(), []int{1}
+}
+
+func (x *Echo) GetID() uint32 {
+	if x != nil {
+		return x.ID
+	}
+	return 0
+}
+
+func (x *Echo) GetSentAt() int64 {
+	if x != nil {
+		return x.SentAt
+	}
+	return 0
+}
+
+func (x *Echo) GetHeld() int64 {
+	if x != nil {
+		return x.Held
+	}
+	return 0
+}
+
+// RTT is the round-trip time measured from the heartbeat's sender to node ID.
+type RTT struct {
+	state         protoimpl.MessageState
+	sizeCache     protoimpl.SizeCache
+	unknownFields protoimpl.UnknownFields
+
+	ID    uint32 `protobuf:"varint,1,opt,name=ID,proto3" json:"ID,omitempty"`
+	Nanos int64  `protobuf:"varint,2,opt,name=Nanos,proto3" json:"Nanos,omitempty"`
+}
+
+func (x *RTT) Reset() {
+	*x = RTT{}
+	if protoimpl.UnsafeEnabled {
+		mi := &file_fd_proto_msgTypes[2]
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		ms.StoreMessageInfo(mi)
+	}
+}
+
+func (x *RTT) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*RTT) ProtoMessage() {}
+
+func (x *RTT) ProtoReflect() protoreflect.Message {
+	mi := &file_fd_proto_msgTypes[2]
+	if protoimpl.UnsafeEnabled && x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use RTT.ProtoReflect.Descriptor instead.
+func (*RTT) Descriptor() ([]byte, []int) {
+	return file_fd_proto_rawDescGZIP(), []int{2}
+}
+
+func (x *RTT) GetID() uint32 {
+	if x != nil {
+		return x.ID
+	}
+	return 0
+}
+
+func (x *RTT) GetNanos() int64 {
+	if x != nil {
+		return x.Nanos
+	}
+	return 0
+}
+
 var File_fd_proto protoreflect.FileDescriptor
 
 var file_fd_proto_rawDesc = []byte{
 	0x0a, 0x08, 0x66, 0x64, 0x2e, 0x70, 0x72, 0x6f, 0x74, 0x6f, 0x12, 0x05, 0x70, 0x72, 0x6f, 0x74,
 	0x6f, 0x1a, 0x0c, 0x67, 0x6f, 0x72, 0x75, 0x6d, 0x73, 0x2e, 0x70, 0x72, 0x6f, 0x74, 0x6f, 0x1a,
 	0x1b, 0x67, 0x6f, 0x6f, 0x67, 0x6c, 0x65, 0x2f, 0x70, 0x72, 0x6f, 0x74, 0x6f, 0x62, 0x75, 0x66,
-	0x2f, 0x65, 0x6d, 0x70, 0x74, 0x79, 0x2e, 0x70, 0x72, 0x6f, 0x74, 0x6f, 0x22, 0x1b, 0x0a, 0x09,
+	0x2f, 0x65, 0x6d, 0x70, 0x74, 0x79, 0x2e, 0x70, 0x72, 0x6f, 0x74, 0x6f, 0x22, 0x72, 0x0a, 0x09,
 	0x48, 0x65, 0x61, 0x72, 0x74, 0x42, 0x65, 0x61, 0x74, 0x12, 0x0e, 0x0a, 0x02, 0x49, 0x44, 0x18,
-	0x01, 0x20, 0x01, 0x28, 0x0d, 0x52, 0x02, 0x49, 0x44, 0x32, 0x4e, 0x0a, 0x0f, 0x46, 0x61, 0x69,
-	0x6c, 0x75, 0x72, 0x65, 0x44, 0x65, 0x74, 0x65, 0x63, 0x74, 0x6f, 0x72, 0x12, 0x3b, 0x0a, 0x09,
-	0x48, 0x65, 0x61, 0x72, 0x74, 0x62, 0x65, 0x61, 0x74, 0x12, 0x10, 0x2e, 0x70, 0x72, 0x6f, 0x74,
-	0x6f, 0x2e, 0x48, 0x65, 0x61, 0x72, 0x74, 0x42, 0x65, 0x61, 0x74, 0x1a, 0x16, 0x2e, 0x67, 0x6f,
-	0x6f, 0x67, 0x6c, 0x65, 0x2e, 0x70, 0x72, 0x6f, 0x74, 0x6f, 0x62, 0x75, 0x66, 0x2e, 0x45, 0x6d,
-	0x70, 0x74, 0x79, 0x22, 0x04, 0x98, 0xb5, 0x18, 0x01, 0x42, 0x1c, 0x5a, 0x1a, 0x64, 0x61, 0x74,
-	0x35, 0x32, 0x30, 0x2f, 0x6c, 0x61, 0x62, 0x33, 0x2f, 0x67, 0x6f, 0x72, 0x75, 0x6d, 0x73, 0x66,
-	0x64, 0x2f, 0x70, 0x72, 0x6f, 0x74, 0x6f, 0x62, 0x06, 0x70, 0x72, 0x6f, 0x74, 0x6f, 0x33,
+	0x01, 0x20, 0x01, 0x28, 0x0d, 0x52, 0x02, 0x49, 0x44, 0x12, 0x16, 0x0a, 0x06, 0x53, 0x65, 0x6e,
+	0x74, 0x41, 0x74, 0x18, 0x02, 0x20, 0x01, 0x28, 0x03, 0x52, 0x06, 0x53, 0x65, 0x6e, 0x74, 0x41,
+	0x74, 0x12, 0x1f, 0x0a, 0x04, 0x45, 0x63, 0x68, 0x6f, 0x18, 0x03, 0x20, 0x03, 0x28, 0x0b, 0x32,
+	0x0b, 0x2e, 0x70, 0x72, 0x6f, 0x74, 0x6f, 0x2e, 0x45, 0x63, 0x68, 0x6f, 0x52, 0x04, 0x45, 0x63,
+	0x68, 0x6f, 0x12, 0x1c, 0x0a, 0x03, 0x52, 0x54, 0x54, 0x18, 0x04, 0x20, 0x03, 0x28, 0x0b, 0x32,
+	0x0a, 0x2e, 0x70, 0x72, 0x6f, 0x74, 0x6f, 0x2e, 0x52, 0x54, 0x54, 0x52, 0x03, 0x52, 0x54, 0x54,
+	0x22, 0x42, 0x0a, 0x04, 0x45, 0x63, 0x68, 0x6f, 0x12, 0x0e, 0x0a, 0x02, 0x49, 0x44, 0x18, 0x01,
+	0x20, 0x01, 0x28, 0x0d, 0x52, 0x02, 0x49, 0x44, 0x12, 0x16, 0x0a, 0x06, 0x53, 0x65, 0x6e, 0x74,
+	0x41, 0x74, 0x18, 0x02, 0x20, 0x01, 0x28, 0x03, 0x52, 0x06, 0x53, 0x65, 0x6e, 0x74, 0x41, 0x74,
+	0x12, 0x12, 0x0a, 0x04, 0x48, 0x65, 0x6c, 0x64, 0x18, 0x03, 0x20, 0x01, 0x28, 0x03, 0x52, 0x04,
+	0x48, 0x65, 0x6c, 0x64, 0x22, 0x2b, 0x0a, 0x03, 0x52, 0x54, 0x54, 0x12, 0x0e, 0x0a, 0x02, 0x49,
+	0x44, 0x18, 0x01, 0x20, 0x01, 0x28, 0x0d, 0x52, 0x02, 0x49, 0x44, 0x12, 0x14, 0x0a, 0x05, 0x4e,
+	0x61, 0x6e, 0x6f, 0x73, 0x18, 0x02, 0x20, 0x01, 0x28, 0x03, 0x52, 0x05, 0x4e, 0x61, 0x6e, 0x6f,
+	0x73, 0x32, 0x4e, 0x0a, 0x0f, 0x46, 0x61, 0x69, 0x6c, 0x75, 0x72, 0x65, 0x44, 0x65, 0x74, 0x65,
+	0x63, 0x74, 0x6f, 0x72, 0x12, 0x3b, 0x0a, 0x09, 0x48, 0x65, 0x61, 0x72, 0x74, 0x62, 0x65, 0x61,
+	0x74, 0x12, 0x10, 0x2e, 0x70, 0x72, 0x6f, 0x74, 0x6f, 0x2e, 0x48, 0x65, 0x61, 0x72, 0x74, 0x42,
+	0x65, 0x61, 0x74, 0x1a, 0x16, 0x2e, 0x67, 0x6f, 0x6f, 0x67, 0x6c, 0x65, 0x2e, 0x70, 0x72, 0x6f,
+	0x74, 0x6f, 0x62, 0x75, 0x66, 0x2e, 0x45, 0x6d, 0x70, 0x74, 0x79, 0x22, 0x04, 0x98, 0xb5, 0x18,
+	0x01, 0x42, 0x1c, 0x5a, 0x1a, 0x64, 0x61, 0x74, 0x35, 0x32, 0x30, 0x2f, 0x6c, 0x61, 0x62, 0x33,
+	0x2f, 0x67, 0x6f, 0x72, 0x75, 0x6d, 0x73, 0x66, 0x64, 0x2f, 0x70, 0x72, 0x6f, 0x74, 0x6f, 0x62,
+	0x06, 0x70, 0x72, 0x6f, 0x74, 0x6f, 0x33,
 }
 
 var (
@@ -99,19 +260,23 @@ func file_fd_proto_rawDescGZIP() []byte {
 	return file_fd_proto_rawDescData
 }
 
-var file_fd_proto_msgTypes = make([]protoimpl.MessageInfo, 1)
+var file_fd_proto_msgTypes = make([]protoimpl.MessageInfo, 3)
 var file_fd_proto_goTypes = []interface{}{
 	(*HeartBeat)(nil),     // 0: proto.HeartBeat
-	(*emptypb.Empty)(nil), // 1: google.protobuf.Empty
+	(*Echo)(nil),          // 1: proto.Echo
+	(*RTT)(nil),           // 2: proto.RTT
+	(*emptypb.Empty)(nil), // 3: google.protobuf.Empty
 }
 var file_fd_proto_depIdxs = []int32{
-	0, // 0: proto.FailureDetector.Heartbeat:input_type -> proto.HeartBeat
-	1, // 1: proto.FailureDetector.Heartbeat:output_type -> google.protobuf.Empty
-	1, // [1:2] is the sub-list for method output_type
-	0, // [0:1] is the sub-list for method input_type
-	0, // [0:0] is the sub-list for extension type_name
-	0, // [0:0] is the sub-list for extension extendee
-	0, // [0:0] is the sub-list for field type_name
+	1, // 0: proto.HeartBeat.Echo:type_name -> proto.Echo
+	2, // 1: proto.HeartBeat.RTT:type_name -> proto.RTT
+	0, // 2: proto.FailureDetector.Heartbeat:input_type -> proto.HeartBeat
+	3, // 3: proto.FailureDetector.Heartbeat:output_type -> google.protobuf.Empty
+	3, // [3:4] is the sub-list for method output_type
+	2, // [2:3] is the sub-list for method input_type
+	2, // [2:2] is the sub-list for extension type_name
+	2, // [2:2] is the sub-list for extension extendee
+	0, // [0:2] is the sub-list for field type_name
 }
 
 func init() { file_fd_proto_init() }
@@ -132,6 +297,30 @@ func file_fd_proto_init() {
 				return nil
 			}
 		}
+		file_fd_proto_msgTypes[1].Exporter = func(v interface{}, i int) interface{} {
+			switch v := v.(*Echo); i {
+			case 0:
+				return &v.state
+			case 1:
+				return &v.sizeCache
+			case 2:
+				return &v.unknownFields
+			default:
+				return nil
+			}
+		}
+		file_fd_proto_msgTypes[2].Exporter = func(v interface{}, i int) interface{} {
+			switch v := v.(*RTT); i {
+			case 0:
+				return &v.state
+			case 1:
+				return &v.sizeCache
+			case 2:
+				return &v.unknownFields
+			default:
+				return nil
+			}
+		}
 	}
 	type x struct{}
 	out := protoimpl.TypeBuilder{
@@ -139,7 +328,7 @@ func file_fd_proto_init() {
 			GoPackagePath: reflect.TypeOf(x{}).PkgPath(),
 			RawDescriptor: file_fd_proto_rawDesc,
 			NumEnums:      0,
-			NumMessages:   1,
+			NumMessages:   3,
 			NumExtensions: 0,
 			NumServices:   1,
 		},
@@ -0,0 +1,265 @@
+package leaderdetector
+
+import (
+	"math"
+	"slices"
+	"sync"
+	"time"
+)
+
+const (
+	// DefaultHysteresis is the fraction by which a node's quorum latency may
+	// exceed the lowest quorum latency for the node to be elected.
+	DefaultHysteresis = 0.2
+	// unknownLatency is the latency of a node whose RTTs to a quorum are unknown.
+	unknownLatency = time.Duration(math.MaxInt64)
+)
+
+// LatencyConfig configures the leader placement of a LatencyLeaderDetector.
+type LatencyConfig struct {
+	// Priorities is an operator-supplied preference order of node ids, e.g.,
+	// listing the nodes closest to most clients first. The first non-suspected
+	// node in the list is the leader; other nodes are only elected if all the
+	// listed nodes are suspected.
+	Priorities []int
+	// Hysteresis is the fraction by which a node's quorum latency may exceed
+	// the lowest quorum latency for the node to be elected.
+	// Zero means DefaultHysteresis.
+	Hysteresis float64
+	// MinHold is the minimum duration between two leader changes caused by
+	// latency. Leader changes caused by suspicions are never held back.
+	MinHold time.Duration
+}
+
+// A LatencyLeaderDetector is an eventual leader detector that elects a node
+// with a low quorum latency among the non-suspected nodes. The quorum latency
+// of a node is the time it takes the node to reach a majority of the nodes,
+// including itself, that is, the largest RTT to the majority's nearest other
+// nodes. The leader is the highest node id among the nodes whose quorum
+// latency is within the configured hysteresis of the lowest one, so that
+// small differences between the latencies do not change the leader; if no
+// quorum latency is known, the leader is the highest node id, as in
+// MonLeaderDetector.
+//
+// RTTs are reported with ObserveRTT for every pair of nodes, for instance by
+// the failure detector's heartbeats. The latest RTT reported for a pair is
+// used as is; the node measuring the RTT should smooth it before reporting.
+// The leader therefore only depends on the reported RTTs and the suspected
+// nodes, and the detectors of all nodes elect the same leader once they have
+// received the same reports, whatever the order they received them in.
+type LatencyLeaderDetector struct {
+	mu          sync.Mutex
+	nodeIDs     []int                         // sorted list of valid (non-negative) node ids
+	suspected   map[int]bool                  // map of suspected node ids
+	rtt         map[int]map[int]time.Duration // latest reported RTTs, by source and destination node id
+	cfg         LatencyConfig
+	leader      int        // current leader
+	changed     time.Time  // time of the last latency-driven leader change
+	subscribers []chan int // channels of the subscribers
+	now         func() time.Time
+}
+
+// NewLatencyLeaderDetector returns a new latency-aware leader detector given
+// a list of node ids and the configuration of its leader placement.
+func NewLatencyLeaderDetector(nodeIDs []int, cfg LatencyConfig) *LatencyLeaderDetector {
+	ids := make([]int, 0, len(nodeIDs))
+	for _, id := range nodeIDs {
+		if id >= 0 && !slices.Contains(ids, id) {
+			ids = append(ids, id)
+		}
+	}
+	slices.Sort(ids)
+	if cfg.Hysteresis <= 0 {
+		cfg.Hysteresis = DefaultHysteresis
+	}
+	m := &LatencyLeaderDetector{
+		nodeIDs:   ids,
+		suspected: make(map[int]bool),
+		rtt:       make(map[int]map[int]time.Duration),
+		cfg:       cfg,
+		now:       time.Now,
+	}
+	m.leader = m.preferred()
+	return m
+}
+
+// NodeIDs returns the list of node ids.
+func (m *LatencyLeaderDetector) NodeIDs() []uint32 {
+	ids := make([]uint32, len(m.nodeIDs))
+	for i, id := range m.nodeIDs {
+		ids[i] = uint32(id)
+	}
+	return ids
+}
+
+// Leader returns the current leader. Leader will return UnknownID if all nodes
+// are suspected.
+func (m *LatencyLeaderDetector) Leader() int {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	return m.leader
+}
+
+// Suspect instructs the leader detector to consider the node with matching
+// id as suspected, publishing a leader change to its subscribers.
+func (m *LatencyLeaderDetector) Suspect(id int) {
+	m.update(id, true)
+}
+
+// Restore instructs the leader detector to consider the node with matching
+// id as restored, publishing a leader change to its subscribers.
+func (m *LatencyLeaderDetector) Restore(id int) {
+	m.update(id, false)
+}
+
+// ObserveRTT records a round-trip time measured from node from to node to,
+// publishing a leader change to its subscribers.
+func (m *LatencyLeaderDetector) ObserveRTT(from, to int, rtt time.Duration) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	if from == to || rtt <= 0 || !slices.Contains(m.nodeIDs, from) || !slices.Contains(m.nodeIDs, to) {
+		return
+	}
+	row, ok := m.rtt[from]
+	if !ok {
+		row = make(map[int]time.Duration)
+		m.rtt[from] = row
+	}
+	row[to] = rtt
+	m.elect()
+}
+
+// RTT returns the latest reported RTT between the two nodes, measured in
+// either direction, and whether it is known.
+func (m *LatencyLeaderDetector) RTT(from, to int) (time.Duration, bool) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	return m.pairRTT(from, to)
+}
+
+// Subscribe returns a buffered channel which will be used by the leader
+// detector to publish the id of the elected node.
+// The leader detector will publish UnknownID if all nodes become suspected.
+// Subscribe will drop publications to slow subscribers.
+// Note: Subscribe returns a unique channel to every subscriber;
+// it is not meant to be shared.
+func (m *LatencyLeaderDetector) Subscribe() <-chan int {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	ch := make(chan int, 10)
+	m.subscribers = append(m.subscribers, ch)
+	return ch
+}
+
+// update marks the node as suspected or restored and publishes the new
+// leader if the leader changed. Unknown node ids are ignored.
+func (m *LatencyLeaderDetector) update(id int, suspected bool) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	if !slices.Contains(m.nodeIDs, id) {
+		return
+	}
+	if suspected {
+		m.suspected[id] = true
+	} else {
+		delete(m.suspected, id)
+	}
+	m.elect()
+}
+
+// elect replaces the leader with the preferred node, if needed, and publishes
+// the new leader. The leader is replaced at once if it is suspected or the
+// priority list prefers another node; otherwise, the leader must have been
+// held for MinHold. The hold only delays the change: the preferred node does
+// not depend on the current leader, so a held back change is made by the next
+// election after MinHold. The caller must hold m.mu.
+func (m *LatencyLeaderDetector) elect() {
+	leader := m.preferred()
+	if leader == m.leader {
+		return
+	}
+	if m.latencyDriven(leader) {
+		now := m.now()
+		if now.Sub(m.changed) < m.cfg.MinHold {
+			return
+		}
+		m.changed = now
+	}
+	m.leader = leader
+	for _, ch := range m.subscribers {
+		select {
+		case ch <- leader:
+		default: // drop publication to slow subscriber
+		}
+	}
+}
+
+// latencyDriven returns true if replacing the current leader with the
+// candidate is only motivated by latency. The caller must hold m.mu.
+func (m *LatencyLeaderDetector) latencyDriven(candidate int) bool {
+	return m.leader != UnknownID && candidate != UnknownID && !m.suspected[m.leader] &&
+		!slices.Contains(m.cfg.Priorities, m.leader) && !slices.Contains(m.cfg.Priorities, candidate)
+}
+
+// preferred returns the first non-suspected node in the priority list, if
+// any, or otherwise the highest non-suspected node whose quorum latency is
+// within the hysteresis of the lowest quorum latency.
+// It returns UnknownID if all nodes are suspected. The caller must hold m.mu.
+func (m *LatencyLeaderDetector) preferred() int {
+	for _, id := range m.cfg.Priorities {
+		if slices.Contains(m.nodeIDs, id) && !m.suspected[id] {
+			return id
+		}
+	}
+	latencies := make(map[int]time.Duration, len(m.nodeIDs))
+	best := unknownLatency
+	for _, id := range m.nodeIDs {
+		if !m.suspected[id] {
+			latencies[id] = m.latency(id)
+			best = min(best, latencies[id])
+		}
+	}
+	limit := unknownLatency
+	if best < unknownLatency {
+		limit = best + time.Duration(m.cfg.Hysteresis*float64(best))
+	}
+	for i := len(m.nodeIDs) - 1; i >= 0; i-- {
+		if latency, ok := latencies[m.nodeIDs[i]]; ok && latency <= limit {
+			return m.nodeIDs[i]
+		}
+	}
+	return UnknownID
+}
+
+// latency returns the quorum latency of the node, or unknownLatency if its
+// RTTs to the nodes of a majority are unknown. The caller must hold m.mu.
+func (m *LatencyLeaderDetector) latency(id int) time.Duration {
+	others := len(m.nodeIDs) / 2 // the other nodes of a majority
+	if others == 0 {
+		return 0
+	}
+	rtts := make([]time.Duration, 0, len(m.nodeIDs))
+	for _, other := range m.nodeIDs {
+		if other == id || m.suspected[other] {
+			continue
+		}
+		if rtt, ok := m.pairRTT(id, other); ok {
+			rtts = append(rtts, rtt)
+		}
+	}
+	if len(rtts) < others {
+		return unknownLatency
+	}
+	slices.Sort(rtts)
+	return rtts[others-1]
+}
+
+// pairRTT returns the RTT measured from node from to node to, or if unknown,
+// the RTT measured in the opposite direction. The caller must hold m.mu.
+func (m *LatencyLeaderDetector) pairRTT(from, to int) (time.Duration, bool) {
+	if rtt, ok := m.rtt[from][to]; ok {
+		return rtt, true
+	}
+	rtt, ok := m.rtt[to][from]
+	return rtt, ok
+}
@@ -0,0 +1,169 @@
+package leaderdetector
+
+import (
+	"slices"
+	"testing"
+	"time"
+)
+
+const ms = time.Millisecond
+
+// rtt is a round-trip time observed from one node to another.
+type rtt struct {
+	from, to int
+	rtt      time.Duration
+}
+
+// line returns the RTTs between nodes placed on a line at the given positions,
+// in milliseconds.
+func line(positions ...int) []rtt {
+	var rtts []rtt
+	for i, p := range positions {
+		for j, q := range positions {
+			if i != j {
+				rtts = append(rtts, rtt{from: i, to: j, rtt: time.Duration(max(p-q, q-p)) * ms})
+			}
+		}
+	}
+	return rtts
+}
+
+func TestLatencyLeaderDetector(t *testing.T) {
+	tests := []struct {
+		name       string
+		nodes      []int
+		cfg        LatencyConfig
+		rtts       []rtt
+		events     []event
+		wantLeader int
+	}{
+		{name: "NoRTTs", nodes: []int{0, 1, 2}, wantLeader: 2},
+		{name: "NoRTTsSuspect", nodes: []int{0, 1, 2}, events: []event{{S, 2}}, wantLeader: 1},
+		{name: "AllSuspected", nodes: []int{0, 1}, events: []event{{S, 0}, {S, 1}}, wantLeader: UnknownID},
+		// quorum latencies: 0: 20ms, 1: 10ms, 2: 20ms, 3: 30ms, 4: 80ms
+		{name: "Central", nodes: []int{0, 1, 2, 3, 4}, rtts: line(0, 10, 20, 40, 100), wantLeader: 1},
+		// quorum latencies: 0: 18ms, 1: 9ms, 2: 10ms, 3: 19ms, 4: 82ms;
+		// 1 is 10% faster than 2, which is within the hysteresis
+		{name: "WithinHysteresis", nodes: []int{0, 1, 2, 3, 4}, rtts: line(0, 9, 18, 28, 100), wantLeader: 2},
+		{name: "BeyondHysteresis", nodes: []int{0, 1, 2, 3, 4}, cfg: LatencyConfig{Hysteresis: 0.05}, rtts: line(0, 9, 18, 28, 100), wantLeader: 1},
+		{name: "CentralSuspected", nodes: []int{0, 1, 2, 3, 4}, rtts: line(0, 10, 20, 40, 100), events: []event{{S, 1}}, wantLeader: 2},
+		{name: "CentralRestored", nodes: []int{0, 1, 2, 3, 4}, rtts: line(0, 10, 20, 40, 100), events: []event{{S, 1}, {R, 1}}, wantLeader: 1},
+		// only node 0's RTTs to a quorum are known
+		{name: "PartiallyKnown", nodes: []int{0, 1, 2}, rtts: []rtt{{0, 1, 50 * ms}}, wantLeader: 1},
+		{name: "OneWayKnown", nodes: []int{0, 1, 2, 3, 4}, rtts: []rtt{{0, 1, 50 * ms}, {0, 2, 50 * ms}}, wantLeader: 0},
+		{name: "Priorities", nodes: []int{0, 1, 2, 3, 4}, cfg: LatencyConfig{Priorities: []int{3, 0}}, rtts: line(0, 10, 20, 40, 100), wantLeader: 3},
+		{name: "PrioritiesSuspected", nodes: []int{0, 1, 2, 3, 4}, cfg: LatencyConfig{Priorities: []int{3, 0}}, rtts: line(0, 10, 20, 40, 100), events: []event{{S, 3}}, wantLeader: 0},
+		// quorum latencies without the suspected nodes: 1: 90ms, 2: 80ms, 4: 90ms
+		{name: "PrioritiesAllSuspected", nodes: []int{0, 1, 2, 3, 4}, cfg: LatencyConfig{Priorities: []int{3, 0}, Hysteresis: 0.1}, rtts: line(0, 10, 20, 40, 100), events: []event{{S, 3}, {S, 0}}, wantLeader: 2},
+		{name: "PrioritiesUnknownNode", nodes: []int{0, 1, 2}, cfg: LatencyConfig{Priorities: []int{7, 0}}, wantLeader: 0},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			ld := NewLatencyLeaderDetector(test.nodes, test.cfg)
+			for _, r := range test.rtts {
+				ld.ObserveRTT(r.from, r.to, r.rtt)
+			}
+			for _, e := range test.events {
+				if e.eType == S {
+					ld.Suspect(e.id)
+				} else {
+					ld.Restore(e.id)
+				}
+			}
+			if got := ld.Leader(); got != test.wantLeader {
+				t.Errorf("Leader() = %d, want %d", got, test.wantLeader)
+			}
+		})
+	}
+}
+
+func TestLatencyLeaderDetectorHysteresis(t *testing.T) {
+	now := time.Unix(0, 0)
+	ld := NewLatencyLeaderDetector([]int{0, 1, 2, 3, 4}, LatencyConfig{MinHold: 10 * time.Second})
+	ld.now = func() time.Time { return now }
+	leaders := ld.Subscribe()
+	observe := func(rtts []rtt) {
+		for _, r := range rtts {
+			ld.ObserveRTT(r.from, r.to, r.rtt)
+		}
+	}
+	wantLeader := func(want int) {
+		t.Helper()
+		if got := ld.Leader(); got != want {
+			t.Errorf("Leader() = %d, want %d", got, want)
+		}
+	}
+
+	// quorum latencies: 0: 20ms, 1: 10ms, 2: 20ms, 3: 30ms, 4: 80ms
+	observe(line(0, 10, 20, 40, 100))
+	now = now.Add(time.Minute) // let the leader settle after the first RTTs
+	observe(line(0, 10, 20, 40, 100))
+	wantLeader(1)
+	for len(leaders) > 0 {
+		<-leaders
+	}
+	// quorum latencies: 0: 14ms, 1: 8ms, 2: 4ms, 3: 8ms, 4: 86ms;
+	// 2 is much faster than 1, but the leader is held for MinHold
+	now = now.Add(time.Second)
+	observe(line(0, 10, 14, 18, 100))
+	wantLeader(1)
+	now = now.Add(10 * time.Second)
+	observe(line(0, 10, 14, 18, 100))
+	wantLeader(2)
+	// quorum latencies: 0: 18ms, 1: 9ms, 2: 10ms, 3: 19ms, 4: 82ms;
+	// 1 is 10% faster than 2, which is within the hysteresis, and the leaders
+	// preferred while the RTTs were partially updated are held back
+	now = now.Add(time.Second)
+	observe(line(0, 9, 18, 28, 100))
+	wantLeader(2)
+	now = now.Add(10 * time.Second)
+	observe(line(0, 9, 18, 28, 100))
+	wantLeader(2)
+	// suspicions are not held back
+	ld.Suspect(2)
+	wantLeader(1)
+
+	var published []int
+	for len(leaders) > 0 {
+		published = append(published, <-leaders)
+	}
+	if len(published) != 2 || published[0] != 2 || published[1] != 1 {
+		t.Errorf("published leaders = %v, want [2 1]", published)
+	}
+}
+
+// TestLatencyLeaderDetectorConvergence checks that detectors receiving the
+// same RTT reports in different orders, and with different reports in
+// between, elect the same leader once they have received the latest reports.
+func TestLatencyLeaderDetectorConvergence(t *testing.T) {
+	nodes := []int{0, 1, 2}
+	// quorum latencies: 0: 12ms, 1: 10ms, 2: 10ms
+	latest := []rtt{{0, 1, 12 * ms}, {0, 2, 50 * ms}, {1, 2, 10 * ms}}
+	reversed := slices.Clone(latest)
+	slices.Reverse(reversed)
+	tests := []struct {
+		name    string
+		reports []rtt
+	}{
+		{name: "Latest", reports: latest},
+		{name: "Reversed", reports: reversed},
+		// earlier reports made 1 faster than 2 by more than the hysteresis
+		{name: "FasterOne", reports: append([]rtt{{0, 1, 10 * ms}, {0, 2, 50 * ms}, {1, 2, 30 * ms}}, latest...)},
+		// earlier reports made 0 the fastest
+		{name: "FasterZero", reports: append([]rtt{{0, 1, 5 * ms}, {0, 2, 5 * ms}, {1, 2, 30 * ms}}, reversed...)},
+		// the node's own row is received before the other nodes' rows
+		{name: "OwnRowFirst", reports: append([]rtt{{1, 2, 10 * ms}, {1, 0, 12 * ms}}, latest...)},
+	}
+	const wantLeader = 2
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			ld := NewLatencyLeaderDetector(nodes, LatencyConfig{})
+			for _, r := range test.reports {
+				ld.ObserveRTT(r.from, r.to, r.rtt)
+			}
+			if got := ld.Leader(); got != wantLeader {
+				t.Errorf("Leader() = %d, want %d", got, wantLeader)
+			}
+		})
+	}
+}
@@ -1,46 +1,69 @@
 package leaderdetector
 
+import (
+	"slices"
+	"sync"
+)
+
 // A MonLeaderDetector represents a Monarchical Eventual Leader Detector as
 // described at page 53 in:
 // Christian Cachin, Rachid Guerraoui, and Luís Rodrigues: "Introduction to
 // Reliable and Secure Distributed Programming" Springer, 2nd edition, 2011.
 type MonLeaderDetector struct {
-	// TODO(student): Add needed fields
+	mu          sync.Mutex
+	nodeIDs     []int        // sorted list of valid (non-negative) node ids
+	suspected   map[int]bool // map of suspected node ids
+	leader      int          // current leader
+	subscribers []chan int   // channels of the subscribers
 }
 
 // NewMonLeaderDetector returns a new Monarchical Eventual Leader Detector
 // given a list of node ids.
 func NewMonLeaderDetector(nodeIDs []int) *MonLeaderDetector {
-	// TODO(student): Add needed implementation
-	m := &MonLeaderDetector{}
+	ids := make([]int, 0, len(nodeIDs))
+	for _, id := range nodeIDs {
+		if id >= 0 && !slices.Contains(ids, id) {
+			ids = append(ids, id)
+		}
+	}
+	slices.Sort(ids)
+	m := &MonLeaderDetector{
+		nodeIDs:   ids,
+		suspected: make(map[int]bool),
+	}
+	m.leader = m.highestRanked()
 	return m
 }
 
 // NodeIDs returns the list of node ids.
 func (m *MonLeaderDetector) NodeIDs() []uint32 {
-	// TODO(student): Implement
-	return []uint32{}
+	ids := make([]uint32, len(m.nodeIDs))
+	for i, id := range m.nodeIDs {
+		ids[i] = uint32(id)
+	}
+	return ids
 }
 
 // Leader returns the current leader. Leader will return UnknownID if all nodes
 // are suspected.
 func (m *MonLeaderDetector) Leader() int {
-	// TODO(student): Implement
-	return UnknownID
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	return m.leader
 }
 
 // Suspect instructs the leader detector to consider the node with matching
 // id as suspected. If the suspect indication result in a leader change
 // the leader detector should publish this change to its subscribers.
 func (m *MonLeaderDetector) Suspect(id int) {
-	// TODO(student): Implement
+	m.update(id, true)
 }
 
 // Restore instructs the leader detector to consider the node with matching
 // id as restored. If the restore indication result in a leader change
 // the leader detector should publish this change to its subscribers.
 func (m *MonLeaderDetector) Restore(id int) {
-	// TODO(student): Implement
+	m.update(id, false)
 }
 
 // Subscribe returns a buffered channel which will be used by the leader
@@ -50,8 +73,46 @@ func (m *MonLeaderDetector) Restore(id int) {
 // Note: Subscribe returns a unique channel to every subscriber;
 // it is not meant to be shared.
 func (m *MonLeaderDetector) Subscribe() <-chan int {
-	// TODO(student): Implement
-	return nil
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	ch := make(chan int, 10)
+	m.subscribers = append(m.subscribers, ch)
+	return ch
+}
+
+// update marks the node as suspected or restored and publishes the new
+// leader if the leader changed. Unknown node ids are ignored.
+func (m *MonLeaderDetector) update(id int, suspected bool) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	if !slices.Contains(m.nodeIDs, id) {
+		return
+	}
+	if suspected {
+		m.suspected[id] = true
+	} else {
+		delete(m.suspected, id)
+	}
+	leader := m.highestRanked()
+	if leader == m.leader {
+		return
+	}
+	m.leader = leader
+	for _, ch := range m.subscribers {
+		select {
+		case ch <- leader:
+		default: // drop publication to slow subscriber
+		}
+	}
 }
 
-// TODO(student): Add other unexported functions or methods if needed.
+// highestRanked returns the highest non-suspected node id, or UnknownID if
+// all nodes are suspected. The caller must hold m.mu, if needed.
+func (m *MonLeaderDetector) highestRanked() int {
+	for i := len(m.nodeIDs) - 1; i >= 0; i-- {
+		if !m.suspected[m.nodeIDs[i]] {
+			return m.nodeIDs[i]
+		}
+	}
+	return UnknownID
+}
@@ -1,32 +1,59 @@
 package multipaxos
 
-// Acceptor represents an acceptor as defined by the Multi-Paxos algorithm.
-type Acceptor struct {
-	// TODO(student): algorithm implementation
-	// Add needed fields
+import "slices"
+
+// AcceptorOf represents an acceptor as defined by the Multi-Paxos algorithm,
+// for values of type V.
+type AcceptorOf[V comparable] struct {
+	id       int
+	rnd      Round                // highest round promised or accepted in
+	accepted map[Slot]PValueOf[V] // the last value accepted in each slot
 }
 
+// Acceptor represents an acceptor as defined by the Multi-Paxos algorithm.
+type Acceptor = AcceptorOf[Value]
+
 // NewAcceptor returns a new Multi-Paxos acceptor.
 // It takes the following arguments:
 //
 // id: The id of the node running this instance of a Paxos acceptor.
 func NewAcceptor(id int) *Acceptor {
-	// TODO(student): algorithm implementation
-	return &Acceptor{}
+	return NewAcceptorOf[Value](id)
+}
+
+// NewAcceptorOf returns a new Multi-Paxos acceptor for values of type V.
+func NewAcceptorOf[V comparable](id int) *AcceptorOf[V] {
+	return &AcceptorOf[V]{
+		id:       id,
+		rnd:      NoRound,
+		accepted: make(map[Slot]PValueOf[V]),
+	}
 }
 
 // handlePrepare processes the prepare according to the Multi-Paxos algorithm,
 // returning a promise, or an empty promise if the prepare should be ignored.
-func (a *Acceptor) handlePrepare(prepare Prepare) Promise {
-	// TODO(student): algorithm implementation
-	return Promise{To: -1, From: -1}
+func (a *AcceptorOf[V]) handlePrepare(prepare Prepare) PromiseOf[V] {
+	if prepare.Crnd <= a.rnd {
+		return PromiseOf[V]{}
+	}
+	a.rnd = prepare.Crnd
+	var accepted []PValueOf[V]
+	for slot, pval := range a.accepted {
+		if slot >= prepare.Slot {
+			accepted = append(accepted, pval)
+		}
+	}
+	slices.SortFunc(accepted, func(x, y PValueOf[V]) int { return int(x.Slot - y.Slot) })
+	return PromiseOf[V]{To: prepare.From, From: a.id, Rnd: a.rnd, Accepted: accepted}
 }
 
 // handleAccept processes the accept according to the Multi-Paxos algorithm,
 // returning a learn, or an empty learn if the accept should be ignored.
-func (a *Acceptor) handleAccept(accept Accept) Learn {
-	// TODO(student): algorithm implementation
-	return Learn{From: -1, Slot: -1, Rnd: -2}
+func (a *AcceptorOf[V]) handleAccept(accept AcceptOf[V]) LearnOf[V] {
+	if accept.Rnd < a.rnd {
+		return LearnOf[V]{}
+	}
+	a.rnd = accept.Rnd
+	a.accepted[accept.Slot] = PValueOf[V]{Slot: accept.Slot, Vrnd: accept.Rnd, Vval: accept.Val}
+	return LearnOf[V]{From: a.id, Slot: accept.Slot, Rnd: accept.Rnd, Val: accept.Val}
 }
-
-// TODO(student): Add any other unexported methods needed.
@@ -0,0 +1,111 @@
+package multipaxos
+
+import (
+	"slices"
+
+	"dat520/lab3/failuredetector"
+)
+
+// Node is a failuredetector.SuspectRestorer, so that the lab3 failure
+// detector can report its suspicions to both the node and its leader detector.
+var _ failuredetector.SuspectRestorer = (*Node)(nil)
+
+// SetAuxiliaryAcceptors makes the nodes with the given ids auxiliary
+// acceptors, as in Cheap Paxos: with F+1 main and F auxiliary acceptors, the
+// leader sends its prepares and accepts only to the main acceptors, a
+// quorum, while they are up. For each main acceptor suspected by the
+// failure detector, an auxiliary acceptor that is not suspected takes part
+// in the phases in its place, until the main acceptor is restored.
+//
+// The auxiliary nodes are not learners, and must not be reported as leader
+// by the leader detectors; for instance, they can have the lowest ids.
+// It must be called before the node is started, with the same ids on every
+// node of the cluster.
+func (n *Node) SetAuxiliaryAcceptors(ids []int) {
+	n.main, n.auxiliary = nil, nil
+	for _, id := range n.nodeIDs {
+		if slices.Contains(ids, id) {
+			n.auxiliary = append(n.auxiliary, id)
+		} else {
+			n.main = append(n.main, id)
+		}
+	}
+	n.acceptors = n.main
+	n.learners = n.main
+}
+
+// Suspect makes the node consider node id suspected, and reports it to the
+// node's leader detector if it is a failuredetector.SuspectRestorer.
+func (n *Node) Suspect(id int) {
+	n.setSuspected(id, true)
+	if sr, ok := n.ld.(failuredetector.SuspectRestorer); ok {
+		sr.Suspect(id)
+	}
+}
+
+// Restore makes the node consider node id restored, and reports it to the
+// node's leader detector if it is a failuredetector.SuspectRestorer.
+func (n *Node) Restore(id int) {
+	n.setSuspected(id, false)
+	if sr, ok := n.ld.(failuredetector.SuspectRestorer); ok {
+		sr.Restore(id)
+	}
+}
+
+// setSuspected updates the suspected nodes, and signals the run loop to
+// reconfigure the acceptors. The acceptors are updated before the leader
+// detector is told, so that a new leader runs phase one with them.
+func (n *Node) setSuspected(id int, suspected bool) {
+	n.mu.Lock()
+	if suspected {
+		n.suspected[id] = true
+	} else {
+		delete(n.suspected, id)
+	}
+	n.mu.Unlock()
+	select {
+	case n.reconfigured <- struct{}{}:
+	default: // already signaled
+	}
+}
+
+// activeAcceptors returns the main acceptors, and an auxiliary acceptor that
+// is not suspected in place of each suspected main acceptor.
+func (n *Node) activeAcceptors() []int {
+	n.mu.Lock()
+	defer n.mu.Unlock()
+	missing := 0
+	for _, id := range n.main {
+		if n.suspected[id] {
+			missing++
+		}
+	}
+	// the suspected main acceptors are kept, in case they are up after all
+	active := slices.Clone(n.main)
+	for _, id := range n.auxiliary {
+		if missing == 0 {
+			break
+		}
+		if !n.suspected[id] {
+			active = append(active, id)
+			missing--
+		}
+	}
+	return active
+}
+
+// reconfigure updates the acceptors sent prepares and accepts after a
+// suspicion or restore. A leader runs phase one again with the new
+// acceptors, which proposes the values accepted in the undecided slots to
+// them, since the auxiliary acceptors have not seen the accepts sent to the
+// main acceptors, and a restored main acceptor may have missed some.
+func (n *Node) reconfigure() {
+	active := n.activeAcceptors()
+	if slices.Equal(active, n.acceptors) {
+		return
+	}
+	n.acceptors = active
+	if n.leader == n.id {
+		n.newLeader(n.id)
+	}
+}
@@ -0,0 +1,93 @@
+package multipaxos
+
+import (
+	"testing"
+
+	"dat520/lab3/leaderdetector"
+
+	"github.com/google/go-cmp/cmp"
+)
+
+// TestCheapPaxos checks that the auxiliary acceptor, node 0, only takes
+// part while main acceptor 1 is suspected.
+func TestCheapPaxos(t *testing.T) {
+	const numNodes, aux = 3, 0
+	network := NewMemNetwork()
+	c := newTestCluster(t, numNodes, network.Transport, func(n *Node) {
+		n.SetAuxiliaryAcceptors([]int{aux})
+	})
+	defer c.stop()
+	mains := []int{1, 2}
+
+	before := testValues("before", 5)
+	for _, val := range before {
+		c.nodes[1].Propose(val)
+	}
+	c.waitForNodes(t, mains, before...)
+	if got := network.Received(aux); got != 0 {
+		t.Errorf("auxiliary acceptor received %d messages with the main acceptors up, want 0", got)
+	}
+
+	// main acceptor 1 crashes; the auxiliary acceptor takes its place
+	c.nodes[1].Stop()
+	c.nodes[1] = nil
+	for _, id := range []int{0, 2} {
+		c.nodes[id].Suspect(1)
+	}
+	during := testValues("during", 5)
+	for _, val := range during {
+		c.nodes[2].Propose(val)
+	}
+	c.waitForNodes(t, []int{2}, append(before, during...)...)
+	if network.Received(aux) == 0 {
+		t.Error("auxiliary acceptor received no messages with a main acceptor suspected")
+	}
+
+	// node 1 is replaced, and the main acceptors take over again
+	ids := []int{0, 1, 2}
+	c.detectors[1] = leaderdetector.NewMonLeaderDetector(ids)
+	c.nodes[1] = NewNode(1, numNodes, c.detectors[1], network.Transport(1))
+	c.nodes[1].SetAuxiliaryAcceptors([]int{aux})
+	c.nodes[1].Start()
+	for _, id := range []int{0, 2} {
+		c.nodes[id].Restore(1)
+	}
+	received := network.Received(aux)
+	after := testValues("after", 5)
+	for _, val := range after {
+		c.nodes[2].Propose(val)
+	}
+	c.waitForNodes(t, []int{2}, append(append(before, during...), after...)...)
+	if got := network.Received(aux); got != received {
+		t.Errorf("auxiliary acceptor received %d messages after main acceptor 1 was restored, want 0", got-received)
+	}
+}
+
+func TestActiveAcceptors(t *testing.T) {
+	tests := []struct {
+		name      string
+		auxiliary []int
+		suspected []int
+		want      []int
+	}{
+		{name: "NoAuxiliary", suspected: []int{3}, want: []int{0, 1, 2, 3, 4}},
+		{name: "MainUp", auxiliary: []int{0, 1}, want: []int{2, 3, 4}},
+		{name: "OneMainSuspected", auxiliary: []int{0, 1}, suspected: []int{3}, want: []int{2, 3, 4, 0}},
+		{name: "TwoMainSuspected", auxiliary: []int{0, 1}, suspected: []int{2, 4}, want: []int{2, 3, 4, 0, 1}},
+		{name: "AuxiliarySuspected", auxiliary: []int{0, 1}, suspected: []int{0, 3}, want: []int{2, 3, 4, 1}},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			n := NewNode(0, 5, &mockLD{}, NewMemNetwork().Transport(0))
+			if test.auxiliary != nil {
+				n.SetAuxiliaryAcceptors(test.auxiliary)
+			}
+			for _, id := range test.suspected {
+				n.Suspect(id)
+			}
+			if diff := cmp.Diff(test.want, n.activeAcceptors()); diff != "" {
+				t.Errorf("activeAcceptors() mismatch (-want +got):\n%s", diff)
+			}
+		})
+	}
+}
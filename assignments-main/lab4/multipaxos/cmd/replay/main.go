@@ -0,0 +1,96 @@
+// Command replay feeds the messages recorded in a Multi-Paxos trace back
+// into fresh handlers, printing the handlers' output for each message and
+// the outputs that the recorded nodes did not send, to reproduce a bug
+// deterministically.
+package main
+
+import (
+	"flag"
+	"fmt"
+	"log"
+	"os"
+	"slices"
+
+	"dat520/lab4/multipaxos"
+)
+
+func main() {
+	var (
+		traceFile = flag.String("trace", "", "trace file written by a multipaxos.Recorder")
+		nodeID    = flag.Int("node", -1, "node whose messages to replay (all nodes if -1)")
+		numNodes  = flag.Int("nodes", 0, "number of nodes in the cluster (derived from the trace if 0)")
+	)
+	flag.Usage = func() {
+		log.Printf("Usage: %s [OPTIONS]\nOptions:", os.Args[0])
+		flag.PrintDefaults()
+	}
+	flag.Parse()
+	if *traceFile == "" {
+		flag.Usage()
+		os.Exit(2)
+	}
+
+	records, err := multipaxos.ReadTraceFile(*traceFile)
+	if err != nil {
+		log.Fatalf("reading %s: %v", *traceFile, err)
+	}
+	ids := nodeIDs(records)
+	if *numNodes == 0 && len(ids) > 0 {
+		*numNodes = ids[len(ids)-1] + 1
+	}
+	if *nodeID >= 0 {
+		ids = []int{*nodeID}
+	}
+	diverged := 0
+	for _, id := range ids {
+		fmt.Printf("node %d\n", id)
+		for _, step := range multipaxos.Replay(id, *numNodes, records) {
+			fmt.Printf("%s <- %v\n", step.Record.Time.Format("15:04:05.000000"), step.Record.Msg)
+			for _, out := range step.Outputs {
+				fmt.Printf("\t-> %v\n", payload(out))
+			}
+			for _, d := range step.Decisions {
+				fmt.Printf("\t=  %v\n", d)
+			}
+			for _, out := range step.Missing {
+				fmt.Printf("\t!  not sent in the trace: %v\n", payload(out))
+			}
+			if len(step.Missing) > 0 {
+				diverged++
+			}
+		}
+	}
+	if diverged > 0 {
+		fmt.Printf("%d messages were handled differently than in the trace\n", diverged)
+		os.Exit(1)
+	}
+}
+
+// nodeIDs returns the sorted ids of the nodes in the trace, as senders,
+// receivers or recorders of messages.
+func nodeIDs(records []multipaxos.Record) []int {
+	var ids []int
+	for _, r := range records {
+		for _, id := range []int{r.Node, r.Msg.From, r.Msg.To} {
+			if !slices.Contains(ids, id) {
+				ids = append(ids, id)
+			}
+		}
+	}
+	slices.Sort(ids)
+	return ids
+}
+
+// payload returns the message's payload; the outputs of the handlers have
+// no receiver.
+func payload(msg multipaxos.Message) any {
+	switch {
+	case msg.Promise != nil:
+		return *msg.Promise
+	case msg.Accept != nil:
+		return *msg.Accept
+	case msg.Learn != nil:
+		return *msg.Learn
+	}
+	return msg
+}
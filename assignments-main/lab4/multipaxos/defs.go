@@ -21,6 +21,10 @@ const NoRound Round = -1
 // ClientSeq: Client local sequence number.
 //
 // Command: The state machine command to be agreed upon and executed.
+//
+// The Multi-Paxos roles and messages are generic over the type of value, such
+// as AcceptorOf[V], with the zero value of V as the no-op value; their plain
+// names, such as Acceptor, are used for Value.
 type Value struct {
 	ClientID  string
 	ClientSeq int
@@ -49,17 +53,20 @@ func (p Prepare) String() string {
 	return fmt.Sprintf("Prepare{From: %d, Slot: %d, Crnd: %d}", p.From, p.Slot, p.Crnd)
 }
 
-// Promise represents a Multi-Paxos promise message.
+// PromiseOf represents a Multi-Paxos promise message for values of type V.
 // The Accepted field is a set of PValues that have been accepted
 // (by the acceptor that created the Promise) in a given slot.
-type Promise struct {
+type PromiseOf[V comparable] struct {
 	To, From int
 	Rnd      Round
-	Accepted []PValue
+	Accepted []PValueOf[V]
 }
 
+// Promise represents a Multi-Paxos promise message.
+type Promise = PromiseOf[Value]
+
 // String returns a string representation of promise p.
-func (p Promise) String() string {
+func (p PromiseOf[V]) String() string {
 	if p.Accepted == nil {
 		return fmt.Sprintf("Promise{To: %d, From: %d, Rnd: %d, No accepted values reported (nil slice)}", p.To, p.From, p.Rnd)
 	}
@@ -69,38 +76,47 @@ func (p Promise) String() string {
 	return fmt.Sprintf("Promise{To: %d, From: %d, Rnd: %d, Accepted: %v}", p.To, p.From, p.Rnd, p.Accepted)
 }
 
-// Accept represents a Multi-Paxos Paxos accept message.
-type Accept struct {
+// AcceptOf represents a Multi-Paxos accept message for values of type V.
+type AcceptOf[V comparable] struct {
 	From int
 	Slot Slot
 	Rnd  Round
-	Val  Value
+	Val  V
 }
 
+// Accept represents a Multi-Paxos Paxos accept message.
+type Accept = AcceptOf[Value]
+
 // String returns a string representation of accept a.
-func (a Accept) String() string {
+func (a AcceptOf[V]) String() string {
 	return fmt.Sprintf("Accept{From: %d, Slot: %d, Rnd: %d, Val: %v}", a.From, a.Slot, a.Rnd, a.Val)
 }
 
-// Learn represents a Multi-Paxos learn message.
-type Learn struct {
+// LearnOf represents a Multi-Paxos learn message for values of type V.
+type LearnOf[V comparable] struct {
 	From int
 	Slot Slot
 	Rnd  Round
-	Val  Value
+	Val  V
 }
 
+// Learn represents a Multi-Paxos learn message.
+type Learn = LearnOf[Value]
+
 // String returns a string representation of learn l.
-func (l Learn) String() string {
+func (l LearnOf[V]) String() string {
 	return fmt.Sprintf("Learn{From: %d, Slot: %d, Rnd: %d, Val: %v}", l.From, l.Slot, l.Rnd, l.Val)
 }
 
-// PValue is a triple consisting of a round number, a slot number,
-// and a value; the value is typically used to represent a command.
+// PValueOf is a triple consisting of a round number, a slot number,
+// and a value of type V; the value is typically used to represent a command.
 //
 // A PValue is created when an acceptor votes for a value in a round and slot.
-type PValue struct {
+type PValueOf[V comparable] struct {
 	Slot Slot
 	Vrnd Round
-	Vval Value
+	Vval V
 }
+
+// PValue is a PValueOf for the Value type.
+type PValue = PValueOf[Value]
@@ -0,0 +1,101 @@
+package multipaxos
+
+import (
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+)
+
+func TestLearnerDelivery(t *testing.T) {
+	for _, test := range deliveryTests {
+		t.Run(test.name, func(t *testing.T) {
+			learner := NewLearner(3)
+			var got []Decision
+			learner.SetDeliverFunc(func(d Decision) { got = append(got, d) })
+			for _, learn := range test.learns {
+				learner.handleLearn(learn)
+			}
+			if diff := cmp.Diff(test.wantDelivered, got); diff != "" {
+				t.Errorf("delivered mismatch (-want +got):\n%s", diff)
+			}
+			if got := learner.Delivered(); got != Slot(len(test.wantDelivered)) {
+				t.Errorf("Delivered() = %d, want %d", got, len(test.wantDelivered))
+			}
+			if diff := cmp.Diff(test.wantGaps, learner.Gaps()); diff != "" {
+				t.Errorf("Gaps() mismatch (-want +got):\n%s", diff)
+			}
+			if len(learner.votes) != test.wantTallies {
+				t.Errorf("learner has %d vote tallies, want %d", len(learner.votes), test.wantTallies)
+			}
+		})
+	}
+}
+
+// quorumLearns returns the learns from nodes 0 and 1 for the slot in round 1.
+func quorumLearns(slot Slot, val Value) []Learn {
+	return []Learn{
+		{From: 0, Slot: slot, Rnd: 1, Val: val},
+		{From: 1, Slot: slot, Rnd: 1, Val: val},
+	}
+}
+
+func concat(learns ...[]Learn) []Learn {
+	var all []Learn
+	for _, l := range learns {
+		all = append(all, l...)
+	}
+	return all
+}
+
+var deliveryTests = []struct {
+	name          string
+	learns        []Learn
+	wantDelivered []Decision
+	wantGaps      []Slot
+	wantTallies   int
+}{
+	{
+		name:          "InOrder",
+		learns:        concat(quorumLearns(1, valOne), quorumLearns(2, valTwo)),
+		wantDelivered: []Decision{{Slot: 1, Val: valOne}, {Slot: 2, Val: valTwo}},
+	},
+	{
+		name:        "GapHoldsBackDelivery",
+		learns:      concat(quorumLearns(2, valTwo), quorumLearns(4, valThree)),
+		wantGaps:    []Slot{1, 3},
+		wantTallies: 0,
+	},
+	{
+		name:          "GapFilled",
+		learns:        concat(quorumLearns(3, valThree), quorumLearns(2, valTwo), quorumLearns(1, valOne)),
+		wantDelivered: []Decision{{Slot: 1, Val: valOne}, {Slot: 2, Val: valTwo}, {Slot: 3, Val: valThree}},
+	},
+	{
+		name:          "PartlyFilled",
+		learns:        concat(quorumLearns(3, valThree), quorumLearns(1, valOne), quorumLearns(5, Value{})),
+		wantDelivered: []Decision{{Slot: 1, Val: valOne}},
+		wantGaps:      []Slot{2, 4},
+	},
+	{
+		name:          "UndecidedSlotTallied",
+		learns:        concat(quorumLearns(1, valOne), []Learn{{From: 2, Slot: 2, Rnd: 1, Val: valTwo}}),
+		wantDelivered: []Decision{{Slot: 1, Val: valOne}},
+		wantTallies:   1,
+	},
+	{
+		name: "LateLearnsForDeliveredSlotIgnored",
+		learns: concat(quorumLearns(1, valOne), []Learn{
+			{From: 2, Slot: 1, Rnd: 1, Val: valOne},
+			{From: 0, Slot: 1, Rnd: 2, Val: valTwo},
+			{From: 1, Slot: 1, Rnd: 2, Val: valTwo},
+		}),
+		wantDelivered: []Decision{{Slot: 1, Val: valOne}},
+	},
+	{
+		name: "LateLearnsForHeldBackSlotIgnored",
+		learns: concat(quorumLearns(2, valTwo), []Learn{
+			{From: 2, Slot: 2, Rnd: 1, Val: valTwo},
+		}),
+		wantGaps: []Slot{1},
+	},
+}
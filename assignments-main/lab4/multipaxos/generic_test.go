@@ -0,0 +1,66 @@
+package multipaxos
+
+import (
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+)
+
+// lease is a value type other than Value, as used to agree on a sequence of
+// leases; the zero lease is the no-op.
+type lease struct {
+	Holder int
+	Term   int
+}
+
+// TestGenericValue runs the three roles on lease values: a new leader
+// recovers the leases accepted in the previous round and fills the gap
+// between them with a no-op.
+func TestGenericValue(t *testing.T) {
+	const numNodes = 3
+	acceptors := make([]*AcceptorOf[lease], numNodes)
+	for id := range acceptors {
+		acceptors[id] = NewAcceptorOf[lease](id)
+	}
+	learner := NewLearnerOf[lease](numNodes)
+	var delivered []DecisionOf[lease]
+	learner.SetDeliverFunc(func(d DecisionOf[lease]) { delivered = append(delivered, d) })
+
+	// a previous leader's leases for slots 1 and 3 were accepted by acceptor 0 only
+	for _, accept := range []AcceptOf[lease]{
+		{From: 1, Slot: 1, Rnd: 1, Val: lease{Holder: 1, Term: 1}},
+		{From: 1, Slot: 3, Rnd: 1, Val: lease{Holder: 1, Term: 2}},
+	} {
+		acceptors[0].handleAccept(accept)
+	}
+
+	proposer := NewProposerOf[lease](2, numNodes, 0, &mockLD{})
+	prepare := proposer.startPhaseOne(NoRound)
+	var accepts []AcceptOf[lease]
+	for _, acceptor := range acceptors[:2] {
+		if acc := proposer.handlePromise(acceptor.handlePrepare(prepare)); acc != nil {
+			accepts = acc
+		}
+	}
+	wantAccepts := []AcceptOf[lease]{
+		{From: 2, Slot: 1, Rnd: 5, Val: lease{Holder: 1, Term: 1}},
+		{From: 2, Slot: 2, Rnd: 5, Val: lease{}},
+		{From: 2, Slot: 3, Rnd: 5, Val: lease{Holder: 1, Term: 2}},
+	}
+	if diff := cmp.Diff(wantAccepts, accepts); diff != "" {
+		t.Fatalf("handlePromise() mismatch (-want +got):\n%s", diff)
+	}
+	for _, accept := range accepts {
+		for _, acceptor := range acceptors {
+			learner.handleLearn(acceptor.handleAccept(accept))
+		}
+	}
+	want := []DecisionOf[lease]{
+		{Slot: 1, Val: lease{Holder: 1, Term: 1}},
+		{Slot: 2, Val: lease{}},
+		{Slot: 3, Val: lease{Holder: 1, Term: 2}},
+	}
+	if diff := cmp.Diff(want, delivered); diff != "" {
+		t.Errorf("delivered mismatch (-want +got):\n%s", diff)
+	}
+}
@@ -1,9 +1,37 @@
 package multipaxos
 
+// DecisionOf is a value of type V decided in a slot. A zero Val is a no-op.
+type DecisionOf[V comparable] struct {
+	Slot Slot
+	Val  V
+}
+
+// Decision is a Value decided in a slot.
+type Decision = DecisionOf[Value]
+
+// LearnerOf represents a learner as defined by the Multi-Paxos algorithm,
+// for values of type V.
+//
+// The learner delivers the decided values in slot order to the function set
+// with SetDeliverFunc: a value decided after a gap is held back until the
+// slots before it have been decided too. The votes for a slot are discarded
+// once it is decided, and the learns for delivered slots are ignored.
+type LearnerOf[V comparable] struct {
+	quorum    int
+	votes     map[Slot]*slotVotes[V] // votes of the undecided slots
+	decided   map[Slot]V             // decided slots waiting for the slots before them
+	delivered Slot                   // highest slot delivered; all the slots before it were too
+	deliver   func(DecisionOf[V])    // called with each decision, in slot order; may be nil
+}
+
 // Learner represents a learner as defined by the Multi-Paxos algorithm.
-type Learner struct {
-	// TODO(student): algorithm implementation
-	// Add needed fields
+type Learner = LearnerOf[Value]
+
+// slotVotes are the learns received for a slot in its highest round.
+type slotVotes[V comparable] struct {
+	rnd  Round
+	val  V
+	from map[int]bool
 }
 
 // NewLearner returns a new Multi-Paxos learner. It takes the following
@@ -11,16 +39,89 @@ type Learner struct {
 //
 // numNodes: The total number of Paxos nodes.
 func NewLearner(numNodes int) *Learner {
-	// TODO(student): algorithm implementation
-	return &Learner{}
+	return NewLearnerOf[Value](numNodes)
+}
+
+// NewLearnerOf returns a new Multi-Paxos learner for values of type V.
+func NewLearnerOf[V comparable](numNodes int) *LearnerOf[V] {
+	return &LearnerOf[V]{
+		quorum:  numNodes/2 + 1,
+		votes:   make(map[Slot]*slotVotes[V]),
+		decided: make(map[Slot]V),
+	}
+}
+
+// SetDeliverFunc sets the function called with each decided slot and value,
+// in slot order, starting from slot 1.
+func (l *LearnerOf[V]) SetDeliverFunc(deliver func(DecisionOf[V])) {
+	l.deliver = deliver
+}
+
+// Delivered returns the highest slot delivered; every slot up to it has been
+// decided and delivered.
+func (l *LearnerOf[V]) Delivered() Slot {
+	return l.delivered
+}
+
+// Gaps returns the undecided slots, in increasing order, that hold back the
+// delivery of the slots decided after them.
+func (l *LearnerOf[V]) Gaps() []Slot {
+	if len(l.decided) == 0 {
+		return nil
+	}
+	highest := l.delivered
+	for slot := range l.decided {
+		highest = max(highest, slot)
+	}
+	var gaps []Slot
+	for slot := l.delivered + 1; slot < highest; slot++ {
+		if _, ok := l.decided[slot]; !ok {
+			gaps = append(gaps, slot)
+		}
+	}
+	return gaps
 }
 
 // handleLearn processes the learn according to the Multi-Paxos algorithm,
 // returning the decided value for the slot, if a quorum of learns have been
 // collected; otherwise, it returns an empty value and 0.
-func (l *Learner) handleLearn(learn Learn) (Value, Slot) {
-	// TODO(student): algorithm implementation
-	return Value{ClientID: "-1", ClientSeq: -1, Command: "-1"}, -1
+func (l *LearnerOf[V]) handleLearn(learn LearnOf[V]) (V, Slot) {
+	var zero V
+	if learn.Slot <= l.delivered {
+		return zero, 0
+	}
+	if _, ok := l.decided[learn.Slot]; ok {
+		return zero, 0
+	}
+	votes, ok := l.votes[learn.Slot]
+	switch {
+	case !ok || learn.Rnd > votes.rnd:
+		votes = &slotVotes[V]{rnd: learn.Rnd, val: learn.Val, from: make(map[int]bool)}
+		l.votes[learn.Slot] = votes
+	case learn.Rnd < votes.rnd:
+		return zero, 0
+	}
+	votes.from[learn.From] = true
+	if len(votes.from) < l.quorum {
+		return zero, 0
+	}
+	delete(l.votes, learn.Slot)
+	l.decided[learn.Slot] = votes.val
+	l.deliverDecided()
+	return votes.val, learn.Slot
 }
 
-// TODO(student): Add any other unexported methods needed.
+// deliverDecided delivers the decided slots following the delivered ones.
+func (l *LearnerOf[V]) deliverDecided() {
+	for {
+		val, ok := l.decided[l.delivered+1]
+		if !ok {
+			return
+		}
+		delete(l.decided, l.delivered+1)
+		l.delivered++
+		if l.deliver != nil {
+			l.deliver(DecisionOf[V]{Slot: l.delivered, Val: val})
+		}
+	}
+}
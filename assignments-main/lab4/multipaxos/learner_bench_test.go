@@ -0,0 +1,30 @@
+package multipaxos
+
+import (
+	"fmt"
+	"testing"
+)
+
+// BenchmarkLearnerHandleLearn measures the cost of deciding a slot, that is,
+// of handling a learn from each of the nodes for the slot. The learner is
+// created with numNodes nodes, and each iteration decides the next slot.
+// The decided/op metric is the fraction of slots decided by the learner.
+func BenchmarkLearnerHandleLearn(b *testing.B) {
+	for _, numNodes := range []int{3, 5, 9} {
+		b.Run(fmt.Sprintf("nodes=%d", numNodes), func(b *testing.B) {
+			learner := NewLearner(numNodes)
+			decided := 0
+			b.ReportAllocs()
+			for i := range b.N {
+				slot := Slot(i + 1)
+				val := Value{ClientID: "bench", ClientSeq: i, Command: "cmd"}
+				for from := range numNodes {
+					if _, s := learner.handleLearn(Learn{From: from, Slot: slot, Rnd: 1, Val: val}); s == slot {
+						decided++
+					}
+				}
+			}
+			b.ReportMetric(float64(decided)/float64(b.N), "decided/op")
+		})
+	}
+}
@@ -0,0 +1,49 @@
+package multipaxos
+
+import "fmt"
+
+// LearningMode is the topology used by the acceptors to tell the learners
+// about the values they accepted. All the nodes must use the same mode.
+type LearningMode int
+
+const (
+	// AllToAll makes every acceptor send its learns to every learner, so
+	// that each node learns the decisions itself; n² messages per slot.
+	AllToAll LearningMode = iota
+	// DistinguishedLearner makes the acceptors send their learns only to
+	// the leader, which sends the decision to the other nodes; 2n messages
+	// per slot, but the decisions take an extra message delay.
+	DistinguishedLearner
+	// LeaderOnly makes the acceptors send their learns only to the leader;
+	// n messages per slot, but only the leader learns the decisions. The
+	// other nodes recover the decided values in phase one if they become
+	// leader.
+	LeaderOnly
+)
+
+// String returns the name of the learning mode.
+func (m LearningMode) String() string {
+	switch m {
+	case AllToAll:
+		return "AllToAll"
+	case DistinguishedLearner:
+		return "DistinguishedLearner"
+	case LeaderOnly:
+		return "LeaderOnly"
+	}
+	return fmt.Sprintf("LearningMode(%d)", int(m))
+}
+
+// handleDecision processes a decision sent by the distinguished learner;
+// the slot is decided without collecting a quorum of learns.
+func (l *LearnerOf[V]) handleDecision(d DecisionOf[V]) {
+	if d.Slot <= l.delivered {
+		return
+	}
+	if _, ok := l.decided[d.Slot]; ok {
+		return
+	}
+	delete(l.votes, d.Slot)
+	l.decided[d.Slot] = d.Val
+	l.deliverDecided()
+}
@@ -0,0 +1,96 @@
+package multipaxos
+
+import (
+	"testing"
+	"time"
+
+	"github.com/google/go-cmp/cmp"
+)
+
+func TestLearningModes(t *testing.T) {
+	const (
+		numNodes  = 5
+		numValues = 10
+		leader    = numNodes - 1
+	)
+	all := []int{0, 1, 2, 3, 4}
+	tests := []struct {
+		mode          LearningMode
+		learners      []int // nodes that learn the decisions
+		wantLearns    int
+		wantDecisions int
+	}{
+		// every acceptor sends its learns to the other four nodes
+		{mode: AllToAll, learners: all, wantLearns: numValues * numNodes * (numNodes - 1)},
+		// the followers send their learns to the leader, which sends the decisions to them
+		{mode: DistinguishedLearner, learners: all, wantLearns: numValues * (numNodes - 1), wantDecisions: numValues * (numNodes - 1)},
+		// the followers send their learns to the leader only
+		{mode: LeaderOnly, learners: []int{leader}, wantLearns: numValues * (numNodes - 1)},
+	}
+	for _, test := range tests {
+		t.Run(test.mode.String(), func(t *testing.T) {
+			network := NewMemNetwork()
+			c := newTestCluster(t, numNodes, network.Transport, func(n *Node) { n.SetLearningMode(test.mode) })
+			defer c.stop()
+			vals := testValues("client", numValues)
+			for _, val := range vals {
+				// proposed at the leader, so that no value is forwarded
+				c.nodes[leader].Propose(val)
+			}
+			c.waitForNodes(t, test.learners, vals...)
+
+			// the acceptors may still be sending learns for the last slots
+			waitForCount(network, "Learn", test.wantLearns)
+			waitForCount(network, "Decision", test.wantDecisions)
+			time.Sleep(50 * time.Millisecond)
+			counts := map[string]int{
+				"Accept":   numValues * (numNodes - 1),
+				"Learn":    test.wantLearns,
+				"Decision": test.wantDecisions,
+				"Value":    0,
+			}
+			for kind, want := range counts {
+				if got := network.Count(kind); got != want {
+					t.Errorf("%s messages = %d, want %d", kind, got, want)
+				}
+			}
+			if test.mode == LeaderOnly {
+				for id := range leader {
+					select {
+					case d := <-c.nodes[id].Decisions():
+						t.Errorf("node %d learnt %v, want only the leader to learn", id, d)
+					default:
+					}
+				}
+			}
+		})
+	}
+}
+
+// waitForCount waits a while for the network to count the messages of the given kind.
+func waitForCount(network *MemNetwork, kind string, want int) {
+	for deadline := time.Now().Add(time.Second); time.Now().Before(deadline) && network.Count(kind) < want; {
+		time.Sleep(time.Millisecond)
+	}
+}
+
+func TestLearnerHandleDecision(t *testing.T) {
+	learner := NewLearner(3)
+	var got []Decision
+	learner.SetDeliverFunc(func(d Decision) { got = append(got, d) })
+	learner.handleLearn(Learn{From: 0, Slot: 2, Rnd: 1, Val: valTwo})
+	learner.handleDecision(Decision{Slot: 2, Val: valTwo})
+	learner.handleDecision(Decision{Slot: 1, Val: valOne})
+	// decisions and learns for delivered slots are ignored
+	learner.handleDecision(Decision{Slot: 1, Val: valThree})
+	if val, slot := learner.handleLearn(Learn{From: 1, Slot: 2, Rnd: 1, Val: valTwo}); slot != 0 {
+		t.Errorf("handleLearn() = %v, %d for a delivered slot, want no output", val, slot)
+	}
+	want := []Decision{{Slot: 1, Val: valOne}, {Slot: 2, Val: valTwo}}
+	if diff := cmp.Diff(want, got); diff != "" {
+		t.Errorf("delivered mismatch (-want +got):\n%s", diff)
+	}
+	if len(learner.votes) != 0 {
+		t.Errorf("learner has %d vote tallies, want none", len(learner.votes))
+	}
+}
@@ -0,0 +1,348 @@
+package multipaxos
+
+import (
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"dat520/lab3/leaderdetector"
+)
+
+// Node runs the three Multi-Paxos roles of a node, connecting their
+// handlers to the node's transport and leader detector. The nodes of a
+// cluster have the ids 0 to numNodes-1, and the first slot is 1, since
+// handleLearn reports slot 0 when no slot was decided.
+//
+// The leader, as reported by the leader detector, runs phase one when it
+// becomes leader, retrying it in higher rounds until a quorum of acceptors
+// promise, and proposes the client values it receives; the other
+// nodes forward their client values to the leader. Values proposed by a
+// leader that loses its leadership before they are decided may be lost,
+// and must be proposed again by the clients.
+type Node struct {
+	id        int
+	nodeIDs   []int
+	acceptors []int // the acceptors sent prepares and accepts
+	learners  []int // the learners sent learns and decisions
+	ld        leaderdetector.LeaderDetector
+	transport Transport
+	proposer  *Proposer
+	acceptor  *Acceptor
+	learner   *Learner
+
+	mode         LearningMode
+	recorder     *Recorder
+	leader       int
+	phaseOneDone bool             // the leader's phase one is done for its current round
+	nextSlot     Slot             // the last slot proposed in by the leader
+	pending      []Value          // client values waiting for a leader or for phase one
+	local        []Message        // messages sent to this node itself
+	timeout      <-chan time.Time // fires when phase one may have timed out
+
+	// Cheap Paxos; see SetAuxiliaryAcceptors
+	main         []int         // the main acceptors
+	auxiliary    []int         // the auxiliary acceptors
+	mu           sync.Mutex    // protects suspected
+	suspected    map[int]bool  // the nodes suspected by the failure detector
+	reconfigured chan struct{} // signals that suspected has changed
+
+	requests  chan Value
+	decisions chan Decision
+	consumed  atomic.Bool // Decisions has been called
+	stop      chan struct{}
+	done      chan struct{}
+}
+
+// NewNode returns a Multi-Paxos node with the given id, in a cluster of
+// numNodes nodes, that communicates through transport and follows the
+// leaders reported by ld.
+func NewNode(id, numNodes int, ld leaderdetector.LeaderDetector, transport Transport) *Node {
+	nodeIDs := make([]int, numNodes)
+	for i := range nodeIDs {
+		nodeIDs[i] = i
+	}
+	n := &Node{
+		id:           id,
+		nodeIDs:      nodeIDs,
+		acceptors:    nodeIDs,
+		learners:     nodeIDs,
+		ld:           ld,
+		transport:    transport,
+		proposer:     NewProposer(id, numNodes, 0, ld),
+		acceptor:     NewAcceptor(id),
+		learner:      NewLearner(numNodes),
+		leader:       leaderdetector.UnknownID,
+		main:         nodeIDs,
+		suspected:    make(map[int]bool),
+		reconfigured: make(chan struct{}, 1),
+		requests:     make(chan Value, inboxSize),
+		decisions:    make(chan Decision, inboxSize),
+		stop:         make(chan struct{}),
+		done:         make(chan struct{}),
+	}
+	n.learner.SetDeliverFunc(n.deliver)
+	return n
+}
+
+// SetRetryConfig sets the phase one timeout and backoff of the node's
+// proposer. It must be called before the node is started.
+func (n *Node) SetRetryConfig(cfg RetryConfig) {
+	n.proposer.SetRetryConfig(cfg)
+}
+
+// SetClock sets the clock of the node's phase one timeouts. It must be
+// called before the node is started.
+func (n *Node) SetClock(clock Clock) {
+	n.proposer.SetClock(clock)
+}
+
+// SetLearningMode sets how the node's acceptor sends its learns to the
+// learners. It must be called before the node is started.
+func (n *Node) SetLearningMode(mode LearningMode) {
+	n.mode = mode
+}
+
+// SetRecorder sets the recorder logging the messages sent and handled by
+// the node. It must be called before the node is started.
+func (n *Node) SetRecorder(r *Recorder) {
+	n.recorder = r
+}
+
+// Start starts the node's run loop.
+func (n *Node) Start() {
+	go n.run()
+}
+
+// Stop stops the node's run loop and closes its transport.
+func (n *Node) Stop() {
+	close(n.stop)
+	<-n.done
+	n.transport.Close()
+}
+
+// Propose proposes the client value val to be decided.
+func (n *Node) Propose(val Value) {
+	select {
+	case n.requests <- val:
+	case <-n.done:
+	}
+}
+
+// Decisions returns the channel of the values decided, as learnt by the
+// node, in slot order. Once Decisions has been called, the run loop blocks
+// until each decision is received. Until then, up to inboxSize decisions are
+// buffered and later ones are dropped, so that a node whose decisions are not
+// consumed, such as a node only serving as an acceptor, keeps running.
+func (n *Node) Decisions() <-chan Decision {
+	n.consumed.Store(true)
+	return n.decisions
+}
+
+// run handles the node's messages, client values and leader changes until
+// the node is stopped.
+func (n *Node) run() {
+	defer close(n.done)
+	leaders := n.ld.Subscribe()
+	n.acceptors = n.activeAcceptors()
+	n.newLeader(n.ld.Leader())
+	for {
+		for len(n.local) > 0 {
+			msg := n.local[0]
+			n.local = n.local[1:]
+			n.handle(msg)
+		}
+		// leader changes and suspicions are handled before the client values
+		// received after them, which would otherwise be forwarded to the old
+		// leader or proposed to the old acceptors
+		select {
+		case leader := <-leaders:
+			n.newLeader(leader)
+			continue
+		case <-n.reconfigured:
+			n.reconfigure()
+			continue
+		default:
+		}
+		select {
+		case leader := <-leaders:
+			n.newLeader(leader)
+		case <-n.reconfigured:
+			n.reconfigure()
+		case msg := <-n.transport.Receive():
+			n.handle(msg)
+		case val := <-n.requests:
+			n.propose(val)
+		case <-n.timeout:
+			if prepare, ok := n.proposer.handleTimeout(); ok {
+				n.multicast(n.acceptors, Message{Prepare: &prepare})
+			}
+			n.setTimeout()
+		case <-n.stop:
+			return
+		}
+	}
+}
+
+// newLeader runs phase one if this node is the new leader; otherwise, the
+// pending client values are forwarded to the new leader.
+func (n *Node) newLeader(leader int) {
+	n.leader = leader
+	n.proposer.leader = leader
+	n.phaseOneDone = false
+	if leader != n.id {
+		n.proposer.stopPhaseOne()
+		n.setTimeout()
+		n.proposePending()
+		return
+	}
+	// the node's acceptor knows the highest round seen, for instance the
+	// previous leader's; a prepare in a lower round would be ignored
+	prepare := n.proposer.startPhaseOne(n.acceptor.rnd)
+	n.setTimeout()
+	n.multicast(n.acceptors, Message{Prepare: &prepare})
+}
+
+// setTimeout sets the timeout channel to fire at the proposer's phase one
+// deadline, if phase one is running.
+func (n *Node) setTimeout() {
+	deadline, ok := n.proposer.phaseOneDeadline()
+	if !ok {
+		n.timeout = nil
+		return
+	}
+	n.timeout = n.proposer.clock.After(deadline.Sub(n.proposer.clock.Now()))
+}
+
+// handle handles the message with the handler of its type.
+func (n *Node) handle(msg Message) {
+	n.recorder.record(n.id, Received, msg)
+	switch {
+	case msg.Prepare != nil:
+		promise := n.acceptor.handlePrepare(*msg.Prepare)
+		if !isEmptyPromise(promise) {
+			n.send(Message{To: promise.To, Promise: &promise})
+		}
+	case msg.Promise != nil:
+		if n.leader != n.id {
+			return
+		}
+		accepts := n.proposer.handlePromise(*msg.Promise)
+		if accepts == nil {
+			return
+		}
+		n.phaseOneDone = true
+		n.setTimeout()
+		n.nextSlot = n.proposer.adu
+		for _, accept := range accepts {
+			n.multicast(n.acceptors, Message{Accept: &accept})
+			n.nextSlot = max(n.nextSlot, accept.Slot)
+		}
+		n.proposePending()
+	case msg.Accept != nil:
+		learn := n.acceptor.handleAccept(*msg.Accept)
+		switch {
+		case learn == (Learn{}):
+		case n.mode == AllToAll:
+			n.multicast(n.learners, Message{Learn: &learn})
+		default:
+			// the leader that sent the accept is the distinguished learner
+			n.send(Message{To: msg.Accept.From, Learn: &learn})
+		}
+	case msg.Learn != nil:
+		val, slot := n.learner.handleLearn(*msg.Learn)
+		if slot == 0 {
+			return
+		}
+		// slots decided in the leader's rounds are never proposed in again
+		n.nextSlot = max(n.nextSlot, slot)
+		if n.mode == DistinguishedLearner {
+			n.broadcastOthers(Message{Decision: &Decision{Slot: slot, Val: val}})
+		}
+	case msg.Decision != nil:
+		n.learner.handleDecision(*msg.Decision)
+		n.nextSlot = max(n.nextSlot, msg.Decision.Slot)
+	case msg.Value != nil:
+		n.propose(*msg.Value)
+	}
+}
+
+// propose proposes val in the next slot if this node is the leader and has
+// completed phase one, or forwards it to the leader. Otherwise, the value
+// is kept until there is a leader.
+func (n *Node) propose(val Value) {
+	switch {
+	case n.leader == n.id && n.phaseOneDone:
+		n.nextSlot++
+		accept := Accept{From: n.id, Slot: n.nextSlot, Rnd: n.proposer.crnd, Val: val}
+		n.multicast(n.acceptors, Message{Accept: &accept})
+	case n.leader != n.id && n.leader != leaderdetector.UnknownID:
+		n.send(Message{To: n.leader, Value: &val})
+	default:
+		n.pending = append(n.pending, val)
+	}
+}
+
+// proposePending proposes the pending client values again.
+func (n *Node) proposePending() {
+	pending := n.pending
+	n.pending = nil
+	for _, val := range pending {
+		n.propose(val)
+	}
+}
+
+// deliver advances the proposer's adu over the decided slot, delivered in
+// order by the learner, and delivers the decision; see Decisions.
+func (n *Node) deliver(d Decision) {
+	n.proposer.incrementAllDecidedUpTo()
+	if !n.consumed.Load() {
+		select {
+		case n.decisions <- d:
+		default: // nobody consumes the decisions
+		}
+		return
+	}
+	select {
+	case n.decisions <- d:
+	case <-n.stop:
+	}
+}
+
+// send sends the message from this node; messages to the node itself are
+// handled by its run loop without going through the transport.
+func (n *Node) send(msg Message) {
+	msg.From = n.id
+	n.recorder.record(n.id, Sent, msg)
+	if msg.To == n.id {
+		n.local = append(n.local, msg)
+		return
+	}
+	// messages may be lost; Paxos tolerates it
+	_ = n.transport.Send(msg)
+}
+
+// multicast sends the message to the nodes with the given ids, including
+// this node if it is one of them.
+func (n *Node) multicast(ids []int, msg Message) {
+	for _, id := range ids {
+		msg.To = id
+		n.send(msg)
+	}
+}
+
+// broadcastOthers sends the message to every learner except this node.
+func (n *Node) broadcastOthers(msg Message) {
+	for _, id := range n.learners {
+		if id != n.id {
+			msg.To = id
+			n.send(msg)
+		}
+	}
+}
+
+// isEmptyPromise returns true if the promise is the empty promise returned
+// by handlePrepare when a prepare is ignored. A node's rounds are above 0,
+// since its proposer increases crnd before it runs phase one.
+func isEmptyPromise(promise Promise) bool {
+	return promise.To == 0 && promise.From == 0 && promise.Rnd == 0 && promise.Accepted == nil
+}
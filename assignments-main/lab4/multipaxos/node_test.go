@@ -0,0 +1,246 @@
+package multipaxos
+
+import (
+	"fmt"
+	"net"
+	"testing"
+	"time"
+
+	"dat520/lab3/leaderdetector"
+
+	"github.com/google/go-cmp/cmp"
+)
+
+// decisionTimeout is the time to wait for the nodes to decide the values.
+const decisionTimeout = 5 * time.Second
+
+// testCluster is a cluster of nodes, each with its own leader detector.
+type testCluster struct {
+	nodes     []*Node
+	detectors []*leaderdetector.MonLeaderDetector
+	logs      []map[Slot]Value // the decisions received from each node
+}
+
+// newTestCluster starts numNodes nodes using the transports returned by
+// transport, after applying the configure functions to them; the leader is
+// the node with the highest id.
+func newTestCluster(t *testing.T, numNodes int, transport func(id int) Transport, configure ...func(*Node)) *testCluster {
+	t.Helper()
+	c := &testCluster{}
+	ids := make([]int, numNodes)
+	for i := range ids {
+		ids[i] = i
+	}
+	for id := range numNodes {
+		ld := leaderdetector.NewMonLeaderDetector(ids)
+		node := NewNode(id, numNodes, ld, transport(id))
+		for _, f := range configure {
+			f(node)
+		}
+		c.nodes = append(c.nodes, node)
+		c.detectors = append(c.detectors, ld)
+		c.logs = append(c.logs, make(map[Slot]Value))
+	}
+	for _, node := range c.nodes {
+		node.Start()
+	}
+	return c
+}
+
+// stop stops the running nodes.
+func (c *testCluster) stop() {
+	for _, node := range c.nodes {
+		if node != nil {
+			node.Stop()
+		}
+	}
+}
+
+// crash stops node id, and makes the other nodes suspect it.
+func (c *testCluster) crash(id int) {
+	c.nodes[id].Stop()
+	c.nodes[id] = nil
+	for _, ld := range c.detectors {
+		ld.Suspect(id)
+	}
+}
+
+// waitFor waits until every running node has decided the values, and
+// checks that the nodes decided the same values in the same slots.
+func (c *testCluster) waitFor(t *testing.T, vals ...Value) {
+	t.Helper()
+	var ids []int
+	for id, node := range c.nodes {
+		if node != nil {
+			ids = append(ids, id)
+		}
+	}
+	c.waitForNodes(t, ids, vals...)
+}
+
+// waitForNodes waits until the nodes with the given ids have decided the
+// values, and checks that the nodes decided the same values in the same slots.
+func (c *testCluster) waitForNodes(t *testing.T, ids []int, vals ...Value) {
+	t.Helper()
+	timeout := time.After(decisionTimeout)
+	for _, id := range ids {
+		node := c.nodes[id]
+		for !containsAll(c.logs[id], vals) {
+			select {
+			case d := <-node.Decisions():
+				if want := Slot(len(c.logs[id]) + 1); d.Slot != want {
+					t.Fatalf("node %d: delivered slot %d, want slot %d", id, d.Slot, want)
+				}
+				c.logs[id][d.Slot] = d.Val
+			case <-timeout:
+				t.Fatalf("node %d decided %v, want all of %v", id, c.logs[id], vals)
+			}
+		}
+	}
+	for _, id := range ids {
+		for _, other := range ids {
+			for slot, val := range c.logs[id] {
+				if otherVal, ok := c.logs[other][slot]; ok && otherVal != val {
+					t.Errorf("slot %d: node %d decided %v, node %d decided %v", slot, id, val, other, otherVal)
+				}
+			}
+		}
+	}
+}
+
+func containsAll(log map[Slot]Value, vals []Value) bool {
+	decided := make(map[Value]bool)
+	for _, val := range log {
+		decided[val] = true
+	}
+	for _, val := range vals {
+		if !decided[val] {
+			return false
+		}
+	}
+	return true
+}
+
+func testValues(client string, n int) []Value {
+	vals := make([]Value, n)
+	for i := range vals {
+		vals[i] = Value{ClientID: client, ClientSeq: i, Command: fmt.Sprintf("cmd%d", i)}
+	}
+	return vals
+}
+
+func TestNodeDecides(t *testing.T) {
+	network := NewMemNetwork()
+	c := newTestCluster(t, 3, network.Transport)
+	defer c.stop()
+	vals := testValues("client", 10)
+	for i, val := range vals {
+		// the followers forward their values to the leader
+		c.nodes[i%3].Propose(val)
+	}
+	c.waitFor(t, vals...)
+	want := map[Slot]Value{}
+	for slot, val := range c.logs[2] {
+		want[slot] = val
+	}
+	if len(want) != len(vals) {
+		t.Errorf("decided %d slots, want %d", len(want), len(vals))
+	}
+	if diff := cmp.Diff(want, c.logs[0]); diff != "" {
+		t.Errorf("node 0 log mismatch (-want +got):\n%s", diff)
+	}
+}
+
+// TestNodeUnconsumedDecisions checks that nodes whose decisions are not
+// consumed keep serving as acceptors after their decision buffer is full.
+func TestNodeUnconsumedDecisions(t *testing.T) {
+	network := NewMemNetwork()
+	c := newTestCluster(t, 3, network.Transport)
+	defer c.stop()
+	vals := testValues("client", inboxSize+64)
+	// the values are proposed in batches, so that the transports do not drop
+	// messages; only the leader's decisions are consumed
+	const batch = 64
+	for i := 0; i < len(vals); i += batch {
+		for _, val := range vals[i : i+batch] {
+			c.nodes[2].Propose(val)
+		}
+		c.waitForNodes(t, []int{2}, vals[i:i+batch]...)
+	}
+}
+
+func TestNodeLeaderChange(t *testing.T) {
+	network := NewMemNetwork()
+	c := newTestCluster(t, 3, network.Transport)
+	defer c.stop()
+	before := testValues("before", 5)
+	for _, val := range before {
+		c.nodes[0].Propose(val)
+	}
+	c.waitFor(t, before...)
+
+	// node 1 becomes leader, and recovers the slots decided by node 2
+	c.crash(2)
+	after := testValues("after", 5)
+	for _, val := range after {
+		c.nodes[0].Propose(val)
+	}
+	c.waitFor(t, append(before, after...)...)
+	if diff := cmp.Diff(c.logs[1], c.logs[0]); diff != "" {
+		t.Errorf("logs mismatch (-node 1 +node 0):\n%s", diff)
+	}
+}
+
+func TestNodeTCP(t *testing.T) {
+	const numNodes = 3
+	addrs := make(map[int]string)
+	for id := range numNodes {
+		lis, err := net.Listen("tcp", "127.0.0.1:0")
+		if err != nil {
+			t.Fatal(err)
+		}
+		addrs[id] = lis.Addr().String()
+		lis.Close()
+	}
+	c := newTestCluster(t, numNodes, func(id int) Transport {
+		transport, err := NewTCPTransport(id, addrs)
+		if err != nil {
+			t.Fatal(err)
+		}
+		return transport
+	})
+	defer c.stop()
+	vals := testValues("client", 10)
+	for i, val := range vals {
+		c.nodes[i%numNodes].Propose(val)
+	}
+	c.waitFor(t, vals...)
+}
+
+func TestMemNetwork(t *testing.T) {
+	network := NewMemNetwork()
+	t0, t1 := network.Transport(0), network.Transport(1)
+	prepare := Prepare{From: 0, Slot: 1, Crnd: 3}
+	if err := t0.Send(Message{From: 0, To: 1, Prepare: &prepare}); err != nil {
+		t.Fatal(err)
+	}
+	select {
+	case msg := <-t1.Receive():
+		if diff := cmp.Diff(Message{From: 0, To: 1, Prepare: &prepare}, msg); diff != "" {
+			t.Errorf("Receive() mismatch (-want +got):\n%s", diff)
+		}
+	case <-time.After(time.Second):
+		t.Fatal("message not received")
+	}
+	// messages to unknown or closed transports are lost
+	if err := t0.Send(Message{From: 0, To: 2, Prepare: &prepare}); err != nil {
+		t.Errorf("Send() to unknown node = %v, want nil", err)
+	}
+	t1.Close()
+	if err := t0.Send(Message{From: 0, To: 1, Prepare: &prepare}); err != nil {
+		t.Errorf("Send() to closed node = %v, want nil", err)
+	}
+	if err := t1.Send(Message{From: 1, To: 0, Prepare: &prepare}); err != ErrTransportClosed {
+		t.Errorf("Send() on closed transport = %v, want %v", err, ErrTransportClosed)
+	}
+}
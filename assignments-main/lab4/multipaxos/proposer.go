@@ -1,22 +1,34 @@
 package multipaxos
 
 import (
+	"math/rand"
+	"time"
+
 	"dat520/lab3/leaderdetector"
 )
 
-// Proposer represents a proposer as defined by the Multi-Paxos algorithm.
-type Proposer struct {
+// ProposerOf represents a proposer as defined by the Multi-Paxos algorithm,
+// for values of type V.
+type ProposerOf[V comparable] struct {
 	id           int
 	quorum       int
 	n            int
 	crnd         Round
 	adu          Slot
-	promises     []*Promise
+	promises     []*PromiseOf[V]
 	promiseCount int
 	ld           leaderdetector.LeaderDetector
 	leader       int
+	clock        Clock       // clock of the phase one timeouts
+	retry        RetryConfig // phase one timeout and backoff
+	rnd          *rand.Rand  // random source of the backoff
+	deadline     time.Time   // when phase one times out; zero if phase one is not running
+	retries      int         // consecutive phase one retries
 }
 
+// Proposer represents a proposer as defined by the Multi-Paxos algorithm.
+type Proposer = ProposerOf[Value]
+
 // NewProposer returns a new Multi-Paxos proposer. It takes the following
 // arguments:
 //
@@ -33,15 +45,23 @@ type Proposer struct {
 //
 // The proposer's crnd field should initially be set to the value of its id.
 func NewProposer(id, numNodes, adu int, ld leaderdetector.LeaderDetector) *Proposer {
-	return &Proposer{
+	return NewProposerOf[Value](id, numNodes, adu, ld)
+}
+
+// NewProposerOf returns a new Multi-Paxos proposer for values of type V.
+func NewProposerOf[V comparable](id, numNodes, adu int, ld leaderdetector.LeaderDetector) *ProposerOf[V] {
+	return &ProposerOf[V]{
 		id:       id,
 		quorum:   (numNodes / 2) + 1,
 		n:        numNodes,
 		crnd:     Round(id),
 		adu:      Slot(adu),
-		promises: make([]*Promise, numNodes),
+		promises: make([]*PromiseOf[V], numNodes),
 		ld:       ld,
 		leader:   ld.Leader(),
+		clock:    systemClock{},
+		retry:    DefaultRetryConfig,
+		rnd:      newRand(id),
 	}
 }
 
@@ -51,9 +71,62 @@ func NewProposer(id, numNodes, adu int, ld leaderdetector.LeaderDetector) *Propo
 // whose Val field is the zero value is unconstrained and can be set to any value.
 // If the slice is empty, the proposer is unconstrained and can send any value
 // in accept messages. If nil is returned, the proposer should ignore the promise.
-func (p *Proposer) handlePromise(prm Promise) []Accept {
-	// TODO(student): algorithm implementation
-	return []Accept{
-		{From: -1, Slot: -1, Rnd: -2},
+func (p *ProposerOf[V]) handlePromise(prm PromiseOf[V]) []AcceptOf[V] {
+	if prm.Rnd != p.crnd || prm.From < 0 || prm.From >= p.n || p.promises[prm.From] != nil {
+		return nil
+	}
+	if p.promiseCount >= p.quorum {
+		// phase one is already done for this round
+		return nil
 	}
+	p.promises[prm.From] = &prm
+	p.promiseCount++
+	if p.promiseCount < p.quorum {
+		return nil
+	}
+	p.stopPhaseOne()
+
+	// lock in the value with the highest vrnd of each slot after adu
+	locked := make(map[Slot]PValueOf[V])
+	maxSlot := p.adu
+	for _, promise := range p.promises {
+		if promise == nil {
+			continue
+		}
+		for _, pval := range promise.Accepted {
+			if pval.Slot <= p.adu {
+				continue
+			}
+			if prev, ok := locked[pval.Slot]; !ok || pval.Vrnd > prev.Vrnd {
+				locked[pval.Slot] = pval
+			}
+			maxSlot = max(maxSlot, pval.Slot)
+		}
+	}
+	accepts := make([]AcceptOf[V], 0, int(maxSlot-p.adu))
+	for slot := p.adu + 1; slot <= maxSlot; slot++ {
+		// gaps are filled with no-ops, the zero value
+		accepts = append(accepts, AcceptOf[V]{From: p.id, Slot: slot, Rnd: p.crnd, Val: locked[slot].Vval})
+	}
+	return accepts
+}
+
+// increaseCrnd increases the proposer's crnd by the total number of Paxos
+// nodes, and discards the promises collected in its previous round.
+func (p *ProposerOf[V]) increaseCrnd() {
+	p.crnd += Round(p.n)
+	p.promises = make([]*PromiseOf[V], p.n)
+	p.promiseCount = 0
+}
+
+// prepare returns the prepare message of the proposer's current round,
+// for the slots after the highest consecutive slot decided.
+func (p *ProposerOf[V]) prepare() Prepare {
+	return Prepare{From: p.id, Slot: p.adu + 1, Crnd: p.crnd}
+}
+
+// incrementAllDecidedUpTo increments the proposer's adu, once the slot after
+// it has been decided.
+func (p *ProposerOf[V]) incrementAllDecidedUpTo() {
+	p.adu++
 }
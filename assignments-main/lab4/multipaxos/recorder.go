@@ -0,0 +1,111 @@
+package multipaxos
+
+import (
+	"io"
+	"os"
+	"sync"
+	"time"
+)
+
+// Direction tells whether a recorded message was sent or received by a node.
+type Direction int
+
+const (
+	Sent Direction = iota
+	Received
+)
+
+// String returns the name of direction d.
+func (d Direction) String() string {
+	if d == Sent {
+		return "Sent"
+	}
+	return "Received"
+}
+
+// Record is a message sent or received by a node, as logged by a Recorder.
+// A received message is recorded when the node handles it.
+type Record struct {
+	Time time.Time
+	Node int
+	Dir  Direction
+	Msg  Message
+}
+
+// Recorder logs the messages sent and received by nodes to a trace, in the
+// wire format of Encoder, with a Record in each frame. The nodes of a cluster
+// may share a recorder. A nil *Recorder is valid and records nothing.
+type Recorder struct {
+	mu     sync.Mutex
+	enc    *Encoder
+	closer io.Closer
+	err    error // the first write error
+}
+
+// NewRecorder returns a recorder writing the trace to w.
+func NewRecorder(w io.Writer) *Recorder {
+	return &Recorder{enc: NewEncoder(w)}
+}
+
+// NewFileRecorder returns a recorder that appends the trace to the named file.
+func NewFileRecorder(name string) (*Recorder, error) {
+	f, err := os.OpenFile(name, os.O_CREATE|os.O_WRONLY|os.O_APPEND, 0o644)
+	if err != nil {
+		return nil, err
+	}
+	return &Recorder{enc: NewEncoder(f), closer: f}, nil
+}
+
+// record logs the message msg, sent or received by node id.
+func (r *Recorder) record(id int, dir Direction, msg Message) {
+	if r == nil {
+		return
+	}
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if r.err != nil {
+		return
+	}
+	r.err = r.enc.encode(Record{Time: time.Now(), Node: id, Dir: dir, Msg: msg})
+}
+
+// Close closes the recorder's file, if any, and returns the first error
+// from writing the trace.
+func (r *Recorder) Close() error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if r.closer != nil {
+		if err := r.closer.Close(); r.err == nil {
+			r.err = err
+		}
+		r.closer = nil
+	}
+	return r.err
+}
+
+// ReadTrace reads the records of a trace written by a Recorder.
+func ReadTrace(r io.Reader) ([]Record, error) {
+	dec := NewDecoder(r)
+	var records []Record
+	for {
+		var record Record
+		switch err := dec.decode(&record); err {
+		case nil:
+			records = append(records, record)
+		case io.EOF:
+			return records, nil
+		default:
+			return records, err
+		}
+	}
+}
+
+// ReadTraceFile reads the records of the named trace file.
+func ReadTraceFile(name string) ([]Record, error) {
+	f, err := os.Open(name)
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+	return ReadTrace(f)
+}
@@ -0,0 +1,104 @@
+package multipaxos
+
+import "fmt"
+
+// ReplayStep is a message handled by a node in a trace, and the output of
+// the node's handlers when the message is replayed.
+type ReplayStep struct {
+	Record    Record
+	Outputs   []Message  // the messages output by the handler, without To
+	Decisions []Decision // the decisions delivered by the learner
+	Missing   []Message  // the outputs that the node did not send in the trace
+}
+
+// Replay feeds the messages handled by node id in the trace, in a cluster
+// of numNodes nodes, to a fresh acceptor, proposer and learner, and returns
+// the handlers' output for each message. An output that the node did not
+// send in the trace is reported as missing, showing where the handlers,
+// for instance after a fix, diverge from the recorded run.
+//
+// The proposer's rounds are taken from the prepares sent by the node, since
+// phase one is started by leader changes and timeouts, not by messages.
+// Values proposed by the node as leader are not replayed.
+func Replay(id, numNodes int, records []Record) []ReplayStep {
+	acceptor := NewAcceptor(id)
+	proposer := NewProposer(id, numNodes, 0, staticLeader(id))
+	learner := NewLearner(numNodes)
+	var decisions []Decision
+	learner.SetDeliverFunc(func(d Decision) {
+		proposer.incrementAllDecidedUpTo()
+		decisions = append(decisions, d)
+	})
+
+	sent := make(map[string]bool)
+	for _, record := range records {
+		if record.Node == id && record.Dir == Sent {
+			sent[payloadKey(record.Msg)] = true
+		}
+	}
+	var steps []ReplayStep
+	for _, record := range records {
+		if record.Node != id {
+			continue
+		}
+		msg := record.Msg
+		if record.Dir == Sent {
+			if msg.Prepare != nil {
+				proposer.replayPrepare(*msg.Prepare)
+			}
+			continue
+		}
+		var outputs []Message
+		switch {
+		case msg.Prepare != nil:
+			if promise := acceptor.handlePrepare(*msg.Prepare); !isEmptyPromise(promise) {
+				outputs = append(outputs, Message{From: id, Promise: &promise})
+			}
+		case msg.Promise != nil:
+			for _, accept := range proposer.handlePromise(*msg.Promise) {
+				outputs = append(outputs, Message{From: id, Accept: &accept})
+			}
+		case msg.Accept != nil:
+			if learn := acceptor.handleAccept(*msg.Accept); learn != (Learn{}) {
+				outputs = append(outputs, Message{From: id, Learn: &learn})
+			}
+		case msg.Learn != nil:
+			learner.handleLearn(*msg.Learn)
+		case msg.Decision != nil:
+			learner.handleDecision(*msg.Decision)
+		}
+		step := ReplayStep{Record: record, Outputs: outputs, Decisions: decisions}
+		for _, out := range outputs {
+			if !sent[payloadKey(out)] {
+				step.Missing = append(step.Missing, out)
+			}
+		}
+		steps = append(steps, step)
+		decisions = nil
+	}
+	return steps
+}
+
+// replayPrepare sets the proposer's round and adu to those of a prepare
+// it sent in a trace, unless it is already in that round.
+func (p *ProposerOf[V]) replayPrepare(prepare Prepare) {
+	if prepare.Crnd == p.crnd {
+		return
+	}
+	p.crnd = prepare.Crnd
+	p.adu = prepare.Slot - 1
+	p.promises = make([]*PromiseOf[V], p.n)
+	p.promiseCount = 0
+}
+
+// payloadKey identifies the payload of a message, regardless of its receiver.
+func payloadKey(msg Message) string {
+	msg.To = 0
+	return fmt.Sprintf("%s %v", msg.Kind(), msg)
+}
+
+// staticLeader is a leader detector whose leader never changes.
+type staticLeader int
+
+func (l staticLeader) Leader() int           { return int(l) }
+func (l staticLeader) Subscribe() <-chan int { return nil }
@@ -0,0 +1,94 @@
+package multipaxos
+
+import (
+	"bytes"
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+)
+
+// recordCluster runs a cluster deciding vals with a leader change, and
+// returns the trace recorded by its nodes and the nodes' decisions.
+func recordCluster(t *testing.T, vals []Value) ([]Record, []map[Slot]Value) {
+	t.Helper()
+	var buf bytes.Buffer
+	recorder := NewRecorder(&buf)
+	network := NewMemNetwork()
+	c := newTestCluster(t, 3, network.Transport, func(n *Node) {
+		n.SetRecorder(recorder)
+	})
+	half := len(vals) / 2
+	for _, val := range vals[:half] {
+		c.nodes[0].Propose(val)
+	}
+	c.waitFor(t, vals[:half]...)
+	c.crash(2)
+	for _, val := range vals[half:] {
+		c.nodes[0].Propose(val)
+	}
+	c.waitFor(t, vals...)
+	c.stop()
+	if err := recorder.Close(); err != nil {
+		t.Fatal(err)
+	}
+	records, err := ReadTrace(&buf)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return records, c.logs
+}
+
+func TestReplay(t *testing.T) {
+	records, logs := recordCluster(t, testValues("client", 10))
+	for id := range logs {
+		decided := make(map[Slot]Value)
+		for _, step := range Replay(id, 3, records) {
+			if len(step.Missing) > 0 {
+				t.Errorf("node %d: replaying %v output %v, not sent in the trace", id, step.Record.Msg, step.Missing)
+			}
+			for _, d := range step.Decisions {
+				decided[d.Slot] = d.Val
+			}
+		}
+		// the nodes' decisions are received by the test after they are
+		// delivered, so a stopped node may have delivered more
+		for slot, val := range logs[id] {
+			if decided[slot] != val {
+				t.Errorf("node %d: replay decided %v in slot %d, want %v", id, decided[slot], slot, val)
+			}
+		}
+	}
+}
+
+func TestReplayDiverges(t *testing.T) {
+	records, _ := recordCluster(t, testValues("client", 4))
+	// an accept received by node 0 is corrupted in the trace
+	var corrupted *Record
+	for i, record := range records {
+		if record.Node == 0 && record.Dir == Received && record.Msg.Accept != nil {
+			accept := *record.Msg.Accept
+			accept.Val = valOne
+			records[i].Msg.Accept = &accept
+			corrupted = &records[i]
+			break
+		}
+	}
+	if corrupted == nil {
+		t.Fatal("no accept received by node 0 in the trace")
+	}
+	var missing []Message
+	for _, step := range Replay(0, 3, records) {
+		if cmp.Equal(step.Record, *corrupted) {
+			missing = step.Missing
+		}
+	}
+	want := []Message{{From: 0, Learn: &Learn{From: 0, Slot: corrupted.Msg.Accept.Slot, Rnd: corrupted.Msg.Accept.Rnd, Val: valOne}}}
+	if diff := cmp.Diff(want, missing); diff != "" {
+		t.Errorf("Missing mismatch (-want +got):\n%s", diff)
+	}
+}
+
+func TestRecorderNil(t *testing.T) {
+	var recorder *Recorder
+	recorder.record(0, Sent, wireMessages[0])
+}
@@ -0,0 +1,109 @@
+package multipaxos
+
+import (
+	"math/rand"
+	"time"
+)
+
+// Clock provides the time to the proposer's phase one timeouts, so that
+// tests can control it.
+type Clock interface {
+	Now() time.Time
+	After(d time.Duration) <-chan time.Time
+}
+
+// systemClock is the Clock using the system's time.
+type systemClock struct{}
+
+func (systemClock) Now() time.Time                         { return time.Now() }
+func (systemClock) After(d time.Duration) <-chan time.Time { return time.After(d) }
+
+// RetryConfig configures how long the proposer waits for a quorum of
+// promises before it retries phase one in a higher round.
+//
+// The first attempt times out after Timeout. Each retry waits Timeout plus
+// a random backoff, drawn from a window that starts at Timeout and doubles
+// with each consecutive retry, up to MaxBackoff; the random backoff keeps
+// dueling proposers from preempting each other's rounds forever.
+// A zero MaxBackoff disables the backoff.
+type RetryConfig struct {
+	Timeout    time.Duration // time to wait for a quorum of promises.
+	MaxBackoff time.Duration // upper bound of the backoff window.
+}
+
+// DefaultRetryConfig is the retry configuration used by new proposers.
+var DefaultRetryConfig = RetryConfig{
+	Timeout:    500 * time.Millisecond,
+	MaxBackoff: 4 * time.Second,
+}
+
+// SetRetryConfig sets the proposer's phase one timeout and backoff.
+func (p *ProposerOf[V]) SetRetryConfig(cfg RetryConfig) {
+	p.retry = cfg
+}
+
+// SetClock sets the clock used for the proposer's phase one timeouts.
+func (p *ProposerOf[V]) SetClock(clock Clock) {
+	p.clock = clock
+}
+
+// startPhaseOne starts phase one in a round higher than both the proposer's
+// current round and seen, the highest round known to have been used by
+// another proposer, and returns the prepare message to send. Phase one times
+// out if a quorum of promises has not been received in time.
+func (p *ProposerOf[V]) startPhaseOne(seen Round) Prepare {
+	p.increaseCrnd()
+	for p.crnd <= seen {
+		p.increaseCrnd()
+	}
+	p.retries = 0
+	p.deadline = p.clock.Now().Add(p.retry.Timeout)
+	return p.prepare()
+}
+
+// stopPhaseOne stops the proposer's phase one timeout, for instance when
+// the proposer is no longer the leader.
+func (p *ProposerOf[V]) stopPhaseOne() {
+	p.deadline = time.Time{}
+}
+
+// phaseOneDeadline returns the time that the proposer's phase one times out,
+// and false if phase one is not running.
+func (p *ProposerOf[V]) phaseOneDeadline() (time.Time, bool) {
+	return p.deadline, !p.deadline.IsZero()
+}
+
+// handleTimeout retries phase one in the proposer's next round if phase one
+// has timed out. It returns the prepare message to send, and false if phase
+// one has not timed out.
+func (p *ProposerOf[V]) handleTimeout() (Prepare, bool) {
+	now := p.clock.Now()
+	if p.deadline.IsZero() || now.Before(p.deadline) {
+		return Prepare{}, false
+	}
+	p.retries++
+	p.increaseCrnd()
+	p.deadline = now.Add(p.retry.Timeout + p.backoff())
+	return p.prepare(), true
+}
+
+// backoff returns a random backoff for the proposer's current retry.
+func (p *ProposerOf[V]) backoff() time.Duration {
+	if p.retry.MaxBackoff <= 0 {
+		return 0
+	}
+	window := p.retry.MaxBackoff
+	if shift := p.retries - 1; shift < 32 {
+		window = min(window, p.retry.Timeout<<shift)
+	}
+	if window <= 0 {
+		return 0
+	}
+	return time.Duration(p.rnd.Int63n(int64(window) + 1))
+}
+
+// newRand returns the random source of the proposer with the given id; the
+// proposers' sources differ, so that their backoffs do too.
+func newRand(id int) *rand.Rand {
+	return rand.New(rand.NewSource(time.Now().UnixNano() + int64(id)))
+}
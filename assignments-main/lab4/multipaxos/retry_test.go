@@ -0,0 +1,173 @@
+package multipaxos
+
+import (
+	"math/rand"
+	"testing"
+	"time"
+
+	"dat520/lab3/leaderdetector"
+
+	"github.com/google/go-cmp/cmp"
+)
+
+const testTimeout = 100 * time.Millisecond
+
+func TestProposerPhaseOneTimeout(t *testing.T) {
+	for _, test := range phaseOneTests {
+		t.Run(test.name, func(t *testing.T) {
+			clock := newFakeClock()
+			proposer := NewProposer(2, 3, test.adu, &mockLD{})
+			proposer.SetClock(clock)
+			proposer.SetRetryConfig(RetryConfig{Timeout: testTimeout})
+			for _, step := range test.steps {
+				var gotPrepare *Prepare
+				switch {
+				case step.start:
+					prepare := proposer.startPhaseOne(step.seen)
+					gotPrepare = &prepare
+				case step.promise != nil:
+					gotAccepts := proposer.handlePromise(*step.promise)
+					if diff := cmp.Diff(step.wantAccepts, gotAccepts); diff != "" {
+						t.Log(step.desc)
+						t.Errorf("handlePromise() mismatch (-want +got):\n%s", diff)
+					}
+					continue
+				default:
+					clock.Advance(step.advance)
+					if prepare, ok := proposer.handleTimeout(); ok {
+						gotPrepare = &prepare
+					}
+				}
+				if diff := cmp.Diff(step.wantPrepare, gotPrepare); diff != "" {
+					t.Log(step.desc)
+					t.Errorf("prepare mismatch (-want +got):\n%s", diff)
+				}
+			}
+		})
+	}
+}
+
+var phaseOneTests = []struct {
+	name  string
+	adu   int
+	steps []phaseOneStep
+}{
+	{
+		name: "TimeoutRetriesInHigherRound",
+		steps: []phaseOneStep{
+			{desc: "start phase one -> prepare in next round", start: true, wantPrepare: &Prepare{From: 2, Slot: 1, Crnd: 5}},
+			{desc: "advance clock before timeout -> no output", advance: testTimeout - time.Millisecond},
+			{desc: "advance clock to timeout -> prepare in next round", advance: time.Millisecond, wantPrepare: &Prepare{From: 2, Slot: 1, Crnd: 8}},
+			{desc: "advance clock to next timeout -> prepare in next round", advance: testTimeout, wantPrepare: &Prepare{From: 2, Slot: 1, Crnd: 11}},
+		},
+	},
+	{
+		name: "StartAboveSeenRound",
+		steps: []phaseOneStep{
+			{desc: "start phase one, seen round 9 -> prepare in round above 9", start: true, seen: 9, wantPrepare: &Prepare{From: 2, Slot: 1, Crnd: 11}},
+			{desc: "timeout -> prepare in next round", advance: testTimeout, wantPrepare: &Prepare{From: 2, Slot: 1, Crnd: 14}},
+		},
+	},
+	{
+		name: "PromisesForTimedOutRoundIgnored",
+		steps: []phaseOneStep{
+			{desc: "start phase one -> prepare in round 5", start: true, wantPrepare: &Prepare{From: 2, Slot: 1, Crnd: 5}},
+			{desc: "promise from 1 for round 5, no quorum -> no output", promise: &Promise{To: 2, From: 1, Rnd: 5}},
+			{desc: "timeout -> prepare in round 8", advance: testTimeout, wantPrepare: &Prepare{From: 2, Slot: 1, Crnd: 8}},
+			{desc: "promise from 0 for timed out round 5, ignore -> no output", promise: &Promise{To: 2, From: 0, Rnd: 5}},
+			{desc: "promise from 0 for round 8, no quorum -> no output", promise: &Promise{To: 2, From: 0, Rnd: 8}},
+			{desc: "promise from 1 for round 8, quorum -> empty accept slice", promise: &Promise{To: 2, From: 1, Rnd: 8}, wantAccepts: []Accept{}},
+			{desc: "advance clock after quorum -> no retry", advance: 10 * testTimeout},
+		},
+	},
+	{
+		name: "RetryLocksInAcceptedValues",
+		adu:  1,
+		steps: []phaseOneStep{
+			{desc: "start phase one -> prepare slot 2 in round 5", start: true, wantPrepare: &Prepare{From: 2, Slot: 2, Crnd: 5}},
+			{desc: "timeout -> prepare slot 2 in round 8", advance: testTimeout, wantPrepare: &Prepare{From: 2, Slot: 2, Crnd: 8}},
+			{desc: "promise from 0 for round 8 with value in slot 3, no quorum -> no output", promise: &Promise{To: 2, From: 0, Rnd: 8, Accepted: []PValue{
+				{Slot: 3, Vrnd: 4, Vval: valOne},
+			}}},
+			{desc: "promise from 1 for round 8, quorum -> accepts for slots 2 and 3 in round 8", promise: &Promise{To: 2, From: 1, Rnd: 8}, wantAccepts: []Accept{
+				{From: 2, Slot: 2, Rnd: 8, Val: Value{}},
+				{From: 2, Slot: 3, Rnd: 8, Val: valOne},
+			}},
+		},
+	},
+	{
+		name: "NoTimeoutWithoutPhaseOne",
+		steps: []phaseOneStep{
+			{desc: "advance clock before phase one is started -> no output", advance: 10 * testTimeout},
+		},
+	},
+}
+
+func TestProposerBackoff(t *testing.T) {
+	const maxBackoff = 4 * testTimeout
+	delays := func(seed int64) []time.Duration {
+		clock := newFakeClock()
+		proposer := NewProposer(2, 3, 0, &mockLD{})
+		proposer.SetClock(clock)
+		proposer.SetRetryConfig(RetryConfig{Timeout: testTimeout, MaxBackoff: maxBackoff})
+		proposer.rnd = rand.New(rand.NewSource(seed))
+		proposer.startPhaseOne(NoRound)
+		var delays []time.Duration
+		for retry := 1; retry <= 6; retry++ {
+			deadline, _ := proposer.phaseOneDeadline()
+			clock.Advance(deadline.Sub(clock.Now()))
+			if _, ok := proposer.handleTimeout(); !ok {
+				t.Fatalf("retry %d: no timeout at the deadline", retry)
+			}
+			deadline, _ = proposer.phaseOneDeadline()
+			delay := deadline.Sub(clock.Now())
+			window := min(maxBackoff, testTimeout<<(retry-1))
+			if delay < testTimeout || delay > testTimeout+window {
+				t.Errorf("retry %d: delay %v, want between %v and %v", retry, delay, testTimeout, testTimeout+window)
+			}
+			delays = append(delays, delay)
+		}
+		return delays
+	}
+	if cmp.Equal(delays(1), delays(2)) {
+		t.Error("proposers with different random sources have the same backoffs")
+	}
+}
+
+// TestNodePhaseOneRetry checks that a leader whose prepares are lost runs
+// phase one again after a timeout.
+func TestNodePhaseOneRetry(t *testing.T) {
+	const numNodes = 3
+	clock := newFakeClock()
+	network := NewMemNetwork()
+	ids := []int{0, 1, 2}
+	nodes := make([]*Node, numNodes)
+	for id := numNodes - 1; id >= 0; id-- {
+		nodes[id] = NewNode(id, numNodes, leaderdetector.NewMonLeaderDetector(ids), network.Transport(id))
+		nodes[id].SetClock(clock)
+		nodes[id].SetRetryConfig(RetryConfig{Timeout: testTimeout})
+		nodes[id].Start()
+		defer nodes[id].Stop()
+		// the leader is started first, and its prepares are lost since the
+		// other nodes are not connected yet; it sets its timeout after sending
+		if id == numNodes-1 && !clock.waitForTimer(time.Second) {
+			t.Fatal("leader did not start phase one")
+		}
+	}
+	val := Value{ClientID: "client", ClientSeq: 1, Command: "cmd"}
+	nodes[0].Propose(val)
+	select {
+	case d := <-nodes[0].Decisions():
+		t.Fatalf("decided %v before phase one was retried", d)
+	case <-time.After(100 * time.Millisecond):
+	}
+	clock.Advance(testTimeout)
+	select {
+	case d := <-nodes[0].Decisions():
+		if want := (Decision{Slot: 1, Val: val}); d != want {
+			t.Errorf("decided %v, want %v", d, want)
+		}
+	case <-time.After(decisionTimeout):
+		t.Fatal("value not decided after phase one was retried")
+	}
+}
@@ -0,0 +1,172 @@
+package multipaxos
+
+import (
+	"errors"
+	"net"
+	"sync"
+	"time"
+)
+
+// writeTimeout is the time to wait for a message to be written to a peer;
+// the message is dropped if the peer does not read it in time.
+const writeTimeout = time.Second
+
+// TCPTransport is a transport sending messages over TCP in the wire format
+// of Encoder.
+// A connection is dialed to each peer when the first message is sent to it,
+// and dialed again after it fails.
+type TCPTransport struct {
+	id       int
+	addrs    map[int]string
+	listener net.Listener
+	inbox    chan Message
+	done     chan struct{}
+
+	mu    sync.Mutex
+	peers map[int]*tcpPeer
+	conns map[net.Conn]bool // accepted connections
+}
+
+// tcpPeer is an outgoing connection to a peer.
+type tcpPeer struct {
+	mu   sync.Mutex
+	conn net.Conn
+	enc  *Encoder
+}
+
+// NewTCPTransport returns a TCP transport for node id, listening on
+// addrs[id], where addrs are the addresses of all the nodes by id.
+func NewTCPTransport(id int, addrs map[int]string) (*TCPTransport, error) {
+	lis, err := net.Listen("tcp", addrs[id])
+	if err != nil {
+		return nil, err
+	}
+	t := &TCPTransport{
+		id:       id,
+		addrs:    addrs,
+		listener: lis,
+		inbox:    make(chan Message, inboxSize),
+		done:     make(chan struct{}),
+		peers:    make(map[int]*tcpPeer),
+		conns:    make(map[net.Conn]bool),
+	}
+	go t.accept()
+	return t, nil
+}
+
+// Addr returns the address the transport listens on.
+func (t *TCPTransport) Addr() net.Addr {
+	return t.listener.Addr()
+}
+
+// accept accepts connections from the peers until the listener is closed.
+func (t *TCPTransport) accept() {
+	for {
+		conn, err := t.listener.Accept()
+		if err != nil {
+			return
+		}
+		t.mu.Lock()
+		t.conns[conn] = true
+		t.mu.Unlock()
+		go t.receive(conn)
+	}
+}
+
+// receive decodes the messages from conn into the inbox until it fails.
+func (t *TCPTransport) receive(conn net.Conn) {
+	defer func() {
+		t.mu.Lock()
+		delete(t.conns, conn)
+		t.mu.Unlock()
+		conn.Close()
+	}()
+	dec := NewDecoder(conn)
+	for {
+		msg, err := dec.Decode()
+		if err != nil {
+			return
+		}
+		select {
+		case t.inbox <- msg:
+		case <-t.done:
+			return
+		}
+	}
+}
+
+// Send sends the message to node msg.To, dialing it if needed.
+func (t *TCPTransport) Send(msg Message) error {
+	peer, err := t.peer(msg.To)
+	if err != nil {
+		return err
+	}
+	peer.mu.Lock()
+	defer peer.mu.Unlock()
+	if peer.conn == nil {
+		conn, err := net.DialTimeout("tcp", t.addrs[msg.To], writeTimeout)
+		if err != nil {
+			return err
+		}
+		peer.conn, peer.enc = conn, NewEncoder(conn)
+	}
+	peer.conn.SetWriteDeadline(time.Now().Add(writeTimeout))
+	if err := peer.enc.Encode(msg); err != nil {
+		// the stream is corrupt after a partly written frame
+		peer.conn.Close()
+		peer.conn, peer.enc = nil, nil
+		return err
+	}
+	return nil
+}
+
+// peer returns the outgoing connection to node id.
+func (t *TCPTransport) peer(id int) (*tcpPeer, error) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	select {
+	case <-t.done:
+		return nil, ErrTransportClosed
+	default:
+	}
+	if _, ok := t.addrs[id]; !ok {
+		return nil, errors.New("unknown node")
+	}
+	peer, ok := t.peers[id]
+	if !ok {
+		peer = &tcpPeer{}
+		t.peers[id] = peer
+	}
+	return peer, nil
+}
+
+// Receive returns the channel of messages received by the node.
+func (t *TCPTransport) Receive() <-chan Message {
+	return t.inbox
+}
+
+// Close stops listening and closes the transport's connections.
+func (t *TCPTransport) Close() error {
+	t.mu.Lock()
+	select {
+	case <-t.done:
+		t.mu.Unlock()
+		return nil
+	default:
+	}
+	close(t.done)
+	peers := t.peers
+	for conn := range t.conns {
+		conn.Close()
+	}
+	t.mu.Unlock()
+	err := t.listener.Close()
+	for _, peer := range peers {
+		peer.mu.Lock()
+		if peer.conn != nil {
+			peer.conn.Close()
+		}
+		peer.mu.Unlock()
+	}
+	return err
+}
@@ -1,5 +1,10 @@
 package multipaxos
 
+import (
+	"sync"
+	"time"
+)
+
 // promiseAccepts is a helper struct for testing the proposer.
 type promiseAccepts struct {
 	desc        string
@@ -28,6 +33,82 @@ type learnValue struct {
 	wantSlot  Slot
 }
 
+// phaseOneStep is a helper struct for testing the proposer's phase one
+// timeouts. A step either starts phase one, advances the clock and checks
+// for a timeout, or handles a promise.
+type phaseOneStep struct {
+	desc        string
+	start       bool          // start phase one
+	seen        Round         // highest round seen when starting phase one
+	advance     time.Duration // advance the clock, and handle a timeout
+	wantPrepare *Prepare      // prepare from starting phase one or from a timeout; nil if none
+	promise     *Promise      // promise to handle
+	wantAccepts []Accept
+}
+
+// fakeClock is a Clock whose time only moves when advanced.
+type fakeClock struct {
+	mu     sync.Mutex
+	now    time.Time
+	timers []fakeTimer
+}
+
+type fakeTimer struct {
+	at time.Time
+	ch chan time.Time
+}
+
+func newFakeClock() *fakeClock {
+	return &fakeClock{now: time.Unix(0, 0)}
+}
+
+func (c *fakeClock) Now() time.Time {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return c.now
+}
+
+func (c *fakeClock) After(d time.Duration) <-chan time.Time {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	ch := make(chan time.Time, 1)
+	if d <= 0 {
+		ch <- c.now
+		return ch
+	}
+	c.timers = append(c.timers, fakeTimer{at: c.now.Add(d), ch: ch})
+	return ch
+}
+
+// waitForTimer waits until a timer has been set, and reports whether one was.
+func (c *fakeClock) waitForTimer(timeout time.Duration) bool {
+	for deadline := time.Now().Add(timeout); time.Now().Before(deadline); time.Sleep(time.Millisecond) {
+		c.mu.Lock()
+		n := len(c.timers)
+		c.mu.Unlock()
+		if n > 0 {
+			return true
+		}
+	}
+	return false
+}
+
+// Advance moves the clock forward by d, firing the timers that expire.
+func (c *fakeClock) Advance(d time.Duration) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.now = c.now.Add(d)
+	timers := c.timers[:0]
+	for _, timer := range c.timers {
+		if timer.at.After(c.now) {
+			timers = append(timers, timer)
+			continue
+		}
+		timer.ch <- c.now
+	}
+	c.timers = timers
+}
+
 // mockLD is a mock leader detector.
 type mockLD struct{}
 
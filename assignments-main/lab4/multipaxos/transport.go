@@ -0,0 +1,177 @@
+package multipaxos
+
+import (
+	"errors"
+	"fmt"
+	"sync"
+	"sync/atomic"
+)
+
+// inboxSize is the number of messages buffered for a node by the transports.
+const inboxSize = 1024
+
+var (
+	// ErrTransportClosed is returned when sending on a closed transport.
+	ErrTransportClosed = errors.New("transport closed")
+	// errInboxFull is returned when the receiver's inbox is full; the
+	// message is dropped, which Paxos tolerates like any other message loss.
+	errInboxFull = errors.New("inbox full")
+)
+
+// Message is a Multi-Paxos message sent between the nodes. Exactly one of
+// the message fields is set; Decision is a decision sent by a distinguished
+// learner, and Value is a client value forwarded to the leader.
+type Message struct {
+	From, To int
+	Prepare  *Prepare
+	Promise  *Promise
+	Accept   *Accept
+	Learn    *Learn
+	Decision *Decision
+	Value    *Value
+}
+
+// Kind returns the name of the message's type, such as "Prepare".
+func (m Message) Kind() string {
+	switch {
+	case m.Prepare != nil:
+		return "Prepare"
+	case m.Promise != nil:
+		return "Promise"
+	case m.Accept != nil:
+		return "Accept"
+	case m.Learn != nil:
+		return "Learn"
+	case m.Decision != nil:
+		return "Decision"
+	case m.Value != nil:
+		return "Value"
+	}
+	return "Empty"
+}
+
+// String returns a string representation of message m.
+func (m Message) String() string {
+	var msg any
+	switch {
+	case m.Prepare != nil:
+		msg = *m.Prepare
+	case m.Promise != nil:
+		msg = *m.Promise
+	case m.Accept != nil:
+		msg = *m.Accept
+	case m.Learn != nil:
+		msg = *m.Learn
+	case m.Decision != nil:
+		msg = *m.Decision
+	case m.Value != nil:
+		msg = *m.Value
+	}
+	return fmt.Sprintf("Message{From: %d, To: %d, %v}", m.From, m.To, msg)
+}
+
+// Transport sends and receives the messages of a node.
+type Transport interface {
+	// Send sends the message to node msg.To. Messages may be lost;
+	// Send returns an error if the message could not be sent.
+	Send(msg Message) error
+	// Receive returns the channel of messages received by the node.
+	Receive() <-chan Message
+	// Close closes the transport. The receive channel is not closed.
+	Close() error
+}
+
+// MemNetwork is an in-memory network connecting the transports of nodes
+// running in the same process.
+type MemNetwork struct {
+	mu      sync.RWMutex
+	inboxes map[int]chan Message
+
+	countMu  sync.Mutex
+	counts   map[string]int // messages sent by kind
+	received map[int]int    // messages sent by receiver
+}
+
+// NewMemNetwork returns an in-memory network without any nodes.
+func NewMemNetwork() *MemNetwork {
+	return &MemNetwork{
+		inboxes:  make(map[int]chan Message),
+		counts:   make(map[string]int),
+		received: make(map[int]int),
+	}
+}
+
+// Count returns the number of messages of the given kind, such as "Learn",
+// sent on the network, including those that were lost.
+func (n *MemNetwork) Count(kind string) int {
+	n.countMu.Lock()
+	defer n.countMu.Unlock()
+	return n.counts[kind]
+}
+
+// Received returns the number of messages sent to node id on the network,
+// including those that were lost.
+func (n *MemNetwork) Received(id int) int {
+	n.countMu.Lock()
+	defer n.countMu.Unlock()
+	return n.received[id]
+}
+
+// Transport returns a new transport of node id, connecting it to the
+// network in place of its previous one, if any. Messages sent to nodes that
+// are not connected, or whose transport has been closed, are dropped.
+func (n *MemNetwork) Transport(id int) Transport {
+	n.mu.Lock()
+	defer n.mu.Unlock()
+	inbox := make(chan Message, inboxSize)
+	n.inboxes[id] = inbox
+	return &memTransport{network: n, id: id, inbox: inbox}
+}
+
+// deliver puts the message in the inbox of node msg.To.
+func (n *MemNetwork) deliver(msg Message) error {
+	n.countMu.Lock()
+	n.counts[msg.Kind()]++
+	n.received[msg.To]++
+	n.countMu.Unlock()
+	n.mu.RLock()
+	defer n.mu.RUnlock()
+	inbox, ok := n.inboxes[msg.To]
+	if !ok {
+		return nil // lost in the network
+	}
+	select {
+	case inbox <- msg:
+		return nil
+	default:
+		return errInboxFull
+	}
+}
+
+type memTransport struct {
+	network *MemNetwork
+	id      int
+	inbox   chan Message
+	closed  atomic.Bool
+}
+
+func (t *memTransport) Send(msg Message) error {
+	if t.closed.Load() {
+		return ErrTransportClosed
+	}
+	return t.network.deliver(msg)
+}
+
+func (t *memTransport) Receive() <-chan Message {
+	return t.inbox
+}
+
+func (t *memTransport) Close() error {
+	t.closed.Store(true)
+	t.network.mu.Lock()
+	defer t.network.mu.Unlock()
+	if t.network.inboxes[t.id] == t.inbox {
+		delete(t.network.inboxes, t.id)
+	}
+	return nil
+}
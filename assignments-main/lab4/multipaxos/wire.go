@@ -0,0 +1,132 @@
+package multipaxos
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/binary"
+	"encoding/gob"
+	"errors"
+	"fmt"
+	"io"
+)
+
+// WireVersion is the version of the wire format written by Encoder.
+//
+// A frame on the wire is the length of its payload, as an unsigned varint,
+// followed by the payload: the wire version byte and the gob encoding of the
+// message. Each frame is encoded with its own gob encoder, so that a frame
+// can be decoded on its own, for instance from a trace file that was
+// appended to by several runs.
+const WireVersion = 1
+
+// maxFrameSize is the largest payload accepted by Decoder; a larger length
+// prefix is taken to be a corrupt frame.
+const maxFrameSize = 16 << 20
+
+var (
+	// ErrUnsupportedVersion is returned when decoding a frame written with
+	// an unknown wire version.
+	ErrUnsupportedVersion = errors.New("unsupported wire version")
+	// errFrameTooLarge is returned when a frame's length prefix exceeds maxFrameSize.
+	errFrameTooLarge = errors.New("frame too large")
+)
+
+// MarshalMessage returns the payload of the frame of msg: the wire version
+// followed by the gob encoding of msg.
+func MarshalMessage(msg Message) ([]byte, error) {
+	return marshalFrame(msg)
+}
+
+// UnmarshalMessage decodes the payload of a message frame.
+func UnmarshalMessage(data []byte) (Message, error) {
+	var msg Message
+	err := unmarshalFrame(data, &msg)
+	return msg, err
+}
+
+// Encoder writes length-prefixed message frames to a stream.
+type Encoder struct {
+	w   io.Writer
+	buf []byte
+}
+
+// NewEncoder returns an encoder writing frames to w.
+func NewEncoder(w io.Writer) *Encoder {
+	return &Encoder{w: w}
+}
+
+// Encode writes the frame of msg.
+func (e *Encoder) Encode(msg Message) error {
+	return e.encode(msg)
+}
+
+// encode writes the frame of v, with a single write, so that concurrent
+// writers to the same file do not interleave their frames.
+func (e *Encoder) encode(v any) error {
+	payload, err := marshalFrame(v)
+	if err != nil {
+		return err
+	}
+	e.buf = binary.AppendUvarint(e.buf[:0], uint64(len(payload)))
+	e.buf = append(e.buf, payload...)
+	_, err = e.w.Write(e.buf)
+	return err
+}
+
+// Decoder reads length-prefixed message frames from a stream.
+type Decoder struct {
+	r *bufio.Reader
+}
+
+// NewDecoder returns a decoder reading frames from r.
+func NewDecoder(r io.Reader) *Decoder {
+	return &Decoder{r: bufio.NewReader(r)}
+}
+
+// Decode reads the next message frame. It returns io.EOF at the end of the
+// stream, and io.ErrUnexpectedEOF if the stream ends within a frame.
+func (d *Decoder) Decode() (Message, error) {
+	var msg Message
+	err := d.decode(&msg)
+	return msg, err
+}
+
+// decode reads the next frame into v.
+func (d *Decoder) decode(v any) error {
+	size, err := binary.ReadUvarint(d.r)
+	if err != nil {
+		return err
+	}
+	if size > maxFrameSize {
+		return errFrameTooLarge
+	}
+	payload := make([]byte, size)
+	if _, err := io.ReadFull(d.r, payload); err != nil {
+		if err == io.EOF {
+			return io.ErrUnexpectedEOF
+		}
+		return err
+	}
+	return unmarshalFrame(payload, v)
+}
+
+// marshalFrame returns the wire version followed by the gob encoding of v.
+func marshalFrame(v any) ([]byte, error) {
+	var buf bytes.Buffer
+	buf.WriteByte(WireVersion)
+	if err := gob.NewEncoder(&buf).Encode(v); err != nil {
+		return nil, err
+	}
+	return buf.Bytes(), nil
+}
+
+// unmarshalFrame decodes a payload written by marshalFrame into v.
+func unmarshalFrame(data []byte, v any) error {
+	if len(data) == 0 {
+		return io.ErrUnexpectedEOF
+	}
+	if data[0] != WireVersion {
+		return fmt.Errorf("%w: %d", ErrUnsupportedVersion, data[0])
+	}
+	return gob.NewDecoder(bytes.NewReader(data[1:])).Decode(v)
+}
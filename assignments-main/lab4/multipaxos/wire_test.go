@@ -0,0 +1,69 @@
+package multipaxos
+
+import (
+	"bytes"
+	"errors"
+	"io"
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+)
+
+var wireMessages = []Message{
+	{From: 2, To: 0, Prepare: &Prepare{From: 2, Slot: 1, Crnd: 5}},
+	{From: 0, To: 2, Promise: &Promise{To: 2, From: 0, Rnd: 5, Accepted: []PValue{
+		{Slot: 1, Vrnd: 2, Vval: valOne},
+		{Slot: 3, Vrnd: 4, Vval: valTwo},
+	}}},
+	{From: 2, To: 1, Accept: &Accept{From: 2, Slot: 2, Rnd: 5, Val: valThree}},
+	{From: 1, To: 2, Learn: &Learn{From: 1, Slot: 2, Rnd: 5, Val: valThree}},
+	{From: 2, To: 1, Decision: &Decision{Slot: 2, Val: valThree}},
+	{From: 1, To: 2, Value: &valOne},
+}
+
+func TestWireRoundTrip(t *testing.T) {
+	var buf bytes.Buffer
+	enc := NewEncoder(&buf)
+	for _, msg := range wireMessages {
+		if err := enc.Encode(msg); err != nil {
+			t.Fatalf("Encode(%v) = %v", msg, err)
+		}
+	}
+	dec := NewDecoder(&buf)
+	for _, want := range wireMessages {
+		got, err := dec.Decode()
+		if err != nil {
+			t.Fatalf("Decode() = %v, want %v", err, want)
+		}
+		if diff := cmp.Diff(want, got); diff != "" {
+			t.Errorf("Decode() mismatch (-want +got):\n%s", diff)
+		}
+	}
+	if _, err := dec.Decode(); err != io.EOF {
+		t.Errorf("Decode() at end of stream = %v, want %v", err, io.EOF)
+	}
+}
+
+func TestWireErrors(t *testing.T) {
+	data, err := MarshalMessage(wireMessages[0])
+	if err != nil {
+		t.Fatal(err)
+	}
+	if data[0] != WireVersion {
+		t.Errorf("version byte = %d, want %d", data[0], WireVersion)
+	}
+	future := bytes.Clone(data)
+	future[0] = WireVersion + 1
+	if _, err := UnmarshalMessage(future); !errors.Is(err, ErrUnsupportedVersion) {
+		t.Errorf("UnmarshalMessage(version %d) = %v, want %v", future[0], err, ErrUnsupportedVersion)
+	}
+
+	var buf bytes.Buffer
+	if err := NewEncoder(&buf).Encode(wireMessages[0]); err != nil {
+		t.Fatal(err)
+	}
+	truncated := buf.Bytes()[:buf.Len()-1]
+	if _, err := NewDecoder(bytes.NewReader(truncated)).Decode(); err != io.ErrUnexpectedEOF {
+		t.Errorf("Decode() of truncated frame = %v, want %v", err, io.ErrUnexpectedEOF)
+	}
+}
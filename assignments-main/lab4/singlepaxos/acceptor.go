@@ -1,31 +1,47 @@
 package singlepaxos
 
-// Acceptor represents an acceptor as defined by the single-decree Paxos algorithm.
-type Acceptor struct { // TODO(student): algorithm implementation
-	// Add needed fields
+// AcceptorOf represents an acceptor as defined by the single-decree Paxos
+// algorithm, for values of type V.
+type AcceptorOf[V comparable] struct {
+	id   int
+	rnd  Round // highest round promised or accepted in
+	vrnd Round // round of the accepted value; NoRound if none
+	vval V     // accepted value
 }
 
+// Acceptor represents an acceptor as defined by the single-decree Paxos algorithm.
+type Acceptor = AcceptorOf[Value]
+
 // NewAcceptor returns a new single-decree Paxos acceptor.
 // It takes the following arguments:
 //
 // id: The id of the node running this instance of a Paxos acceptor.
 func NewAcceptor(id int) *Acceptor {
-	// TODO(student): algorithm implementation
-	return &Acceptor{}
+	return NewAcceptorOf[Value](id)
+}
+
+// NewAcceptorOf returns a new single-decree Paxos acceptor for values of type V.
+func NewAcceptorOf[V comparable](id int) *AcceptorOf[V] {
+	return &AcceptorOf[V]{id: id, rnd: NoRound, vrnd: NoRound}
 }
 
 // handlePrepare processes the prepare according to the single-decree Paxos algorithm,
 // returning a promise, or an empty promise if the prepare should be ignored.
-func (a *Acceptor) handlePrepare(prepare Prepare) Promise {
-	// TODO(student): algorithm implementation
-	return Promise{To: -1, From: -1, Vrnd: -2, Vval: "FooBar"}
+func (a *AcceptorOf[V]) handlePrepare(prepare Prepare) PromiseOf[V] {
+	if prepare.Crnd <= a.rnd {
+		return PromiseOf[V]{}
+	}
+	a.rnd = prepare.Crnd
+	return PromiseOf[V]{To: prepare.From, From: a.id, Rnd: a.rnd, Vrnd: a.vrnd, Vval: a.vval}
 }
 
 // handleAccept processes the accept according to the single-decree Paxos algorithm,
 // returning a learn, or an empty learn if the accept should be ignored.
-func (a *Acceptor) handleAccept(accept Accept) Learn {
-	// TODO(student): algorithm implementation
-	return Learn{From: -1, Rnd: -2, Val: "FooBar"}
+func (a *AcceptorOf[V]) handleAccept(accept AcceptOf[V]) LearnOf[V] {
+	if accept.Rnd < a.rnd {
+		return LearnOf[V]{}
+	}
+	a.rnd = accept.Rnd
+	a.vrnd, a.vval = accept.Rnd, accept.Val
+	return LearnOf[V]{From: a.id, Rnd: accept.Rnd, Val: accept.Val}
 }
-
-// TODO(student): Add any other unexported methods needed.
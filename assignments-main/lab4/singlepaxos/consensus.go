@@ -0,0 +1,279 @@
+package singlepaxos
+
+import (
+	"context"
+	"errors"
+	"math/rand"
+	"time"
+)
+
+var (
+	// ErrZeroValue is returned when proposing the zero value, which the
+	// learner uses to report that no value was decided.
+	ErrZeroValue = errors.New("cannot propose the zero value")
+	// ErrStopped is returned when the consensus instance has been stopped.
+	ErrStopped = errors.New("consensus stopped")
+)
+
+// RetryConfig configures how long a proposer waits for its value to be
+// chosen before it retries in a higher round.
+//
+// The first attempt times out after Timeout. Each retry waits Timeout plus
+// a random backoff, drawn from a window that starts at Timeout and doubles
+// with each consecutive retry, up to MaxBackoff; the random backoff keeps
+// dueling proposers from preempting each other's rounds forever.
+// A zero MaxBackoff disables the backoff.
+type RetryConfig struct {
+	Timeout    time.Duration // time to wait for a value to be chosen.
+	MaxBackoff time.Duration // upper bound of the backoff window.
+}
+
+// DefaultRetryConfig is the retry configuration used by new consensus instances.
+var DefaultRetryConfig = RetryConfig{
+	Timeout:    500 * time.Millisecond,
+	MaxBackoff: 4 * time.Second,
+}
+
+// Consensus is a single-decree Paxos instance, choosing a single value
+// among the values proposed by the nodes of a cluster, for instance to elect
+// a coordinator or to agree on a cluster ID. Each node runs the three Paxos
+// roles, connected to the node's transport; the nodes of a cluster have
+// the ids 0 to numNodes-1.
+//
+// A node that proposes a value runs both phases in a new round, and retries
+// in a higher round if the value is not chosen in time. The acceptors send
+// their learns to every node, so that every node learns the chosen value,
+// whether it proposed a value or not.
+type Consensus struct {
+	id        int
+	nodeIDs   []int
+	transport Transport
+	proposer  *Proposer
+	acceptor  *Acceptor
+	learner   *Learner
+
+	retry     RetryConfig
+	rnd       *rand.Rand
+	retries   int              // retries of the current proposal
+	proposals int              // Propose calls waiting for the chosen value
+	timeout   <-chan time.Time // fires when the current round may have failed
+	local     []Message        // messages sent to this node itself
+
+	chosen   Value
+	decided  chan struct{} // closed when the chosen value is learnt
+	requests chan Value
+	withdraw chan struct{}
+	stop     chan struct{}
+	done     chan struct{}
+}
+
+// NewConsensus returns a single-decree Paxos instance for node id, in a
+// cluster of numNodes nodes, that communicates through transport.
+func NewConsensus(id, numNodes int, transport Transport) *Consensus {
+	nodeIDs := make([]int, numNodes)
+	for i := range nodeIDs {
+		nodeIDs[i] = i
+	}
+	return &Consensus{
+		id:        id,
+		nodeIDs:   nodeIDs,
+		transport: transport,
+		proposer:  NewProposer(id, numNodes),
+		acceptor:  NewAcceptor(id),
+		learner:   NewLearner(id, numNodes),
+		retry:     DefaultRetryConfig,
+		rnd:       rand.New(rand.NewSource(time.Now().UnixNano() + int64(id))),
+		decided:   make(chan struct{}),
+		requests:  make(chan Value),
+		withdraw:  make(chan struct{}),
+		stop:      make(chan struct{}),
+		done:      make(chan struct{}),
+	}
+}
+
+// SetRetryConfig sets the proposer's timeout and backoff. It must be called
+// before the instance is started.
+func (c *Consensus) SetRetryConfig(cfg RetryConfig) {
+	c.retry = cfg
+}
+
+// Start starts the instance's run loop.
+func (c *Consensus) Start() {
+	go c.run()
+}
+
+// Stop stops the instance's run loop and closes its transport.
+func (c *Consensus) Stop() {
+	close(c.stop)
+	<-c.done
+	c.transport.Close()
+}
+
+// Propose proposes val and waits for a value to be chosen, retrying in
+// higher rounds until then. It returns the chosen value, which may have been
+// proposed by another node, or the context's error if ctx is done first.
+// Only the first of concurrent proposals from the same node is proposed.
+func (c *Consensus) Propose(ctx context.Context, val Value) (chosen Value, err error) {
+	if val == ZeroValue {
+		return ZeroValue, ErrZeroValue
+	}
+	select {
+	case c.requests <- val:
+	case <-c.decided:
+		return c.chosen, nil
+	case <-c.done:
+		return ZeroValue, ErrStopped
+	case <-ctx.Done():
+		return ZeroValue, ctx.Err()
+	}
+	chosen, err = c.Wait(ctx)
+	if err != nil {
+		// stop retrying if there are no other proposals waiting
+		select {
+		case c.withdraw <- struct{}{}:
+		case <-c.done:
+		}
+	}
+	return chosen, err
+}
+
+// Wait waits for a value to be chosen, without proposing a value, and
+// returns the chosen value, or the context's error if ctx is done first.
+func (c *Consensus) Wait(ctx context.Context) (Value, error) {
+	select {
+	case <-c.decided:
+		return c.chosen, nil
+	case <-c.done:
+		return ZeroValue, ErrStopped
+	case <-ctx.Done():
+		return ZeroValue, ctx.Err()
+	}
+}
+
+// run handles the instance's messages and proposals until it is stopped.
+func (c *Consensus) run() {
+	defer close(c.done)
+	for {
+		for len(c.local) > 0 {
+			msg := c.local[0]
+			c.local = c.local[1:]
+			c.handle(msg)
+		}
+		select {
+		case msg := <-c.transport.Receive():
+			c.handle(msg)
+		case val := <-c.requests:
+			c.propose(val)
+		case <-c.withdraw:
+			c.proposals--
+			if c.proposals == 0 {
+				c.timeout = nil
+			}
+		case <-c.timeout:
+			c.retries++
+			c.startRound()
+		case <-c.stop:
+			return
+		}
+	}
+}
+
+// propose starts proposing val, unless a value is already chosen or being
+// proposed by this node.
+func (c *Consensus) propose(val Value) {
+	c.proposals++
+	if c.proposals > 1 || c.isDecided() {
+		return
+	}
+	c.proposer.clientValue = val
+	c.retries = 0
+	c.startRound()
+}
+
+// startRound runs phase one in a round higher than any round seen by this
+// node's acceptor, since a prepare in a lower round would be ignored.
+// The round times out if no value is chosen in time.
+func (c *Consensus) startRound() {
+	c.proposer.increaseCrnd()
+	for c.proposer.crnd <= c.acceptor.rnd {
+		c.proposer.increaseCrnd()
+	}
+	c.timeout = time.After(c.retry.Timeout + c.backoff())
+	c.broadcast(Message{Prepare: &Prepare{From: c.id, Crnd: c.proposer.crnd}})
+}
+
+// backoff returns a random backoff for the current retry; the first
+// attempt has no backoff.
+func (c *Consensus) backoff() time.Duration {
+	if c.retries == 0 || c.retry.MaxBackoff <= 0 {
+		return 0
+	}
+	window := c.retry.MaxBackoff
+	if shift := c.retries - 1; shift < 32 {
+		window = min(window, c.retry.Timeout<<shift)
+	}
+	if window <= 0 {
+		return 0
+	}
+	return time.Duration(c.rnd.Int63n(int64(window) + 1))
+}
+
+// handle handles the message with the handler of its type.
+func (c *Consensus) handle(msg Message) {
+	switch {
+	case msg.Prepare != nil:
+		promise := c.acceptor.handlePrepare(*msg.Prepare)
+		if promise != (Promise{}) {
+			c.send(Message{To: promise.To, Promise: &promise})
+		}
+	case msg.Promise != nil:
+		if c.proposals == 0 || c.isDecided() {
+			return
+		}
+		accept := c.proposer.handlePromise(*msg.Promise)
+		if accept != (Accept{}) {
+			c.broadcast(Message{Accept: &accept})
+		}
+	case msg.Accept != nil:
+		learn := c.acceptor.handleAccept(*msg.Accept)
+		if learn != (Learn{}) {
+			c.broadcast(Message{Learn: &learn})
+		}
+	case msg.Learn != nil:
+		if val := c.learner.handleLearn(*msg.Learn); val != ZeroValue {
+			c.chosen = val
+			c.timeout = nil
+			close(c.decided)
+		}
+	}
+}
+
+// isDecided returns true if the chosen value has been learnt.
+func (c *Consensus) isDecided() bool {
+	select {
+	case <-c.decided:
+		return true
+	default:
+		return false
+	}
+}
+
+// send sends the message from this node; messages to the node itself are
+// handled by its run loop without going through the transport.
+func (c *Consensus) send(msg Message) {
+	msg.From = c.id
+	if msg.To == c.id {
+		c.local = append(c.local, msg)
+		return
+	}
+	// messages may be lost; Paxos tolerates it
+	_ = c.transport.Send(msg)
+}
+
+// broadcast sends the message to every node, including this node.
+func (c *Consensus) broadcast(msg Message) {
+	for _, id := range c.nodeIDs {
+		msg.To = id
+		c.send(msg)
+	}
+}
@@ -0,0 +1,122 @@
+package singlepaxos
+
+import (
+	"context"
+	"fmt"
+	"sync"
+	"testing"
+	"time"
+)
+
+// testRetryConfig makes rounds time out quickly, so that the tests retry.
+var testRetryConfig = RetryConfig{Timeout: 50 * time.Millisecond, MaxBackoff: 200 * time.Millisecond}
+
+// newTestConsensus starts numNodes consensus instances connected by network.
+func newTestConsensus(network *MemNetwork, numNodes int) []*Consensus {
+	nodes := make([]*Consensus, numNodes)
+	for id := range nodes {
+		nodes[id] = NewConsensus(id, numNodes, network.Transport(id))
+		nodes[id].SetRetryConfig(testRetryConfig)
+		nodes[id].Start()
+	}
+	return nodes
+}
+
+func stopAll(nodes []*Consensus) {
+	for _, node := range nodes {
+		node.Stop()
+	}
+}
+
+func TestConsensusDecides(t *testing.T) {
+	const numNodes = 5
+	nodes := newTestConsensus(NewMemNetwork(), numNodes)
+	defer stopAll(nodes)
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	// the proposers duel until one of them wins a round
+	proposed := make(map[Value]bool)
+	chosen := make([]Value, numNodes)
+	var wg sync.WaitGroup
+	for id := range numNodes - 1 {
+		val := Value(fmt.Sprintf("coordinator %d", id))
+		proposed[val] = true
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			var err error
+			if chosen[id], err = nodes[id].Propose(ctx, val); err != nil {
+				t.Errorf("node %d: Propose() = %v", id, err)
+			}
+		}()
+	}
+	// the last node learns the chosen value without proposing
+	last := numNodes - 1
+	var err error
+	if chosen[last], err = nodes[last].Wait(ctx); err != nil {
+		t.Errorf("node %d: Wait() = %v", last, err)
+	}
+	wg.Wait()
+	if !proposed[chosen[0]] {
+		t.Errorf("chose %q, want one of the proposed values", chosen[0])
+	}
+	for id, val := range chosen {
+		if val != chosen[0] {
+			t.Errorf("node %d chose %q, node 0 chose %q", id, val, chosen[0])
+		}
+	}
+
+	// the chosen value is returned to later proposals
+	if val, err := nodes[0].Propose(ctx, "too late"); err != nil || val != chosen[0] {
+		t.Errorf("Propose() after decision = %q, %v, want %q, nil", val, err, chosen[0])
+	}
+}
+
+func TestConsensusRetry(t *testing.T) {
+	const numNodes = 3
+	network := NewMemNetwork()
+	proposer := NewConsensus(0, numNodes, network.Transport(0))
+	proposer.SetRetryConfig(testRetryConfig)
+	proposer.Start()
+	defer proposer.Stop()
+
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+	result := make(chan Value, 1)
+	go func() {
+		val, err := proposer.Propose(ctx, "cluster-1")
+		if err != nil {
+			t.Errorf("Propose() = %v", err)
+		}
+		result <- val
+	}()
+	// the first round's prepares are lost, since the other nodes are not
+	// connected yet; the value is chosen in a later round
+	time.Sleep(testRetryConfig.Timeout / 2)
+	for id := 1; id < numNodes; id++ {
+		node := NewConsensus(id, numNodes, network.Transport(id))
+		node.Start()
+		defer node.Stop()
+	}
+	if val := <-result; val != "cluster-1" {
+		t.Errorf("Propose() = %q, want %q", val, "cluster-1")
+	}
+}
+
+func TestConsensusNoQuorum(t *testing.T) {
+	network := NewMemNetwork()
+	node := NewConsensus(0, 3, network.Transport(0))
+	node.SetRetryConfig(testRetryConfig)
+	node.Start()
+	defer node.Stop()
+
+	ctx, cancel := context.WithTimeout(context.Background(), 3*testRetryConfig.Timeout)
+	defer cancel()
+	if val, err := node.Propose(ctx, "cluster-1"); err != context.DeadlineExceeded {
+		t.Errorf("Propose() without quorum = %q, %v, want %v", val, err, context.DeadlineExceeded)
+	}
+	if _, err := node.Propose(context.Background(), ZeroValue); err != ErrZeroValue {
+		t.Errorf("Propose(ZeroValue) = %v, want %v", err, ErrZeroValue)
+	}
+}
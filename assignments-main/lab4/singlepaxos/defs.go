@@ -13,6 +13,8 @@ type Round int
 const NoRound Round = -1
 
 // Value represents a value that can be chosen using the Paxos algorithm.
+// The Paxos roles and messages are generic over the type of value, such as
+// AcceptorOf[V]; their plain names, such as Acceptor, are used for Value.
 type Value string
 
 // ZeroValue is a constant that represents the zero value for the Value type.
@@ -31,16 +33,19 @@ func (p Prepare) String() string {
 	return fmt.Sprintf("Prepare{From: %d, Crnd: %d}", p.From, p.Crnd)
 }
 
-// Promise represents a single-decree Paxos promise message.
-type Promise struct {
+// PromiseOf represents a single-decree Paxos promise message for values of type V.
+type PromiseOf[V comparable] struct {
 	To, From int
 	Rnd      Round
 	Vrnd     Round
-	Vval     Value
+	Vval     V
 }
 
+// Promise represents a single-decree Paxos promise message.
+type Promise = PromiseOf[Value]
+
 // String returns a string representation of promise p.
-func (p Promise) String() string {
+func (p PromiseOf[V]) String() string {
 	if p.Vrnd == NoRound {
 		return fmt.Sprintf("Promise{To: %d, From: %d, Rnd: %d, No value reported}",
 			p.To, p.From, p.Rnd)
@@ -49,26 +54,32 @@ func (p Promise) String() string {
 		p.To, p.From, p.Rnd, p.Vrnd, p.Vval)
 }
 
-// Accept represents a single-decree Paxos accept message.
-type Accept struct {
+// AcceptOf represents a single-decree Paxos accept message for values of type V.
+type AcceptOf[V comparable] struct {
 	From int
 	Rnd  Round
-	Val  Value
+	Val  V
 }
 
+// Accept represents a single-decree Paxos accept message.
+type Accept = AcceptOf[Value]
+
 // String returns a string representation of accept a.
-func (a Accept) String() string {
+func (a AcceptOf[V]) String() string {
 	return fmt.Sprintf("Accept{From: %d, Rnd: %d, Val: %v}", a.From, a.Rnd, a.Val)
 }
 
-// Learn represents a single-decree Paxos learn message.
-type Learn struct {
+// LearnOf represents a single-decree Paxos learn message for values of type V.
+type LearnOf[V comparable] struct {
 	From int
 	Rnd  Round
-	Val  Value
+	Val  V
 }
 
+// Learn represents a single-decree Paxos learn message.
+type Learn = LearnOf[Value]
+
 // String returns a string representation of learn l.
-func (l Learn) String() string {
+func (l LearnOf[V]) String() string {
 	return fmt.Sprintf("Learn{From: %d, Rnd: %d, Val: %v}", l.From, l.Rnd, l.Val)
 }
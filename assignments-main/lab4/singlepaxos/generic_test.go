@@ -0,0 +1,64 @@
+package singlepaxos
+
+import (
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+)
+
+// clusterConfig is a value type other than Value, as used to agree on the
+// configuration of a cluster.
+type clusterConfig struct {
+	Epoch   int
+	Members string
+}
+
+// TestGenericValue runs the three roles on clusterConfig values: proposer 2
+// is locked in to the value accepted by proposer 1 in an earlier round.
+func TestGenericValue(t *testing.T) {
+	const numNodes = 3
+	acceptors := make([]*AcceptorOf[clusterConfig], numNodes)
+	for id := range acceptors {
+		acceptors[id] = NewAcceptorOf[clusterConfig](id)
+	}
+	learner := NewLearnerOf[clusterConfig](0, numNodes)
+	first := clusterConfig{Epoch: 1, Members: "a,b,c"}
+	second := clusterConfig{Epoch: 2, Members: "a,b,d"}
+
+	// runRound runs both phases of proposer p's round with the acceptors
+	// with the given ids, and returns the learns of the acceptors.
+	runRound := func(p *ProposerOf[clusterConfig], ids ...int) []LearnOf[clusterConfig] {
+		var accept AcceptOf[clusterConfig]
+		for _, id := range ids {
+			promise := acceptors[id].handlePrepare(Prepare{From: p.id, Crnd: p.crnd})
+			if acc := p.handlePromise(promise); acc != (AcceptOf[clusterConfig]{}) {
+				accept = acc
+			}
+		}
+		var learns []LearnOf[clusterConfig]
+		for _, id := range ids {
+			learns = append(learns, acceptors[id].handleAccept(accept))
+		}
+		return learns
+	}
+
+	p1 := NewProposerOf[clusterConfig](1, numNodes)
+	p1.clientValue = first
+	// only acceptor 0's learn is received
+	learns := runRound(p1, 0, 1)
+	if got := learner.handleLearn(learns[0]); got != (clusterConfig{}) {
+		t.Errorf("handleLearn() = %v without a quorum, want zero value", got)
+	}
+
+	p2 := NewProposerOf[clusterConfig](2, numNodes)
+	p2.clientValue = second
+	var got clusterConfig
+	for _, learn := range runRound(p2, 1, 2) {
+		if val := learner.handleLearn(learn); val != (clusterConfig{}) {
+			got = val
+		}
+	}
+	if diff := cmp.Diff(first, got); diff != "" {
+		t.Errorf("decided value mismatch (-want +got):\n%s", diff)
+	}
+}
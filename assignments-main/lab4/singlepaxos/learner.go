@@ -1,11 +1,19 @@
 package singlepaxos
 
-// Learner represents a learner as defined by the single-decree Paxos algorithm.
-type Learner struct { // TODO(student): algorithm implementation
-	// Add needed fields
-	// Tip: you need to keep the decided values by the Paxos nodes somewhere
+// LearnerOf represents a learner as defined by the single-decree Paxos
+// algorithm, for values of type V.
+type LearnerOf[V comparable] struct {
+	id      int
+	quorum  int
+	rnd     Round        // highest round learnt in
+	val     V            // value learnt in rnd
+	from    map[int]bool // senders of the learns for rnd
+	decided bool
 }
 
+// Learner represents a learner as defined by the single-decree Paxos algorithm.
+type Learner = LearnerOf[Value]
+
 // NewLearner returns a new single-decree Paxos learner. It takes the
 // following arguments:
 //
@@ -13,16 +21,38 @@ type Learner struct { // TODO(student): algorithm implementation
 //
 // numNodes: The total number of Paxos nodes.
 func NewLearner(id int, numNodes int) *Learner {
-	// TODO(student): algorithm implementation
-	return &Learner{}
+	return NewLearnerOf[Value](id, numNodes)
+}
+
+// NewLearnerOf returns a new single-decree Paxos learner for values of type V.
+func NewLearnerOf[V comparable](id int, numNodes int) *LearnerOf[V] {
+	return &LearnerOf[V]{
+		id:     id,
+		quorum: numNodes/2 + 1,
+		rnd:    NoRound,
+		from:   make(map[int]bool),
+	}
 }
 
 // handleLearn processes the learn according to the single-decree Paxos algorithm,
 // returning a value if the learn results in the learner emitting a decided value.
 // Otherwise, it returns an empty value.
-func (l *Learner) handleLearn(learn Learn) Value {
-	// TODO(student): algorithm implementation
-	return "FooBar"
+func (l *LearnerOf[V]) handleLearn(learn LearnOf[V]) V {
+	var zero V
+	switch {
+	case l.decided, learn.Rnd < l.rnd:
+		return zero
+	case learn.Rnd > l.rnd:
+		l.rnd, l.val = learn.Rnd, learn.Val
+		clear(l.from)
+	case learn.Val != l.val:
+		// a round has a single value; ignore a conflicting learn
+		return zero
+	}
+	l.from[learn.From] = true
+	if len(l.from) < l.quorum {
+		return zero
+	}
+	l.decided = true
+	return l.val
 }
-
-// TODO(student): Add any other unexported methods needed.
@@ -1,13 +1,21 @@
 package singlepaxos
 
-// Proposer represents a proposer as defined by the single-decree Paxos algorithm.
-type Proposer struct {
+// ProposerOf represents a proposer as defined by the single-decree Paxos
+// algorithm, for values of type V.
+type ProposerOf[V comparable] struct {
 	crnd        Round
-	clientValue Value
-	// TODO(student): algorithm implementation
-	// Add other needed fields
+	clientValue V
+	id          int
+	n           int
+	quorum      int
+	promises    map[int]bool // senders of the promises for crnd
+	vrnd        Round        // highest vrnd reported in the promises; NoRound if none
+	vval        V            // value reported with vrnd
 }
 
+// Proposer represents a proposer as defined by the single-decree Paxos algorithm.
+type Proposer = ProposerOf[Value]
+
 // NewProposer returns a new single-decree Paxos proposer.
 // It takes the following arguments:
 //
@@ -18,22 +26,49 @@ type Proposer struct {
 // The proposer's internal crnd field should initially be set to the value of
 // its id.
 func NewProposer(id int, numNodes int) *Proposer {
-	// TODO(student): algorithm implementation
-	return &Proposer{}
+	return NewProposerOf[Value](id, numNodes)
+}
+
+// NewProposerOf returns a new single-decree Paxos proposer for values of type V.
+func NewProposerOf[V comparable](id int, numNodes int) *ProposerOf[V] {
+	return &ProposerOf[V]{
+		crnd:     Round(id),
+		id:       id,
+		n:        numNodes,
+		quorum:   numNodes/2 + 1,
+		promises: make(map[int]bool),
+		vrnd:     NoRound,
+	}
 }
 
 // handlePromise processes the promise according to the single-decree Paxos algorithm.
 // It returns an accept message to send if the proposer has gathered a majority of promises.
 // If an empty accept message is returned, the proposer should ignore the promise.
-func (p *Proposer) handlePromise(promise Promise) Accept {
-	// TODO(student): algorithm implementation
-	return Accept{From: -1, Rnd: -2, Val: "FooBar"}
+func (p *ProposerOf[V]) handlePromise(promise PromiseOf[V]) AcceptOf[V] {
+	if promise.Rnd != p.crnd || p.promises[promise.From] || len(p.promises) >= p.quorum {
+		return AcceptOf[V]{}
+	}
+	p.promises[promise.From] = true
+	if promise.Vrnd > p.vrnd {
+		p.vrnd, p.vval = promise.Vrnd, promise.Vval
+	}
+	if len(p.promises) < p.quorum {
+		return AcceptOf[V]{}
+	}
+	val := p.clientValue
+	if p.vrnd != NoRound {
+		// locked in to the value with the highest vrnd
+		val = p.vval
+	}
+	return AcceptOf[V]{From: p.id, Rnd: p.crnd, Val: val}
 }
 
 // increaseCrnd increases proposer p's crnd field by the total number
 // of Paxos nodes.
-func (p *Proposer) increaseCrnd() {
-	// TODO(student): algorithm implementation
+func (p *ProposerOf[V]) increaseCrnd() {
+	p.crnd += Round(p.n)
+	clear(p.promises)
+	p.vrnd = NoRound
+	var zero V
+	p.vval = zero
 }
-
-// TODO(student): Add any other unexported methods needed.
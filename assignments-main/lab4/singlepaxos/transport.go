@@ -0,0 +1,123 @@
+package singlepaxos
+
+import (
+	"errors"
+	"fmt"
+	"sync"
+	"sync/atomic"
+)
+
+// inboxSize is the number of messages buffered for a node by the transports.
+const inboxSize = 1024
+
+var (
+	// ErrTransportClosed is returned when sending on a closed transport.
+	ErrTransportClosed = errors.New("transport closed")
+	// errInboxFull is returned when the receiver's inbox is full; the
+	// message is dropped, which Paxos tolerates like any other message loss.
+	errInboxFull = errors.New("inbox full")
+)
+
+// Message is a single-decree Paxos message sent between the nodes.
+// Exactly one of the message fields is set.
+type Message struct {
+	From, To int
+	Prepare  *Prepare
+	Promise  *Promise
+	Accept   *Accept
+	Learn    *Learn
+}
+
+// String returns a string representation of message m.
+func (m Message) String() string {
+	var msg any
+	switch {
+	case m.Prepare != nil:
+		msg = *m.Prepare
+	case m.Promise != nil:
+		msg = *m.Promise
+	case m.Accept != nil:
+		msg = *m.Accept
+	case m.Learn != nil:
+		msg = *m.Learn
+	}
+	return fmt.Sprintf("Message{From: %d, To: %d, %v}", m.From, m.To, msg)
+}
+
+// Transport sends and receives the messages of a node.
+type Transport interface {
+	// Send sends the message to node msg.To. Messages may be lost;
+	// Send returns an error if the message could not be sent.
+	Send(msg Message) error
+	// Receive returns the channel of messages received by the node.
+	Receive() <-chan Message
+	// Close closes the transport. The receive channel is not closed.
+	Close() error
+}
+
+// MemNetwork is an in-memory network connecting the transports of nodes
+// running in the same process.
+type MemNetwork struct {
+	mu      sync.RWMutex
+	inboxes map[int]chan Message
+}
+
+// NewMemNetwork returns an in-memory network without any nodes.
+func NewMemNetwork() *MemNetwork {
+	return &MemNetwork{inboxes: make(map[int]chan Message)}
+}
+
+// Transport returns a new transport of node id, connecting it to the
+// network in place of its previous one, if any. Messages sent to nodes that
+// are not connected, or whose transport has been closed, are dropped.
+func (n *MemNetwork) Transport(id int) Transport {
+	n.mu.Lock()
+	defer n.mu.Unlock()
+	inbox := make(chan Message, inboxSize)
+	n.inboxes[id] = inbox
+	return &memTransport{network: n, id: id, inbox: inbox}
+}
+
+// deliver puts the message in the inbox of node msg.To.
+func (n *MemNetwork) deliver(msg Message) error {
+	n.mu.RLock()
+	defer n.mu.RUnlock()
+	inbox, ok := n.inboxes[msg.To]
+	if !ok {
+		return nil // lost in the network
+	}
+	select {
+	case inbox <- msg:
+		return nil
+	default:
+		return errInboxFull
+	}
+}
+
+type memTransport struct {
+	network *MemNetwork
+	id      int
+	inbox   chan Message
+	closed  atomic.Bool
+}
+
+func (t *memTransport) Send(msg Message) error {
+	if t.closed.Load() {
+		return ErrTransportClosed
+	}
+	return t.network.deliver(msg)
+}
+
+func (t *memTransport) Receive() <-chan Message {
+	return t.inbox
+}
+
+func (t *memTransport) Close() error {
+	t.closed.Store(true)
+	t.network.mu.Lock()
+	defer t.network.mu.Unlock()
+	if t.network.inboxes[t.id] == t.inbox {
+		delete(t.network.inboxes, t.id)
+	}
+	return nil
+}
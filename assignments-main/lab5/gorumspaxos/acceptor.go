@@ -1,6 +1,9 @@
 package gorumspaxos
 
 import (
+	"cmp"
+	"slices"
+
 	pb "dat520/lab5/gorumspaxos/proto"
 )
 
@@ -22,13 +25,29 @@ func NewAcceptor() *Acceptor {
 // handlePrepare processes the prepare according to the Multi-Paxos algorithm,
 // returning a promise, or nil if the prepare should be ignored.
 func (a *Acceptor) handlePrepare(prepare *pb.PrepareMsg) (prm *pb.PromiseMsg) {
-	// TODO(student): Complete the handlePrepare
-	return &pb.PromiseMsg{}
+	if prepare.GetCrnd() <= a.rnd {
+		return nil
+	}
+	a.rnd = prepare.GetCrnd()
+	a.highestSeen = max(a.highestSeen, prepare.GetSlot())
+	prm = &pb.PromiseMsg{Rnd: a.rnd}
+	for slot, pval := range a.accepted {
+		if slot >= prepare.GetSlot() {
+			prm.Accepted = append(prm.Accepted, pval)
+		}
+	}
+	slices.SortFunc(prm.Accepted, func(a, b *pb.PValue) int { return cmp.Compare(a.GetSlot(), b.GetSlot()) })
+	return prm
 }
 
 // handleAccept processes the accept according to the Multi-Paxos algorithm,
 // returning a learn, or nil if the accept should be ignored.
 func (a *Acceptor) handleAccept(accept *pb.AcceptMsg) (lrn *pb.LearnMsg) {
-	// TODO(student): Complete the handleAccept
-	return &pb.LearnMsg{}
+	if accept.GetRnd() < a.rnd {
+		return nil
+	}
+	a.rnd = accept.GetRnd()
+	// the accept's signature, if any, is the proof that the value was proposed in this round
+	a.accepted[accept.GetSlot()] = &pb.PValue{Slot: accept.GetSlot(), Vrnd: accept.GetRnd(), Vval: accept.GetVal(), Proof: accept.GetSignature()}
+	return &pb.LearnMsg{Slot: accept.GetSlot(), Rnd: accept.GetRnd(), Val: accept.GetVal()}
 }
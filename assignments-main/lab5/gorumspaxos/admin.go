@@ -0,0 +1,291 @@
+package gorumspaxos
+
+import (
+	"cmp"
+	"errors"
+	"slices"
+	"sync"
+	"time"
+
+	"dat520/lab3/gorumsfd"
+	pb "dat520/lab5/gorumspaxos/proto"
+	apb "dat520/lab5/gorumspaxos/proto/admin"
+
+	"github.com/relab/gorums"
+)
+
+// suspectTracker forwards suspect and restore indications to the leader
+// detector while recording which nodes are currently suspected. It is used by
+// both the failure detector and the admin service.
+//
+// A node suspected by ForceElection is suspected for a limited time only,
+// since the failure detector restores only the nodes that it suspected itself.
+// The leader detector is told that the node is restored when neither the
+// failure detector nor the admin service suspects it anymore.
+type suspectTracker struct {
+	gorumsfd.SuspectRestorer
+	mu        sync.Mutex
+	suspected map[uint32]bool        // nodes suspected by the failure detector or SuspectNode
+	forced    map[uint32]*time.Timer // nodes suspected by ForceElection, until their timer fires
+	forcedFor time.Duration          // how long ForceElection suspects a node
+}
+
+func newSuspectTracker(sr gorumsfd.SuspectRestorer) *suspectTracker {
+	return &suspectTracker{
+		SuspectRestorer: sr,
+		suspected:       make(map[uint32]bool),
+		forced:          make(map[uint32]*time.Timer),
+		forcedFor:       forcedSuspicion,
+	}
+}
+
+// isSuspected returns true if the node is suspected; the caller must hold s.mu.
+func (s *suspectTracker) isSuspected(id uint32) bool {
+	return s.suspected[id] || s.forced[id] != nil
+}
+
+// Suspect records the node as suspected and forwards the indication.
+func (s *suspectTracker) Suspect(id int) {
+	s.mu.Lock()
+	s.suspected[uint32(id)] = true
+	s.mu.Unlock()
+	s.SuspectRestorer.Suspect(id)
+}
+
+// Restore records the node as restored and forwards the indication,
+// unless the node's suspicion by ForceElection has not expired.
+func (s *suspectTracker) Restore(id int) {
+	s.mu.Lock()
+	delete(s.suspected, uint32(id))
+	forced := s.forced[uint32(id)] != nil
+	s.mu.Unlock()
+	if !forced {
+		s.SuspectRestorer.Restore(id)
+	}
+}
+
+// suspectFor suspects the node for s.forcedFor, after which the node is
+// restored unless it is suspected by the failure detector or SuspectNode.
+func (s *suspectTracker) suspectFor(id int) {
+	s.mu.Lock()
+	if timer := s.forced[uint32(id)]; timer != nil {
+		timer.Stop()
+	}
+	var timer *time.Timer
+	timer = time.AfterFunc(s.forcedFor, func() {
+		s.mu.Lock()
+		if s.forced[uint32(id)] != timer {
+			s.mu.Unlock()
+			return // replaced by a later suspectFor
+		}
+		delete(s.forced, uint32(id))
+		restore := !s.isSuspected(uint32(id))
+		s.mu.Unlock()
+		if restore {
+			s.SuspectRestorer.Restore(id)
+		}
+	})
+	s.forced[uint32(id)] = timer
+	s.mu.Unlock()
+	s.SuspectRestorer.Suspect(id)
+}
+
+// Suspected returns the sorted ids of the currently suspected nodes.
+func (s *suspectTracker) Suspected() []uint32 {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	ids := Keys(s.suspected)
+	for id := range s.forced {
+		if !s.suspected[id] {
+			ids = append(ids, id)
+		}
+	}
+	slices.Sort(ids)
+	return ids
+}
+
+// setPaused pauses or resumes the proposer. A paused proposer keeps
+// accepting client requests into its queue, but does not propose them.
+func (p *Proposer) setPaused(paused bool) {
+	p.mu.Lock()
+	p.paused = paused
+	p.mu.Unlock()
+}
+
+// isPaused returns true if the proposer has been paused.
+func (p *Proposer) isPaused() bool {
+	p.mu.RLock()
+	defer p.mu.RUnlock()
+	return p.paused
+}
+
+// status returns the replica's current state.
+func (r *PaxosReplica) status() *apb.StatusReply {
+	stats := r.AdmissionStats()
+	p := r.Proposer
+	p.mu.RLock()
+	reply := &apb.StatusReply{
+		ID:               uint32(r.id),
+		GroupID:          r.group,
+		Leader:           int32(p.leader),
+		Crnd:             p.crnd,
+		Adu:              p.adu,
+		NextSlot:         p.nextSlot,
+		PhaseOneDone:     p.phaseOneDone,
+		Paused:           p.paused,
+		AcceptQueueSize:  uint32(len(p.acceptMsgQueue)),
+		ClientQueueSize:  uint32(len(p.clientRequestQueue)),
+		AdmittedRequests: stats.Admitted,
+		RejectedRequests: stats.Rejected(),
+	}
+	p.mu.RUnlock()
+	r.mu.Lock()
+	reply.Learned = uint32(len(r.learntVal))
+	r.mu.Unlock()
+	pending := r.PendingStats()
+	reply.PendingRequests = uint32(pending.Waiters)
+	reply.CancelledRequests = pending.Cancelled
+	reply.TimedOutRequests = pending.TimedOut
+	if r.suspects != nil {
+		reply.Suspected = r.suspects.Suspected()
+	}
+	return reply
+}
+
+// inRange returns true if slot is in the range [from, to]; to == NoSlot means no upper bound.
+func inRange(slot, from, to Slot) bool {
+	return slot >= from && (to == NoSlot || slot <= to)
+}
+
+// ReplicaStatus returns the replica's current state.
+func (r *PaxosReplica) ReplicaStatus(ctx gorums.ServerCtx, _ *apb.StatusRequest) (*apb.StatusReply, error) {
+	return r.status(), nil
+}
+
+// DumpLog returns the decided and accepted log entries in the requested slot range.
+func (r *PaxosReplica) DumpLog(ctx gorums.ServerCtx, req *apb.LogRequest) (*apb.LogReply, error) {
+	if req.GetToSlot() != NoSlot && req.GetToSlot() < req.GetFromSlot() {
+		return nil, errors.New("invalid slot range")
+	}
+	reply := &apb.LogReply{}
+	r.mu.Lock()
+	for slot, learn := range r.learntVal {
+		if inRange(slot, req.GetFromSlot(), req.GetToSlot()) {
+			reply.Decided = append(reply.Decided, learn)
+		}
+	}
+	r.mu.Unlock()
+	r.acceptorMu.Lock()
+	if r.Acceptor != nil {
+		for slot, pval := range r.accepted {
+			if inRange(slot, req.GetFromSlot(), req.GetToSlot()) {
+				reply.Accepted = append(reply.Accepted, pval)
+			}
+		}
+	}
+	r.acceptorMu.Unlock()
+	slices.SortFunc(reply.Decided, func(a, b *pb.LearnMsg) int { return cmp.Compare(a.GetSlot(), b.GetSlot()) })
+	slices.SortFunc(reply.Accepted, func(a, b *pb.PValue) int { return cmp.Compare(a.GetSlot(), b.GetSlot()) })
+	return reply, nil
+}
+
+// ForceElection makes the replica's leader detector suspect the current
+// leader for a limited time, so that the next leader is elected and the
+// replica's proposer moves to a new round. Once the suspicion expires, the old
+// leader is restored and may be elected again.
+//
+// The election is local to the replica: the other replicas keep their leader,
+// so forcing an election at a single replica leaves the cluster with two
+// proposers competing for the same slots until the suspicion expires. To move
+// the leader of the whole cluster, force an election at every replica.
+func (r *PaxosReplica) ForceElection(ctx gorums.ServerCtx, _ *apb.StatusRequest) (*apb.StatusReply, error) {
+	if r.suspects == nil {
+		return nil, errors.New("no leader detector")
+	}
+	leader := r.leaderDetector.Leader()
+	if leader < 0 {
+		return nil, errors.New("no leader to replace")
+	}
+	r.Logf("Admin: forcing leader election; suspecting leader %d for %v", leader, r.suspects.forcedFor)
+	r.suspects.suspectFor(leader)
+	return r.status(), nil
+}
+
+// SetProposing pauses or resumes the replica's proposer.
+func (r *PaxosReplica) SetProposing(ctx gorums.ServerCtx, req *apb.ProposingRequest) (*apb.StatusReply, error) {
+	r.Logf("Admin: setting paused=%t", req.GetPaused())
+	r.setPaused(req.GetPaused())
+	return r.status(), nil
+}
+
+// SuspectNode makes the replica's leader detector suspect the given node.
+func (r *PaxosReplica) SuspectNode(ctx gorums.ServerCtx, req *apb.NodeRequest) (*apb.StatusReply, error) {
+	if r.suspects == nil {
+		return nil, errors.New("no leader detector")
+	}
+	r.Logf("Admin: suspecting node %d", req.GetID())
+	r.suspects.Suspect(int(req.GetID()))
+	return r.status(), nil
+}
+
+// RestoreNode makes the replica's leader detector restore the given node.
+func (r *PaxosReplica) RestoreNode(ctx gorums.ServerCtx, req *apb.NodeRequest) (*apb.StatusReply, error) {
+	if r.suspects == nil {
+		return nil, errors.New("no leader detector")
+	}
+	r.Logf("Admin: restoring node %d", req.GetID())
+	r.suspects.Restore(int(req.GetID()))
+	return r.status(), nil
+}
+
+// FaultInjector returns the injector of faults into the replica's gorums calls.
+func (r *PaxosReplica) FaultInjector() *FaultInjector {
+	return r.faults
+}
+
+// faultsReply returns the injector's rules and number of injected faults.
+func faultsReply(f *FaultInjector) *apb.FaultsReply {
+	reply := &apb.FaultsReply{Injected: f.Injected()}
+	for _, rule := range f.Rules() {
+		reply.Rules = append(reply.Rules, &apb.FaultRule{
+			Method:      rule.Method,
+			Peer:        int64(rule.Peer),
+			Incoming:    rule.Incoming,
+			Action:      string(rule.Action),
+			Delay:       int64(rule.Delay),
+			Probability: rule.Probability,
+		})
+	}
+	return reply
+}
+
+// SetFaults replaces the rules of the replica's fault injector.
+func (r *PaxosReplica) SetFaults(ctx gorums.ServerCtx, req *apb.FaultsRequest) (*apb.FaultsReply, error) {
+	if r.faults == nil {
+		return nil, errors.New("no fault injector")
+	}
+	rules := make([]FaultRule, 0, len(req.GetRules()))
+	for _, rule := range req.GetRules() {
+		rules = append(rules, FaultRule{
+			Method:      rule.GetMethod(),
+			Peer:        int(rule.GetPeer()),
+			Incoming:    rule.GetIncoming(),
+			Action:      FaultAction(rule.GetAction()),
+			Delay:       time.Duration(rule.GetDelay()),
+			Probability: rule.GetProbability(),
+		})
+	}
+	if err := r.faults.SetRules(rules...); err != nil {
+		return nil, err
+	}
+	r.Logf("Admin: setting fault rules %v", rules)
+	return faultsReply(r.faults), nil
+}
+
+// Faults returns the rules of the replica's fault injector.
+func (r *PaxosReplica) Faults(ctx gorums.ServerCtx, _ *apb.StatusRequest) (*apb.FaultsReply, error) {
+	if r.faults == nil {
+		return nil, errors.New("no fault injector")
+	}
+	return faultsReply(r.faults), nil
+}
@@ -0,0 +1,324 @@
+package gorumspaxos
+
+import (
+	"context"
+	"net"
+	"slices"
+	"sync"
+	"testing"
+	"time"
+
+	pb "dat520/lab5/gorumspaxos/proto"
+	apb "dat520/lab5/gorumspaxos/proto/admin"
+
+	"github.com/google/go-cmp/cmp"
+	"github.com/google/go-cmp/cmp/cmpopts"
+	"github.com/relab/gorums"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/credentials/insecure"
+	"google.golang.org/protobuf/testing/protocmp"
+)
+
+// mockSR is a mock leader detector that records suspect and restore indications.
+type mockSR struct {
+	mu     sync.Mutex
+	events []int // suspected ids are positive, restored ids are negative
+}
+
+func (m *mockSR) Suspect(id int)        { m.record(id) }
+func (m *mockSR) Restore(id int)        { m.record(-id) }
+func (m *mockSR) NodeIDs() []uint32     { return []uint32{1, 2, 3} }
+func (m *mockSR) Leader() int           { return 3 }
+func (m *mockSR) Subscribe() <-chan int { return make(chan int) }
+
+func TestAdminDumpLog(t *testing.T) {
+	replica := newTestReplicaLeader()
+	replica.Acceptor = NewAcceptor()
+	for slot := Slot(1); slot <= 5; slot++ {
+		replica.learntVal[slot] = &pb.LearnMsg{Slot: slot, Rnd: 1, Val: valOne}
+	}
+	replica.accepted[4] = &pb.PValue{Slot: 4, Vrnd: 1, Vval: valOne}
+	replica.accepted[6] = &pb.PValue{Slot: 6, Vrnd: 1, Vval: valTwo}
+
+	tests := []struct {
+		name        string
+		req         *apb.LogRequest
+		wantDecided []Slot
+		wantAccept  []Slot
+		wantErr     bool
+	}{
+		{name: "All", req: &apb.LogRequest{}, wantDecided: []Slot{1, 2, 3, 4, 5}, wantAccept: []Slot{4, 6}},
+		{name: "Range", req: &apb.LogRequest{FromSlot: 2, ToSlot: 4}, wantDecided: []Slot{2, 3, 4}, wantAccept: []Slot{4}},
+		{name: "OpenEnded", req: &apb.LogRequest{FromSlot: 5}, wantDecided: []Slot{5}, wantAccept: []Slot{6}},
+		{name: "Empty", req: &apb.LogRequest{FromSlot: 7}},
+		{name: "Invalid", req: &apb.LogRequest{FromSlot: 4, ToSlot: 2}, wantErr: true},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			reply, err := replica.DumpLog(gorums.ServerCtx{}, test.req)
+			if (err != nil) != test.wantErr {
+				t.Fatalf("DumpLog(%v) error = %v, want error %t", test.req, err, test.wantErr)
+			}
+			var gotDecided, gotAccept []Slot
+			for _, learn := range reply.GetDecided() {
+				gotDecided = append(gotDecided, learn.GetSlot())
+			}
+			for _, pval := range reply.GetAccepted() {
+				gotAccept = append(gotAccept, pval.GetSlot())
+			}
+			if diff := cmp.Diff(test.wantDecided, gotDecided); diff != "" {
+				t.Errorf("DumpLog(%v) decided slots mismatch (-want +got):\n%s", test.req, diff)
+			}
+			if diff := cmp.Diff(test.wantAccept, gotAccept); diff != "" {
+				t.Errorf("DumpLog(%v) accepted slots mismatch (-want +got):\n%s", test.req, diff)
+			}
+		})
+	}
+}
+
+func TestAdminSuspectRestore(t *testing.T) {
+	sr := &mockSR{}
+	replica := newTestReplicaLeader()
+	replica.suspects = newSuspectTracker(sr)
+	replica.leaderDetector = sr
+
+	steps := []struct {
+		call          func(*apb.NodeRequest) (*apb.StatusReply, error)
+		id            uint32
+		wantSuspected []uint32
+	}{
+		{call: suspectFn(replica), id: 3, wantSuspected: []uint32{3}},
+		{call: suspectFn(replica), id: 1, wantSuspected: []uint32{1, 3}},
+		{call: restoreFn(replica), id: 3, wantSuspected: []uint32{1}},
+		{call: restoreFn(replica), id: 1, wantSuspected: nil},
+	}
+	for _, step := range steps {
+		status, err := step.call(&apb.NodeRequest{ID: step.id})
+		if err != nil {
+			t.Fatal(err)
+		}
+		if diff := cmp.Diff(step.wantSuspected, status.GetSuspected(), cmpopts.EquateEmpty()); diff != "" {
+			t.Errorf("Suspected mismatch (-want +got):\n%s", diff)
+		}
+	}
+	if diff := cmp.Diff([]int{3, 1, -3, -1}, sr.recorded()); diff != "" {
+		t.Errorf("leader detector events mismatch (-want +got):\n%s", diff)
+	}
+}
+
+func (m *mockSR) record(event int) {
+	m.mu.Lock()
+	m.events = append(m.events, event)
+	m.mu.Unlock()
+}
+
+func (m *mockSR) recorded() []int {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	return slices.Clone(m.events)
+}
+
+func TestAdminForcedSuspicionExpires(t *testing.T) {
+	const forcedFor = 20 * time.Millisecond
+	sr := &mockSR{}
+	replica := newTestReplicaLeader()
+	replica.suspects = newSuspectTracker(sr)
+	replica.suspects.forcedFor = forcedFor
+	replica.leaderDetector = sr
+
+	steps := []struct {
+		name          string
+		expire        bool // wait for a forced suspicion to expire before the call
+		call          func() (*apb.StatusReply, error)
+		wantSuspected []uint32
+		wantEvents    []int
+	}{
+		{
+			name: "ForceElection",
+			call: func() (*apb.StatusReply, error) {
+				return replica.ForceElection(gorums.ServerCtx{}, &apb.StatusRequest{})
+			},
+			wantSuspected: []uint32{3},
+			wantEvents:    []int{3},
+		},
+		{
+			name:          "Expired",
+			expire:        true,
+			call:          func() (*apb.StatusReply, error) { return replica.status(), nil },
+			wantSuspected: nil,
+			wantEvents:    []int{3, -3},
+		},
+		{
+			name: "ForceElectionAgain",
+			call: func() (*apb.StatusReply, error) {
+				return replica.ForceElection(gorums.ServerCtx{}, &apb.StatusRequest{})
+			},
+			wantSuspected: []uint32{3},
+			wantEvents:    []int{3, -3, 3},
+		},
+		{
+			// the failure detector suspects the node before the forced suspicion expires
+			name:          "SuspectNode",
+			call:          func() (*apb.StatusReply, error) { return suspectFn(replica)(&apb.NodeRequest{ID: 3}) },
+			wantSuspected: []uint32{3},
+			wantEvents:    []int{3, -3, 3, 3},
+		},
+		{
+			name:          "ExpiredWhileSuspected",
+			expire:        true,
+			call:          func() (*apb.StatusReply, error) { return replica.status(), nil },
+			wantSuspected: []uint32{3},
+			wantEvents:    []int{3, -3, 3, 3},
+		},
+		{
+			name:          "RestoreNode",
+			call:          func() (*apb.StatusReply, error) { return restoreFn(replica)(&apb.NodeRequest{ID: 3}) },
+			wantSuspected: nil,
+			wantEvents:    []int{3, -3, 3, 3, -3},
+		},
+	}
+	for _, step := range steps {
+		if step.expire {
+			time.Sleep(3 * forcedFor)
+		}
+		status, err := step.call()
+		if err != nil {
+			t.Fatalf("%s: %v", step.name, err)
+		}
+		if diff := cmp.Diff(step.wantSuspected, status.GetSuspected(), cmpopts.EquateEmpty()); diff != "" {
+			t.Errorf("%s: Suspected mismatch (-want +got):\n%s", step.name, diff)
+		}
+		if diff := cmp.Diff(step.wantEvents, sr.recorded()); diff != "" {
+			t.Errorf("%s: leader detector events mismatch (-want +got):\n%s", step.name, diff)
+		}
+	}
+}
+
+func suspectFn(r *PaxosReplica) func(*apb.NodeRequest) (*apb.StatusReply, error) {
+	return func(req *apb.NodeRequest) (*apb.StatusReply, error) { return r.SuspectNode(gorums.ServerCtx{}, req) }
+}
+
+func restoreFn(r *PaxosReplica) func(*apb.NodeRequest) (*apb.StatusReply, error) {
+	return func(req *apb.NodeRequest) (*apb.StatusReply, error) { return r.RestoreNode(gorums.ServerCtx{}, req) }
+}
+
+func TestAdminService(t *testing.T) {
+	lis, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	addr := lis.Addr().String()
+	replica := NewPaxosReplica(0, map[string]uint32{addr: 0})
+	go replica.Serve(lis)
+	defer replica.Stop()
+
+	mgr := apb.NewManager(gorums.WithDialTimeout(5*time.Second),
+		gorums.WithGrpcDialOptions(
+			grpc.WithBlock(), // block until connections are made
+			grpc.WithTransportCredentials(insecure.NewCredentials()), // disable TLS
+		),
+	)
+	defer mgr.Close()
+	cfg, err := mgr.NewConfiguration(gorums.WithNodeList([]string{addr}))
+	if err != nil {
+		t.Fatal(err)
+	}
+	node := cfg.Nodes()[0]
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	// the replica is its own leader, and completes phase one on its own
+	for deadline := time.Now().Add(5 * time.Second); !replica.isPhaseOneDone(); time.Sleep(10 * time.Millisecond) {
+		if time.Now().After(deadline) {
+			t.Fatal("replica did not complete phase one")
+		}
+	}
+	status, err := node.SetProposing(ctx, &apb.ProposingRequest{Paused: true})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !status.GetPaused() || !replica.isPaused() {
+		t.Errorf("SetProposing(Paused: true): status.Paused = %t, replica.isPaused() = %t, want true", status.GetPaused(), replica.isPaused())
+	}
+	if _, err = node.SuspectNode(ctx, &apb.NodeRequest{ID: 7}); err != nil {
+		t.Fatal(err)
+	}
+	status, err = node.ReplicaStatus(ctx, &apb.StatusRequest{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := &apb.StatusReply{
+		ID:           0,
+		Leader:       int32(replica.leader),
+		Crnd:         replica.crnd,
+		PhaseOneDone: true,
+		Paused:       true,
+		Suspected:    []uint32{7},
+	}
+	if diff := cmp.Diff(want, status, protocmp.Transform()); diff != "" {
+		t.Errorf("ReplicaStatus() mismatch (-want +got):\n%s", diff)
+	}
+}
+
+func TestAdminForceElection(t *testing.T) {
+	nodeMap, stop, _, replicas := startReplicas(t, 3)
+	defer stop()
+	config, closeMgr, err := newConfiguration(nodeMap)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer closeMgr()
+
+	// the monarchical leader detector elects the highest id, 2, and then 1
+	// once every replica suspects it
+	const forcedFor = 3 * time.Second
+	for _, replica := range replicas {
+		replica.suspects.mu.Lock()
+		replica.suspects.forcedFor = forcedFor
+		replica.suspects.mu.Unlock()
+		if _, err := replica.ForceElection(gorums.ServerCtx{}, &apb.StatusRequest{}); err != nil {
+			t.Fatal(err)
+		}
+	}
+	for _, replica := range replicas {
+		for deadline := time.Now().Add(5 * time.Second); ; time.Sleep(10 * time.Millisecond) {
+			status := replica.status()
+			if status.GetLeader() == 1 && (replica.id != 1 || status.GetPhaseOneDone()) {
+				break
+			}
+			if time.Now().After(deadline) {
+				t.Fatalf("replica %d: Leader = %d, PhaseOneDone = %t, want leader 1 to complete phase one",
+					replica.id, status.GetLeader(), status.GetPhaseOneDone())
+			}
+		}
+		if diff := cmp.Diff([]uint32{2}, replica.suspects.Suspected()); diff != "" {
+			t.Errorf("replica %d: suspected mismatch (-want +got):\n%s", replica.id, diff)
+		}
+	}
+
+	// the new leader decides client requests
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+	req := &pb.Value{ClientID: "1", ClientSeq: 1, ClientCommand: "ls"}
+	rsp, err := config.ClientHandle(ctx, req)
+	if err != nil {
+		t.Fatalf("ClientHandle(%v) = %v, want nil", req, err)
+	}
+	if !req.Match(rsp) {
+		t.Errorf("ClientHandle(%v) = %v, want matching response", req, rsp)
+	}
+
+	// the suspicion expires, and 2 is the leader again
+	for _, replica := range replicas {
+		for deadline := time.Now().Add(forcedFor + 5*time.Second); ; time.Sleep(10 * time.Millisecond) {
+			status := replica.status()
+			if status.GetLeader() == 2 && len(status.GetSuspected()) == 0 {
+				break
+			}
+			if time.Now().After(deadline) {
+				t.Fatalf("replica %d: Leader = %d, Suspected = %v, want leader 2 and no suspected nodes",
+					replica.id, status.GetLeader(), status.GetSuspected())
+			}
+		}
+	}
+}
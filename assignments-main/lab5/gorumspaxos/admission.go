@@ -0,0 +1,185 @@
+package gorumspaxos
+
+import (
+	"errors"
+	"fmt"
+	"slices"
+	"sync/atomic"
+	"time"
+
+	pb "dat520/lab5/gorumspaxos/proto"
+
+	"github.com/relab/gorums"
+	"google.golang.org/genproto/googleapis/rpc/errdetails"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+	"google.golang.org/protobuf/types/known/durationpb"
+)
+
+// AdmissionConfig holds the limits enforced by the proposer before a client
+// request is added to the clientRequestQueue. A zero limit means unbounded.
+type AdmissionConfig struct {
+	MaxQueueSize int           // maximum number of pending client requests.
+	MaxPerClient int           // maximum number of pending requests from a single client.
+	RetryAfter   time.Duration // how long rejected clients are asked to wait before retrying.
+}
+
+// DefaultAdmissionConfig is the admission configuration used by new replicas.
+var DefaultAdmissionConfig = AdmissionConfig{
+	MaxQueueSize: 1024,
+	MaxPerClient: 64,
+	RetryAfter:   100 * time.Millisecond,
+}
+
+// Reasons for rejecting a client request.
+const (
+	reasonQueueFull   = "request queue full"
+	reasonClientLimit = "too many pending requests from client"
+)
+
+// OverloadedError is returned to clients whose request was rejected by the
+// proposer's admission control. The client should wait at least RetryAfter
+// before retrying the request.
+type OverloadedError struct {
+	Reason     string
+	RetryAfter time.Duration
+}
+
+func (e OverloadedError) Error() string {
+	return fmt.Sprintf("overloaded: %s, retry after %v", e.Reason, e.RetryAfter)
+}
+
+// GRPCStatus returns the status sent to the client when the error is returned
+// from a gorums handler. The retry delay is attached as a RetryInfo detail.
+func (e OverloadedError) GRPCStatus() *status.Status {
+	st := status.New(codes.ResourceExhausted, e.Error())
+	if ds, err := st.WithDetails(&errdetails.RetryInfo{RetryDelay: durationpb.New(e.RetryAfter)}); err == nil {
+		return ds
+	}
+	return st
+}
+
+// RetryAfter returns the retry delay suggested by an overloaded replica, if err
+// (or, for quorum call errors, any of the node errors) reports an overload.
+// An overload reported without a suggested delay, or with a non-positive one,
+// returns DefaultAdmissionConfig.RetryAfter, so that callers never retry at once.
+func RetryAfter(err error) (time.Duration, bool) {
+	var oe OverloadedError
+	if errors.As(err, &oe) {
+		return retryDelay(oe.RetryAfter), true
+	}
+	var qcErr gorums.QuorumCallError
+	if errors.As(err, &qcErr) {
+		var retryAfter time.Duration
+		found := false
+		for _, nodeErr := range qcErr.Errors {
+			if d, ok := RetryAfter(nodeErr.Cause); ok {
+				retryAfter = max(retryAfter, d)
+				found = true
+			}
+		}
+		return retryAfter, found
+	}
+	st, ok := status.FromError(err)
+	if !ok || st.Code() != codes.ResourceExhausted {
+		return 0, false
+	}
+	for _, detail := range st.Details() {
+		if info, ok := detail.(*errdetails.RetryInfo); ok {
+			return retryDelay(info.GetRetryDelay().AsDuration()), true
+		}
+	}
+	return retryDelay(0), true
+}
+
+// retryDelay returns d, or DefaultAdmissionConfig.RetryAfter if d is not positive.
+func retryDelay(d time.Duration) time.Duration {
+	if d <= 0 {
+		return DefaultAdmissionConfig.RetryAfter
+	}
+	return d
+}
+
+// admissionCounters counts the admission decisions made by a proposer.
+type admissionCounters struct {
+	admitted          atomic.Uint64
+	rejectedQueueFull atomic.Uint64
+	rejectedPerClient atomic.Uint64
+}
+
+// AdmissionStats is a snapshot of a proposer's admission metrics.
+type AdmissionStats struct {
+	Admitted          uint64
+	RejectedQueueFull uint64
+	RejectedPerClient uint64
+	QueueLength       int
+}
+
+// Rejected returns the total number of rejected requests.
+func (s AdmissionStats) Rejected() uint64 {
+	return s.RejectedQueueFull + s.RejectedPerClient
+}
+
+// SetAdmissionConfig sets the limits used to admit client requests.
+func (p *Proposer) SetAdmissionConfig(cfg AdmissionConfig) {
+	p.mu.Lock()
+	p.admission = cfg
+	p.mu.Unlock()
+}
+
+// AdmissionStats returns a snapshot of the proposer's admission metrics.
+func (p *Proposer) AdmissionStats() AdmissionStats {
+	p.mu.RLock()
+	queueLen := len(p.clientRequestQueue)
+	p.mu.RUnlock()
+	return AdmissionStats{
+		Admitted:          p.admissionMetrics.admitted.Load(),
+		RejectedQueueFull: p.admissionMetrics.rejectedQueueFull.Load(),
+		RejectedPerClient: p.admissionMetrics.rejectedPerClient.Load(),
+		QueueLength:       queueLen,
+	}
+}
+
+// enqueueRequest adds the request to the clientRequestQueue if the admission
+// limits allow it; otherwise an OverloadedError is returned. Requests are
+// inserted so that the queue is served round-robin across clients, i.e., the
+// k-th pending request of a client is placed after the k-th pending request of
+// every other client. The caller must hold p.mu.
+func (p *Proposer) enqueueRequest(request *pb.Value) error {
+	if p.admission.MaxQueueSize > 0 && len(p.clientRequestQueue) >= p.admission.MaxQueueSize {
+		p.admissionMetrics.rejectedQueueFull.Add(1)
+		return OverloadedError{Reason: reasonQueueFull, RetryAfter: p.admission.RetryAfter}
+	}
+	clientID := request.GetClientID()
+	rank := 0
+	for _, accept := range p.clientRequestQueue {
+		if accept.GetVal().GetClientID() == clientID {
+			rank++
+		}
+	}
+	if p.admission.MaxPerClient > 0 && rank >= p.admission.MaxPerClient {
+		p.admissionMetrics.rejectedPerClient.Add(1)
+		return OverloadedError{Reason: reasonClientLimit, RetryAfter: p.admission.RetryAfter}
+	}
+	// insert after the last request whose per-client rank does not exceed ours
+	seen := make(map[string]int)
+	insertAt := 0
+	for i, accept := range p.clientRequestQueue {
+		id := accept.GetVal().GetClientID()
+		if seen[id] <= rank {
+			insertAt = i + 1
+		}
+		seen[id]++
+	}
+	p.clientRequestQueue = slices.Insert(p.clientRequestQueue, insertAt, &pb.AcceptMsg{Val: request})
+	p.admissionMetrics.admitted.Add(1)
+	if p.tracer != nil {
+		// a retried request replaces the span of the copy queued before it
+		if queued, ok := p.queueSpans[request.Hash()]; ok {
+			queued.SetAttribute(AttrError, "superseded by a retried request")
+			queued.End()
+		}
+		p.queueSpans[request.Hash()] = p.tracer.Start("Proposer.Queue", request.GetTrace())
+	}
+	return nil
+}
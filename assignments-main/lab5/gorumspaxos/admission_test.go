@@ -0,0 +1,133 @@
+package gorumspaxos
+
+import (
+	"errors"
+	"testing"
+	"time"
+
+	pb "dat520/lab5/gorumspaxos/proto"
+
+	"github.com/google/go-cmp/cmp"
+	"github.com/relab/gorums"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+func req(clientID string, seq uint32) *pb.Value {
+	return &pb.Value{ClientID: clientID, ClientSeq: seq, ClientCommand: "cmd"}
+}
+
+func queuedClients(p *Proposer) []string {
+	ids := make([]string, len(p.clientRequestQueue))
+	for i, accept := range p.clientRequestQueue {
+		ids[i] = accept.GetVal().GetClientID()
+	}
+	return ids
+}
+
+func TestAdmissionFairQueuing(t *testing.T) {
+	tests := []struct {
+		name     string
+		requests []*pb.Value
+		want     []string
+	}{
+		{name: "SingleClient", requests: []*pb.Value{req("A", 1), req("A", 2), req("A", 3)}, want: []string{"A", "A", "A"}},
+		{name: "TwoClientsInterleaved", requests: []*pb.Value{req("A", 1), req("B", 1), req("A", 2), req("B", 2)}, want: []string{"A", "B", "A", "B"}},
+		{name: "BurstThenNewClient", requests: []*pb.Value{req("A", 1), req("A", 2), req("A", 3), req("B", 1)}, want: []string{"A", "B", "A", "A"}},
+		{name: "BurstThenTwoNewClients", requests: []*pb.Value{req("A", 1), req("A", 2), req("A", 3), req("B", 1), req("C", 1), req("B", 2)}, want: []string{"A", "B", "C", "A", "B", "A"}},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			p := NewProposer(0, 0, map[string]uint32{"0": 0})
+			p.SetAdmissionConfig(AdmissionConfig{})
+			for _, r := range test.requests {
+				if err := p.enqueueRequest(r); err != nil {
+					t.Fatalf("enqueueRequest(%v) = %v, want nil", r, err)
+				}
+			}
+			if diff := cmp.Diff(test.want, queuedClients(p)); diff != "" {
+				t.Errorf("clientRequestQueue mismatch (-want +got):\n%s", diff)
+			}
+		})
+	}
+}
+
+func TestAdmissionLimits(t *testing.T) {
+	cfg := AdmissionConfig{MaxQueueSize: 4, MaxPerClient: 2, RetryAfter: 50 * time.Millisecond}
+	p := NewProposer(0, 0, map[string]uint32{"0": 0})
+	p.SetAdmissionConfig(cfg)
+
+	tests := []struct {
+		request    *pb.Value
+		wantReason string
+	}{
+		{request: req("A", 1)},
+		{request: req("A", 2)},
+		{request: req("A", 3), wantReason: reasonClientLimit},
+		{request: req("B", 1)},
+		{request: req("C", 1)},
+		{request: req("D", 1), wantReason: reasonQueueFull},
+	}
+	for _, test := range tests {
+		err := p.enqueueRequest(test.request)
+		if test.wantReason == "" {
+			if err != nil {
+				t.Errorf("enqueueRequest(%v) = %v, want nil", test.request, err)
+			}
+			continue
+		}
+		var oe OverloadedError
+		if !errors.As(err, &oe) {
+			t.Fatalf("enqueueRequest(%v) = %v, want OverloadedError", test.request, err)
+		}
+		if oe.Reason != test.wantReason || oe.RetryAfter != cfg.RetryAfter {
+			t.Errorf("enqueueRequest(%v) = %+v, want reason %q, retry after %v", test.request, oe, test.wantReason, cfg.RetryAfter)
+		}
+	}
+	want := AdmissionStats{Admitted: 4, RejectedQueueFull: 1, RejectedPerClient: 1, QueueLength: 4}
+	if diff := cmp.Diff(want, p.AdmissionStats()); diff != "" {
+		t.Errorf("AdmissionStats() mismatch (-want +got):\n%s", diff)
+	}
+}
+
+func TestRetryAfter(t *testing.T) {
+	overloaded := OverloadedError{Reason: reasonQueueFull, RetryAfter: 75 * time.Millisecond}
+	// the error as seen by the client after being sent over the wire
+	wireErr := status.FromProto(overloaded.GRPCStatus().Proto()).Err()
+
+	tests := []struct {
+		name         string
+		err          error
+		wantDelay    time.Duration
+		wantOverload bool
+	}{
+		{name: "Nil", err: nil},
+		{name: "Unrelated", err: errors.New("boom")},
+		{name: "Local", err: overloaded, wantDelay: 75 * time.Millisecond, wantOverload: true},
+		{name: "Wire", err: wireErr, wantDelay: 75 * time.Millisecond, wantOverload: true},
+		{name: "WireNoRetryInfo", err: status.Error(codes.ResourceExhausted, "overloaded"), wantDelay: DefaultAdmissionConfig.RetryAfter, wantOverload: true},
+		{name: "LocalNoDelay", err: OverloadedError{Reason: reasonQueueFull}, wantDelay: DefaultAdmissionConfig.RetryAfter, wantOverload: true},
+		{name: "QuorumCall", err: gorums.QuorumCallError{
+			Reason: "incomplete call",
+			Errors: []gorums.Error{
+				{NodeID: 1, Cause: errors.New("unavailable")},
+				{NodeID: 2, Cause: wireErr},
+			},
+		}, wantDelay: 75 * time.Millisecond, wantOverload: true},
+		{name: "QuorumCallNoOverload", err: gorums.QuorumCallError{
+			Reason: "incomplete call",
+			Errors: []gorums.Error{{NodeID: 1, Cause: errors.New("unavailable")}},
+		}},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			gotDelay, gotOverload := RetryAfter(test.err)
+			if gotDelay != test.wantDelay || gotOverload != test.wantOverload {
+				t.Errorf("RetryAfter(%v) = (%v, %t), want (%v, %t)", test.err, gotDelay, gotOverload, test.wantDelay, test.wantOverload)
+			}
+		})
+	}
+	if got := status.Code(wireErr); got != codes.ResourceExhausted {
+		t.Errorf("status.Code(%v) = %v, want %v", wireErr, got, codes.ResourceExhausted)
+	}
+}
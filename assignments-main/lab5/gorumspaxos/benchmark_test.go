@@ -0,0 +1,156 @@
+package gorumspaxos
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"sync/atomic"
+	"testing"
+
+	pb "dat520/lab5/gorumspaxos/proto"
+)
+
+// benchValue returns a client request whose command is size bytes long.
+func benchValue(seq uint32, size int) *pb.Value {
+	return &pb.Value{ClientID: "bench", ClientSeq: seq, ClientCommand: strings.Repeat("x", size)}
+}
+
+func BenchmarkAcceptorHandlePrepare(b *testing.B) {
+	for _, accepted := range []int{100, 10_000, 100_000} {
+		a := NewAcceptor()
+		for slot := Slot(1); slot <= Slot(accepted); slot++ {
+			a.handleAccept(&pb.AcceptMsg{Slot: slot, Rnd: 1, Val: benchValue(slot, 16)})
+		}
+		// a prepare from the first slot returns all accepted values,
+		// one from the last slot returns a single value
+		for _, from := range []Slot{1, Slot(accepted)} {
+			b.Run(fmt.Sprintf("accepted=%d/from=%d", accepted, from), func(b *testing.B) {
+				b.ReportAllocs()
+				for i := range b.N {
+					a.rnd = NoRound
+					if a.handlePrepare(&pb.PrepareMsg{Slot: from, Crnd: Round(i + 2)}) == nil {
+						b.Fatal("prepare ignored")
+					}
+				}
+			})
+		}
+	}
+}
+
+func BenchmarkPrepareQF(b *testing.B) {
+	const accepted = 100
+	for _, n := range []int{3, 9, 33} {
+		b.Run(fmt.Sprintf("replies=%d", n), func(b *testing.B) {
+			qspec := NewPaxosQSpec(n)
+			prepare := &pb.PrepareMsg{Slot: 1, Crnd: Round(n + 1)}
+			replies := make(map[uint32]*pb.PromiseMsg, n)
+			for id := range uint32(n) {
+				promise := &pb.PromiseMsg{Rnd: prepare.GetCrnd()}
+				// the acceptors have accepted every other slot in different rounds
+				for slot := Slot(2); slot < 2*accepted+2; slot += 2 {
+					promise.Accepted = append(promise.Accepted, &pb.PValue{Slot: slot, Vrnd: Round(id), Vval: benchValue(slot, 16)})
+				}
+				replies[id] = promise
+			}
+			b.ReportAllocs()
+			b.ResetTimer()
+			for range b.N {
+				if _, ok := qspec.PrepareQF(prepare, replies); !ok {
+					b.Fatal("no quorum")
+				}
+			}
+		})
+	}
+}
+
+func BenchmarkAcceptQF(b *testing.B) {
+	for _, bft := range []bool{false, true} {
+		for _, n := range []int{3, 9, 33} {
+			b.Run(fmt.Sprintf("bft=%t/replies=%d", bft, n), func(b *testing.B) {
+				accept := &pb.AcceptMsg{Slot: 1, Rnd: 1, Val: benchValue(1, 16)}
+				qspec := NewPaxosQSpec(n)
+				var keys map[uint32]*Keyring
+				if bft {
+					ids := make([]uint32, n)
+					for i := range ids {
+						ids[i] = uint32(i)
+					}
+					var err error
+					if keys, err = GenerateKeyrings(ids); err != nil {
+						b.Fatal(err)
+					}
+					qspec = NewBFTPaxosQSpec(n, keys[0])
+				}
+				replies := make(map[uint32]*pb.LearnMsg, n)
+				for id := range uint32(n) {
+					learn := &pb.LearnMsg{Slot: accept.GetSlot(), Rnd: accept.GetRnd(), Val: accept.GetVal()}
+					if bft {
+						learn.Signature = keys[id].sign(learnDigest(learn.GetSlot(), learn.GetRnd(), learn.GetVal()))
+					}
+					replies[id] = learn
+				}
+				b.ReportAllocs()
+				b.ResetTimer()
+				for range b.N {
+					if _, ok := qspec.AcceptQF(accept, replies); !ok {
+						b.Fatal("no quorum")
+					}
+				}
+			})
+		}
+	}
+}
+
+func BenchmarkValueHash(b *testing.B) {
+	for _, size := range []int{16, 1024, 64 * 1024} {
+		b.Run(fmt.Sprintf("command=%d", size), func(b *testing.B) {
+			val := benchValue(1, size)
+			b.SetBytes(int64(size))
+			b.ReportAllocs()
+			for range b.N {
+				val.Hash()
+			}
+		})
+		b.Run(fmt.Sprintf("payload=%d", size), func(b *testing.B) {
+			val := &pb.Value{ClientID: "bench", ClientSeq: 1, ContentType: ContentTypeRaw, Payload: make([]byte, size)}
+			b.SetBytes(int64(size))
+			b.ReportAllocs()
+			for range b.N {
+				val.Hash()
+			}
+		})
+	}
+}
+
+// BenchmarkCluster measures the throughput of client requests decided by an
+// in-process cluster of three replicas communicating over loopback TCP.
+// Each client sends one request at a time; since an idle leader polls for
+// requests every requestWaitTime, use -cpu to run more concurrent clients.
+func BenchmarkCluster(b *testing.B) {
+	nodeMap, teardown, _, _ := startReplicas(b, 3)
+	defer teardown()
+	config, closeMgr, err := newConfiguration(nodeMap)
+	if err != nil {
+		b.Fatal(err)
+	}
+	defer closeMgr()
+	// wait for a leader to be elected and complete phase one
+	ctx := context.Background()
+	if _, err := config.ClientHandle(ctx, &pb.Value{ClientID: "warmup", ClientCommand: "warmup"}); err != nil {
+		b.Fatal(err)
+	}
+	var clients atomic.Uint32
+	b.ReportAllocs()
+	b.ResetTimer()
+	b.RunParallel(func(pb *testing.PB) {
+		id := fmt.Sprintf("bench-%d", clients.Add(1))
+		for seq := uint32(1); pb.Next(); seq++ {
+			req := benchValue(seq, 16)
+			req.ClientID = id
+			if _, err := config.ClientHandle(ctx, req); err != nil {
+				b.Error(err)
+				return
+			}
+		}
+	})
+}
@@ -0,0 +1,298 @@
+package gorumspaxos
+
+import (
+	"bytes"
+	"context"
+	"crypto/ed25519"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/binary"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"hash"
+	"os"
+	"path/filepath"
+	"slices"
+
+	pb "dat520/lab5/gorumspaxos/proto"
+)
+
+// In BFT mode, replicas tolerate f Byzantine replicas out of n >= 3f+1.
+// Every message is signed with the sender's ed25519 key:
+//
+//   - The proposer signs its PrepareMsgs and AcceptMsgs. Acceptors ignore
+//     messages that are not signed by the owner of the message's round.
+//   - An acceptor signs its PromiseMsgs and LearnMsgs. Each PValue in a promise
+//     carries the proposer's signature of the AcceptMsg in which the value was
+//     accepted, so that a Byzantine acceptor cannot forge accepted values.
+//   - The proposer commits a LearnMsg with a certificate of the votes of a
+//     quorum of acceptors, which replicas verify before delivering the value.
+//
+// Quorums hold a majority of the correct replicas in every quorum, that is,
+// ceil((n+f+1)/2) replicas, which is 2f+1 when n = 3f+1. Since the replicas
+// sending a response to a client may be Byzantine, clients wait for f+1
+// matching responses, of which at least one is from a correct replica.
+
+// errInvalidSignature is returned by the replica's handlers when a message
+// is not correctly signed in BFT mode.
+var errInvalidSignature = errors.New("invalid signature")
+
+// bftQuorum returns the quorum size and the number of Byzantine replicas
+// tolerated by n replicas.
+func bftQuorum(n int) (quorum, f int) {
+	f = (n - 1) / 3
+	return (n + f + 2) / 2, f
+}
+
+// Keyring holds a replica's ed25519 private key and the public keys of all
+// replicas in its configuration, indexed by node ID.
+type Keyring struct {
+	id      uint32
+	private ed25519.PrivateKey
+	public  map[uint32]ed25519.PublicKey
+	ids     []uint32 // sorted node IDs; round r is owned by ids[r % len(ids)]
+}
+
+// NewKeyring returns the keyring of the replica with the given node ID.
+// The public keys must include the keys of all replicas, including its own.
+func NewKeyring(id uint32, private ed25519.PrivateKey, public map[uint32]ed25519.PublicKey) *Keyring {
+	ids := Keys(public)
+	slices.Sort(ids)
+	return &Keyring{id: id, private: private, public: public, ids: ids}
+}
+
+// GenerateKeyrings generates a key pair for each of the node IDs and returns
+// the keyring of each node.
+func GenerateKeyrings(ids []uint32) (map[uint32]*Keyring, error) {
+	private := make(map[uint32]ed25519.PrivateKey, len(ids))
+	public := make(map[uint32]ed25519.PublicKey, len(ids))
+	for _, id := range ids {
+		pub, priv, err := ed25519.GenerateKey(rand.Reader)
+		if err != nil {
+			return nil, err
+		}
+		private[id], public[id] = priv, pub
+	}
+	keyrings := make(map[uint32]*Keyring, len(ids))
+	for _, id := range ids {
+		keyrings[id] = NewKeyring(id, private[id], public)
+	}
+	return keyrings, nil
+}
+
+// WriteKeyrings writes the keys of the keyrings to dir, so that each replica
+// can load its keyring with ReadKeyring. Each node's public key is written to
+// replica-<id>.pub and its private key to replica-<id>.key, hex encoded.
+// The private key files must only be copied to their owners.
+func WriteKeyrings(dir string, keyrings map[uint32]*Keyring) error {
+	if err := os.MkdirAll(dir, 0o700); err != nil {
+		return err
+	}
+	for id, keys := range keyrings {
+		if err := writeKey(keyFile(dir, id, "pub"), keys.public[id], 0o644); err != nil {
+			return err
+		}
+		if err := writeKey(keyFile(dir, id, "key"), keys.private.Seed(), 0o600); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// ReadKeyring reads the keyring of the replica with the given node ID from
+// dir, as written by WriteKeyrings. It reads the replica's private key and
+// the public keys of all the node IDs.
+func ReadKeyring(dir string, id uint32, ids []uint32) (*Keyring, error) {
+	seed, err := readKey(keyFile(dir, id, "key"), ed25519.SeedSize)
+	if err != nil {
+		return nil, err
+	}
+	public := make(map[uint32]ed25519.PublicKey, len(ids))
+	for _, nodeID := range ids {
+		pub, err := readKey(keyFile(dir, nodeID, "pub"), ed25519.PublicKeySize)
+		if err != nil {
+			return nil, err
+		}
+		public[nodeID] = pub
+	}
+	private := ed25519.NewKeyFromSeed(seed)
+	if !private.Public().(ed25519.PublicKey).Equal(public[id]) {
+		return nil, fmt.Errorf("private key of node %d does not match its public key", id)
+	}
+	return NewKeyring(id, private, public), nil
+}
+
+func keyFile(dir string, id uint32, ext string) string {
+	return filepath.Join(dir, fmt.Sprintf("replica-%d.%s", id, ext))
+}
+
+func writeKey(name string, key []byte, perm os.FileMode) error {
+	return os.WriteFile(name, []byte(hex.EncodeToString(key)+"\n"), perm)
+}
+
+func readKey(name string, size int) ([]byte, error) {
+	b, err := os.ReadFile(name)
+	if err != nil {
+		return nil, err
+	}
+	key, err := hex.DecodeString(string(bytes.TrimSpace(b)))
+	if err != nil || len(key) != size {
+		return nil, fmt.Errorf("%s: invalid key", name)
+	}
+	return key, nil
+}
+
+// ID returns the node ID of the keyring's owner.
+func (k *Keyring) ID() uint32 {
+	return k.id
+}
+
+// sign returns the keyring owner's signature of the digest.
+func (k *Keyring) sign(digest []byte) []byte {
+	return ed25519.Sign(k.private, digest)
+}
+
+// verify returns true if sig is node id's signature of the digest.
+func (k *Keyring) verify(id uint32, digest, sig []byte) bool {
+	pub, ok := k.public[id]
+	return ok && len(sig) == ed25519.SignatureSize && ed25519.Verify(pub, digest, sig)
+}
+
+// roundOwner returns the ID of the node that proposes in round rnd.
+// Proposers start in the round given by their index in the sorted node IDs
+// and move to the next round by adding the number of nodes (see NewProposer).
+func (k *Keyring) roundOwner(rnd Round) (uint32, bool) {
+	if rnd < 0 || len(k.ids) == 0 {
+		return 0, false
+	}
+	return k.ids[int(rnd)%len(k.ids)], true
+}
+
+// verifyRound returns true if sig is the round owner's signature of the digest.
+func (k *Keyring) verifyRound(rnd Round, digest, sig []byte) bool {
+	owner, ok := k.roundOwner(rnd)
+	return ok && k.verify(owner, digest, sig)
+}
+
+// verifyCertificate returns true if the learn's certificate holds valid votes
+// for the learn from a quorum of distinct acceptors.
+func (k *Keyring) verifyCertificate(learn *pb.LearnMsg) bool {
+	quorum, _ := bftQuorum(len(k.ids))
+	digest := learnDigest(learn.GetSlot(), learn.GetRnd(), learn.GetVal())
+	voters := make(map[uint32]bool)
+	for _, vote := range learn.GetCertificate() {
+		if !voters[vote.GetAcceptorID()] && k.verify(vote.GetAcceptorID(), digest, vote.GetSignature()) {
+			voters[vote.GetAcceptorID()] = true
+		}
+	}
+	return len(voters) >= quorum
+}
+
+// WithBFT enables BFT mode, in which the replica signs its messages with the
+// keyring's private key and verifies the messages of other replicas with
+// their public keys.
+func WithBFT(keys *Keyring) ReplicaOption {
+	return func(r *PaxosReplica) {
+		r.keys = keys
+	}
+}
+
+// signingConfig is a MultiPaxosConfig that signs the proposer's messages.
+type signingConfig struct {
+	MultiPaxosConfig
+	keys *Keyring
+}
+
+func (c signingConfig) Prepare(ctx context.Context, request *pb.PrepareMsg) (*pb.PromiseMsg, error) {
+	request.Signature = c.keys.sign(prepareDigest(request))
+	return c.MultiPaxosConfig.Prepare(ctx, request)
+}
+
+func (c signingConfig) Accept(ctx context.Context, request *pb.AcceptMsg) (*pb.LearnMsg, error) {
+	request.Signature = c.keys.sign(acceptDigest(request.GetSlot(), request.GetRnd(), request.GetVal()))
+	return c.MultiPaxosConfig.Accept(ctx, request)
+}
+
+// digester computes the digests that are signed in BFT mode. Each digest
+// starts with the kind of message, so that a signature of one kind of
+// message cannot be used for another.
+type digester struct {
+	h hash.Hash
+}
+
+func newDigester(kind string) *digester {
+	d := &digester{h: sha256.New()}
+	d.string(kind)
+	return d
+}
+
+func (d *digester) uint32(v uint32) {
+	d.h.Write(binary.BigEndian.AppendUint32(nil, v))
+}
+
+func (d *digester) bytes(b []byte) {
+	d.uint32(uint32(len(b)))
+	d.h.Write(b)
+}
+
+func (d *digester) string(s string) {
+	d.bytes([]byte(s))
+}
+
+// value adds the fields of the value that determine the request; the trace
+// context is excluded since replicas may set it.
+func (d *digester) value(val *pb.Value) {
+	d.string(val.GetClientID())
+	d.uint32(val.GetClientSeq())
+	if val.GetIsNoop() {
+		d.uint32(1)
+	} else {
+		d.uint32(0)
+	}
+	d.string(val.GetClientCommand())
+	d.uint32(val.GetGroupID())
+	d.string(val.GetContentType())
+	d.bytes(val.GetPayload())
+}
+
+func (d *digester) sum() []byte {
+	return d.h.Sum(nil)
+}
+
+func prepareDigest(prepare *pb.PrepareMsg) []byte {
+	d := newDigester("prepare")
+	d.uint32(prepare.GetSlot())
+	d.uint32(uint32(prepare.GetCrnd()))
+	d.uint32(prepare.GetGroupID())
+	return d.sum()
+}
+
+func acceptDigest(slot Slot, rnd Round, val *pb.Value) []byte {
+	d := newDigester("accept")
+	d.uint32(slot)
+	d.uint32(uint32(rnd))
+	d.value(val)
+	return d.sum()
+}
+
+func learnDigest(slot Slot, rnd Round, val *pb.Value) []byte {
+	d := newDigester("learn")
+	d.uint32(slot)
+	d.uint32(uint32(rnd))
+	d.value(val)
+	return d.sum()
+}
+
+func promiseDigest(promise *pb.PromiseMsg) []byte {
+	d := newDigester("promise")
+	d.uint32(uint32(promise.GetRnd()))
+	d.uint32(uint32(len(promise.GetAccepted())))
+	for _, pval := range promise.GetAccepted() {
+		d.uint32(pval.GetSlot())
+		d.uint32(uint32(pval.GetVrnd()))
+		d.value(pval.GetVval())
+		d.bytes(pval.GetProof())
+	}
+	return d.sum()
+}
@@ -0,0 +1,363 @@
+package gorumspaxos
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"os"
+	"testing"
+	"time"
+
+	pb "dat520/lab5/gorumspaxos/proto"
+
+	"github.com/google/go-cmp/cmp"
+	"github.com/relab/gorums"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/credentials/insecure"
+	"google.golang.org/protobuf/proto"
+	"google.golang.org/protobuf/testing/protocmp"
+)
+
+func TestBFTQuorum(t *testing.T) {
+	tests := []struct {
+		size            int
+		wantQuorum      int
+		wantReplyQuorum int
+	}{
+		{1, 1, 1},
+		{3, 2, 1},
+		{4, 3, 2},
+		{5, 4, 2},
+		{7, 5, 3},
+		{10, 7, 4},
+	}
+	for _, test := range tests {
+		qs := NewBFTPaxosQSpec(test.size, nil)
+		if qs.quorum != test.wantQuorum || qs.replyQuorum != test.wantReplyQuorum {
+			t.Errorf("NewBFTPaxosQSpec(%d) quorums = %d, %d; want %d, %d", test.size, qs.quorum, qs.replyQuorum, test.wantQuorum, test.wantReplyQuorum)
+		}
+	}
+}
+
+// bftKeys returns the keyrings of nodes 0 to n-1.
+func bftKeys(t testing.TB, n int) map[uint32]*Keyring {
+	t.Helper()
+	ids := make([]uint32, n)
+	for i := range ids {
+		ids[i] = uint32(i)
+	}
+	keys, err := GenerateKeyrings(ids)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return keys
+}
+
+func TestBFTReadKeyring(t *testing.T) {
+	dir := t.TempDir()
+	keys := bftKeys(t, 4)
+	if err := WriteKeyrings(dir, keys); err != nil {
+		t.Fatal(err)
+	}
+	ids := []uint32{0, 1, 2, 3}
+	for id, want := range keys {
+		got, err := ReadKeyring(dir, id, ids)
+		if err != nil {
+			t.Fatalf("ReadKeyring(%d) error: %v", id, err)
+		}
+		if diff := cmp.Diff(want, got, cmp.AllowUnexported(Keyring{})); diff != "" {
+			t.Errorf("ReadKeyring(%d) mismatch (-want +got):\n%s", id, diff)
+		}
+	}
+
+	// a private key that does not match the node's public key is rejected
+	if err := os.Rename(keyFile(dir, 1, "key"), keyFile(dir, 0, "key")); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := ReadKeyring(dir, 0, ids); err == nil {
+		t.Error("ReadKeyring(0) with node 1's private key succeeded, want error")
+	}
+	if _, err := ReadKeyring(dir, 1, ids); err == nil {
+		t.Error("ReadKeyring(1) without a private key succeeded, want error")
+	}
+}
+
+// signedPValue returns a pvalue with signer's signature of the accept as proof.
+func signedPValue(signer *Keyring, slot Slot, rnd Round, val *pb.Value) *pb.PValue {
+	return &pb.PValue{Slot: slot, Vrnd: rnd, Vval: val, Proof: signer.sign(acceptDigest(slot, rnd, val))}
+}
+
+// signedPromise returns a promise signed by signer.
+func signedPromise(signer *Keyring, rnd Round, pvals ...*pb.PValue) *pb.PromiseMsg {
+	promise := &pb.PromiseMsg{Rnd: rnd, Accepted: pvals}
+	promise.Signature = signer.sign(promiseDigest(promise))
+	return promise
+}
+
+// signedLearn returns a learn signed by signer.
+func signedLearn(signer *Keyring, slot Slot, rnd Round, val *pb.Value) *pb.LearnMsg {
+	return &pb.LearnMsg{Slot: slot, Rnd: rnd, Val: val, Signature: signer.sign(learnDigest(slot, rnd, val))}
+}
+
+func TestBFTPrepareQF(t *testing.T) {
+	keys := bftKeys(t, 4)
+	qs := NewBFTPaxosQSpec(4, keys[0])
+	prepare := &pb.PrepareMsg{Slot: 1, Crnd: 6}
+	// round 5 is owned by node 1 and round 4 by node 0
+	accepted := signedPValue(keys[1], 2, 5, valOne)
+	correct := func(id uint32) *pb.PromiseMsg { return signedPromise(keys[id], 6, accepted) }
+	want := &pb.PromiseMsg{Rnd: 6, Accepted: []*pb.PValue{accepted}}
+
+	tests := []struct {
+		desc    string
+		replies map[uint32]*pb.PromiseMsg
+		want    *pb.PromiseMsg
+	}{
+		{
+			desc:    "quorum of correct promises",
+			replies: map[uint32]*pb.PromiseMsg{0: correct(0), 1: correct(1), 2: correct(2)},
+			want:    want,
+		},
+		{
+			desc:    "too few promises",
+			replies: map[uint32]*pb.PromiseMsg{0: correct(0), 1: correct(1)},
+		},
+		{
+			desc: "forged value in a higher round is ignored",
+			replies: map[uint32]*pb.PromiseMsg{
+				0: correct(0), 1: correct(1), 2: correct(2),
+				3: signedPromise(keys[3], 6, signedPValue(keys[3], 2, 4, valTwo)),
+			},
+			want: want,
+		},
+		{
+			desc: "forged value does not count towards the quorum",
+			replies: map[uint32]*pb.PromiseMsg{
+				0: correct(0), 1: correct(1),
+				3: signedPromise(keys[3], 6, signedPValue(keys[3], 2, 4, valTwo)),
+			},
+		},
+		{
+			desc: "value without proof is ignored",
+			replies: map[uint32]*pb.PromiseMsg{
+				0: correct(0), 1: correct(1),
+				3: signedPromise(keys[3], 6, &pb.PValue{Slot: 2, Vrnd: 5, Vval: valTwo}),
+			},
+		},
+		{
+			desc: "value signed by another node than the round's owner is ignored",
+			replies: map[uint32]*pb.PromiseMsg{
+				0: correct(0), 1: correct(1),
+				3: signedPromise(keys[3], 6, signedPValue(keys[2], 2, 5, valTwo)),
+			},
+		},
+		{
+			desc: "proof for another value is ignored",
+			replies: map[uint32]*pb.PromiseMsg{
+				0: correct(0), 1: correct(1),
+				3: signedPromise(keys[3], 6, &pb.PValue{Slot: 2, Vrnd: 5, Vval: valTwo, Proof: accepted.GetProof()}),
+			},
+		},
+		{
+			desc:    "promise replayed from another acceptor is ignored",
+			replies: map[uint32]*pb.PromiseMsg{0: correct(0), 1: correct(1), 3: correct(0)},
+		},
+		{
+			desc:    "unsigned promise is ignored",
+			replies: map[uint32]*pb.PromiseMsg{0: correct(0), 1: correct(1), 2: {Rnd: 6, Accepted: []*pb.PValue{accepted}}},
+		},
+		{
+			desc: "acceptor hiding its accepted value cannot hide the value",
+			replies: map[uint32]*pb.PromiseMsg{
+				0: correct(0), 1: signedPromise(keys[1], 6), 3: signedPromise(keys[3], 6),
+			},
+			want: want,
+		},
+	}
+	for _, test := range tests {
+		t.Run(test.desc, func(t *testing.T) {
+			got, ok := qs.PrepareQF(prepare, test.replies)
+			if ok != (test.want != nil) {
+				t.Errorf("PrepareQF() quorum = %t, want %t", ok, test.want != nil)
+			}
+			if diff := cmp.Diff(test.want, got, protocmp.Transform()); diff != "" {
+				t.Errorf("PrepareQF() mismatch (-want +got):\n%s", diff)
+			}
+		})
+	}
+}
+
+func TestBFTAcceptQF(t *testing.T) {
+	keys := bftKeys(t, 4)
+	qs := NewBFTPaxosQSpec(4, keys[0])
+	accept := &pb.AcceptMsg{Slot: 2, Rnd: 5, Val: valOne}
+	learn := func(id uint32) *pb.LearnMsg { return signedLearn(keys[id], 2, 5, valOne) }
+	vote := func(id uint32) *pb.Vote { return &pb.Vote{AcceptorID: id, Signature: learn(id).GetSignature()} }
+
+	tests := []struct {
+		desc    string
+		replies map[uint32]*pb.LearnMsg
+		want    *pb.LearnMsg
+	}{
+		{
+			desc:    "quorum of matching learns",
+			replies: map[uint32]*pb.LearnMsg{0: learn(0), 1: learn(1), 2: learn(2)},
+			want:    &pb.LearnMsg{Slot: 2, Rnd: 5, Val: valOne, Certificate: []*pb.Vote{vote(0), vote(1), vote(2)}},
+		},
+		{
+			desc:    "equivocating acceptor voting for another value",
+			replies: map[uint32]*pb.LearnMsg{0: learn(0), 1: learn(1), 3: signedLearn(keys[3], 2, 5, valTwo)},
+		},
+		{
+			desc: "equivocating acceptor is not part of the certificate",
+			replies: map[uint32]*pb.LearnMsg{
+				0: learn(0), 1: learn(1), 2: learn(2), 3: signedLearn(keys[3], 2, 5, valTwo),
+			},
+			want: &pb.LearnMsg{Slot: 2, Rnd: 5, Val: valOne, Certificate: []*pb.Vote{vote(0), vote(1), vote(2)}},
+		},
+		{
+			desc:    "learn signed by another acceptor",
+			replies: map[uint32]*pb.LearnMsg{0: learn(0), 1: learn(1), 3: learn(2)},
+		},
+		{
+			desc:    "unsigned learn",
+			replies: map[uint32]*pb.LearnMsg{0: learn(0), 1: learn(1), 2: {Slot: 2, Rnd: 5, Val: valOne}},
+		},
+	}
+	for _, test := range tests {
+		t.Run(test.desc, func(t *testing.T) {
+			got, ok := qs.AcceptQF(accept, test.replies)
+			if ok != (test.want != nil) {
+				t.Errorf("AcceptQF() quorum = %t, want %t", ok, test.want != nil)
+			}
+			if diff := cmp.Diff(test.want, got, protocmp.Transform()); diff != "" {
+				t.Errorf("AcceptQF() mismatch (-want +got):\n%s", diff)
+			}
+			if ok && !keys[3].verifyCertificate(got) {
+				t.Errorf("AcceptQF() certificate is not valid")
+			}
+		})
+	}
+}
+
+func TestBFTReplica(t *testing.T) {
+	keys := bftKeys(t, 4)
+	r := newTestReplicaLeader()
+	r.Acceptor = NewAcceptor()
+	r.keys = keys[2]
+
+	// round 5 is owned by node 1
+	prepare := &pb.PrepareMsg{Slot: 1, Crnd: 5}
+	if _, err := r.Prepare(gorums.ServerCtx{}, prepare); !errors.Is(err, errInvalidSignature) {
+		t.Errorf("Prepare(unsigned) error = %v, want %v", err, errInvalidSignature)
+	}
+	prepare.Signature = keys[0].sign(prepareDigest(prepare))
+	if _, err := r.Prepare(gorums.ServerCtx{}, prepare); !errors.Is(err, errInvalidSignature) {
+		t.Errorf("Prepare(signed by non-owner) error = %v, want %v", err, errInvalidSignature)
+	}
+	prepare.Signature = keys[1].sign(prepareDigest(prepare))
+	if _, err := r.Prepare(gorums.ServerCtx{}, prepare); err != nil {
+		t.Fatalf("Prepare() error = %v", err)
+	}
+
+	accept := &pb.AcceptMsg{Slot: 1, Rnd: 5, Val: valOne}
+	accept.Signature = keys[1].sign(acceptDigest(accept.Slot, accept.Rnd, accept.Val))
+	learn, err := r.Accept(gorums.ServerCtx{}, accept)
+	if err != nil {
+		t.Fatalf("Accept() error = %v", err)
+	}
+	if !keys[0].verify(2, learnDigest(learn.Slot, learn.Rnd, learn.Val), learn.GetSignature()) {
+		t.Error("Accept() learn is not signed by the acceptor")
+	}
+	// an equivocating proposer sends another value in the same slot and round
+	equivocation := &pb.AcceptMsg{Slot: 1, Rnd: 5, Val: valTwo}
+	equivocation.Signature = keys[1].sign(acceptDigest(equivocation.Slot, equivocation.Rnd, equivocation.Val))
+	if _, err := r.Accept(gorums.ServerCtx{}, equivocation); !errors.Is(err, errIgnored) {
+		t.Errorf("Accept(equivocation) error = %v, want %v", err, errIgnored)
+	}
+	forged := &pb.AcceptMsg{Slot: 2, Rnd: 5, Val: valTwo, Signature: keys[2].sign(acceptDigest(2, 5, valTwo))}
+	if _, err := r.Accept(gorums.ServerCtx{}, forged); !errors.Is(err, errInvalidSignature) {
+		t.Errorf("Accept(forged) error = %v, want %v", err, errInvalidSignature)
+	}
+
+	// a new proposer can verify the accepted value reported in the promise
+	next := &pb.PrepareMsg{Slot: 1, Crnd: 6}
+	next.Signature = keys[2].sign(prepareDigest(next))
+	promise, err := r.Prepare(gorums.ServerCtx{}, next)
+	if err != nil {
+		t.Fatalf("Prepare() error = %v", err)
+	}
+	qs := NewBFTPaxosQSpec(4, keys[2])
+	if len(promise.GetAccepted()) != 1 || !qs.verifyPromise(2, promise) {
+		t.Errorf("Prepare() promise %v is not verifiable", promise)
+	}
+
+	// commits are only delivered with a valid certificate
+	r.Commit(gorums.ServerCtx{}, &pb.LearnMsg{Slot: 1, Rnd: 5, Val: valOne})
+	if adu := r.allDecidedUpTo(); adu != 0 {
+		t.Fatalf("Commit(uncertified) delivered slot %d", adu)
+	}
+	certified := &pb.LearnMsg{Slot: 1, Rnd: 5, Val: valOne}
+	for _, id := range []uint32{0, 1, 1, 3} {
+		vote := &pb.Vote{AcceptorID: id, Signature: keys[id].sign(learnDigest(1, 5, valOne))}
+		certified.Certificate = append(certified.Certificate, vote)
+		// duplicate votes do not count
+		if id == 1 {
+			r.Commit(gorums.ServerCtx{}, proto.Clone(certified).(*pb.LearnMsg))
+			if adu := r.allDecidedUpTo(); adu != 0 {
+				t.Fatalf("Commit(%d votes) delivered slot %d", len(certified.Certificate), adu)
+			}
+		}
+	}
+	r.Commit(gorums.ServerCtx{}, certified)
+	if adu := r.allDecidedUpTo(); adu != 1 {
+		t.Errorf("Commit(certified) allDecidedUpTo = %d, want 1", adu)
+	}
+}
+
+func TestBFTReplicas(t *testing.T) {
+	const numReplicas = 4
+	keys := bftKeys(t, numReplicas)
+	nodeMap, replicas := serveReplicas(t, numReplicas, func(id int, nodeMap map[string]uint32) *PaxosReplica {
+		return NewPaxosReplica(id, nodeMap, WithBFT(keys[uint32(id)]))
+	})
+
+	mgr := pb.NewManager(gorums.WithDialTimeout(5*time.Second),
+		gorums.WithGrpcDialOptions(
+			grpc.WithBlock(), // block until connections are made
+			grpc.WithTransportCredentials(insecure.NewCredentials()), // disable TLS
+		),
+	)
+	defer mgr.Close()
+	config, err := mgr.NewConfiguration(NewBFTPaxosQSpec(numReplicas, nil), gorums.WithNodeMap(nodeMap))
+	if err != nil {
+		t.Fatal(err)
+	}
+	const numRequests = 5
+	for k := range numRequests {
+		ctx, cancel := context.WithTimeout(context.Background(), waitTimeForRequest)
+		req := &pb.Value{ClientID: "bft", ClientSeq: uint32(k), ClientCommand: fmt.Sprint(k)}
+		_, err := config.ClientHandle(ctx, req)
+		cancel()
+		if err != nil {
+			t.Fatalf("ClientHandle(%v) error = %v", req, err)
+		}
+	}
+	// a quorum of replicas has delivered all requests, each with a valid certificate
+	delivered := 0
+	for _, r := range replicas {
+		r.mu.Lock()
+		for slot := Slot(1); slot <= r.allDecidedUpTo(); slot++ {
+			if !keys[0].verifyCertificate(r.learntVal[slot]) {
+				t.Errorf("replica %d: slot %d has no valid certificate", r.id, slot)
+			}
+		}
+		if r.allDecidedUpTo() >= numRequests {
+			delivered++
+		}
+		r.mu.Unlock()
+	}
+	if quorum, _ := bftQuorum(numReplicas); delivered < quorum {
+		t.Errorf("%d replicas delivered all requests, want at least %d", delivered, quorum)
+	}
+}
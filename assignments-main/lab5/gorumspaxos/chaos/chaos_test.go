@@ -0,0 +1,205 @@
+//go:build chaos
+
+package chaos
+
+import (
+	"context"
+	"flag"
+	"math/rand"
+	"sync"
+	"testing"
+	"time"
+)
+
+var (
+	duration   = flag.Duration("chaos.duration", 30*time.Second, "duration of the workload under faults")
+	numNodes   = flag.Int("chaos.nodes", 5, "number of replicas")
+	numClients = flag.Int("chaos.clients", 4, "number of clients")
+	seed       = flag.Int64("chaos.seed", 0, "seed of the fault schedule (0 uses the current time)")
+)
+
+const (
+	// faultTime is how long each fault lasts
+	faultTime = 3 * time.Second
+	// calmTime is the time between faults
+	calmTime = 2 * time.Second
+	// requestTimeout is the clients' timeout for each request
+	requestTimeout = 3 * time.Second
+	// settleTime is the time for the replicas to decide and stream the
+	// last requests after the faults are recovered from
+	settleTime = 5 * time.Second
+)
+
+// nemesis injects one fault at a time into the cluster until ctx is done,
+// and recovers from it after faultTime.
+type nemesis struct {
+	t   *testing.T
+	c   *Cluster
+	rnd *rand.Rand
+}
+
+func (n *nemesis) run(ctx context.Context) {
+	faults := []struct {
+		name   string
+		inject func() func() error
+	}{
+		{"kill", n.kill},
+		{"pause", n.pause},
+		{"partition", n.partition},
+		{"isolate", n.isolate},
+	}
+	for {
+		select {
+		case <-time.After(calmTime):
+		case <-ctx.Done():
+			return
+		}
+		fault := faults[n.rnd.Intn(len(faults))]
+		repair := fault.inject()
+		select {
+		case <-time.After(faultTime):
+		case <-ctx.Done():
+		}
+		if err := repair(); err != nil {
+			n.t.Errorf("recovering from %s: %v", fault.name, err)
+		}
+	}
+}
+
+// kill kills a random replica and restarts it.
+func (n *nemesis) kill() func() error {
+	i := n.rnd.Intn(len(n.c.Nodes()))
+	n.t.Logf("killing replica %d", i)
+	if err := n.c.Kill(i); err != nil {
+		n.t.Error(err)
+	}
+	return func() error {
+		n.t.Logf("restarting replica %d", i)
+		return n.c.Restart(i)
+	}
+}
+
+// pause pauses a random replica and resumes it.
+func (n *nemesis) pause() func() error {
+	i := n.rnd.Intn(len(n.c.Nodes()))
+	n.t.Logf("pausing replica %d", i)
+	if err := n.c.Pause(i); err != nil {
+		n.t.Error(err)
+	}
+	return func() error {
+		n.t.Logf("resuming replica %d", i)
+		return n.c.Resume(i)
+	}
+}
+
+// partition splits the replicas into a random majority and minority.
+func (n *nemesis) partition() func() error {
+	perm := n.rnd.Perm(len(n.c.Nodes()))
+	minority, majority := perm[:len(perm)/2], perm[len(perm)/2:]
+	n.t.Logf("partitioning replicas into %v and %v", minority, majority)
+	if err := n.c.Partition(minority, majority); err != nil {
+		n.t.Error(err)
+	}
+	return n.heal
+}
+
+// isolate isolates the leader of the replicas from the others.
+func (n *nemesis) isolate() func() error {
+	i := n.rnd.Intn(len(n.c.Nodes()))
+	if status, err := n.c.Status(i); err == nil {
+		for j, node := range n.c.Nodes() {
+			if node.ID == int(status.GetLeader()) {
+				i = j
+			}
+		}
+	}
+	others := make([]int, 0, len(n.c.Nodes())-1)
+	for j := range n.c.Nodes() {
+		if j != i {
+			others = append(others, j)
+		}
+	}
+	n.t.Logf("isolating replica %d", i)
+	if err := n.c.Partition(others); err != nil {
+		n.t.Error(err)
+	}
+	return n.heal
+}
+
+func (n *nemesis) heal() error {
+	n.t.Log("healing partition")
+	return n.c.Heal()
+}
+
+// TestChaos runs clients against a cluster of replicas while injecting
+// crashes, pauses and partitions, and checks the clients' history.
+func TestChaos(t *testing.T) {
+	dir := t.TempDir()
+	bin, err := BuildServer(dir)
+	if err != nil {
+		t.Fatal(err)
+	}
+	c, err := Start(bin, dir, *numNodes)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer c.Close()
+	if *seed == 0 {
+		*seed = time.Now().UnixNano()
+	}
+	t.Logf("replica logs in %s, fault schedule seed %d", dir, *seed)
+
+	observeCtx, stopObserving := context.WithCancel(context.Background())
+	decided := NewDecidedLog()
+	var observers sync.WaitGroup
+	observers.Add(1)
+	go func() {
+		defer observers.Done()
+		decided.Observe(observeCtx, c.Addrs())
+	}()
+
+	ctx, cancel := context.WithTimeout(context.Background(), *duration)
+	defer cancel()
+	history := &History{}
+	var wg sync.WaitGroup
+	wg.Add(2)
+	go func() {
+		defer wg.Done()
+		if err := RunClients(ctx, c.Addrs(), *numClients, requestTimeout, history); err != nil {
+			t.Error(err)
+		}
+	}()
+	go func() {
+		defer wg.Done()
+		(&nemesis{t: t, c: c, rnd: rand.New(rand.NewSource(*seed))}).run(ctx)
+	}()
+	wg.Wait()
+
+	if err := c.Recover(); err != nil {
+		t.Error(err)
+	}
+	time.Sleep(settleTime)
+	stopObserving()
+	observers.Wait()
+
+	ops := history.Ops()
+	acked := 0
+	for _, op := range ops {
+		if op.OK {
+			acked++
+		}
+	}
+	entries := decided.Entries()
+	t.Logf("%d requests, %d acknowledged, %d slots decided", len(ops), acked, len(entries))
+	if acked == 0 {
+		t.Error("no request was acknowledged")
+	}
+	errs := append(decided.Conflicts(), Check(ops, entries)...)
+	for i, err := range errs {
+		if i == 20 {
+			t.Errorf("... and %d more violations", len(errs)-i)
+			break
+		}
+		t.Error(err)
+	}
+}
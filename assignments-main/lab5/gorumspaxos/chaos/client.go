@@ -0,0 +1,59 @@
+package chaos
+
+import (
+	"context"
+	"fmt"
+	"sync"
+	"time"
+
+	paxos "dat520/lab5/gorumspaxos"
+	pb "dat520/lab5/gorumspaxos/proto"
+
+	"github.com/relab/gorums"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/credentials/insecure"
+)
+
+// RunClients runs n clients sending requests to the replicas at addrs until
+// ctx is done, and records the requests in the history. Each client sends
+// one request at a time, waiting at most timeout for each response.
+func RunClients(ctx context.Context, addrs []string, n int, timeout time.Duration, history *History) error {
+	mgr := pb.NewManager(
+		gorums.WithDialTimeout(startTimeout),
+		gorums.WithGrpcDialOptions(
+			grpc.WithBlock(), // block until connections are made
+			grpc.WithTransportCredentials(insecure.NewCredentials()), // disable TLS
+		),
+	)
+	defer mgr.Close()
+	config, err := mgr.NewConfiguration(paxos.NewPaxosQSpec(len(addrs)), gorums.WithNodeList(addrs))
+	if err != nil {
+		return err
+	}
+	var wg sync.WaitGroup
+	for i := range n {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			id := fmt.Sprintf("client%d", i)
+			for seq := uint32(1); ctx.Err() == nil; seq++ {
+				req := &pb.Value{ClientID: id, ClientSeq: seq, ClientCommand: fmt.Sprintf("%s-%d", id, seq)}
+				op := Op{ClientID: id, ClientSeq: seq, Command: req.GetClientCommand(), Invoke: time.Now()}
+				reqCtx, cancel := context.WithTimeout(ctx, timeout)
+				_, err := config.ClientHandle(reqCtx, req)
+				cancel()
+				op.Complete, op.OK = time.Now(), err == nil
+				history.Add(op)
+				if err != nil {
+					// back off, so that failing requests do not spin
+					select {
+					case <-time.After(100 * time.Millisecond):
+					case <-ctx.Done():
+					}
+				}
+			}
+		}()
+	}
+	wg.Wait()
+	return nil
+}
@@ -0,0 +1,355 @@
+//go:build unix
+
+package chaos
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"hash/fnv"
+	"net"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"slices"
+	"strings"
+	"sync"
+	"syscall"
+	"time"
+
+	paxos "dat520/lab5/gorumspaxos"
+	apb "dat520/lab5/gorumspaxos/proto/admin"
+
+	"github.com/relab/gorums"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/credentials/insecure"
+)
+
+const (
+	// startTimeout is the time to wait for a started replica to serve requests
+	startTimeout = 10 * time.Second
+	// adminTimeout is the timeout of the admin calls used to inject partitions
+	adminTimeout = 2 * time.Second
+)
+
+// BuildServer builds the paxosserver command into dir and returns the path
+// of its binary. It must be called from within the module.
+func BuildServer(dir string) (string, error) {
+	bin := filepath.Join(dir, "paxosserver")
+	out, err := exec.Command("go", "build", "-o", bin, "dat520/lab5/gorumspaxos/cmd/paxosserver").CombinedOutput()
+	if err != nil {
+		return "", fmt.Errorf("building paxosserver: %v\n%s", err, out)
+	}
+	return bin, nil
+}
+
+// NodeState is the state of a replica process.
+type NodeState string
+
+const (
+	Running NodeState = "running"
+	Paused  NodeState = "paused" // stopped with SIGSTOP
+	Killed  NodeState = "killed" // killed with SIGKILL
+)
+
+// Node is a replica run as a child process.
+type Node struct {
+	ID    int    // the replica's ID, as computed by paxosserver from its address
+	Addr  string // the replica's loopback address
+	Log   string // file that the process' output is appended to
+	Data  string // directory the replica persists its acceptors' state in
+	cmd   *exec.Cmd
+	state NodeState
+}
+
+// Cluster is a cluster of replicas run as child processes on loopback
+// addresses. Replicas persist their acceptors' state in their data
+// directories, so a killed replica is restarted with the promises and
+// accepted values of its acceptors, but an otherwise empty state.
+type Cluster struct {
+	bin   string
+	args  []string
+	mu    sync.Mutex
+	nodes []*Node
+	sides [][]int // the current partition; nil if there is none
+}
+
+// Start starts n replicas running the paxosserver binary bin, each with the
+// additional arguments args. The output and data of the replicas are written to dir.
+func Start(bin, dir string, n int, args ...string) (*Cluster, error) {
+	addrs, err := freeAddrs(n)
+	if err != nil {
+		return nil, err
+	}
+	c := &Cluster{bin: bin, args: args}
+	for i, addr := range addrs {
+		c.nodes = append(c.nodes, &Node{
+			ID:   nodeID(addr),
+			Addr: addr,
+			Log:  filepath.Join(dir, fmt.Sprintf("node%d.log", i)),
+			Data: filepath.Join(dir, fmt.Sprintf("node%d", i)),
+		})
+	}
+	for _, node := range c.nodes {
+		if err := c.start(node); err != nil {
+			c.Close()
+			return nil, err
+		}
+	}
+	return c, nil
+}
+
+// freeAddrs returns n loopback addresses with free ports.
+func freeAddrs(n int) ([]string, error) {
+	addrs := make([]string, 0, n)
+	for range n {
+		lis, err := net.Listen("tcp", "127.0.0.1:0")
+		if err != nil {
+			return nil, err
+		}
+		addrs = append(addrs, lis.Addr().String())
+		lis.Close()
+	}
+	return addrs, nil
+}
+
+// nodeID returns the ID of the replica with the given address,
+// computed in the same way as by paxosserver.
+func nodeID(addr string) int {
+	h := fnv.New32a()
+	h.Write([]byte(addr))
+	return int(h.Sum32())
+}
+
+// start starts the node's process and waits until it accepts connections.
+func (c *Cluster) start(node *Node) error {
+	others := make([]string, 0, len(c.nodes)-1)
+	for _, other := range c.nodes {
+		if other != node {
+			others = append(others, other.Addr)
+		}
+	}
+	out, err := os.OpenFile(node.Log, os.O_CREATE|os.O_WRONLY|os.O_APPEND, 0o644)
+	if err != nil {
+		return err
+	}
+	defer out.Close()
+	args := append([]string{"-laddr", node.Addr, "-addrs", strings.Join(others, ","), "-data-dir", node.Data}, c.args...)
+	cmd := exec.Command(c.bin, args...)
+	cmd.Stdout, cmd.Stderr = out, out
+	if err := cmd.Start(); err != nil {
+		return err
+	}
+	node.cmd, node.state = cmd, Running
+	for deadline := time.Now().Add(startTimeout); time.Now().Before(deadline); time.Sleep(50 * time.Millisecond) {
+		if conn, err := net.Dial("tcp", node.Addr); err == nil {
+			conn.Close()
+			return nil
+		}
+	}
+	return fmt.Errorf("replica %s did not start", node.Addr)
+}
+
+// Nodes returns the cluster's replicas.
+func (c *Cluster) Nodes() []*Node {
+	return c.nodes
+}
+
+// Addrs returns the addresses of the cluster's replicas.
+func (c *Cluster) Addrs() []string {
+	addrs := make([]string, len(c.nodes))
+	for i, node := range c.nodes {
+		addrs[i] = node.Addr
+	}
+	return addrs
+}
+
+// State returns the state of replica i.
+func (c *Cluster) State(i int) NodeState {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return c.nodes[i].state
+}
+
+// signal sends sig to replica i, which must be in state from, and moves it to state to.
+func (c *Cluster) signal(i int, sig syscall.Signal, from, to NodeState) error {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	node := c.nodes[i]
+	if node.state != from {
+		return fmt.Errorf("replica %d is %s, not %s", i, node.state, from)
+	}
+	if err := node.cmd.Process.Signal(sig); err != nil {
+		return err
+	}
+	if sig == syscall.SIGKILL {
+		node.cmd.Wait()
+	}
+	node.state = to
+	return nil
+}
+
+// Kill kills replica i with SIGKILL.
+func (c *Cluster) Kill(i int) error {
+	return c.signal(i, syscall.SIGKILL, Running, Killed)
+}
+
+// Pause stops replica i with SIGSTOP; its connections stay open, but it
+// neither sends nor handles messages until it is resumed.
+func (c *Cluster) Pause(i int) error {
+	return c.signal(i, syscall.SIGSTOP, Running, Paused)
+}
+
+// Resume continues replica i, stopped by Pause, with SIGCONT, and updates
+// its fault rules to the current partition.
+func (c *Cluster) Resume(i int) error {
+	if err := c.signal(i, syscall.SIGCONT, Paused, Running); err != nil {
+		return err
+	}
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return c.setFaults(c.nodes[i], c.partitionRules(i))
+}
+
+// Restart starts replica i, killed by Kill, again. The replica starts with
+// its acceptors' persisted state, and is partitioned as the other replicas are.
+func (c *Cluster) Restart(i int) error {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	node := c.nodes[i]
+	if node.state != Killed {
+		return fmt.Errorf("replica %d is %s, not %s", i, node.state, Killed)
+	}
+	if err := c.start(node); err != nil {
+		return err
+	}
+	if c.sides == nil {
+		return nil
+	}
+	return c.setFaults(node, c.partitionRules(i))
+}
+
+// Partition partitions the replicas into the given sides, each a list of
+// replica indices; replicas not in any side are isolated. The replicas drop
+// all messages sent to and received from replicas on other sides. Messages
+// from clients are not affected. Replicas that are not running are
+// partitioned when restarted; if paused, the others drop their messages.
+func (c *Cluster) Partition(sides ...[]int) error {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.sides = sides
+	var errs []error
+	for i, node := range c.nodes {
+		if node.state == Running {
+			errs = append(errs, c.setFaults(node, c.partitionRules(i)))
+		}
+	}
+	return errors.Join(errs...)
+}
+
+// Heal removes the partition from the running replicas.
+func (c *Cluster) Heal() error {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.sides = nil
+	var errs []error
+	for _, node := range c.nodes {
+		if node.state == Running {
+			errs = append(errs, c.setFaults(node, nil))
+		}
+	}
+	return errors.Join(errs...)
+}
+
+// partitionRules returns the fault rules of replica i in the current partition.
+func (c *Cluster) partitionRules(i int) []*apb.FaultRule {
+	if c.sides == nil {
+		return nil
+	}
+	side := []int{i}
+	for _, s := range c.sides {
+		if slices.Contains(s, i) {
+			side = s
+		}
+	}
+	var rules []*apb.FaultRule
+	for j, other := range c.nodes {
+		if slices.Contains(side, j) {
+			continue
+		}
+		for _, incoming := range []bool{false, true} {
+			rules = append(rules, &apb.FaultRule{Peer: int64(other.ID), Incoming: incoming, Action: string(paxos.FaultDrop)})
+		}
+	}
+	return rules
+}
+
+// admin calls the node's admin service with call. Each call uses a new
+// connection, since a restarted replica may not be reconnected to for a
+// while by a connection to its previous process.
+func admin(node *Node, call func(context.Context, *apb.Node) error) error {
+	mgr := apb.NewManager(
+		gorums.WithDialTimeout(adminTimeout),
+		gorums.WithGrpcDialOptions(
+			grpc.WithBlock(), // block until connections are made
+			grpc.WithTransportCredentials(insecure.NewCredentials()), // disable TLS
+		),
+	)
+	defer mgr.Close()
+	cfg, err := mgr.NewConfiguration(gorums.WithNodeList([]string{node.Addr}))
+	if err != nil {
+		return fmt.Errorf("replica %s: %w", node.Addr, err)
+	}
+	ctx, cancel := context.WithTimeout(context.Background(), adminTimeout)
+	defer cancel()
+	if err := call(ctx, cfg.Nodes()[0]); err != nil {
+		return fmt.Errorf("replica %s: %w", node.Addr, err)
+	}
+	return nil
+}
+
+// setFaults replaces the node's fault rules.
+func (c *Cluster) setFaults(node *Node, rules []*apb.FaultRule) error {
+	return admin(node, func(ctx context.Context, n *apb.Node) error {
+		_, err := n.SetFaults(ctx, &apb.FaultsRequest{Rules: rules})
+		return err
+	})
+}
+
+// Status returns the status of replica i.
+func (c *Cluster) Status(i int) (status *apb.StatusReply, err error) {
+	err = admin(c.nodes[i], func(ctx context.Context, n *apb.Node) error {
+		status, err = n.ReplicaStatus(ctx, &apb.StatusRequest{})
+		return err
+	})
+	return status, err
+}
+
+// Recover heals the partition, resumes the paused replicas and restarts
+// the killed ones.
+func (c *Cluster) Recover() error {
+	var errs []error
+	for i := range c.nodes {
+		switch c.State(i) {
+		case Paused:
+			errs = append(errs, c.Resume(i))
+		case Killed:
+			errs = append(errs, c.Restart(i))
+		}
+	}
+	errs = append(errs, c.Heal())
+	return errors.Join(errs...)
+}
+
+// Close kills the replicas.
+func (c *Cluster) Close() {
+	for i := range c.nodes {
+		switch c.State(i) {
+		case Paused:
+			c.Resume(i)
+			c.Kill(i)
+		case Running:
+			c.Kill(i)
+		}
+	}
+}
@@ -0,0 +1,16 @@
+// Package chaos tests gorumspaxos replicas under crashes, pauses and network
+// partitions. It runs paxosserver binaries as child processes on loopback
+// addresses, kills and restarts them with SIGKILL, pauses them with SIGSTOP,
+// and partitions them using the replicas' fault injection rules, while
+// clients send requests. The clients' history is then checked against the
+// log decided by the replicas.
+//
+// Replicas persist their acceptors' promises and accepted values in their
+// data directories, so a killed replica is restarted without forgetting what
+// its acceptors promised and accepted. Since every fault is one that Paxos
+// tolerates, the checker must report no violations for any fault schedule.
+//
+// The test starting the replicas is built with the chaos build tag:
+//
+//	go test -tags=chaos -v ./lab5/gorumspaxos/chaos
+package chaos
@@ -0,0 +1,215 @@
+package chaos
+
+import (
+	"context"
+	"fmt"
+	"slices"
+	"sync"
+	"time"
+
+	paxos "dat520/lab5/gorumspaxos"
+	pb "dat520/lab5/gorumspaxos/proto"
+
+	"github.com/relab/gorums"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/credentials/insecure"
+	"google.golang.org/protobuf/proto"
+)
+
+// Op is a client request and its outcome. A request that failed, for
+// instance because it timed out, may or may not have been decided.
+type Op struct {
+	ClientID  string
+	ClientSeq uint32
+	Command   string
+	Invoke    time.Time // when the request was sent
+	Complete  time.Time // when the response or error was received
+	OK        bool      // the request was acknowledged by a response
+}
+
+func (op Op) String() string {
+	return fmt.Sprintf("%s/%d", op.ClientID, op.ClientSeq)
+}
+
+// History records the requests of the clients.
+type History struct {
+	mu  sync.Mutex
+	ops []Op
+}
+
+// Add records the request.
+func (h *History) Add(op Op) {
+	h.mu.Lock()
+	h.ops = append(h.ops, op)
+	h.mu.Unlock()
+}
+
+// Ops returns the recorded requests.
+func (h *History) Ops() []Op {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	return slices.Clone(h.ops)
+}
+
+// DecidedLog records the log entries decided by the replicas, as observed
+// by watching their logs, and the entries on which they disagree.
+type DecidedLog struct {
+	mu        sync.Mutex
+	entries   map[uint32]*pb.Value
+	conflicts []error
+}
+
+// NewDecidedLog returns an empty decided log.
+func NewDecidedLog() *DecidedLog {
+	return &DecidedLog{entries: make(map[uint32]*pb.Value)}
+}
+
+// Add records the entry decided by the replica at addr.
+func (l *DecidedLog) Add(addr string, learn *pb.LearnMsg) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	val, ok := l.entries[learn.GetSlot()]
+	if !ok {
+		l.entries[learn.GetSlot()] = learn.GetVal()
+		return
+	}
+	if !proto.Equal(val, learn.GetVal()) {
+		l.conflicts = append(l.conflicts, fmt.Errorf("slot %d: replica %s decided %v, but %v was decided", learn.GetSlot(), addr, learn.GetVal(), val))
+	}
+}
+
+// Entries returns the decided entries by slot.
+func (l *DecidedLog) Entries() map[uint32]*pb.Value {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	entries := make(map[uint32]*pb.Value, len(l.entries))
+	for slot, val := range l.entries {
+		entries[slot] = val
+	}
+	return entries
+}
+
+// Conflicts returns an error for each entry decided differently by two replicas.
+func (l *DecidedLog) Conflicts() []error {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	return slices.Clone(l.conflicts)
+}
+
+// Observe watches the decided logs of the replicas at addrs and records
+// their entries in the decided log until ctx is done. The watch of a
+// replica is started again when it fails, for instance after a restart.
+func (l *DecidedLog) Observe(ctx context.Context, addrs []string) {
+	var wg sync.WaitGroup
+	for _, addr := range addrs {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			l.observe(ctx, addr)
+		}()
+	}
+	wg.Wait()
+}
+
+func (l *DecidedLog) observe(ctx context.Context, addr string) {
+	next := uint32(1)
+	for ctx.Err() == nil {
+		w, err := paxos.WatchLog(ctx, addr, 0, next,
+			gorums.WithDialTimeout(time.Second),
+			gorums.WithGrpcDialOptions(
+				grpc.WithBlock(), // block until connections are made
+				grpc.WithTransportCredentials(insecure.NewCredentials()), // disable TLS
+			),
+		)
+		if err == nil {
+			for learn := range w.Entries() {
+				l.Add(addr, learn)
+				next = max(next, learn.GetSlot()+1)
+			}
+			w.Close()
+		}
+		select {
+		case <-time.After(200 * time.Millisecond):
+		case <-ctx.Done():
+		}
+	}
+}
+
+// Check checks that the history of the clients' requests is consistent with
+// the decided log, and returns an error for each violation found:
+//
+//   - every decided request was sent by a client, with the decided command;
+//   - every acknowledged request was decided;
+//   - a request sent after another was acknowledged was decided in a later
+//     slot, that is, the log is linearizable.
+//
+// A request decided in several slots is ordered by the first one.
+func Check(ops []Op, entries map[uint32]*pb.Value) []error {
+	var errs []error
+	type request struct {
+		clientID  string
+		clientSeq uint32
+	}
+	sent := make(map[request]Op)
+	for _, op := range ops {
+		sent[request{op.ClientID, op.ClientSeq}] = op
+	}
+	slots := make([]uint32, 0, len(entries))
+	for slot := range entries {
+		slots = append(slots, slot)
+	}
+	slices.Sort(slots)
+	first := make(map[request]uint32)
+	for _, slot := range slots {
+		val := entries[slot]
+		if val.GetIsNoop() {
+			continue
+		}
+		req := request{val.GetClientID(), val.GetClientSeq()}
+		op, ok := sent[req]
+		switch {
+		case !ok:
+			errs = append(errs, fmt.Errorf("slot %d: decided %s/%d, which was never sent", slot, req.clientID, req.clientSeq))
+		case op.Command != val.GetClientCommand():
+			errs = append(errs, fmt.Errorf("slot %d: decided %v with command %q, but %q was sent", slot, op, val.GetClientCommand(), op.Command))
+		}
+		if _, ok := first[req]; !ok {
+			first[req] = slot
+		}
+	}
+
+	acked := make([]Op, 0, len(ops))
+	decided := make([]Op, 0, len(ops))
+	for _, op := range ops {
+		_, ok := first[request{op.ClientID, op.ClientSeq}]
+		if op.OK {
+			acked = append(acked, op)
+			if !ok {
+				errs = append(errs, fmt.Errorf("%v was acknowledged, but not decided", op))
+			}
+		}
+		if ok {
+			decided = append(decided, op)
+		}
+	}
+	// sweep the decided requests in invocation order, keeping the latest
+	// slot of the requests acknowledged before each invocation
+	slices.SortFunc(acked, func(a, b Op) int { return a.Complete.Compare(b.Complete) })
+	slices.SortFunc(decided, func(a, b Op) int { return a.Invoke.Compare(b.Invoke) })
+	var (
+		latest   Op
+		maxSlot  uint32
+		finished int
+	)
+	for _, op := range decided {
+		for ; finished < len(acked) && acked[finished].Complete.Before(op.Invoke); finished++ {
+			if slot, ok := first[request{acked[finished].ClientID, acked[finished].ClientSeq}]; ok && slot > maxSlot {
+				latest, maxSlot = acked[finished], slot
+			}
+		}
+		if slot := first[request{op.ClientID, op.ClientSeq}]; slot < maxSlot {
+			errs = append(errs, fmt.Errorf("%v was decided in slot %d, before %v in slot %d, which was acknowledged before %v was sent", op, slot, latest, maxSlot, op))
+		}
+	}
+	return errs
+}
@@ -0,0 +1,105 @@
+package chaos
+
+import (
+	"testing"
+	"time"
+
+	pb "dat520/lab5/gorumspaxos/proto"
+
+	"github.com/google/go-cmp/cmp"
+)
+
+func TestCheck(t *testing.T) {
+	t0 := time.Now()
+	at := func(ms int) time.Time { return t0.Add(time.Duration(ms) * time.Millisecond) }
+	op := func(client string, seq uint32, invoke, complete int, ok bool) Op {
+		return Op{ClientID: client, ClientSeq: seq, Command: client + "cmd", Invoke: at(invoke), Complete: at(complete), OK: ok}
+	}
+	val := func(client string, seq uint32) *pb.Value {
+		return &pb.Value{ClientID: client, ClientSeq: seq, ClientCommand: client + "cmd"}
+	}
+	tests := []struct {
+		name    string
+		ops     []Op
+		entries map[uint32]*pb.Value
+		want    []string
+	}{
+		{
+			name:    "Sequential",
+			ops:     []Op{op("a", 1, 0, 10, true), op("a", 2, 11, 20, true), op("b", 1, 21, 30, true)},
+			entries: map[uint32]*pb.Value{1: val("a", 1), 2: val("a", 2), 3: {IsNoop: true}, 4: val("b", 1)},
+		},
+		{
+			name:    "Concurrent",
+			ops:     []Op{op("a", 1, 0, 20, true), op("b", 1, 5, 15, true)},
+			entries: map[uint32]*pb.Value{1: val("b", 1), 2: val("a", 1)},
+		},
+		{
+			name:    "FailedMaybeDecided",
+			ops:     []Op{op("a", 1, 0, 10, false), op("a", 2, 11, 20, false), op("a", 3, 21, 30, true)},
+			entries: map[uint32]*pb.Value{1: val("a", 1), 2: val("a", 3)},
+		},
+		{
+			name:    "DecidedTwice",
+			ops:     []Op{op("a", 1, 0, 10, true), op("b", 1, 11, 20, true)},
+			entries: map[uint32]*pb.Value{1: val("a", 1), 2: val("b", 1), 3: val("a", 1)},
+		},
+		{
+			name:    "Lost",
+			ops:     []Op{op("a", 1, 0, 10, true), op("b", 1, 11, 20, true)},
+			entries: map[uint32]*pb.Value{2: val("b", 1)},
+			want:    []string{"a/1 was acknowledged, but not decided"},
+		},
+		{
+			name:    "NeverSent",
+			ops:     []Op{op("a", 1, 0, 10, true)},
+			entries: map[uint32]*pb.Value{1: val("a", 1), 2: val("c", 1)},
+			want:    []string{"slot 2: decided c/1, which was never sent"},
+		},
+		{
+			name:    "WrongCommand",
+			ops:     []Op{op("a", 1, 0, 10, true)},
+			entries: map[uint32]*pb.Value{1: {ClientID: "a", ClientSeq: 1, ClientCommand: "other"}},
+			want:    []string{`slot 1: decided a/1 with command "other", but "acmd" was sent`},
+		},
+		{
+			name:    "Reordered",
+			ops:     []Op{op("a", 1, 0, 10, true), op("b", 1, 11, 20, true), op("c", 1, 12, 30, false)},
+			entries: map[uint32]*pb.Value{1: val("c", 1), 2: val("b", 1), 3: val("a", 1)},
+			want: []string{
+				"b/1 was decided in slot 2, before a/1 in slot 3, which was acknowledged before b/1 was sent",
+				"c/1 was decided in slot 1, before a/1 in slot 3, which was acknowledged before c/1 was sent",
+			},
+		},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			var got []string
+			for _, err := range Check(test.ops, test.entries) {
+				got = append(got, err.Error())
+			}
+			if diff := cmp.Diff(test.want, got); diff != "" {
+				t.Errorf("Check() mismatch (-want +got):\n%s", diff)
+			}
+		})
+	}
+}
+
+func TestDecidedLogConflicts(t *testing.T) {
+	l := NewDecidedLog()
+	a := &pb.Value{ClientID: "a", ClientSeq: 1}
+	b := &pb.Value{ClientID: "b", ClientSeq: 1}
+	l.Add("n1", &pb.LearnMsg{Slot: 1, Val: a})
+	l.Add("n2", &pb.LearnMsg{Slot: 1, Val: a})
+	l.Add("n2", &pb.LearnMsg{Slot: 2, Val: b})
+	if got := l.Conflicts(); len(got) != 0 {
+		t.Errorf("Conflicts() = %v, want none", got)
+	}
+	l.Add("n3", &pb.LearnMsg{Slot: 2, Val: a})
+	if got := l.Conflicts(); len(got) != 1 {
+		t.Errorf("Conflicts() = %v, want one conflict", got)
+	}
+	if got := l.Entries(); len(got) != 2 || got[2] != b {
+		t.Errorf("Entries() = %v, want slot 2 to hold %v", got, b)
+	}
+}
@@ -0,0 +1,117 @@
+package main
+
+import (
+	"math"
+	"math/bits"
+	"time"
+)
+
+// Latencies below subBuckets microseconds have their own bucket, and each
+// larger power of two is split into subBuckets/2 buckets of equal width,
+// which bounds the relative error of a recorded latency to 2/subBuckets.
+const (
+	subBucketBits = 5
+	subBuckets    = 1 << subBucketBits
+)
+
+// histogram records latencies in log-linear buckets of microseconds.
+type histogram struct {
+	counts []uint64
+	count  uint64
+	sum    time.Duration
+	min    time.Duration
+	max    time.Duration
+}
+
+func newHistogram() *histogram {
+	return &histogram{counts: make([]uint64, bucketIndex(math.MaxInt64)+1)}
+}
+
+// bucketIndex returns the index of the bucket holding a value of us microseconds.
+func bucketIndex(us int64) int {
+	if us < subBuckets {
+		return int(max(us, 0))
+	}
+	shift := bits.Len64(uint64(us)) - subBucketBits // us>>shift is in [subBuckets/2, subBuckets)
+	return shift*subBuckets/2 + int(us>>shift)
+}
+
+// bucketUpper returns the largest value, in microseconds, held by the bucket.
+func bucketUpper(index int) int64 {
+	if index < subBuckets {
+		return int64(index)
+	}
+	shift := index/(subBuckets/2) - 1
+	sub := int64(index%(subBuckets/2) + subBuckets/2)
+	return (sub+1)<<shift - 1
+}
+
+// record adds a latency to the histogram.
+func (h *histogram) record(d time.Duration) {
+	h.counts[bucketIndex(d.Microseconds())]++
+	if h.count == 0 || d < h.min {
+		h.min = d
+	}
+	h.max = max(h.max, d)
+	h.count++
+	h.sum += d
+}
+
+// merge adds the latencies recorded by other to the histogram.
+func (h *histogram) merge(other *histogram) {
+	if other.count == 0 {
+		return
+	}
+	for i, n := range other.counts {
+		h.counts[i] += n
+	}
+	if h.count == 0 || other.min < h.min {
+		h.min = other.min
+	}
+	h.max = max(h.max, other.max)
+	h.count += other.count
+	h.sum += other.sum
+}
+
+// mean returns the mean of the recorded latencies.
+func (h *histogram) mean() time.Duration {
+	if h.count == 0 {
+		return 0
+	}
+	return h.sum / time.Duration(h.count)
+}
+
+// quantile returns the latency below which the fraction q of the recorded
+// latencies fall, rounded up to the upper bound of its bucket.
+func (h *histogram) quantile(q float64) time.Duration {
+	if h.count == 0 {
+		return 0
+	}
+	rank := uint64(math.Ceil(q * float64(h.count)))
+	rank = max(rank, 1)
+	var seen uint64
+	for i, n := range h.counts {
+		seen += n
+		if seen >= rank {
+			return min(time.Duration(bucketUpper(i))*time.Microsecond, h.max)
+		}
+	}
+	return h.max
+}
+
+// bucket is a non-empty bucket of a histogram.
+type bucket struct {
+	UpperMicros int64  `json:"upper_us"`
+	Count       uint64 `json:"count"`
+}
+
+// buckets returns the non-empty buckets of the histogram.
+func (h *histogram) buckets() []bucket {
+	var bs []bucket
+	for i, n := range h.counts {
+		if n > 0 {
+			bs = append(bs, bucket{UpperMicros: bucketUpper(i), Count: n})
+		}
+	}
+	return bs
+}
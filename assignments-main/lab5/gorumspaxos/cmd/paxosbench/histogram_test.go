@@ -0,0 +1,62 @@
+package main
+
+import (
+	"math"
+	"testing"
+	"time"
+)
+
+func TestBucketBounds(t *testing.T) {
+	for _, us := range []int64{0, 1, 31, 32, 33, 63, 64, 100, 1000, 12345, 1 << 40, math.MaxInt64} {
+		i := bucketIndex(us)
+		upper := bucketUpper(i)
+		if us > upper {
+			t.Errorf("bucketUpper(bucketIndex(%d)) = %d, want >= %d", us, upper, us)
+		}
+		if i > 0 && us <= bucketUpper(i-1) {
+			t.Errorf("value %d in bucket %d is also held by bucket %d (upper %d)", us, i, i-1, bucketUpper(i-1))
+		}
+		if us >= subBuckets && float64(upper-us) > float64(us)*2/subBuckets {
+			t.Errorf("bucket upper bound %d of %d exceeds the relative error", upper, us)
+		}
+	}
+}
+
+func TestHistogramQuantiles(t *testing.T) {
+	h := newHistogram()
+	for i := 1; i <= 1000; i++ {
+		h.record(time.Duration(i) * time.Millisecond)
+	}
+	if h.count != 1000 || h.min != time.Millisecond || h.max != time.Second {
+		t.Errorf("count, min, max = %d, %v, %v; want 1000, 1ms, 1s", h.count, h.min, h.max)
+	}
+	if got, want := h.mean(), 500500*time.Microsecond; got != want {
+		t.Errorf("mean() = %v, want %v", got, want)
+	}
+	tests := []struct {
+		q    float64
+		want time.Duration
+	}{
+		{q: 0, want: time.Millisecond},
+		{q: 0.5, want: 500 * time.Millisecond},
+		{q: 0.9, want: 900 * time.Millisecond},
+		{q: 0.99, want: 990 * time.Millisecond},
+		{q: 1, want: time.Second},
+	}
+	for _, test := range tests {
+		got := h.quantile(test.q)
+		if got < test.want || float64(got-test.want) > float64(test.want)*2/subBuckets {
+			t.Errorf("quantile(%v) = %v, want about %v", test.q, got, test.want)
+		}
+	}
+
+	other := newHistogram()
+	other.record(2 * time.Second)
+	h.merge(other)
+	if h.count != 1001 || h.max != 2*time.Second || h.quantile(1) != 2*time.Second {
+		t.Errorf("after merge: count, max, quantile(1) = %d, %v, %v; want 1001, 2s, 2s", h.count, h.max, h.quantile(1))
+	}
+	if empty := newHistogram(); empty.quantile(0.5) != 0 || empty.mean() != 0 {
+		t.Error("empty histogram has non-zero quantile or mean")
+	}
+}
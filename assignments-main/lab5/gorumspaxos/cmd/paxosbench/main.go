@@ -0,0 +1,217 @@
+// Command paxosbench measures the latency and throughput of a gorumspaxos
+// cluster under a configurable workload.
+//
+// In closed-loop mode, each of the clients sends a request and waits for its
+// response before sending the next. In open-loop mode, requests are sent at a
+// fixed total rate, spread round-robin over the clients, regardless of how
+// many requests are outstanding; latencies are measured from the time each
+// request was scheduled, so that a slow cluster does not hide its queueing.
+package main
+
+import (
+	"context"
+	"flag"
+	"fmt"
+	"log"
+	"math/rand"
+	"os"
+	"strings"
+	"sync"
+	"time"
+
+	paxos "dat520/lab5/gorumspaxos"
+	pb "dat520/lab5/gorumspaxos/proto"
+
+	"github.com/relab/gorums"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/credentials/insecure"
+)
+
+func main() {
+	var (
+		srvAddrs    = flag.String("addrs", "", "server addresses separated by ','")
+		mode        = flag.String("mode", "closed", "workload mode: closed (clients wait for responses) or open (fixed request rate)")
+		clients     = flag.Int("clients", 8, "number of concurrent clients")
+		rate        = flag.Float64("rate", 1000, "total request rate in requests per second (open mode)")
+		maxInFlight = flag.Int("max-inflight", 10000, "maximum number of outstanding requests; requests beyond it are dropped (open mode)")
+		duration    = flag.Duration("duration", 10*time.Second, "duration of the measurement")
+		warmup      = flag.Duration("warmup", time.Second, "duration of the warmup before the measurement")
+		interval    = flag.Duration("interval", time.Second, "interval of the throughput and latency time series")
+		timeout     = flag.Duration("timeout", 5*time.Second, "timeout of each request")
+		size        = flag.Int("size", 16, "size of the values written, in bytes")
+		reads       = flag.Float64("reads", 0, "fraction of requests that are reads (0 to 1)")
+		keys        = flag.Int("keys", 1000, "number of distinct keys")
+		numGroups   = flag.Int("groups", 1, "number of Paxos groups (shards) run by the replicas")
+		csvFile     = flag.String("csv", "", "file to write the time series to as CSV (disabled if empty)")
+		jsonFile    = flag.String("json", "", "file to write the results to as JSON (disabled if empty)")
+	)
+	flag.Usage = func() {
+		log.Printf("Usage: %s [OPTIONS]\nOptions:", os.Args[0])
+		flag.PrintDefaults()
+	}
+	flag.Parse()
+
+	if *srvAddrs == "" {
+		log.Fatalln("no server addresses provided")
+	}
+	if *mode != "closed" && *mode != "open" {
+		log.Fatalf("unknown mode %q", *mode)
+	}
+	if *clients < 1 || *rate <= 0 || *maxInFlight < 1 || *interval <= 0 || *reads < 0 || *reads > 1 {
+		log.Fatalln("invalid workload options")
+	}
+	addrs := strings.Split(*srvAddrs, ",")
+	b := &bench{
+		mode:        *mode,
+		clients:     *clients,
+		rate:        *rate,
+		maxInFlight: *maxInFlight,
+		duration:    *duration,
+		warmup:      *warmup,
+		interval:    *interval,
+		timeout:     *timeout,
+		workload:    workload{size: *size, reads: *reads, keys: *keys},
+		router:      paxos.NewShardRouterN(*numGroups),
+	}
+	log.Printf("Connecting to %d Paxos replicas: %v", len(addrs), addrs)
+	config, mgr := createConfiguration(addrs)
+	defer mgr.Close()
+	b.config = config
+
+	log.Printf("Running %s-loop workload with %d clients for %v (warmup %v)", b.mode, b.clients, b.duration, b.warmup)
+	res := b.run()
+	res.print(os.Stdout)
+	if *csvFile != "" {
+		if err := writeFile(*csvFile, res.writeCSV); err != nil {
+			log.Fatal(err)
+		}
+	}
+	if *jsonFile != "" {
+		if err := writeFile(*jsonFile, res.writeJSON); err != nil {
+			log.Fatal(err)
+		}
+	}
+}
+
+// bench is a benchmark run against a configuration of replicas.
+type bench struct {
+	config      *pb.Configuration
+	mode        string
+	clients     int
+	rate        float64
+	maxInFlight int
+	duration    time.Duration
+	warmup      time.Duration
+	interval    time.Duration
+	timeout     time.Duration
+	workload    workload
+	router      *paxos.ShardRouter
+}
+
+// run runs the benchmark and returns its results.
+func (b *bench) run() *results {
+	start := time.Now()
+	rec := newRecorder(start.Add(b.warmup), start.Add(b.warmup+b.duration))
+	ctx, cancel := context.WithDeadline(context.Background(), rec.end)
+	defer cancel()
+
+	clients := make([]*client, b.clients)
+	for i := range clients {
+		clients[i] = &client{
+			id:  fmt.Sprintf("bench-%d-%d", os.Getpid(), i),
+			rnd: rand.New(rand.NewSource(start.UnixNano() + int64(i))),
+		}
+	}
+	var wg sync.WaitGroup
+	wg.Add(1)
+	go func() {
+		defer wg.Done()
+		rec.sample(ctx, b.interval)
+	}()
+	if b.mode == "open" {
+		b.openLoop(ctx, clients, rec)
+	} else {
+		for _, c := range clients {
+			wg.Add(1)
+			go func() {
+				defer wg.Done()
+				b.closedLoop(ctx, c, rec)
+			}()
+		}
+	}
+	wg.Wait()
+	return rec.results(b)
+}
+
+// closedLoop sends the client's requests one at a time until ctx is done.
+// A client whose request is rejected by overloaded replicas waits for the
+// suggested delay before sending its next request.
+func (b *bench) closedLoop(ctx context.Context, c *client, rec *recorder) {
+	for ctx.Err() == nil {
+		req, read := c.request(b.workload, b.router)
+		sent := time.Now()
+		err := b.send(ctx, req)
+		rec.record(sent, time.Now(), read, err)
+		if retryAfter, overloaded := paxos.RetryAfter(err); overloaded {
+			select {
+			case <-time.After(retryAfter):
+			case <-ctx.Done():
+			}
+		}
+	}
+}
+
+// openLoop sends requests at the configured rate until ctx is done,
+// and waits for the outstanding requests.
+func (b *bench) openLoop(ctx context.Context, clients []*client, rec *recorder) {
+	period := time.Duration(float64(time.Second) / b.rate)
+	inFlight := make(chan struct{}, b.maxInFlight)
+	var wg sync.WaitGroup
+	defer wg.Wait()
+	next := time.Now()
+	for i := 0; ; i++ {
+		next = next.Add(period)
+		select {
+		case <-time.After(time.Until(next)):
+		case <-ctx.Done():
+			return
+		}
+		req, read := clients[i%len(clients)].request(b.workload, b.router)
+		select {
+		case inFlight <- struct{}{}:
+		default:
+			rec.drop(next)
+			continue
+		}
+		wg.Add(1)
+		go func(scheduled time.Time) {
+			defer wg.Done()
+			err := b.send(ctx, req)
+			<-inFlight
+			rec.record(scheduled, time.Now(), read, err)
+		}(next)
+	}
+}
+
+// send sends the request to the replicas and waits for the response.
+func (b *bench) send(ctx context.Context, req *pb.Value) error {
+	ctx, cancel := context.WithTimeout(ctx, b.timeout)
+	defer cancel()
+	_, err := b.config.ClientHandle(ctx, req)
+	return err
+}
+
+// createConfiguration creates the gorums configuration with the list of addresses.
+func createConfiguration(addrs []string) (*pb.Configuration, *pb.Manager) {
+	mgr := pb.NewManager(gorums.WithDialTimeout(5*time.Second),
+		gorums.WithGrpcDialOptions(
+			grpc.WithBlock(), // block until connections are made
+			grpc.WithTransportCredentials(insecure.NewCredentials()), // disable TLS
+		),
+	)
+	config, err := mgr.NewConfiguration(paxos.NewPaxosQSpec(len(addrs)), gorums.WithNodeList(addrs))
+	if err != nil {
+		log.Fatalf("Error in forming the configuration: %v\n", err)
+	}
+	return config, mgr
+}
@@ -0,0 +1,252 @@
+package main
+
+import (
+	"context"
+	"encoding/csv"
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+	"strconv"
+	"sync"
+	"time"
+
+	paxos "dat520/lab5/gorumspaxos"
+)
+
+// recorder records the outcome of the requests completed during the
+// measurement, that is, between the end of the warmup and the end.
+type recorder struct {
+	start, end time.Time
+
+	mu         sync.Mutex
+	reads      *histogram
+	writes     *histogram
+	errors     uint64 // failed requests, including rejected ones
+	overloaded uint64 // requests rejected by overloaded replicas
+	dropped    uint64 // requests not sent because too many were outstanding
+	window     *histogram
+	windowErrs uint64
+	timeline   []sample
+}
+
+func newRecorder(start, end time.Time) *recorder {
+	return &recorder{start: start, end: end, reads: newHistogram(), writes: newHistogram(), window: newHistogram()}
+}
+
+// measured returns true if a request completed at t counts towards the results.
+func (r *recorder) measured(t time.Time) bool {
+	return !t.Before(r.start) && t.Before(r.end)
+}
+
+// record records a request sent at sent and completed at done.
+func (r *recorder) record(sent, done time.Time, read bool, err error) {
+	if !r.measured(done) {
+		return
+	}
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if err != nil {
+		r.errors++
+		r.windowErrs++
+		if _, overloaded := paxos.RetryAfter(err); overloaded {
+			r.overloaded++
+		}
+		return
+	}
+	latency := done.Sub(sent)
+	if read {
+		r.reads.record(latency)
+	} else {
+		r.writes.record(latency)
+	}
+	r.window.record(latency)
+}
+
+// drop records a request scheduled at t that was not sent.
+func (r *recorder) drop(t time.Time) {
+	if !r.measured(t) {
+		return
+	}
+	r.mu.Lock()
+	r.dropped++
+	r.mu.Unlock()
+}
+
+// sample is the throughput and latency of the requests completed in an
+// interval of the measurement.
+type sample struct {
+	Elapsed    float64 `json:"elapsed_s"` // end of the interval, since the start of the measurement
+	Completed  uint64  `json:"completed"`
+	Throughput float64 `json:"throughput"` // requests per second
+	Errors     uint64  `json:"errors"`
+	P50        float64 `json:"p50_ms"`
+	P99        float64 `json:"p99_ms"`
+}
+
+// sample records a sample of the window every interval of the measurement
+// until ctx is done.
+func (r *recorder) sample(ctx context.Context, interval time.Duration) {
+	select {
+	case <-time.After(time.Until(r.start)):
+	case <-ctx.Done():
+		return
+	}
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+	last := r.start
+	for {
+		select {
+		case now := <-ticker.C:
+			r.mu.Lock()
+			r.timeline = append(r.timeline, sample{
+				Elapsed:    now.Sub(r.start).Seconds(),
+				Completed:  r.window.count,
+				Throughput: float64(r.window.count) / now.Sub(last).Seconds(),
+				Errors:     r.windowErrs,
+				P50:        millis(r.window.quantile(0.5)),
+				P99:        millis(r.window.quantile(0.99)),
+			})
+			r.window, r.windowErrs = newHistogram(), 0
+			r.mu.Unlock()
+			last = now
+		case <-ctx.Done():
+			return
+		}
+	}
+}
+
+// latency summarizes a latency histogram, in milliseconds.
+type latency struct {
+	Count uint64  `json:"count"`
+	Mean  float64 `json:"mean_ms"`
+	Min   float64 `json:"min_ms"`
+	Max   float64 `json:"max_ms"`
+	P50   float64 `json:"p50_ms"`
+	P90   float64 `json:"p90_ms"`
+	P99   float64 `json:"p99_ms"`
+	P999  float64 `json:"p999_ms"`
+}
+
+func summarize(h *histogram) latency {
+	return latency{
+		Count: h.count,
+		Mean:  millis(h.mean()),
+		Min:   millis(h.min),
+		Max:   millis(h.max),
+		P50:   millis(h.quantile(0.5)),
+		P90:   millis(h.quantile(0.9)),
+		P99:   millis(h.quantile(0.99)),
+		P999:  millis(h.quantile(0.999)),
+	}
+}
+
+func millis(d time.Duration) float64 {
+	return float64(d) / float64(time.Millisecond)
+}
+
+// results are the results of a benchmark run.
+type results struct {
+	Mode       string   `json:"mode"`
+	Clients    int      `json:"clients"`
+	Rate       float64  `json:"rate,omitempty"`
+	Size       int      `json:"size"`
+	Reads      float64  `json:"reads"`
+	Duration   float64  `json:"duration_s"`
+	Completed  uint64   `json:"completed"`
+	Errors     uint64   `json:"errors"`
+	Overloaded uint64   `json:"overloaded"`
+	Dropped    uint64   `json:"dropped"`
+	Throughput float64  `json:"throughput"` // requests per second
+	Latency    latency  `json:"latency"`
+	Read       latency  `json:"read_latency"`
+	Write      latency  `json:"write_latency"`
+	Histogram  []bucket `json:"histogram"`
+	Timeline   []sample `json:"timeline"`
+}
+
+// results returns the results of the benchmark recorded by r.
+func (r *recorder) results(b *bench) *results {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	all := newHistogram()
+	all.merge(r.reads)
+	all.merge(r.writes)
+	res := &results{
+		Mode:       b.mode,
+		Clients:    b.clients,
+		Size:       b.workload.size,
+		Reads:      b.workload.reads,
+		Duration:   r.end.Sub(r.start).Seconds(),
+		Completed:  all.count,
+		Errors:     r.errors,
+		Overloaded: r.overloaded,
+		Dropped:    r.dropped,
+		Latency:    summarize(all),
+		Read:       summarize(r.reads),
+		Write:      summarize(r.writes),
+		Histogram:  all.buckets(),
+		Timeline:   r.timeline,
+	}
+	if b.mode == "open" {
+		res.Rate = b.rate
+	}
+	if res.Duration > 0 {
+		res.Throughput = float64(res.Completed) / res.Duration
+	}
+	return res
+}
+
+// print writes a human-readable summary of the results to w.
+func (res *results) print(w io.Writer) {
+	fmt.Fprintf(w, "Completed:  %d requests in %.1fs (%.1f req/s)\n", res.Completed, res.Duration, res.Throughput)
+	fmt.Fprintf(w, "Errors:     %d (overloaded %d), dropped %d\n", res.Errors, res.Overloaded, res.Dropped)
+	for _, l := range []struct {
+		name string
+		latency
+	}{{"Latency:", res.Latency}, {"Reads:", res.Read}, {"Writes:", res.Write}} {
+		if l.Count == 0 {
+			continue
+		}
+		fmt.Fprintf(w, "%-11s mean %.2fms, p50 %.2fms, p90 %.2fms, p99 %.2fms, p99.9 %.2fms, max %.2fms\n",
+			l.name, l.Mean, l.P50, l.P90, l.P99, l.P999, l.Max)
+	}
+}
+
+// writeJSON writes the results to w as JSON.
+func (res *results) writeJSON(w io.Writer) error {
+	enc := json.NewEncoder(w)
+	enc.SetIndent("", "  ")
+	return enc.Encode(res)
+}
+
+// writeCSV writes the time series of the results to w as CSV.
+func (res *results) writeCSV(w io.Writer) error {
+	cw := csv.NewWriter(w)
+	cw.Write([]string{"elapsed_s", "completed", "throughput", "errors", "p50_ms", "p99_ms"})
+	for _, s := range res.Timeline {
+		cw.Write([]string{
+			strconv.FormatFloat(s.Elapsed, 'f', 3, 64),
+			strconv.FormatUint(s.Completed, 10),
+			strconv.FormatFloat(s.Throughput, 'f', 1, 64),
+			strconv.FormatUint(s.Errors, 10),
+			strconv.FormatFloat(s.P50, 'f', 3, 64),
+			strconv.FormatFloat(s.P99, 'f', 3, 64),
+		})
+	}
+	cw.Flush()
+	return cw.Error()
+}
+
+// writeFile creates the file and writes to it with write.
+func writeFile(name string, write func(io.Writer) error) error {
+	f, err := os.Create(name)
+	if err != nil {
+		return err
+	}
+	if err := write(f); err != nil {
+		f.Close()
+		return err
+	}
+	return f.Close()
+}
@@ -0,0 +1,54 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"math/rand"
+	"strings"
+
+	paxos "dat520/lab5/gorumspaxos"
+	pb "dat520/lab5/gorumspaxos/proto"
+)
+
+// workload generates the commands sent by the benchmark's clients. Commands
+// are key-value store commands (see paxos.KVCommand), so that replicas serving
+// the key-value store apply them; other replicas order them as opaque strings.
+// Every command is ordered by Paxos; reads are also decided, not served locally.
+type workload struct {
+	size  int     // size of the values written by put commands, in bytes
+	reads float64 // fraction of commands that are gets
+	keys  int     // number of distinct keys
+}
+
+// next returns the next command of the workload, the key it accesses and
+// whether it is a read.
+func (w workload) next(rnd *rand.Rand) (command, key string, read bool) {
+	cmd := paxos.KVCommand{Op: paxos.KVPut, Key: fmt.Sprintf("key%d", rnd.Intn(max(w.keys, 1)))}
+	if rnd.Float64() < w.reads {
+		cmd.Op = paxos.KVGet
+		read = true
+	} else {
+		cmd.Value = strings.Repeat("x", w.size)
+	}
+	b, err := json.Marshal(cmd)
+	if err != nil {
+		panic(err) // KVCommand is always encodable
+	}
+	return string(b), cmd.Key, read
+}
+
+// client is a benchmark client; each client has its own client ID and
+// sequence numbers.
+type client struct {
+	id  string
+	seq uint32
+	rnd *rand.Rand
+}
+
+// request returns the client's next request and whether it is a read.
+func (c *client) request(w workload, router *paxos.ShardRouter) (*pb.Value, bool) {
+	command, key, read := w.next(c.rnd)
+	c.seq++
+	req := &pb.Value{ClientID: c.id, ClientSeq: c.seq, ClientCommand: command}
+	return router.Route(key, req), read
+}
@@ -21,6 +21,9 @@ func main() {
 		srvAddrs      = flag.String("addrs", "", "server addresses separated by ','")
 		clientRequest = flag.String("clientRequest", "", "client requests separated by ','")
 		clientId      = flag.String("clientId", "", "Client Id, different for each client")
+		traceFile     = flag.String("trace", "", "file to write trace spans to (disabled if empty)")
+		numGroups     = flag.Int("groups", 1, "number of Paxos groups (shards) run by the replicas")
+		bft           = flag.Bool("bft", false, "wait for f+1 matching responses, for replicas running in BFT mode")
 	)
 
 	flag.Usage = func() {
@@ -38,31 +41,64 @@ func main() {
 	if len(clientRequests) == 0 {
 		log.Fatalln("no client requests are provided")
 	}
+	var tracer *paxos.Tracer
+	if *traceFile != "" {
+		exporter, err := paxos.NewFileExporter(*traceFile)
+		if err != nil {
+			log.Fatal(err)
+		}
+		defer exporter.Close()
+		tracer = paxos.NewTracer(-1, exporter)
+	}
 	// start a initial proposer
-	ClientStart(addrs, clientRequests, clientId)
+	ClientStart(addrs, clientRequests, clientId, tracer, paxos.NewShardRouterN(*numGroups), *bft)
 }
 
 // ClientStart creates the configuration with the list of replicas addresses, which are read from the
 // command line. From the list of clientRequests, send each request to the configuration and
 // wait for the reply. Upon receiving the reply send the next request.
-func ClientStart(addrs []string, clientRequests []string, clientId *string) {
+// If tracer is non-nil, each request is recorded as the root span of a trace.
+// Each request is sent to the Paxos group that the router maps the request's command to.
+// If bft is true, the client waits for matching responses from f+1 replicas.
+//
+// The requests are numbered from the client's start time in milliseconds, so
+// that a restarted client does not reuse the sequence numbers of its earlier
+// requests, whose responses the replicas keep to answer retries.
+func ClientStart(addrs []string, clientRequests []string, clientId *string, tracer *paxos.Tracer, router *paxos.ShardRouter, bft bool) {
 	log.Printf("Connecting to %d Paxos replicas: %v", len(addrs), addrs)
-	config, mgr := createConfiguration(addrs)
+	config, mgr := createConfiguration(addrs, bft)
 	defer mgr.Close()
+	firstSeq := uint32(time.Now().UnixMilli())
 	for index, request := range clientRequests {
-		req := pb.Value{ClientID: *clientId, ClientSeq: uint32(index), ClientCommand: request}
-		resp := doSendRequest(config, &req)
+		span := tracer.Start("paxosclient.Request", nil)
+		req := pb.Value{ClientID: *clientId, ClientSeq: firstSeq + uint32(index), ClientCommand: request, Trace: span.Context()}
+		resp := doSendRequest(config, router.Route(request, &req))
+		span.End()
 		log.Printf("response: %v\t for the client request: %v", resp, &req)
 	}
 }
 
 // Internal: doSendRequest can send requests to paxos servers by quorum call and
-// for the response from the quorum function.
+// for the response from the quorum function. If the replicas report that they
+// are overloaded, the request is retried after the suggested delay.
 func doSendRequest(config *pb.Configuration, value *pb.Value) *pb.Response {
+	const maxAttempts = 5
 	waitTimeForRequest := 5 * time.Second
-	ctx, cancel := context.WithTimeout(context.Background(), waitTimeForRequest)
-	defer cancel()
-	resp, err := config.ClientHandle(ctx, value)
+	var (
+		resp *pb.Response
+		err  error
+	)
+	for attempt := 1; attempt <= maxAttempts; attempt++ {
+		ctx, cancel := context.WithTimeout(context.Background(), waitTimeForRequest)
+		resp, err = config.ClientHandle(ctx, value)
+		cancel()
+		retryAfter, overloaded := paxos.RetryAfter(err)
+		if err == nil || !overloaded {
+			break
+		}
+		log.Printf("replicas overloaded (attempt %d/%d); retrying in %v", attempt, maxAttempts, retryAfter)
+		time.Sleep(retryAfter)
+	}
 	if err != nil {
 		log.Fatalf("ClientHandle quorum call error: %v", err)
 	}
@@ -72,8 +108,9 @@ func doSendRequest(config *pb.Configuration, value *pb.Value) *pb.Response {
 	return resp
 }
 
-// createConfiguration creates the gorums configuration with the list of addresses.
-func createConfiguration(addrs []string) (configuration *pb.Configuration, manager *pb.Manager) {
+// createConfiguration creates the gorums configuration with the list of addresses,
+// using the BFT quorum sizes if bft is true.
+func createConfiguration(addrs []string, bft bool) (configuration *pb.Configuration, manager *pb.Manager) {
 	mgr := pb.NewManager(gorums.WithDialTimeout(5*time.Second),
 		gorums.WithGrpcDialOptions(
 			grpc.WithBlock(), // block until connections are made
@@ -81,6 +118,9 @@ func createConfiguration(addrs []string) (configuration *pb.Configuration, manag
 		),
 	)
 	qspec := paxos.NewPaxosQSpec(len(addrs))
+	if bft {
+		qspec = paxos.NewBFTPaxosQSpec(len(addrs), nil)
+	}
 	config, err := mgr.NewConfiguration(qspec, gorums.WithNodeList(addrs))
 	if err != nil {
 		log.Fatalf("Error in forming the configuration: %v\n", err)
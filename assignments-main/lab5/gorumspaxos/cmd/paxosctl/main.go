@@ -0,0 +1,287 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"flag"
+	"fmt"
+	"log"
+	"os"
+	"os/signal"
+	"strconv"
+	"time"
+
+	paxos "dat520/lab5/gorumspaxos"
+	apb "dat520/lab5/gorumspaxos/proto/admin"
+
+	"github.com/relab/gorums"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/credentials/insecure"
+)
+
+const usage = `Usage: %s [OPTIONS] COMMAND [ARGS]
+Commands:
+  status              show the replica's state
+  log [FROM [TO]]     dump the replica's log entries in the slot range [FROM, TO]
+  watch [FROM]        follow the replica's decided log from slot FROM until interrupted
+  elect               make the replica suspect its leader for a while, electing a
+                      new one; run it at every replica to move the cluster's leader
+  pause               pause the replica's proposer
+  resume              resume the replica's proposer
+  suspect ID          make the replica suspect node ID
+  restore ID          make the replica restore node ID
+  faults              show the replica's fault rules
+  fault ACTION METHOD to|from PEER [DELAY]
+                      add a rule injecting ACTION (delay, drop or error) into the
+                      calls of METHOD (* for all) sent to or received from PEER
+                      (any for all); the delay action requires a DELAY
+  clear-faults        remove the replica's fault rules
+Options:
+`
+
+func main() {
+	var (
+		addr    = flag.String("addr", "localhost:50081", "address of the replica to control")
+		timeout = flag.Duration("timeout", 5*time.Second, "timeout for the admin call")
+		group   = flag.Uint("group", 0, "Paxos group to inspect or control")
+		prob    = flag.Float64("probability", 0, "probability that an added fault is injected (0 means always)")
+	)
+	flag.Usage = func() {
+		fmt.Fprintf(os.Stderr, usage, os.Args[0])
+		flag.PrintDefaults()
+	}
+	flag.Parse()
+	if flag.NArg() == 0 {
+		flag.Usage()
+		os.Exit(2)
+	}
+	if flag.Arg(0) == "watch" {
+		if err := watch(*addr, *timeout, uint32(*group), flag.Args()[1:]); err != nil {
+			log.Fatal(err)
+		}
+		return
+	}
+
+	mgr := apb.NewManager(gorums.WithDialTimeout(*timeout),
+		gorums.WithGrpcDialOptions(
+			grpc.WithBlock(), // block until connections are made
+			grpc.WithTransportCredentials(insecure.NewCredentials()), // disable TLS
+		),
+	)
+	defer mgr.Close()
+	cfg, err := mgr.NewConfiguration(gorums.WithNodeList([]string{*addr}))
+	if err != nil {
+		log.Fatalf("Failed to connect to %s: %v", *addr, err)
+	}
+	node := cfg.Nodes()[0]
+
+	ctx, cancel := context.WithTimeout(context.Background(), *timeout)
+	defer cancel()
+	switch flag.Arg(0) {
+	case "faults", "fault", "clear-faults":
+		err = faults(ctx, node, flag.Arg(0), flag.Args()[1:], *prob)
+	default:
+		err = run(ctx, node, uint32(*group), flag.Arg(0), flag.Args()[1:])
+	}
+	if err != nil {
+		log.Fatal(err)
+	}
+}
+
+// run executes the admin command for the given Paxos group on the node and prints the result.
+// The suspect and restore commands apply to the node's leader detector, which is shared by all groups.
+func run(ctx context.Context, node *apb.Node, group uint32, cmd string, args []string) error {
+	var (
+		status *apb.StatusReply
+		err    error
+	)
+	switch cmd {
+	case "status":
+		status, err = node.ReplicaStatus(ctx, &apb.StatusRequest{GroupID: group})
+	case "log":
+		req := &apb.LogRequest{GroupID: group}
+		if req.FromSlot, err = slotArg(args, 0); err != nil {
+			return err
+		}
+		if req.ToSlot, err = slotArg(args, 1); err != nil {
+			return err
+		}
+		logReply, err := node.DumpLog(ctx, req)
+		if err != nil {
+			return err
+		}
+		printLog(logReply)
+		return nil
+	case "elect":
+		status, err = node.ForceElection(ctx, &apb.StatusRequest{GroupID: group})
+	case "pause", "resume":
+		status, err = node.SetProposing(ctx, &apb.ProposingRequest{Paused: cmd == "pause", GroupID: group})
+	case "suspect", "restore":
+		if len(args) != 1 {
+			return fmt.Errorf("%s requires a node ID", cmd)
+		}
+		id, perr := strconv.ParseUint(args[0], 10, 32)
+		if perr != nil {
+			return fmt.Errorf("invalid node ID %q: %v", args[0], perr)
+		}
+		if cmd == "suspect" {
+			status, err = node.SuspectNode(ctx, &apb.NodeRequest{ID: uint32(id)})
+		} else {
+			status, err = node.RestoreNode(ctx, &apb.NodeRequest{ID: uint32(id)})
+		}
+	default:
+		return fmt.Errorf("unknown command %q", cmd)
+	}
+	if err != nil {
+		return err
+	}
+	printStatus(status)
+	return nil
+}
+
+// watch prints the decided log entries of the given Paxos group as they are
+// streamed by the replica, until the stream fails or the user interrupts it.
+func watch(addr string, timeout time.Duration, group uint32, args []string) error {
+	from, err := slotArg(args, 0)
+	if err != nil {
+		return err
+	}
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt)
+	defer stop()
+	w, err := paxos.WatchLog(ctx, addr, group, from,
+		gorums.WithDialTimeout(timeout),
+		gorums.WithGrpcDialOptions(
+			grpc.WithBlock(), // block until connections are made
+			grpc.WithTransportCredentials(insecure.NewCredentials()), // disable TLS
+		),
+	)
+	if err != nil {
+		return fmt.Errorf("failed to connect to %s: %v", addr, err)
+	}
+	defer w.Close()
+	for learn := range w.Entries() {
+		fmt.Printf("%v\n", learn)
+	}
+	if ctx.Err() != nil {
+		return nil
+	}
+	return w.Err()
+}
+
+// faults executes the fault injection command on the node and prints the
+// resulting rules. The rules apply to all of the node's Paxos groups.
+func faults(ctx context.Context, node *apb.Node, cmd string, args []string, prob float64) error {
+	var (
+		reply *apb.FaultsReply
+		err   error
+	)
+	switch cmd {
+	case "faults":
+		reply, err = node.Faults(ctx, &apb.StatusRequest{})
+	case "clear-faults":
+		reply, err = node.SetFaults(ctx, &apb.FaultsRequest{})
+	case "fault":
+		rule, perr := faultArg(args)
+		if perr != nil {
+			return perr
+		}
+		rule.Probability = prob
+		if reply, err = node.Faults(ctx, &apb.StatusRequest{}); err != nil {
+			return err
+		}
+		reply, err = node.SetFaults(ctx, &apb.FaultsRequest{Rules: append(reply.GetRules(), rule)})
+	}
+	if err != nil {
+		return err
+	}
+	printFaults(reply)
+	return nil
+}
+
+// faultArg parses the arguments ACTION METHOD to|from PEER [DELAY] of a fault rule.
+func faultArg(args []string) (*apb.FaultRule, error) {
+	if len(args) < 4 || len(args) > 5 {
+		return nil, errors.New("fault requires ACTION METHOD to|from PEER [DELAY]")
+	}
+	rule := &apb.FaultRule{Action: args[0], Method: args[1], Peer: paxos.AnyNode}
+	if rule.Method == "*" {
+		rule.Method = ""
+	}
+	switch args[2] {
+	case "to":
+	case "from":
+		rule.Incoming = true
+	default:
+		return nil, fmt.Errorf("invalid direction %q; want to or from", args[2])
+	}
+	if args[3] != "any" {
+		peer, err := strconv.ParseUint(args[3], 10, 32)
+		if err != nil {
+			return nil, fmt.Errorf("invalid peer %q: %v", args[3], err)
+		}
+		rule.Peer = int64(peer)
+	}
+	if len(args) == 5 {
+		delay, err := time.ParseDuration(args[4])
+		if err != nil {
+			return nil, fmt.Errorf("invalid delay %q: %v", args[4], err)
+		}
+		rule.Delay = int64(delay)
+	}
+	return rule, nil
+}
+
+// slotArg returns the i'th argument as a slot number, or 0 if it is missing.
+func slotArg(args []string, i int) (uint32, error) {
+	if len(args) <= i {
+		return 0, nil
+	}
+	slot, err := strconv.ParseUint(args[i], 10, 32)
+	if err != nil {
+		return 0, fmt.Errorf("invalid slot %q: %v", args[i], err)
+	}
+	return uint32(slot), nil
+}
+
+func printStatus(s *apb.StatusReply) {
+	fmt.Printf("Replica:          %d\n", s.GetID())
+	fmt.Printf("Group:            %d\n", s.GetGroupID())
+	fmt.Printf("Leader:           %d\n", s.GetLeader())
+	fmt.Printf("Crnd:             %d\n", s.GetCrnd())
+	fmt.Printf("Adu:              %d\n", s.GetAdu())
+	fmt.Printf("NextSlot:         %d\n", s.GetNextSlot())
+	fmt.Printf("PhaseOneDone:     %t\n", s.GetPhaseOneDone())
+	fmt.Printf("Paused:           %t\n", s.GetPaused())
+	fmt.Printf("Accept queue:     %d\n", s.GetAcceptQueueSize())
+	fmt.Printf("Client queue:     %d\n", s.GetClientQueueSize())
+	fmt.Printf("Learned slots:    %d\n", s.GetLearned())
+	fmt.Printf("Admitted/Rejected %d/%d\n", s.GetAdmittedRequests(), s.GetRejectedRequests())
+	fmt.Printf("Pending:          %d (cancelled %d, timed out %d)\n", s.GetPendingRequests(), s.GetCancelledRequests(), s.GetTimedOutRequests())
+	fmt.Printf("Suspected:        %v\n", s.GetSuspected())
+}
+
+func printFaults(f *apb.FaultsReply) {
+	fmt.Printf("Injected faults:  %d\n", f.GetInjected())
+	fmt.Println("Rules:")
+	for _, rule := range f.GetRules() {
+		fmt.Printf("  %v\n", paxos.FaultRule{
+			Method:      rule.GetMethod(),
+			Peer:        int(rule.GetPeer()),
+			Incoming:    rule.GetIncoming(),
+			Action:      paxos.FaultAction(rule.GetAction()),
+			Delay:       time.Duration(rule.GetDelay()),
+			Probability: rule.GetProbability(),
+		})
+	}
+}
+
+func printLog(l *apb.LogReply) {
+	fmt.Println("Decided:")
+	for _, learn := range l.GetDecided() {
+		fmt.Printf("  %v\n", learn)
+	}
+	fmt.Println("Accepted:")
+	for _, pval := range l.GetAccepted() {
+		fmt.Printf("  %v\n", pval)
+	}
+}
@@ -8,6 +8,7 @@ import (
 	"os"
 	"strings"
 
+	"dat520/lab3/leaderdetector"
 	paxos "dat520/lab5/gorumspaxos"
 )
 
@@ -15,6 +16,20 @@ func main() {
 	var (
 		localAddr = flag.String("laddr", "localhost:8080", "local address to listen on")
 		srvAddrs  = flag.String("addrs", "", "all other remaining replica addresses separated by ','")
+		maxQueue  = flag.Int("max-queue", paxos.DefaultAdmissionConfig.MaxQueueSize, "maximum number of pending client requests (0 = unbounded)")
+		maxClient = flag.Int("max-per-client", paxos.DefaultAdmissionConfig.MaxPerClient, "maximum number of pending requests per client (0 = unbounded)")
+		retry     = flag.Duration("retry-after", paxos.DefaultAdmissionConfig.RetryAfter, "delay suggested to clients rejected due to overload")
+		traceFile = flag.String("trace", "", "file to write trace spans to (disabled if empty)")
+		numGroups = flag.Int("groups", 1, "number of Paxos groups (shards) to run")
+		kvStore   = flag.Bool("kv", false, "serve the replicated key-value store (single group only)")
+		kvAddr    = flag.String("kv-addr", "", "address to serve the lab2 KeyValueService on, with -kv (disabled if empty)")
+		lockSvc   = flag.Bool("lock", false, "serve the replicated lock service (single group only)")
+		placement = flag.Bool("latency-leader", false, "place the leader on the replica with the lowest RTTs to a majority")
+		priority  = flag.String("leader-priority", "", "replica addresses separated by ',' in order of leader preference (implies -latency-leader)")
+		hold      = flag.Duration("leader-hold", 0, "minimum duration between leader changes caused by latency")
+		dataDir   = flag.String("data-dir", "", "directory to persist the acceptors' state in, to keep it across restarts (memory only if empty)")
+		bftKeys   = flag.String("bft-keys", "", "directory to load the replicas' ed25519 keys from, to run in BFT mode (disabled if empty)")
+		genKeys   = flag.Bool("gen-bft-keys", false, "generate keys for all replicas in the -bft-keys directory and exit")
 	)
 	flag.Usage = func() {
 		log.Printf("Usage: %s [OPTIONS]\nOptions:", os.Args[0])
@@ -26,6 +41,25 @@ func main() {
 	if len(addrs) == 0 {
 		log.Fatalln("no server addresses provided")
 	}
+	addrs = append(addrs, *localAddr)
+	ids := make([]uint32, 0, len(addrs))
+	for _, addr := range addrs {
+		ids = append(ids, uint32(calculateHash(addr)))
+	}
+	if *genKeys {
+		if *bftKeys == "" {
+			log.Fatalln("-gen-bft-keys requires -bft-keys")
+		}
+		keyrings, err := paxos.GenerateKeyrings(ids)
+		if err != nil {
+			log.Fatal(err)
+		}
+		if err := paxos.WriteKeyrings(*bftKeys, keyrings); err != nil {
+			log.Fatal(err)
+		}
+		log.Printf("Wrote keys for %d replicas to %s", len(ids), *bftKeys)
+		return
+	}
 	l, err := net.Listen("tcp", *localAddr)
 	if err != nil {
 		log.Fatal(err)
@@ -33,12 +67,82 @@ func main() {
 	defer l.Close()
 	log.Printf("Waiting for requests at %s", l.Addr().String())
 	nodeMap := make(map[string]uint32)
-	addrs = append(addrs, *localAddr)
-	for _, addr := range addrs {
-		id := calculateHash(addr)
-		nodeMap[addr] = uint32(id)
+	for i, addr := range addrs {
+		nodeMap[addr] = ids[i]
+	}
+	admission := paxos.AdmissionConfig{
+		MaxQueueSize: *maxQueue,
+		MaxPerClient: *maxClient,
+		RetryAfter:   *retry,
+	}
+	myID := calculateHash(*localAddr)
+	opts := []paxos.ReplicaOption{paxos.WithAdmissionConfig(admission)}
+	if *traceFile != "" {
+		exporter, err := paxos.NewFileExporter(*traceFile)
+		if err != nil {
+			log.Fatal(err)
+		}
+		defer exporter.Close()
+		opts = append(opts, paxos.WithTracer(paxos.NewTracer(myID, exporter)))
+	}
+	if *dataDir != "" {
+		storage, err := paxos.NewAcceptorStorage(*dataDir)
+		if err != nil {
+			log.Fatal(err)
+		}
+		defer storage.Close()
+		opts = append(opts, paxos.WithAcceptorStorage(storage))
+	}
+	if *bftKeys != "" {
+		keys, err := paxos.ReadKeyring(*bftKeys, uint32(myID), ids)
+		if err != nil {
+			log.Fatal(err)
+		}
+		log.Printf("Running in BFT mode")
+		opts = append(opts, paxos.WithBFT(keys))
+	}
+	if *placement || *priority != "" {
+		cfg := leaderdetector.LatencyConfig{MinHold: *hold}
+		if *priority != "" {
+			for _, addr := range strings.Split(*priority, ",") {
+				cfg.Priorities = append(cfg.Priorities, calculateHash(addr))
+			}
+		}
+		opts = append(opts, paxos.WithLeaderPlacement(cfg))
+	}
+	if *kvStore && *numGroups > 1 {
+		log.Fatalln("the key-value store requires a single Paxos group")
+	}
+	if *lockSvc && (*numGroups > 1 || *kvStore) {
+		log.Fatalln("the lock service requires a single Paxos group without the key-value store")
+	}
+	if *numGroups > 1 {
+		log.Printf("Running %d Paxos groups", *numGroups)
+		replica := paxos.NewShardedReplica(myID, nodeMap, *numGroups, opts...)
+		replica.Serve(l)
+		return
+	}
+	if *kvStore {
+		log.Printf("Serving the replicated key-value store")
+		replica := paxos.NewKVReplica(myID, nodeMap, paxos.NewKVStateMachine(), opts...)
+		if *kvAddr != "" {
+			kvl, err := net.Listen("tcp", *kvAddr)
+			if err != nil {
+				log.Fatal(err)
+			}
+			log.Printf("Serving the lab2 KeyValueService at %s", kvl.Addr().String())
+			go paxos.NewKeyValueServer(replica).Serve(kvl)
+		}
+		replica.Serve(l)
+		return
+	}
+	if *lockSvc {
+		log.Printf("Serving the replicated lock service")
+		replica := paxos.NewLockReplica(myID, nodeMap, paxos.NewLockStateMachine(), opts...)
+		replica.Serve(l)
+		return
 	}
-	replica := paxos.NewPaxosReplica(calculateHash(*localAddr), nodeMap)
+	replica := paxos.NewPaxosReplica(myID, nodeMap, opts...)
 	replica.Serve(l)
 }
 
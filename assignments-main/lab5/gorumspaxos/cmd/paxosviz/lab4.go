@@ -0,0 +1,120 @@
+package main
+
+import (
+	"fmt"
+	"slices"
+
+	"dat520/lab4/multipaxos"
+)
+
+// lab4Trace returns the trace of the records written by a lab4
+// multipaxos.Recorder, in a cluster of numNodes nodes; if numNodes is 0, it
+// is the number of nodes in the records.
+//
+// A message is matched with the record of its receipt, and is lost if
+// there is none. A Prepare or Accept is rejected if its receiver did not
+// reply with a Promise or Learn in the message's round when handling it.
+func lab4Trace(records []multipaxos.Record, numNodes int) trace {
+	var tr trace
+	for _, r := range records {
+		for _, id := range []int{r.Node, r.Msg.From, r.Msg.To} {
+			if !slices.Contains(tr.nodes, id) {
+				tr.nodes = append(tr.nodes, id)
+			}
+		}
+	}
+	slices.Sort(tr.nodes)
+	if numNodes == 0 {
+		numNodes = len(tr.nodes)
+	}
+
+	prepareSlots := make(map[multipaxos.Round]int) // the first slot of the prepare of each round
+	for _, r := range records {
+		if r.Msg.Prepare != nil {
+			prepareSlots[r.Msg.Prepare.Crnd] = int(r.Msg.Prepare.Slot)
+		}
+	}
+	sent := make(map[string][]int) // indexes of the unmatched sent messages by payload
+	for i, r := range records {
+		m, ok := lab4Message(r.Msg, prepareSlots)
+		if !ok {
+			continue
+		}
+		key := fmt.Sprint(r.Msg.From, r.Msg.To, r.Msg)
+		if r.Dir == multipaxos.Sent {
+			m.Sent = r.Time
+			tr.messages = append(tr.messages, m)
+			sent[key] = append(sent[key], len(tr.messages)-1)
+			continue
+		}
+		m.Rejected = (m.Kind == "Prepare" || m.Kind == "Accept") && !replied(records, i)
+		if pending := sent[key]; len(pending) > 0 {
+			sent[key] = pending[1:]
+			tr.messages[pending[0]].Recv = r.Time
+			tr.messages[pending[0]].Rejected = m.Rejected
+			continue
+		}
+		m.Recv = r.Time
+		tr.messages = append(tr.messages, m)
+	}
+	tr.chosen = learnChosen(tr.messages, numNodes/2+1)
+	return tr
+}
+
+// replied returns true if the node that handled the Prepare or Accept of
+// records[i] replied to it with a Promise or Learn in the message's round;
+// a node records the messages sent while handling a message right after it.
+func replied(records []multipaxos.Record, i int) bool {
+	handled := records[i]
+	for _, r := range records[i+1:] {
+		if r.Node != handled.Node {
+			continue
+		}
+		if r.Dir == multipaxos.Received {
+			return false
+		}
+		switch {
+		case handled.Msg.Prepare != nil && r.Msg.Promise != nil:
+			if r.Msg.Promise.Rnd == handled.Msg.Prepare.Crnd {
+				return true
+			}
+		case handled.Msg.Accept != nil && r.Msg.Learn != nil:
+			if r.Msg.Learn.Slot == handled.Msg.Accept.Slot && r.Msg.Learn.Rnd == handled.Msg.Accept.Rnd {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+// lab4Message returns the message drawn for msg, and false for client
+// values forwarded to the leader, which are not drawn.
+func lab4Message(msg multipaxos.Message, prepareSlots map[multipaxos.Round]int) (message, bool) {
+	m := message{Kind: msg.Kind(), From: msg.From, To: msg.To}
+	switch {
+	case msg.Prepare != nil:
+		m.Slot, m.Rnd = int(msg.Prepare.Slot), int(msg.Prepare.Crnd)
+	case msg.Promise != nil:
+		m.Slot, m.Rnd = prepareSlots[msg.Promise.Rnd], int(msg.Promise.Rnd)
+		if n := len(msg.Promise.Accepted); n > 0 {
+			m.Val = fmt.Sprintf("%d accepted", n)
+		}
+	case msg.Accept != nil:
+		m.Slot, m.Rnd, m.Val = int(msg.Accept.Slot), int(msg.Accept.Rnd), lab4Value(msg.Accept.Val)
+	case msg.Learn != nil:
+		m.Slot, m.Rnd, m.Val = int(msg.Learn.Slot), int(msg.Learn.Rnd), lab4Value(msg.Learn.Val)
+	case msg.Decision != nil:
+		m.Slot, m.Val = int(msg.Decision.Slot), lab4Value(msg.Decision.Val)
+	default:
+		return message{}, false
+	}
+	return m, true
+}
+
+// lab4Value returns a short description of val, like the lab5 span attributes.
+func lab4Value(val multipaxos.Value) string {
+	if val == (multipaxos.Value{}) {
+		return "noop"
+	}
+	return fmt.Sprintf("%s/%d %s", val.ClientID, val.ClientSeq, val.Command)
+}
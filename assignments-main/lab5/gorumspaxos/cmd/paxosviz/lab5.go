@@ -0,0 +1,109 @@
+package main
+
+import (
+	"bufio"
+	"encoding/json"
+	"io"
+	"slices"
+	"strconv"
+
+	paxos "dat520/lab5/gorumspaxos"
+)
+
+// readSpans reads the spans written by a lab5 FileExporter, one JSON
+// object per line.
+func readSpans(r io.Reader) ([]paxos.Span, error) {
+	var spans []paxos.Span
+	scanner := bufio.NewScanner(r)
+	scanner.Buffer(nil, 1<<20)
+	for scanner.Scan() {
+		var span paxos.Span
+		if err := json.Unmarshal(scanner.Bytes(), &span); err != nil {
+			return nil, err
+		}
+		spans = append(spans, span)
+	}
+	return spans, scanner.Err()
+}
+
+// lab5Trace returns the trace of the spans recorded by the lab5 replicas.
+//
+// The quorum calls are drawn from the spans of their handlers: a Prepare is
+// sent by the proposer whose Proposer.PhaseOne span has the prepare's
+// round, since the rounds of the proposers differ, and an Accept or Commit
+// is sent by the node of the handler span's parent; messages whose sender's
+// span was not recorded are not drawn. The replies are drawn from the end
+// of the handler span to the end of the sender's span. A value is chosen
+// when a replica handles its Commit.
+func lab5Trace(spans []paxos.Span) trace {
+	var tr trace
+	byID := make(map[string]paxos.Span)
+	phaseOnes := make(map[int]paxos.Span) // by round
+	for _, s := range spans {
+		byID[s.SpanID] = s
+		if s.NodeID >= 0 && !slices.Contains(tr.nodes, s.NodeID) {
+			tr.nodes = append(tr.nodes, s.NodeID)
+		}
+		if s.Name == "Proposer.PhaseOne" {
+			phaseOnes[attr(s, paxos.AttrRound)] = s
+		}
+	}
+	slices.Sort(tr.nodes)
+
+	for _, s := range spans {
+		var sender paxos.Span
+		var ok bool
+		var kind, reply string
+		switch s.Name {
+		case "Acceptor.Prepare":
+			sender, ok = phaseOnes[attr(s, paxos.AttrRound)]
+			kind, reply = "Prepare", "Promise"
+		case "Acceptor.Accept":
+			sender, ok = byID[s.ParentID]
+			kind, reply = "Accept", "Learn"
+		case "Replica.Commit":
+			sender, ok = byID[s.ParentID]
+			kind = "Commit"
+		default:
+			continue
+		}
+		if kind == "Commit" {
+			tr.chosen = append(tr.chosen, choice{Node: s.NodeID, Slot: attr(s, paxos.AttrSlot), Rnd: attr(s, paxos.AttrRound), Val: s.Attributes[paxos.AttrValue], Time: s.Start})
+		}
+		if !ok {
+			// the sender's span was not recorded
+			continue
+		}
+		m := message{
+			Kind:     kind,
+			From:     sender.NodeID,
+			To:       s.NodeID,
+			Sent:     sender.Start,
+			Recv:     s.Start,
+			Slot:     attr(s, paxos.AttrSlot),
+			Rnd:      attr(s, paxos.AttrRound),
+			Val:      s.Attributes[paxos.AttrValue],
+			Rejected: s.Attributes[paxos.AttrIgnored] != "",
+		}
+		tr.messages = append(tr.messages, m)
+		if reply != "" && !m.Rejected {
+			tr.messages = append(tr.messages, message{
+				Kind: reply,
+				From: s.NodeID,
+				To:   sender.NodeID,
+				Sent: s.End,
+				Recv: latest(s.End, sender.End),
+				Slot: m.Slot,
+				Rnd:  m.Rnd,
+				Val:  m.Val,
+			})
+		}
+	}
+	return tr
+}
+
+// attr returns the integer attribute of the span, or 0 if it has none.
+func attr(s paxos.Span, key string) int {
+	v, _ := strconv.Atoi(s.Attributes[key])
+	return v
+}
@@ -0,0 +1,84 @@
+// Command paxosviz renders the Paxos messages of a recorded trace as an
+// HTML page with a space-time sequence diagram for each slot, highlighting
+// round changes, rejected messages and the chosen values.
+//
+// It reads either a lab4 trace, written by a multipaxos.Recorder, or a
+// lab5 trace, written by the replicas' FileExporter (paxosserver -trace).
+package main
+
+import (
+	"flag"
+	"log"
+	"os"
+	"path/filepath"
+
+	"dat520/lab4/multipaxos"
+)
+
+func main() {
+	var (
+		lab4File = flag.String("lab4", "", "lab4 trace file written by a multipaxos.Recorder")
+		lab5File = flag.String("lab5", "", "lab5 trace file of spans written by a FileExporter")
+		numNodes = flag.Int("nodes", 0, "number of nodes in a lab4 cluster (derived from the trace if 0)")
+		outFile  = flag.String("o", "trace.html", "HTML file to write the diagrams to")
+		slot     = flag.Int("slot", 0, "slot to draw (all slots if 0)")
+	)
+	flag.Usage = func() {
+		log.Printf("Usage: %s [OPTIONS]\nOptions:", os.Args[0])
+		flag.PrintDefaults()
+	}
+	flag.Parse()
+
+	var (
+		tr    trace
+		input string
+	)
+	switch {
+	case *lab4File != "" && *lab5File == "":
+		input = *lab4File
+		records, err := multipaxos.ReadTraceFile(input)
+		if err != nil {
+			log.Fatalf("reading %s: %v", input, err)
+		}
+		tr = lab4Trace(records, *numNodes)
+	case *lab5File != "" && *lab4File == "":
+		input = *lab5File
+		f, err := os.Open(input)
+		if err != nil {
+			log.Fatal(err)
+		}
+		spans, err := readSpans(f)
+		f.Close()
+		if err != nil {
+			log.Fatalf("reading %s: %v", input, err)
+		}
+		tr = lab5Trace(spans)
+	default:
+		log.Println("exactly one of -lab4 and -lab5 must be given")
+		flag.Usage()
+		os.Exit(2)
+	}
+
+	ds := diagrams(tr)
+	if *slot != 0 {
+		var selected []diagram
+		for _, d := range ds {
+			if d.Slot == *slot {
+				selected = append(selected, d)
+			}
+		}
+		ds = selected
+	}
+	out, err := os.Create(*outFile)
+	if err != nil {
+		log.Fatal(err)
+	}
+	if err := render(out, filepath.Base(input), ds); err != nil {
+		out.Close()
+		log.Fatal(err)
+	}
+	if err := out.Close(); err != nil {
+		log.Fatal(err)
+	}
+	log.Printf("Wrote %d slot diagrams to %s", len(ds), *outFile)
+}
@@ -0,0 +1,178 @@
+package main
+
+import (
+	"strings"
+	"testing"
+	"time"
+
+	"dat520/lab4/multipaxos"
+	paxos "dat520/lab5/gorumspaxos"
+
+	"github.com/google/go-cmp/cmp"
+)
+
+var t0 = time.Date(2024, 3, 1, 12, 0, 0, 0, time.UTC)
+
+// at returns the time i milliseconds into the trace.
+func at(i int) time.Time {
+	return t0.Add(time.Duration(i) * time.Millisecond)
+}
+
+func TestLab4Trace(t *testing.T) {
+	val := multipaxos.Value{ClientID: "c", ClientSeq: 1, Command: "x"}
+	prepare := &multipaxos.Prepare{From: 2, Slot: 1, Crnd: 5}
+	promise := &multipaxos.Promise{To: 2, From: 0, Rnd: 5}
+	accept := &multipaxos.Accept{From: 2, Slot: 1, Rnd: 5, Val: val}
+	record := func(i, node int, dir multipaxos.Direction, msg multipaxos.Message) multipaxos.Record {
+		return multipaxos.Record{Time: at(i), Node: node, Dir: dir, Msg: msg}
+	}
+	records := []multipaxos.Record{
+		record(0, 2, multipaxos.Sent, multipaxos.Message{From: 2, To: 0, Prepare: prepare}),
+		record(1, 0, multipaxos.Received, multipaxos.Message{From: 2, To: 0, Prepare: prepare}),
+		record(2, 0, multipaxos.Sent, multipaxos.Message{From: 0, To: 2, Promise: promise}),
+		record(3, 2, multipaxos.Received, multipaxos.Message{From: 0, To: 2, Promise: promise}),
+		record(4, 2, multipaxos.Sent, multipaxos.Message{From: 2, To: 1, Accept: accept}),
+		record(5, 2, multipaxos.Sent, multipaxos.Message{From: 2, To: 0, Accept: accept}),
+		// node 1 has promised a higher round, and ignores the accept
+		record(6, 1, multipaxos.Received, multipaxos.Message{From: 2, To: 1, Accept: accept}),
+		// node 1 handles a value forwarded by a client, which is not drawn
+		record(7, 1, multipaxos.Received, multipaxos.Message{From: 0, To: 1, Value: &val}),
+	}
+	tr := lab4Trace(records, 0)
+	if diff := cmp.Diff([]int{0, 1, 2}, tr.nodes); diff != "" {
+		t.Errorf("nodes mismatch (-want +got):\n%s", diff)
+	}
+	want := []message{
+		{Kind: "Prepare", From: 2, To: 0, Sent: at(0), Recv: at(1), Slot: 1, Rnd: 5},
+		{Kind: "Promise", From: 0, To: 2, Sent: at(2), Recv: at(3), Slot: 1, Rnd: 5},
+		{Kind: "Accept", From: 2, To: 1, Sent: at(4), Recv: at(6), Slot: 1, Rnd: 5, Val: "c/1 x", Rejected: true},
+		// the accept to node 0 is lost
+		{Kind: "Accept", From: 2, To: 0, Sent: at(5), Slot: 1, Rnd: 5, Val: "c/1 x"},
+	}
+	if diff := cmp.Diff(want, tr.messages); diff != "" {
+		t.Errorf("messages mismatch (-want +got):\n%s", diff)
+	}
+}
+
+func TestLab5Trace(t *testing.T) {
+	span := func(name, id, parent string, node, start, end int, attrs ...string) paxos.Span {
+		s := paxos.Span{Name: name, SpanID: id, ParentID: parent, NodeID: node, Start: at(start), End: at(end), Attributes: map[string]string{}}
+		for i := 0; i < len(attrs); i += 2 {
+			s.Attributes[attrs[i]] = attrs[i+1]
+		}
+		return s
+	}
+	spans := []paxos.Span{
+		span("Acceptor.Prepare", "p0", "", 0, 1, 2, paxos.AttrSlot, "1", paxos.AttrRound, "4"),
+		span("Acceptor.Prepare", "p2", "", 2, 1, 2, paxos.AttrSlot, "1", paxos.AttrRound, "4", paxos.AttrIgnored, "ignored by acceptor"),
+		span("Proposer.PhaseOne", "one", "", 1, 0, 3, paxos.AttrSlot, "1", paxos.AttrRound, "4"),
+		span("Proposer.Accept", "acc", "req", 1, 4, 7, paxos.AttrSlot, "1", paxos.AttrRound, "4", paxos.AttrValue, "c/1 x"),
+		span("Acceptor.Accept", "a0", "acc", 0, 5, 6, paxos.AttrSlot, "1", paxos.AttrRound, "4", paxos.AttrValue, "c/1 x"),
+		span("Proposer.Commit", "com", "req", 1, 8, 9, paxos.AttrSlot, "1", paxos.AttrRound, "4", paxos.AttrValue, "c/1 x"),
+		span("Replica.Commit", "c2", "com", 2, 10, 11, paxos.AttrSlot, "1", paxos.AttrRound, "4", paxos.AttrValue, "c/1 x"),
+		span("paxosclient.Request", "req", "", -1, 0, 12),
+	}
+	tr := lab5Trace(spans)
+	if diff := cmp.Diff([]int{0, 1, 2}, tr.nodes); diff != "" {
+		t.Errorf("nodes mismatch (-want +got):\n%s", diff)
+	}
+	wantMessages := []message{
+		{Kind: "Prepare", From: 1, To: 0, Sent: at(0), Recv: at(1), Slot: 1, Rnd: 4},
+		{Kind: "Promise", From: 0, To: 1, Sent: at(2), Recv: at(3), Slot: 1, Rnd: 4},
+		{Kind: "Prepare", From: 1, To: 2, Sent: at(0), Recv: at(1), Slot: 1, Rnd: 4, Rejected: true},
+		{Kind: "Accept", From: 1, To: 0, Sent: at(4), Recv: at(5), Slot: 1, Rnd: 4, Val: "c/1 x"},
+		{Kind: "Learn", From: 0, To: 1, Sent: at(6), Recv: at(7), Slot: 1, Rnd: 4, Val: "c/1 x"},
+		{Kind: "Commit", From: 1, To: 2, Sent: at(8), Recv: at(10), Slot: 1, Rnd: 4, Val: "c/1 x"},
+	}
+	if diff := cmp.Diff(wantMessages, tr.messages); diff != "" {
+		t.Errorf("messages mismatch (-want +got):\n%s", diff)
+	}
+	wantChosen := []choice{{Node: 2, Slot: 1, Rnd: 4, Val: "c/1 x", Time: at(10)}}
+	if diff := cmp.Diff(wantChosen, tr.chosen); diff != "" {
+		t.Errorf("chosen mismatch (-want +got):\n%s", diff)
+	}
+}
+
+func TestLearnChosen(t *testing.T) {
+	learn := func(from, to, recv, slot, rnd int, val string) message {
+		return message{Kind: "Learn", From: from, To: to, Sent: at(recv - 1), Recv: at(recv), Slot: slot, Rnd: rnd, Val: val}
+	}
+	messages := []message{
+		learn(0, 2, 3, 1, 5, "a"),
+		learn(1, 2, 1, 1, 4, "b"), // a lower round's value is not chosen without a quorum
+		learn(1, 2, 4, 1, 5, "a"),
+		learn(0, 2, 5, 1, 5, "a"), // already learnt
+		learn(0, 1, 6, 2, 5, "c"),
+		{Kind: "Learn", From: 2, To: 1, Sent: at(7), Slot: 2, Rnd: 5, Val: "c"}, // lost
+		{Kind: "Decision", From: 2, To: 0, Sent: at(8), Recv: at(9), Slot: 1, Val: "a"},
+	}
+	want := []choice{
+		{Node: 2, Slot: 1, Rnd: 5, Val: "a", Time: at(4)},
+		{Node: 0, Slot: 1, Val: "a", Time: at(9)},
+	}
+	if diff := cmp.Diff(want, learnChosen(messages, 2)); diff != "" {
+		t.Errorf("learnChosen() mismatch (-want +got):\n%s", diff)
+	}
+}
+
+func TestDiagrams(t *testing.T) {
+	tr := trace{
+		nodes: []int{0, 1, 2},
+		messages: []message{
+			{Kind: "Prepare", From: 1, To: 0, Sent: at(0), Recv: at(1), Slot: 1, Rnd: 4},
+			{Kind: "Accept", From: 1, To: 0, Sent: at(2), Recv: at(3), Slot: 1, Rnd: 4, Val: "a"},
+			{Kind: "Accept", From: 1, To: 0, Sent: at(4), Recv: at(5), Slot: 2, Rnd: 4, Val: "b"},
+			// node 2 takes over in a higher round while slot 2 is undecided
+			{Kind: "Prepare", From: 2, To: 0, Sent: at(6), Recv: at(7), Slot: 2, Rnd: 5},
+			{Kind: "Accept", From: 2, To: 1, Sent: at(8), Recv: at(9), Slot: 2, Rnd: 5, Val: "c", Rejected: true},
+		},
+		chosen: []choice{
+			{Node: 0, Slot: 1, Rnd: 4, Val: "a", Time: at(3)},
+			{Node: 0, Slot: 2, Rnd: 4, Val: "b", Time: at(5)},
+			{Node: 2, Slot: 2, Rnd: 5, Val: "c", Time: at(9)},
+		},
+	}
+	ds := diagrams(tr)
+	type summary struct {
+		Slot     int
+		Kinds    []string
+		Rounds   []int
+		Conflict bool
+	}
+	var got []summary
+	for _, d := range ds {
+		s := summary{Slot: d.Slot, Conflict: d.conflict()}
+		for _, m := range d.Messages {
+			s.Kinds = append(s.Kinds, m.Kind)
+		}
+		for _, r := range d.Rounds {
+			s.Rounds = append(s.Rounds, r.Rnd)
+		}
+		got = append(got, s)
+	}
+	want := []summary{
+		// node 2's prepare for slot 2 is sent after slot 1 is done
+		{Slot: 1, Kinds: []string{"Prepare", "Accept"}, Rounds: []int{4}},
+		{Slot: 2, Kinds: []string{"Prepare", "Accept", "Prepare", "Accept"}, Rounds: []int{4, 5}, Conflict: true},
+	}
+	if diff := cmp.Diff(want, got); diff != "" {
+		t.Errorf("diagrams mismatch (-want +got):\n%s", diff)
+	}
+
+	var b strings.Builder
+	if err := render(&b, "trace <test>", ds); err != nil {
+		t.Fatal(err)
+	}
+	page := b.String()
+	for _, want := range []string{
+		"<title>trace &lt;test&gt;</title>",
+		`<h2 id="slot-2">Slot 2</h2>`,
+		`<p class="conflict">CONFLICT: node 0 chose &#34;b&#34; in round 4, node 2 chose &#34;c&#34; in round 5; 2 rounds; 1 rejected</p>`,
+		"Accept r5 c (rejected)",
+		">round 5</text>",
+	} {
+		if !strings.Contains(page, want) {
+			t.Errorf("rendered page does not contain %q", want)
+		}
+	}
+}
@@ -0,0 +1,242 @@
+package main
+
+import (
+	"fmt"
+	"html"
+	"html/template"
+	"io"
+	"slices"
+	"strings"
+	"time"
+)
+
+// The layout of the diagrams, in pixels: the nodes are vertical lanes, and
+// time flows downwards, one row for each distinct time in the diagram.
+const (
+	laneGap    = 180
+	marginLeft = 90
+	headerY    = 30
+	firstRowY  = 60
+	rowHeight  = 30
+)
+
+// kindColors are the colors of the arrows of each kind of message.
+var kindColors = map[string]string{
+	"Prepare":  "#1f77b4",
+	"Promise":  "#17becf",
+	"Accept":   "#ff7f0e",
+	"Learn":    "#2ca02c",
+	"Decision": "#9467bd",
+	"Commit":   "#9467bd",
+}
+
+const (
+	rejectedColor = "#d62728"
+	lostColor     = "#999999"
+	chosenColor   = "#2ca02c"
+)
+
+var page = template.Must(template.New("page").Parse(`<!DOCTYPE html>
+<html>
+<head>
+<meta charset="utf-8">
+<title>{{.Title}}</title>
+<style>
+body { font-family: sans-serif; }
+svg { font-size: 11px; }
+.conflict { color: ` + rejectedColor + `; font-weight: bold; }
+.legend span { margin-right: 1em; }
+</style>
+</head>
+<body>
+<h1>{{.Title}}</h1>
+<p class="legend">{{range .Legend}}<span style="color: {{.Color}}">&#9632; {{.Kind}}</span>{{end}}<span style="color: ` + rejectedColor + `">&#10005; rejected</span><span style="color: ` + lostColor + `">- - lost</span><span style="color: ` + chosenColor + `">&#9679; chosen</span></p>
+{{range .Slots}}
+<h2 id="slot-{{.Slot}}">Slot {{.Slot}}</h2>
+<p{{if .Conflict}} class="conflict"{{end}}>{{.Caption}}</p>
+{{.SVG}}
+{{end}}
+</body>
+</html>
+`))
+
+// render writes an HTML page with the diagram of each slot.
+func render(w io.Writer, title string, ds []diagram) error {
+	type legend struct{ Kind, Color string }
+	type slot struct {
+		Slot     int
+		Caption  string
+		Conflict bool
+		SVG      template.HTML
+	}
+	data := struct {
+		Title  string
+		Legend []legend
+		Slots  []slot
+	}{Title: title}
+	for _, kind := range []string{"Prepare", "Promise", "Accept", "Learn", "Decision", "Commit"} {
+		data.Legend = append(data.Legend, legend{Kind: kind, Color: kindColors[kind]})
+	}
+	for _, d := range ds {
+		data.Slots = append(data.Slots, slot{
+			Slot:     d.Slot,
+			Caption:  caption(d),
+			Conflict: d.conflict(),
+			SVG:      template.HTML(svg(d)),
+		})
+	}
+	return page.Execute(w, data)
+}
+
+// caption summarizes the diagram's rounds, rejections and chosen value.
+func caption(d diagram) string {
+	var parts []string
+	switch {
+	case d.conflict():
+		var vals []string
+		for _, c := range d.Chosen {
+			vals = append(vals, fmt.Sprintf("node %d chose %q in round %d", c.Node, c.Val, c.Rnd))
+		}
+		parts = append(parts, "CONFLICT: "+strings.Join(vals, ", "))
+	case len(d.Chosen) > 0:
+		parts = append(parts, fmt.Sprintf("chosen %q in round %d, learnt by %d nodes", d.Chosen[0].Val, d.Chosen[0].Rnd, len(d.Chosen)))
+	default:
+		parts = append(parts, "no value chosen")
+	}
+	if n := len(d.Rounds); n > 1 {
+		parts = append(parts, fmt.Sprintf("%d rounds", n))
+	}
+	rejected := 0
+	for _, m := range d.Messages {
+		if m.Rejected {
+			rejected++
+		}
+	}
+	if rejected > 0 {
+		parts = append(parts, fmt.Sprintf("%d rejected", rejected))
+	}
+	return strings.Join(parts, "; ")
+}
+
+// svg returns the space-time diagram of the slot as an SVG element.
+func svg(d diagram) string {
+	var times []time.Time
+	for _, m := range d.Messages {
+		for _, t := range []time.Time{m.Sent, m.Recv} {
+			if !t.IsZero() {
+				times = append(times, t)
+			}
+		}
+	}
+	for _, c := range d.Chosen {
+		times = append(times, c.Time)
+	}
+	slices.SortFunc(times, time.Time.Compare)
+	times = slices.CompactFunc(times, time.Time.Equal)
+	y := func(t time.Time) int {
+		i, _ := slices.BinarySearchFunc(times, t, time.Time.Compare)
+		return firstRowY + i*rowHeight
+	}
+	x := func(node int) int {
+		return marginLeft + slices.Index(d.Nodes, node)*laneGap
+	}
+	width := marginLeft + len(d.Nodes)*laneGap
+	height := firstRowY + len(times)*rowHeight + rowHeight
+
+	var b strings.Builder
+	fmt.Fprintf(&b, `<svg xmlns="http://www.w3.org/2000/svg" width="%d" height="%d">`+"\n", width, height)
+	b.WriteString("<defs>")
+	for _, color := range markerColors() {
+		fmt.Fprintf(&b, `<marker id="arrow%s" viewBox="0 0 10 10" refX="10" refY="5" markerWidth="7" markerHeight="7" orient="auto-start-reverse"><path d="M 0 0 L 10 5 L 0 10 z" fill="%s"/></marker>`, color[1:], color)
+	}
+	b.WriteString("</defs>\n")
+
+	for _, node := range d.Nodes {
+		fmt.Fprintf(&b, `<text x="%d" y="%d" text-anchor="middle" font-weight="bold">node %d</text>`+"\n", x(node), headerY, node)
+		fmt.Fprintf(&b, `<line x1="%d" y1="%d" x2="%d" y2="%d" stroke="#333"/>`+"\n", x(node), headerY+10, x(node), height-10)
+	}
+	for _, r := range d.Rounds {
+		ry := y(r.Time) - rowHeight/2
+		fmt.Fprintf(&b, `<line x1="0" y1="%d" x2="%d" y2="%d" stroke="#bbb" stroke-dasharray="6 3"/>`+"\n", ry, width, ry)
+		fmt.Fprintf(&b, `<text x="4" y="%d" fill="#555">round %d</text>`+"\n", ry+12, r.Rnd)
+	}
+	for _, m := range d.Messages {
+		if m.From == m.To || !slices.Contains(d.Nodes, m.From) || !slices.Contains(d.Nodes, m.To) {
+			continue // messages a node sends to itself are not drawn
+		}
+		writeArrow(&b, m, x, y)
+	}
+	conflict := d.conflict()
+	for _, c := range d.Chosen {
+		color := chosenColor
+		if conflict {
+			color = rejectedColor
+		}
+		fmt.Fprintf(&b, `<circle cx="%d" cy="%d" r="6" fill="%s"><title>%s</title></circle>`+"\n",
+			x(c.Node), y(c.Time), color, html.EscapeString(fmt.Sprintf("node %d learnt %q, chosen in round %d, at %s", c.Node, c.Val, c.Rnd, c.Time.Format(timeFormat))))
+		fmt.Fprintf(&b, `<text x="%d" y="%d" fill="%s">%s</text>`+"\n", x(c.Node)+10, y(c.Time)+4, color, html.EscapeString(c.Val))
+	}
+	b.WriteString("</svg>")
+	return b.String()
+}
+
+const timeFormat = "15:04:05.000000"
+
+// writeArrow writes the arrow of message m. A lost message ends halfway to
+// its receiver, and a message whose send was not recorded starts half a row
+// before it was received.
+func writeArrow(b *strings.Builder, m message, x func(int) int, y func(time.Time) int) {
+	x1, x2 := x(m.From), x(m.To)
+	var y1, y2 int
+	switch {
+	case m.Recv.IsZero():
+		y1 = y(m.Sent)
+		x2, y2 = (x1+x2)/2, y1+rowHeight/2
+	case m.Sent.IsZero():
+		y2 = y(m.Recv)
+		y1 = y2 - rowHeight/2
+	default:
+		y1, y2 = y(m.Sent), y(m.Recv)
+	}
+	color, dash := kindColors[m.Kind], ""
+	label := fmt.Sprintf("%s r%d", m.Kind, m.Rnd)
+	if m.Val != "" {
+		label += " " + m.Val
+	}
+	switch {
+	case m.Rejected:
+		color, dash = rejectedColor, ` stroke-dasharray="4 2"`
+		label += " (rejected)"
+	case m.Recv.IsZero():
+		color, dash = lostColor, ` stroke-dasharray="4 2"`
+		label += " (lost)"
+	}
+	title := fmt.Sprintf("%s from %d to %d, slot %d, round %d", m.Kind, m.From, m.To, m.Slot, m.Rnd)
+	if m.Val != "" {
+		title += ", value " + m.Val
+	}
+	if !m.Sent.IsZero() {
+		title += ", sent " + m.Sent.Format(timeFormat)
+	}
+	if !m.Recv.IsZero() {
+		title += ", received " + m.Recv.Format(timeFormat)
+	}
+	fmt.Fprintf(b, `<g><title>%s</title>`, html.EscapeString(title))
+	fmt.Fprintf(b, `<line x1="%d" y1="%d" x2="%d" y2="%d" stroke="%s"%s marker-end="url(#arrow%s)"/>`, x1, y1, x2, y2, color, dash, color[1:])
+	if m.Rejected {
+		fmt.Fprintf(b, `<text x="%d" y="%d" text-anchor="middle" fill="%s" font-size="14">&#10005;</text>`, x2, y2+5, color)
+	}
+	fmt.Fprintf(b, `<text x="%d" y="%d" text-anchor="middle" fill="%s">%s</text></g>`+"\n", (x1+x2)/2, (y1+y2)/2-3, color, html.EscapeString(label))
+}
+
+// markerColors returns the distinct colors of the arrows, in a fixed order.
+func markerColors() []string {
+	colors := []string{rejectedColor, lostColor}
+	for _, color := range kindColors {
+		if !slices.Contains(colors, color) {
+			colors = append(colors, color)
+		}
+	}
+	slices.Sort(colors)
+	return colors
+}
@@ -0,0 +1,209 @@
+package main
+
+import (
+	"slices"
+	"time"
+)
+
+// message is a Paxos message drawn as an arrow from node From to node To.
+type message struct {
+	Kind     string // Prepare, Promise, Accept, Learn, Decision or Commit
+	From, To int
+	Sent     time.Time // zero if the send was not recorded
+	Recv     time.Time // zero if the message was lost
+	Slot     int       // the slot; the first slot for a Prepare or Promise
+	Rnd      int
+	Val      string
+	Rejected bool // ignored by the receiving acceptor
+}
+
+// time returns the time the message was sent, or received if the send was
+// not recorded.
+func (m message) time() time.Time {
+	if m.Sent.IsZero() {
+		return m.Recv
+	}
+	return m.Sent
+}
+
+// phaseOne returns true if the message is sent in phase one, which covers
+// every slot from the message's slot.
+func (m message) phaseOne() bool {
+	return m.Kind == "Prepare" || m.Kind == "Promise"
+}
+
+// choice is a value chosen in a slot, as learnt by a node.
+type choice struct {
+	Node, Slot, Rnd int
+	Val             string
+	Time            time.Time
+}
+
+// trace is the messages of a recorded run and the values chosen in it.
+type trace struct {
+	nodes    []int
+	messages []message
+	chosen   []choice
+}
+
+// roundChange is the first message in a higher round in a diagram.
+type roundChange struct {
+	Rnd  int
+	Time time.Time
+}
+
+// diagram is the space-time diagram of a slot: the messages of the slot,
+// and the phase one messages sent for it, ordered by time.
+type diagram struct {
+	Slot     int
+	Nodes    []int
+	Messages []message
+	Rounds   []roundChange
+	Chosen   []choice
+}
+
+// conflict returns true if the nodes learnt different values in the slot,
+// which Paxos must never allow.
+func (d diagram) conflict() bool {
+	for _, c := range d.Chosen {
+		if c.Val != d.Chosen[0].Val {
+			return true
+		}
+	}
+	return false
+}
+
+// diagrams returns the diagram of each slot in the trace, in slot order.
+// A phase one message is drawn in the diagram of a slot from its slot if it
+// is in a round of the slot's other messages, or if it is sent while the
+// slot's other messages are.
+func diagrams(tr trace) []diagram {
+	bySlot := make(map[int]*diagram)
+	get := func(slot int) *diagram {
+		d, ok := bySlot[slot]
+		if !ok {
+			d = &diagram{Slot: slot, Nodes: tr.nodes}
+			bySlot[slot] = d
+		}
+		return d
+	}
+	for _, m := range tr.messages {
+		if !m.phaseOne() {
+			get(m.Slot).Messages = append(get(m.Slot).Messages, m)
+		}
+	}
+	for _, c := range tr.chosen {
+		get(c.Slot).Chosen = append(get(c.Slot).Chosen, c)
+	}
+	if len(bySlot) == 0 {
+		// phase one only; draw it in the diagram of its first slot
+		for _, m := range tr.messages {
+			get(m.Slot)
+		}
+	}
+	for _, d := range bySlot {
+		start, end := d.span()
+		rounds := make(map[int]bool)
+		for _, m := range d.Messages {
+			rounds[m.Rnd] = true
+		}
+		for _, m := range tr.messages {
+			during := !m.time().Before(start) && !m.time().After(end)
+			if m.phaseOne() && m.Slot <= d.Slot && (rounds[m.Rnd] || during) {
+				d.Messages = append(d.Messages, m)
+			}
+		}
+		slices.SortStableFunc(d.Messages, func(a, b message) int {
+			return a.time().Compare(b.time())
+		})
+		rnd := -1
+		for _, m := range d.Messages {
+			if m.Rnd > rnd {
+				rnd = m.Rnd
+				d.Rounds = append(d.Rounds, roundChange{Rnd: m.Rnd, Time: m.time()})
+			}
+		}
+	}
+	var ds []diagram
+	for _, d := range bySlot {
+		ds = append(ds, *d)
+	}
+	slices.SortFunc(ds, func(a, b diagram) int { return a.Slot - b.Slot })
+	return ds
+}
+
+// span returns the times of the first and last messages of the slot, and
+// the last time a node learnt its value; a slot without messages spans
+// all time.
+func (d diagram) span() (start, end time.Time) {
+	for _, m := range d.Messages {
+		start = earliest(start, m.Sent, m.Recv)
+		end = latest(end, m.Sent, m.Recv)
+	}
+	for _, c := range d.Chosen {
+		start = earliest(start, c.Time)
+		end = latest(end, c.Time)
+	}
+	if end.IsZero() {
+		return time.Time{}, time.Unix(1<<40, 0)
+	}
+	return start, end
+}
+
+// earliest returns the earliest of the non-zero times.
+func earliest(times ...time.Time) time.Time {
+	var t time.Time
+	for _, u := range times {
+		if !u.IsZero() && (t.IsZero() || u.Before(t)) {
+			t = u
+		}
+	}
+	return t
+}
+
+// latest returns the latest of the times.
+func latest(times ...time.Time) time.Time {
+	var t time.Time
+	for _, u := range times {
+		if u.After(t) {
+			t = u
+		}
+	}
+	return t
+}
+
+// learnChosen returns the values chosen according to the learns received
+// by each node: a value is chosen once a quorum of acceptors report it in
+// the same slot and round.
+func learnChosen(messages []message, quorum int) []choice {
+	type vote struct {
+		node, slot, rnd int
+		val             string
+	}
+	votes := make(map[vote]map[int]bool)
+	learnt := make(map[[2]int]bool) // node and slot
+	var chosen []choice
+	messages = slices.Clone(messages)
+	slices.SortStableFunc(messages, func(a, b message) int { return a.Recv.Compare(b.Recv) })
+	for _, m := range messages {
+		if m.Recv.IsZero() || learnt[[2]int{m.To, m.Slot}] {
+			continue
+		}
+		switch m.Kind {
+		case "Decision":
+			learnt[[2]int{m.To, m.Slot}] = true
+			chosen = append(chosen, choice{Node: m.To, Slot: m.Slot, Rnd: m.Rnd, Val: m.Val, Time: m.Recv})
+		case "Learn":
+			v := vote{node: m.To, slot: m.Slot, rnd: m.Rnd, val: m.Val}
+			if votes[v] == nil {
+				votes[v] = make(map[int]bool)
+			}
+			votes[v][m.From] = true
+			if len(votes[v]) >= quorum {
+				learnt[[2]int{m.To, m.Slot}] = true
+				chosen = append(chosen, choice{Node: m.To, Slot: m.Slot, Rnd: m.Rnd, Val: m.Val, Time: m.Recv})
+			}
+		}
+	}
+	return chosen
+}
@@ -0,0 +1,192 @@
+package gorumspaxos
+
+import (
+	"encoding/json"
+	"fmt"
+	"sync"
+
+	pb "dat520/lab5/gorumspaxos/proto"
+
+	"google.golang.org/protobuf/proto"
+)
+
+// Content types of the built-in codecs. Protobuf messages are identified by
+// their full name; see ProtoContentType.
+const (
+	ContentTypeRaw  = "application/octet-stream"
+	ContentTypeJSON = "application/json"
+)
+
+// Codec encodes and decodes the commands of one content type.
+type Codec interface {
+	// Marshal encodes the command as the payload of a request.
+	Marshal(cmd any) ([]byte, error)
+	// Unmarshal decodes a payload into a command.
+	Unmarshal(payload []byte) (cmd any, err error)
+}
+
+// ProtoContentType returns the content type of requests holding the
+// protobuf message type of msg.
+func ProtoContentType(msg proto.Message) string {
+	return "application/x-protobuf; messageType=" + string(msg.ProtoReflect().Descriptor().FullName())
+}
+
+// ProtoCodec encodes protobuf messages of one type.
+type ProtoCodec struct {
+	msg proto.Message // a message of the decoded type
+}
+
+// NewProtoCodec returns a codec that decodes payloads into messages of the
+// same type as msg.
+func NewProtoCodec(msg proto.Message) *ProtoCodec {
+	return &ProtoCodec{msg: msg}
+}
+
+func (c *ProtoCodec) Marshal(cmd any) ([]byte, error) {
+	msg, ok := cmd.(proto.Message)
+	if !ok || msg.ProtoReflect().Descriptor() != c.msg.ProtoReflect().Descriptor() {
+		return nil, fmt.Errorf("codec: %T is not a %s", cmd, c.msg.ProtoReflect().Descriptor().FullName())
+	}
+	return proto.Marshal(msg)
+}
+
+func (c *ProtoCodec) Unmarshal(payload []byte) (any, error) {
+	msg := c.msg.ProtoReflect().New().Interface()
+	if err := proto.Unmarshal(payload, msg); err != nil {
+		return nil, err
+	}
+	return msg, nil
+}
+
+// JSONCodec encodes commands of type T as JSON.
+type JSONCodec[T any] struct{}
+
+func (JSONCodec[T]) Marshal(cmd any) ([]byte, error) {
+	if _, ok := cmd.(T); !ok {
+		return nil, fmt.Errorf("codec: %T is not a %T", cmd, *new(T))
+	}
+	return json.Marshal(cmd)
+}
+
+func (JSONCodec[T]) Unmarshal(payload []byte) (any, error) {
+	var cmd T
+	if err := json.Unmarshal(payload, &cmd); err != nil {
+		return nil, err
+	}
+	return cmd, nil
+}
+
+// RawCodec passes payloads through as []byte commands.
+type RawCodec struct{}
+
+func (RawCodec) Marshal(cmd any) ([]byte, error) {
+	b, ok := cmd.([]byte)
+	if !ok {
+		return nil, fmt.Errorf("codec: %T is not a []byte", cmd)
+	}
+	return b, nil
+}
+
+func (RawCodec) Unmarshal(payload []byte) (any, error) {
+	return payload, nil
+}
+
+// CodecRegistry maps content types to the codecs of an application's commands.
+// A new registry holds the raw codec.
+type CodecRegistry struct {
+	mu     sync.RWMutex
+	codecs map[string]Codec
+}
+
+// NewCodecRegistry returns a registry holding the raw codec.
+func NewCodecRegistry() *CodecRegistry {
+	return &CodecRegistry{codecs: map[string]Codec{ContentTypeRaw: RawCodec{}}}
+}
+
+// Register registers the codec for the content type.
+// It returns an error if a codec is already registered for the content type.
+func (c *CodecRegistry) Register(contentType string, codec Codec) error {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if contentType == "" {
+		return fmt.Errorf("codec: empty content type")
+	}
+	if _, ok := c.codecs[contentType]; ok {
+		return fmt.Errorf("codec: content type %q already registered", contentType)
+	}
+	c.codecs[contentType] = codec
+	return nil
+}
+
+// RegisterProto registers a protobuf codec for the message type of msg,
+// under the content type given by ProtoContentType.
+func (c *CodecRegistry) RegisterProto(msg proto.Message) error {
+	return c.Register(ProtoContentType(msg), NewProtoCodec(msg))
+}
+
+func (c *CodecRegistry) codec(contentType string) (Codec, error) {
+	c.mu.RLock()
+	defer c.mu.RUnlock()
+	codec, ok := c.codecs[contentType]
+	if !ok {
+		return nil, fmt.Errorf("codec: unknown content type %q", contentType)
+	}
+	return codec, nil
+}
+
+// Encode returns a request holding the command encoded with the codec of the
+// content type. The caller sets the request's client ID and sequence number.
+func (c *CodecRegistry) Encode(contentType string, cmd any) (*pb.Value, error) {
+	codec, err := c.codec(contentType)
+	if err != nil {
+		return nil, err
+	}
+	payload, err := codec.Marshal(cmd)
+	if err != nil {
+		return nil, err
+	}
+	return &pb.Value{Payload: payload, ContentType: contentType}, nil
+}
+
+// Decode returns the command of the request, decoded with the codec of its
+// content type. The command of a request without a content type is its
+// ClientCommand string.
+func (c *CodecRegistry) Decode(val *pb.Value) (any, error) {
+	if val.GetContentType() == "" {
+		return val.GetClientCommand(), nil
+	}
+	codec, err := c.codec(val.GetContentType())
+	if err != nil {
+		return nil, err
+	}
+	return codec.Unmarshal(val.GetPayload())
+}
+
+// CommandStateMachine is an application whose requests are decoded before
+// they are applied; see WithCodecs.
+type CommandStateMachine interface {
+	// ApplyCommand applies the command of the request decided in learn and
+	// returns the result that is sent back to the client in the Response.
+	ApplyCommand(learn *pb.LearnMsg, cmd any) (result string)
+}
+
+// WithCodecs sets the state machine that decided requests are applied to,
+// after decoding their commands with the codecs. Requests that cannot be
+// decoded are not applied; their result is an error message.
+func WithCodecs(codecs *CodecRegistry, sm CommandStateMachine) ReplicaOption {
+	return WithStateMachine(&decodingStateMachine{codecs: codecs, sm: sm})
+}
+
+// decodingStateMachine is a StateMachine that decodes requests for a CommandStateMachine.
+type decodingStateMachine struct {
+	codecs *CodecRegistry
+	sm     CommandStateMachine
+}
+
+func (d *decodingStateMachine) Apply(learn *pb.LearnMsg) string {
+	cmd, err := d.codecs.Decode(learn.GetVal())
+	if err != nil {
+		return "error: " + err.Error()
+	}
+	return d.sm.ApplyCommand(learn, cmd)
+}
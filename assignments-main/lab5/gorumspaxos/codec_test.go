@@ -0,0 +1,147 @@
+package gorumspaxos
+
+import (
+	"testing"
+
+	pb "dat520/lab5/gorumspaxos/proto"
+	kvpb "dat520/lab5/gorumspaxos/proto/kv"
+
+	"github.com/google/go-cmp/cmp"
+	"google.golang.org/protobuf/testing/protocmp"
+)
+
+type moveCmd struct {
+	From, To string
+}
+
+func testCodecs(t *testing.T) *CodecRegistry {
+	t.Helper()
+	codecs := NewCodecRegistry()
+	if err := codecs.RegisterProto(&kvpb.InsertRequest{}); err != nil {
+		t.Fatal(err)
+	}
+	if err := codecs.Register(ContentTypeJSON, JSONCodec[moveCmd]{}); err != nil {
+		t.Fatal(err)
+	}
+	return codecs
+}
+
+func TestCodecRegistry(t *testing.T) {
+	codecs := testCodecs(t)
+	insert := &kvpb.InsertRequest{Key: "k", Value: "v"}
+	tests := []struct {
+		name        string
+		contentType string
+		cmd         any
+		wantErr     bool
+	}{
+		{name: "Proto", contentType: ProtoContentType(insert), cmd: insert},
+		{name: "JSON", contentType: ContentTypeJSON, cmd: moveCmd{From: "a", To: "b"}},
+		{name: "Raw", contentType: ContentTypeRaw, cmd: []byte{0, 1, 0xff}},
+		{name: "ProtoWrongType", contentType: ProtoContentType(insert), cmd: &kvpb.LookupRequest{Key: "k"}, wantErr: true},
+		{name: "JSONWrongType", contentType: ContentTypeJSON, cmd: "a to b", wantErr: true},
+		{name: "RawWrongType", contentType: ContentTypeRaw, cmd: "bytes", wantErr: true},
+		{name: "UnknownContentType", contentType: "text/plain", cmd: "ls", wantErr: true},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			val, err := codecs.Encode(test.contentType, test.cmd)
+			if (err != nil) != test.wantErr {
+				t.Fatalf("Encode() error = %v, wantErr %t", err, test.wantErr)
+			}
+			if err != nil {
+				return
+			}
+			if val.GetContentType() != test.contentType {
+				t.Errorf("ContentType = %q, want %q", val.GetContentType(), test.contentType)
+			}
+			got, err := codecs.Decode(val)
+			if err != nil {
+				t.Fatalf("Decode() error = %v", err)
+			}
+			if diff := cmp.Diff(test.cmd, got, protocmp.Transform()); diff != "" {
+				t.Errorf("Decode() mismatch (-want +got):\n%s", diff)
+			}
+		})
+	}
+
+	if err := codecs.Register(ContentTypeJSON, JSONCodec[string]{}); err == nil {
+		t.Error("Register() of a registered content type succeeded")
+	}
+	if err := codecs.Register("", RawCodec{}); err == nil {
+		t.Error("Register() of an empty content type succeeded")
+	}
+	// requests without a content type hold string commands
+	if got, err := codecs.Decode(&pb.Value{ClientCommand: "ls"}); err != nil || got != "ls" {
+		t.Errorf("Decode() = (%v, %v), want (ls, nil)", got, err)
+	}
+	if _, err := codecs.Decode(&pb.Value{ContentType: "text/plain", Payload: []byte("ls")}); err == nil {
+		t.Error("Decode() of an unknown content type succeeded")
+	}
+	bad := &pb.Value{ContentType: ProtoContentType(insert), Payload: []byte{0xff}}
+	if _, err := codecs.Decode(bad); err == nil {
+		t.Error("Decode() of an invalid payload succeeded")
+	}
+}
+
+func TestValueHashPayload(t *testing.T) {
+	vals := []*pb.Value{
+		{ClientID: "A", ClientSeq: 1, ClientCommand: "ls"},
+		{ClientID: "A", ClientSeq: 1, Payload: []byte("ls")},
+		{ClientID: "A", ClientSeq: 1, ContentType: ContentTypeRaw, Payload: []byte("ls")},
+		{ClientID: "A", ClientSeq: 1, ContentType: ContentTypeRaw, Payload: []byte("rm")},
+		{ClientID: "A", ClientSeq: 1, ContentType: ContentTypeJSON, Payload: []byte("ls")},
+	}
+	seen := make(map[uint64]*pb.Value)
+	for _, val := range vals {
+		if other, ok := seen[val.Hash()]; ok {
+			t.Errorf("Hash(%v) = Hash(%v)", val, other)
+		}
+		seen[val.Hash()] = val
+	}
+}
+
+// commandSM records the decoded commands of the applied requests.
+type commandSM struct{ cmds []any }
+
+func (sm *commandSM) ApplyCommand(learn *pb.LearnMsg, cmd any) string {
+	sm.cmds = append(sm.cmds, cmd)
+	return "done"
+}
+
+func TestDeliverDecoded(t *testing.T) {
+	codecs := testCodecs(t)
+	sm := &commandSM{}
+	replica := newTestReplicaLeader()
+	WithCodecs(codecs, sm)(replica)
+
+	insert := &kvpb.InsertRequest{Key: "k", Value: "v"}
+	insertVal, err := codecs.Encode(ProtoContentType(insert), insert)
+	if err != nil {
+		t.Fatal(err)
+	}
+	moveVal, err := codecs.Encode(ContentTypeJSON, moveCmd{From: "a", To: "b"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	unknownVal := &pb.Value{ContentType: "text/plain", Payload: []byte("ls")}
+	tests := []struct {
+		val        *pb.Value
+		wantResult string
+	}{
+		{val: insertVal, wantResult: "done"},
+		{val: moveVal, wantResult: "done"},
+		{val: &pb.Value{ClientCommand: "ls"}, wantResult: "done"},
+		{val: unknownVal, wantResult: `error: codec: unknown content type "text/plain"`},
+	}
+	for i, test := range tests {
+		rsp := replica.deliver(&pb.LearnMsg{Slot: Slot(i + 1), Val: test.val})
+		if rsp.GetResult() != test.wantResult {
+			t.Errorf("deliver(%v).Result = %q, want %q", test.val, rsp.GetResult(), test.wantResult)
+		}
+	}
+	want := []any{insert, moveCmd{From: "a", To: "b"}, "ls"}
+	if diff := cmp.Diff(want, sm.cmds, protocmp.Transform()); diff != "" {
+		t.Errorf("applied commands mismatch (-want +got):\n%s", diff)
+	}
+}
@@ -0,0 +1,428 @@
+package gorumspaxos
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"math/rand"
+	"slices"
+	"strconv"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"github.com/relab/gorums"
+	"github.com/relab/gorums/ordering"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/grpc/status"
+	"google.golang.org/protobuf/proto"
+)
+
+// AnyNode matches every peer in a FaultRule.
+const AnyNode = -1
+
+// FaultAction is the fault injected into the calls matched by a FaultRule.
+type FaultAction string
+
+const (
+	// FaultDelay delays the message. The messages after it on the same stream
+	// are not held back, so a delayed message may be overtaken by them.
+	FaultDelay FaultAction = "delay"
+	// FaultDrop discards the message; the caller does not get a reply from the peer.
+	FaultDrop FaultAction = "drop"
+	// FaultError replies with an error instead of handling the message. The
+	// replica replies itself to the messages it sends, which are not sent, and
+	// to the messages it receives, which are not handled. For calls without
+	// replies, such as Commit and Heartbeat, the error has the same effect as
+	// dropping the message.
+	FaultError FaultAction = "error"
+)
+
+// errInjectedFault is the error replied for calls matched by a FaultError rule.
+var errInjectedFault = status.Error(codes.Unavailable, "injected fault")
+
+// metadata keys used to identify the nodes of a gorums stream
+const (
+	faultFromKey = "gorumspaxos-from"
+	faultToKey   = "gorumspaxos-to"
+)
+
+// FaultRule describes a fault to inject into the gorums calls of a method
+// between the replica and a peer.
+type FaultRule struct {
+	// Method is the name of the method, such as Prepare, Accept, Commit or
+	// Heartbeat, or its full name, such as proto.MultiPaxos.Prepare.
+	// An empty method matches every method.
+	Method string
+	// Peer is the ID of the node the message is sent to, or, if Incoming,
+	// received from. AnyNode matches every node, including clients.
+	Peer int
+	// Incoming applies the rule to messages received by the replica
+	// rather than messages sent by it.
+	Incoming bool
+	Action   FaultAction
+	// Delay is the delay of the FaultDelay action.
+	Delay time.Duration
+	// Probability is the probability that the fault is injected into a
+	// matching message; zero means that it is always injected.
+	Probability float64
+}
+
+func (f FaultRule) String() string {
+	dir := "to"
+	if f.Incoming {
+		dir = "from"
+	}
+	peer := strconv.Itoa(f.Peer)
+	if f.Peer == AnyNode {
+		peer = "any"
+	}
+	method := f.Method
+	if method == "" {
+		method = "*"
+	}
+	s := fmt.Sprintf("%s %s %s %s", f.Action, method, dir, peer)
+	if f.Action == FaultDelay {
+		s += " " + f.Delay.String()
+	}
+	if f.Probability > 0 {
+		s += fmt.Sprintf(" p=%g", f.Probability)
+	}
+	return s
+}
+
+// validate returns an error if the rule is not valid.
+func (f FaultRule) validate() error {
+	switch f.Action {
+	case FaultDelay:
+		if f.Delay <= 0 {
+			return errors.New("delay fault requires a positive delay")
+		}
+	case FaultDrop, FaultError:
+	default:
+		return fmt.Errorf("unknown fault action %q", f.Action)
+	}
+	if f.Peer < AnyNode {
+		return fmt.Errorf("invalid peer %d", f.Peer)
+	}
+	if f.Probability < 0 || f.Probability > 1 {
+		return fmt.Errorf("invalid probability %v", f.Probability)
+	}
+	return nil
+}
+
+// matches returns true if the rule applies to a message of the method
+// sent to or received from the peer.
+func (f FaultRule) matches(method string, peer int, incoming bool) bool {
+	if f.Incoming != incoming || f.Peer != AnyNode && f.Peer != peer {
+		return false
+	}
+	return f.Method == "" || f.Method == method || strings.HasSuffix(method, "."+f.Method)
+}
+
+// FaultInjector injects faults into the gorums calls sent and received by a
+// replica, according to rules that can be changed at runtime. It is installed
+// as gRPC stream interceptors on the replica's managers and server, where it
+// inspects the gorums messages multiplexed on each node's stream.
+type FaultInjector struct {
+	id       int
+	mu       sync.RWMutex
+	rules    []FaultRule
+	injected atomic.Uint64
+}
+
+// NewFaultInjector returns a fault injector without rules for the node with the given id.
+func NewFaultInjector(id int) *FaultInjector {
+	return &FaultInjector{id: id}
+}
+
+// SetRules replaces the injector's rules; no rules clears them.
+func (f *FaultInjector) SetRules(rules ...FaultRule) error {
+	for _, rule := range rules {
+		if err := rule.validate(); err != nil {
+			return err
+		}
+	}
+	f.mu.Lock()
+	f.rules = slices.Clone(rules)
+	f.mu.Unlock()
+	return nil
+}
+
+// AddRule adds a rule to the injector's rules.
+func (f *FaultInjector) AddRule(rule FaultRule) error {
+	if err := rule.validate(); err != nil {
+		return err
+	}
+	f.mu.Lock()
+	f.rules = append(f.rules, rule)
+	f.mu.Unlock()
+	return nil
+}
+
+// Clear removes the injector's rules.
+func (f *FaultInjector) Clear() {
+	f.mu.Lock()
+	f.rules = nil
+	f.mu.Unlock()
+}
+
+// Rules returns the injector's rules.
+func (f *FaultInjector) Rules() []FaultRule {
+	f.mu.RLock()
+	defer f.mu.RUnlock()
+	return slices.Clone(f.rules)
+}
+
+// Injected returns the number of faults injected.
+func (f *FaultInjector) Injected() uint64 {
+	return f.injected.Load()
+}
+
+// fault returns the first rule matching the message that fires.
+func (f *FaultInjector) fault(method string, peer int, incoming bool) (FaultRule, bool) {
+	f.mu.RLock()
+	defer f.mu.RUnlock()
+	for _, rule := range f.rules {
+		if rule.matches(method, peer, incoming) && (rule.Probability == 0 || rand.Float64() < rule.Probability) {
+			f.injected.Add(1)
+			return rule, true
+		}
+	}
+	return FaultRule{}, false
+}
+
+// managerOptions returns the options that install the injector on a manager.
+// The streams to each node carry the IDs of both ends as metadata.
+func (f *FaultInjector) managerOptions() []gorums.ManagerOption {
+	return []gorums.ManagerOption{
+		gorums.WithPerNodeMetadata(func(id uint32) metadata.MD {
+			return metadata.Pairs(faultFromKey, strconv.Itoa(f.id), faultToKey, strconv.Itoa(int(id)))
+		}),
+		gorums.WithGrpcDialOptions(grpc.WithStreamInterceptor(f.interceptClient)),
+	}
+}
+
+// serverOptions returns the options that install the injector on a server.
+func (f *FaultInjector) serverOptions() []gorums.ServerOption {
+	return []gorums.ServerOption{
+		gorums.WithGRPCServerOptions(grpc.StreamInterceptor(f.interceptServer)),
+	}
+}
+
+// peerID returns the node ID stored under key in md, or AnyNode if there is none.
+func peerID(md metadata.MD, key string) int {
+	if values := md.Get(key); len(values) > 0 {
+		if id, err := strconv.Atoi(values[0]); err == nil {
+			return id
+		}
+	}
+	return AnyNode
+}
+
+func (f *FaultInjector) interceptClient(ctx context.Context, desc *grpc.StreamDesc, cc *grpc.ClientConn, method string, streamer grpc.Streamer, opts ...grpc.CallOption) (grpc.ClientStream, error) {
+	cs, err := streamer(ctx, desc, cc, method, opts...)
+	if err != nil {
+		return nil, err
+	}
+	md, _ := metadata.FromOutgoingContext(ctx)
+	return &faultClientStream{
+		ClientStream: cs,
+		faults:       f,
+		peer:         peerID(md, faultToKey),
+		recv:         newBackgroundRecv(cs.RecvMsg),
+		errReplies:   make(chan *gorums.Message, maxInjectedMsgs),
+	}, nil
+}
+
+func (f *FaultInjector) interceptServer(srv any, ss grpc.ServerStream, _ *grpc.StreamServerInfo, handler grpc.StreamHandler) error {
+	md, _ := metadata.FromIncomingContext(ss.Context())
+	return handler(srv, &faultServerStream{
+		ServerStream: ss,
+		faults:       f,
+		peer:         peerID(md, faultFromKey),
+		recv:         newBackgroundRecv(ss.RecvMsg),
+		delayed:      make(chan *gorums.Message),
+	})
+}
+
+// maxInjectedMsgs is the number of error replies a client stream buffers for
+// its receiver; further error replies are dropped.
+const maxInjectedMsgs = 64
+
+// backgroundRecv receives the messages of a gorums stream in a separate
+// goroutine, so that the stream's RecvMsg can wait both for the next received
+// message and for the messages injected by the fault injector.
+// It must only be used by the goroutine calling the stream's RecvMsg.
+type backgroundRecv struct {
+	recvMsg func(m any) error
+	msg     *gorums.Message // the message being received; nil if none
+	done    chan error      // the result of receiving msg
+}
+
+func newBackgroundRecv(recvMsg func(m any) error) *backgroundRecv {
+	return &backgroundRecv{recvMsg: recvMsg, done: make(chan error, 1)}
+}
+
+// receive starts receiving a message of the same type as m, unless a message
+// is already being received, and returns the channel the result is sent on.
+func (b *backgroundRecv) receive(m *gorums.Message) <-chan error {
+	if b.msg == nil {
+		next := *m
+		next.Metadata = &ordering.Metadata{}
+		next.Message = nil
+		b.msg = &next
+		go func() { b.done <- b.recvMsg(&next) }()
+	}
+	return b.done
+}
+
+// received returns the message received after its result was read from the
+// channel returned by receive.
+func (b *backgroundRecv) received() *gorums.Message {
+	msg := b.msg
+	b.msg = nil
+	return msg
+}
+
+// faultClientStream injects faults into the messages sent to a peer.
+// The requests failed by FaultError rules are not sent; instead, their error
+// replies are returned by RecvMsg as if the peer had sent them.
+type faultClientStream struct {
+	grpc.ClientStream
+	faults     *FaultInjector
+	peer       int
+	sendMu     sync.Mutex // serializes the sent messages and the delayed messages
+	recv       *backgroundRecv
+	errReplies chan *gorums.Message // error replies to the failed requests
+}
+
+func (s *faultClientStream) SendMsg(m any) error {
+	msg, ok := m.(*gorums.Message)
+	if !ok {
+		return s.send(m)
+	}
+	rule, ok := s.faults.fault(msg.Metadata.GetMethod(), s.peer, false)
+	if !ok {
+		return s.send(m)
+	}
+	switch rule.Action {
+	case FaultDelay:
+		// The message is sent later, without holding back the messages after it.
+		// Multicast messages are shared by the streams, and the caller may reuse
+		// the message once sent, so the delayed message is a copy.
+		delayed := &gorums.Message{
+			Metadata: proto.Clone(msg.Metadata).(*ordering.Metadata),
+			Message:  proto.Clone(msg.Message),
+		}
+		time.AfterFunc(rule.Delay, func() {
+			// an error means that the stream is broken, which its receiver reports
+			_ = s.send(delayed)
+		})
+		return nil
+	case FaultError:
+		reply := &ordering.Metadata{
+			MessageID: msg.Metadata.GetMessageID(),
+			Method:    msg.Metadata.GetMethod(),
+			Status:    status.Convert(errInjectedFault).Proto(),
+		}
+		select {
+		case s.errReplies <- &gorums.Message{Metadata: reply}:
+		default: // drop the reply rather than block the sender
+		}
+	}
+	// the message is not sent
+	return nil
+}
+
+// send sends the message on the stream.
+func (s *faultClientStream) send(m any) error {
+	s.sendMu.Lock()
+	defer s.sendMu.Unlock()
+	return s.ClientStream.SendMsg(m)
+}
+
+func (s *faultClientStream) RecvMsg(m any) error {
+	msg, ok := m.(*gorums.Message)
+	if !ok {
+		return s.ClientStream.RecvMsg(m)
+	}
+	select {
+	case reply := <-s.errReplies:
+		msg.Metadata = reply.Metadata
+	case err := <-s.recv.receive(msg):
+		received := s.recv.received()
+		if err != nil {
+			return err
+		}
+		*msg = *received
+	}
+	return nil
+}
+
+// faultServerStream injects faults into the messages received from a peer.
+// Messages delayed by FaultDelay rules are returned by RecvMsg once their
+// delay has passed, without holding back the messages received after them.
+type faultServerStream struct {
+	grpc.ServerStream
+	faults  *FaultInjector
+	peer    int
+	sendMu  sync.Mutex // serializes the server's replies and the injected error replies
+	recv    *backgroundRecv
+	delayed chan *gorums.Message // delayed messages whose delay has passed
+}
+
+func (s *faultServerStream) SendMsg(m any) error {
+	s.sendMu.Lock()
+	defer s.sendMu.Unlock()
+	return s.ServerStream.SendMsg(m)
+}
+
+func (s *faultServerStream) RecvMsg(m any) error {
+	msg, ok := m.(*gorums.Message)
+	if !ok {
+		return s.ServerStream.RecvMsg(m)
+	}
+	for {
+		var received *gorums.Message
+		select {
+		case delayed := <-s.delayed:
+			*msg = *delayed
+			return nil
+		case err := <-s.recv.receive(msg):
+			received = s.recv.received()
+			if err != nil {
+				return err
+			}
+		}
+		md := received.Metadata
+		rule, ok := s.faults.fault(md.GetMethod(), s.peer, true)
+		if !ok {
+			*msg = *received
+			return nil
+		}
+		switch rule.Action {
+		case FaultDelay:
+			time.AfterFunc(rule.Delay, func() {
+				select {
+				case s.delayed <- received:
+				case <-s.Context().Done():
+				}
+			})
+		case FaultError:
+			if err := s.replyError(md, errInjectedFault); err != nil {
+				return err
+			}
+		}
+		// the message is not handled now; receive the next one
+	}
+}
+
+// replyError replies to the request with the given metadata with err.
+func (s *faultServerStream) replyError(md *ordering.Metadata, err error) error {
+	reply := &ordering.Metadata{MessageID: md.GetMessageID(), Method: md.GetMethod()}
+	return s.SendMsg(gorums.WrapMessage(reply, nil, err))
+}
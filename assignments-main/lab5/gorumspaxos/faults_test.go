@@ -0,0 +1,299 @@
+package gorumspaxos
+
+import (
+	"context"
+	"fmt"
+	"testing"
+	"time"
+
+	pb "dat520/lab5/gorumspaxos/proto"
+	apb "dat520/lab5/gorumspaxos/proto/admin"
+
+	"github.com/google/go-cmp/cmp"
+	"github.com/relab/gorums"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/credentials/insecure"
+	"google.golang.org/protobuf/testing/protocmp"
+)
+
+func TestFaultRule(t *testing.T) {
+	const prepare = "proto.MultiPaxos.Prepare"
+	tests := []struct {
+		name     string
+		rule     FaultRule
+		method   string
+		peer     int
+		incoming bool
+		want     bool
+	}{
+		{name: "ShortMethod", rule: FaultRule{Method: "Prepare", Peer: 1}, method: prepare, peer: 1, want: true},
+		{name: "FullMethod", rule: FaultRule{Method: prepare, Peer: 1}, method: prepare, peer: 1, want: true},
+		{name: "AnyMethod", rule: FaultRule{Peer: 1}, method: prepare, peer: 1, want: true},
+		{name: "OtherMethod", rule: FaultRule{Method: "Accept", Peer: 1}, method: prepare, peer: 1},
+		{name: "MethodSuffix", rule: FaultRule{Method: "pare", Peer: 1}, method: prepare, peer: 1},
+		{name: "OtherPeer", rule: FaultRule{Method: "Prepare", Peer: 2}, method: prepare, peer: 1},
+		{name: "AnyPeer", rule: FaultRule{Method: "Prepare", Peer: AnyNode}, method: prepare, peer: 1, want: true},
+		{name: "AnyPeerUnknown", rule: FaultRule{Method: "Prepare", Peer: AnyNode}, method: prepare, peer: AnyNode, want: true},
+		{name: "PeerUnknown", rule: FaultRule{Method: "Prepare", Peer: 0}, method: prepare, peer: AnyNode},
+		{name: "Incoming", rule: FaultRule{Method: "Prepare", Peer: 1, Incoming: true}, method: prepare, peer: 1, incoming: true, want: true},
+		{name: "NotIncoming", rule: FaultRule{Method: "Prepare", Peer: 1}, method: prepare, peer: 1, incoming: true},
+		{name: "NotOutgoing", rule: FaultRule{Method: "Prepare", Peer: 1, Incoming: true}, method: prepare, peer: 1},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			if got := test.rule.matches(test.method, test.peer, test.incoming); got != test.want {
+				t.Errorf("%v.matches(%q, %d, %t) = %t, want %t", test.rule, test.method, test.peer, test.incoming, got, test.want)
+			}
+		})
+	}
+}
+
+func TestFaultInjectorRules(t *testing.T) {
+	f := NewFaultInjector(0)
+	for _, rule := range []FaultRule{
+		{Action: "crash"},
+		{Action: FaultDelay},
+		{Action: FaultDrop, Peer: -2},
+		{Action: FaultDrop, Probability: 1.5},
+	} {
+		if err := f.SetRules(rule); err == nil {
+			t.Errorf("SetRules(%v) succeeded, want error", rule)
+		}
+	}
+	drop := FaultRule{Method: "Accept", Peer: 1, Action: FaultDrop}
+	never := FaultRule{Method: "Prepare", Peer: 1, Action: FaultError, Probability: 1e-300}
+	delay := FaultRule{Peer: AnyNode, Action: FaultDelay, Delay: time.Millisecond}
+	if err := f.SetRules(drop, never); err != nil {
+		t.Fatal(err)
+	}
+	if err := f.AddRule(delay); err != nil {
+		t.Fatal(err)
+	}
+	if diff := cmp.Diff([]FaultRule{drop, never, delay}, f.Rules()); diff != "" {
+		t.Errorf("Rules() mismatch (-want +got):\n%s", diff)
+	}
+	tests := []struct {
+		method string
+		peer   int
+		want   FaultRule
+	}{
+		{method: "proto.MultiPaxos.Accept", peer: 1, want: drop},
+		{method: "proto.MultiPaxos.Accept", peer: 2, want: delay},
+		{method: "proto.MultiPaxos.Prepare", peer: 1, want: delay}, // the error rule (almost) never fires
+	}
+	for _, test := range tests {
+		if got, ok := f.fault(test.method, test.peer, false); !ok || got != test.want {
+			t.Errorf("fault(%q, %d) = %v, %t, want %v, true", test.method, test.peer, got, ok, test.want)
+		}
+	}
+	if _, ok := f.fault("proto.MultiPaxos.Accept", 1, true); ok {
+		t.Error("fault() matched an outgoing rule for an incoming message")
+	}
+	if got := f.Injected(); got != uint64(len(tests)) {
+		t.Errorf("Injected() = %d, want %d", got, len(tests))
+	}
+	f.Clear()
+	if got := f.Rules(); len(got) != 0 {
+		t.Errorf("Rules() = %v after Clear(), want none", got)
+	}
+}
+
+func TestAdminFaults(t *testing.T) {
+	replica := newTestReplicaLeader()
+	replica.faults = NewFaultInjector(replica.id)
+	rules := []*apb.FaultRule{
+		{Method: "Accept", Peer: 2, Action: "drop"},
+		{Method: "Heartbeat", Peer: -1, Incoming: true, Action: "delay", Delay: int64(time.Second), Probability: 0.5},
+	}
+	reply, err := replica.SetFaults(gorums.ServerCtx{}, &apb.FaultsRequest{Rules: rules})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if diff := cmp.Diff(&apb.FaultsReply{Rules: rules}, reply, protocmp.Transform()); diff != "" {
+		t.Errorf("SetFaults() mismatch (-want +got):\n%s", diff)
+	}
+	want := []FaultRule{
+		{Method: "Accept", Peer: 2, Action: FaultDrop},
+		{Method: "Heartbeat", Peer: AnyNode, Incoming: true, Action: FaultDelay, Delay: time.Second, Probability: 0.5},
+	}
+	if diff := cmp.Diff(want, replica.FaultInjector().Rules()); diff != "" {
+		t.Errorf("Rules() mismatch (-want +got):\n%s", diff)
+	}
+	if _, err := replica.SetFaults(gorums.ServerCtx{}, &apb.FaultsRequest{Rules: []*apb.FaultRule{{Action: "delay"}}}); err == nil {
+		t.Error("SetFaults() with an invalid rule succeeded, want error")
+	}
+	if reply, err = replica.SetFaults(gorums.ServerCtx{}, &apb.FaultsRequest{}); err != nil || len(reply.GetRules()) != 0 {
+		t.Errorf("SetFaults() = %v, %v; want no rules", reply, err)
+	}
+}
+
+// TestFaultInjection injects faults into the calls between the replicas of
+// a cluster, whose leader is node 2.
+func TestFaultInjection(t *testing.T) {
+	nodeMap, teardown, _, replicas := startReplicas(t, 3)
+	defer teardown()
+	byID := make(map[int]*PaxosReplica)
+	for _, r := range replicas {
+		byID[r.id] = r
+	}
+	leader := byID[2]
+	config, closeMgr, err := newConfiguration(nodeMap)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer closeMgr()
+
+	seq := uint32(0)
+	send := func(timeout time.Duration) (time.Duration, error) {
+		seq++
+		ctx, cancel := context.WithTimeout(context.Background(), timeout)
+		defer cancel()
+		req := &pb.Value{ClientID: "faults", ClientSeq: seq, ClientCommand: fmt.Sprint(seq)}
+		start := time.Now()
+		_, err := config.ClientHandle(ctx, req)
+		return time.Since(start), err
+	}
+	// retry sends the last request again
+	retry := func() error {
+		seq--
+		_, err := send(waitTimeForRequest)
+		return err
+	}
+	setRules := func(r *PaxosReplica, rules ...FaultRule) {
+		t.Helper()
+		if err := r.FaultInjector().SetRules(rules...); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if _, err := send(waitTimeForRequest); err != nil {
+		t.Fatal(err)
+	}
+
+	t.Run("DropAcceptToOne", func(t *testing.T) {
+		setRules(leader, FaultRule{Method: "Accept", Peer: 0, Action: FaultDrop})
+		defer leader.FaultInjector().Clear()
+		injected := leader.FaultInjector().Injected()
+		if _, err := send(waitTimeForRequest); err != nil {
+			t.Fatalf("request failed with a quorum of acceptors: %v", err)
+		}
+		if leader.FaultInjector().Injected() == injected {
+			t.Error("no fault injected")
+		}
+	})
+
+	t.Run("ErrorAcceptToAll", func(t *testing.T) {
+		setRules(leader, FaultRule{Method: "Accept", Peer: AnyNode, Action: FaultError})
+		if _, err := send(time.Second); err == nil {
+			t.Error("request succeeded without a quorum of acceptors")
+		}
+		leader.FaultInjector().Clear()
+		if err := retry(); err != nil {
+			t.Errorf("request failed after clearing faults: %v", err)
+		}
+	})
+
+	t.Run("DelayAccept", func(t *testing.T) {
+		const delay = 300 * time.Millisecond
+		setRules(leader, FaultRule{Method: "Accept", Peer: AnyNode, Action: FaultDelay, Delay: delay})
+		defer leader.FaultInjector().Clear()
+		latency, err := send(waitTimeForRequest)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if latency < delay {
+			t.Errorf("request latency %v, want at least %v", latency, delay)
+		}
+	})
+
+	t.Run("DelayAcceptToOne", func(t *testing.T) {
+		// the Commit sent to node 0 after the delayed Accept is not held back by it
+		const delay = 2 * time.Second
+		setRules(leader, FaultRule{Method: "Accept", Peer: 0, Action: FaultDelay, Delay: delay})
+		defer leader.FaultInjector().Clear()
+		start := time.Now()
+		if _, err := send(waitTimeForRequest); err != nil {
+			t.Fatal(err)
+		}
+		for ; byID[0].allDecidedUpTo() < leader.allDecidedUpTo(); time.Sleep(10 * time.Millisecond) {
+			if time.Since(start) >= delay {
+				t.Fatalf("node 0 learned the decision after the Accept's %v delay", delay)
+			}
+		}
+	})
+
+	t.Run("ErrorClientHandleAtClient", func(t *testing.T) {
+		// the client's fault injector replies with the errors itself,
+		// so the replicas need not run a fault injector
+		faults := NewFaultInjector(AnyNode)
+		if err := faults.AddRule(FaultRule{Method: "ClientHandle", Peer: AnyNode, Action: FaultError}); err != nil {
+			t.Fatal(err)
+		}
+		opts := append([]gorums.ManagerOption{
+			gorums.WithDialTimeout(5 * time.Second),
+			gorums.WithGrpcDialOptions(grpc.WithTransportCredentials(insecure.NewCredentials())),
+		}, faults.managerOptions()...)
+		mgr := pb.NewManager(opts...)
+		defer mgr.Close()
+		faultyConfig, err := mgr.NewConfiguration(NewPaxosQSpec(len(nodeMap)), gorums.WithNodeMap(nodeMap))
+		if err != nil {
+			t.Fatal(err)
+		}
+		ctx, cancel := context.WithTimeout(context.Background(), waitTimeForRequest)
+		defer cancel()
+		start := time.Now()
+		req := &pb.Value{ClientID: "faults", ClientSeq: 0, ClientCommand: "never sent"}
+		if _, err := faultyConfig.ClientHandle(ctx, req); err == nil {
+			t.Fatal("request succeeded when the client replies with errors")
+		}
+		if latency := time.Since(start); latency >= waitTimeForRequest {
+			t.Errorf("request failed after %v, want an immediate error", latency)
+		}
+		if got, want := faults.Injected(), uint64(len(nodeMap)); got != want {
+			t.Errorf("Injected() = %d, want %d", got, want)
+		}
+		for _, r := range replicas {
+			if stats := r.PendingStats(); stats.Waiters > 0 {
+				t.Errorf("replica %d received the request: %d waiters", r.id, stats.Waiters)
+			}
+		}
+	})
+
+	t.Run("ErrorClientHandle", func(t *testing.T) {
+		// client requests are received from unknown peers, matched only by AnyNode
+		for _, r := range replicas {
+			setRules(r,
+				FaultRule{Method: "ClientHandle", Peer: 0, Incoming: true, Action: FaultDrop},
+				FaultRule{Method: "ClientHandle", Peer: AnyNode, Incoming: true, Action: FaultError},
+			)
+			defer r.FaultInjector().Clear()
+		}
+		latency, err := send(waitTimeForRequest)
+		if err == nil {
+			t.Fatal("request succeeded when all replicas reply with errors")
+		}
+		if latency >= waitTimeForRequest {
+			t.Errorf("request failed after %v, want an immediate error", latency)
+		}
+	})
+
+	t.Run("DropHeartbeats", func(t *testing.T) {
+		// the other replicas stop receiving heartbeats from the leader and elect node 1
+		for _, id := range []int{0, 1} {
+			setRules(byID[id], FaultRule{Method: "Heartbeat", Peer: 2, Incoming: true, Action: FaultDrop})
+		}
+		waitForLeader := func(want int) {
+			t.Helper()
+			for deadline := time.Now().Add(10 * delta); time.Now().Before(deadline); time.Sleep(100 * time.Millisecond) {
+				if byID[0].leaderDetector.Leader() == want && byID[1].leaderDetector.Leader() == want {
+					return
+				}
+			}
+			t.Fatalf("leaders = %d, %d; want %d", byID[0].leaderDetector.Leader(), byID[1].leaderDetector.Leader(), want)
+		}
+		waitForLeader(1)
+		for _, id := range []int{0, 1} {
+			byID[id].FaultInjector().Clear()
+		}
+		waitForLeader(2)
+	})
+}
@@ -3,7 +3,6 @@ package gorumspaxos
 import (
 	"context"
 	"fmt"
-	"net"
 	"slices"
 	"sync"
 	"testing"
@@ -27,23 +26,9 @@ var (
 
 func startReplicas(t testing.TB, numServers int) (map[string]uint32, func(), func() int, []*PaxosReplica) {
 	t.Helper()
-	nodeMap := make(map[string]uint32)
-	lisMap := make(map[string]net.Listener)
-	for i := range numServers {
-		lis, err := net.Listen("tcp", "127.0.0.1:0")
-		if err != nil {
-			t.Fatal(err)
-		}
-		addr := lis.Addr().String()
-		nodeMap[addr] = uint32(i)
-		lisMap[addr] = lis
-	}
-	replicas := make([]*PaxosReplica, 0)
-	for addr, id := range nodeMap {
-		replica := NewPaxosReplica(int(id), nodeMap)
-		replicas = append(replicas, replica)
-		go func() { replica.Serve(lisMap[addr]) }()
-	}
+	nodeMap, replicas := serveReplicas(t, numServers, func(id int, nodeMap map[string]uint32) *PaxosReplica {
+		return NewPaxosReplica(id, nodeMap)
+	})
 	stopFn := func() {
 		for _, replica := range replicas {
 			replica.Stop()
@@ -60,7 +45,6 @@ func startReplicas(t testing.TB, numServers int) (map[string]uint32, func(), fun
 		}
 		return -1
 	}
-	time.Sleep(waitForReplicasToStart)
 	return nodeMap, stopFn, crashLeader, replicas
 }
 
@@ -1,6 +1,10 @@
 package gorumspaxos
 
-import "testing"
+import (
+	"net"
+	"testing"
+	"time"
+)
 
 func TestFiveReplicas(t *testing.T) {
 	testFiveReplicas(t, func() {})
@@ -9,3 +13,34 @@ func TestFiveReplicas(t *testing.T) {
 func TestLeaderFailure(t *testing.T) {
 	testLeaderFailure(t, func() {})
 }
+
+// replicaServer is a replica serving its gorums services on a listener.
+type replicaServer interface {
+	Serve(lis net.Listener)
+	Stop()
+}
+
+// serveReplicas starts numServers replicas created by newReplica, serving on
+// local listeners, and returns the node map and the replicas in id order.
+// The replicas are stopped when the test ends.
+func serveReplicas[R replicaServer](t testing.TB, numServers int, newReplica func(id int, nodeMap map[string]uint32) R) (map[string]uint32, []R) {
+	t.Helper()
+	nodeMap := make(map[string]uint32)
+	lis := make([]net.Listener, numServers)
+	for i := range numServers {
+		l, err := net.Listen("tcp", "127.0.0.1:0")
+		if err != nil {
+			t.Fatal(err)
+		}
+		lis[i] = l
+		nodeMap[l.Addr().String()] = uint32(i)
+	}
+	replicas := make([]R, numServers)
+	for i := range numServers {
+		replicas[i] = newReplica(i, nodeMap)
+		go replicas[i].Serve(lis[i])
+		t.Cleanup(replicas[i].Stop)
+	}
+	time.Sleep(waitForReplicasToStart)
+	return nodeMap, replicas
+}
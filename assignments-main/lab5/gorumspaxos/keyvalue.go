@@ -0,0 +1,102 @@
+package gorumspaxos
+
+import (
+	"context"
+
+	kvpb "dat520/lab5/gorumspaxos/proto/kv"
+
+	"google.golang.org/grpc"
+)
+
+// The lab2 KeyValueService is a plain gRPC service, which cannot be served by
+// a replica's gorums server. NewKeyValueServer therefore returns a separate
+// gRPC server for it, so that lab2 clients can use the replicated key-value
+// store without changes. The KVService's Insert, Lookup and Keys messages are
+// wire compatible with lab2's messages, and keyValueServiceDesc describes the
+// service as protoc-gen-go-grpc would for lab2's kv.proto; the tests check
+// both against the proto file.
+
+// keyValueServiceName is the full name of the lab2 KeyValueService.
+const keyValueServiceName = "proto.KeyValueService"
+
+// NewKeyValueServer returns a gRPC server serving the lab2 KeyValueService
+// using the key-value store of a replica returned by NewKVReplica. Every
+// request is decided in the Paxos log before it is applied and answered.
+func NewKeyValueServer(r *PaxosReplica, opts ...grpc.ServerOption) *grpc.Server {
+	srv := grpc.NewServer(opts...)
+	srv.RegisterService(&keyValueServiceDesc, &keyValueServer{kv: &kvServer{proxy: newCommandProxy(r, "kv")}})
+	return srv
+}
+
+// keyValueServiceServer is the server API of the lab2 KeyValueService.
+type keyValueServiceServer interface {
+	Insert(context.Context, *kvpb.InsertRequest) (*kvpb.InsertResponse, error)
+	Lookup(context.Context, *kvpb.LookupRequest) (*kvpb.LookupResponse, error)
+	Keys(context.Context, *kvpb.KeysRequest) (*kvpb.KeysResponse, error)
+}
+
+var keyValueServiceDesc = grpc.ServiceDesc{
+	ServiceName: keyValueServiceName,
+	HandlerType: (*keyValueServiceServer)(nil),
+	Methods: []grpc.MethodDesc{
+		keyValueMethod("Insert", keyValueServiceServer.Insert),
+		keyValueMethod("Lookup", keyValueServiceServer.Lookup),
+		keyValueMethod("Keys", keyValueServiceServer.Keys),
+	},
+	Metadata: "kv.proto",
+}
+
+// keyValueMethod returns the description of the KeyValueService method with
+// the given name, whose requests are handled by call.
+func keyValueMethod[Req, Rsp any](name string, call func(keyValueServiceServer, context.Context, *Req) (*Rsp, error)) grpc.MethodDesc {
+	handler := func(srv any, ctx context.Context, dec func(any) error, interceptor grpc.UnaryServerInterceptor) (any, error) {
+		req := new(Req)
+		if err := dec(req); err != nil {
+			return nil, err
+		}
+		s := srv.(keyValueServiceServer)
+		if interceptor == nil {
+			return call(s, ctx, req)
+		}
+		info := &grpc.UnaryServerInfo{Server: srv, FullMethod: "/" + keyValueServiceName + "/" + name}
+		return interceptor(ctx, req, info, func(ctx context.Context, req any) (any, error) {
+			return call(s, ctx, req.(*Req))
+		})
+	}
+	return grpc.MethodDesc{MethodName: name, Handler: handler}
+}
+
+// keyValueServer serves the lab2 KeyValueService of a replica.
+type keyValueServer struct {
+	kv *kvServer
+}
+
+// Insert sets the key to the value.
+func (s *keyValueServer) Insert(ctx context.Context, req *kvpb.InsertRequest) (*kvpb.InsertResponse, error) {
+	if _, err := s.kv.send(ctx, KVCommand{Op: KVPut, Key: req.GetKey(), Value: req.GetValue()}); err != nil {
+		return nil, err
+	}
+	return &kvpb.InsertResponse{Success: true}, nil
+}
+
+// Lookup returns the value of the key, or an empty value if the key is not found.
+func (s *keyValueServer) Lookup(ctx context.Context, req *kvpb.LookupRequest) (*kvpb.LookupResponse, error) {
+	result, err := s.kv.send(ctx, KVCommand{Op: KVGet, Key: req.GetKey()})
+	if err != nil {
+		return nil, err
+	}
+	return &kvpb.LookupResponse{Value: result.Value}, nil
+}
+
+// Keys returns all keys in sorted order.
+func (s *keyValueServer) Keys(ctx context.Context, req *kvpb.KeysRequest) (*kvpb.KeysResponse, error) {
+	result, err := s.kv.send(ctx, KVCommand{Op: KVScan})
+	if err != nil {
+		return nil, err
+	}
+	keys := make([]string, len(result.Entries))
+	for i, entry := range result.Entries {
+		keys[i] = entry.Key
+	}
+	return &kvpb.KeysResponse{Keys: keys}, nil
+}
@@ -0,0 +1,259 @@
+package gorumspaxos
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"slices"
+	"sync"
+
+	pb "dat520/lab5/gorumspaxos/proto"
+	kvpb "dat520/lab5/gorumspaxos/proto/kv"
+
+	"github.com/relab/gorums"
+)
+
+// KVOp is the operation of a key-value store command.
+type KVOp string
+
+const (
+	KVGet            KVOp = "get"
+	KVPut            KVOp = "put"
+	KVDelete         KVOp = "delete"
+	KVCompareAndSwap KVOp = "cas"
+	KVScan           KVOp = "scan"
+)
+
+// KVCommand is a key-value store command; it is sent as a JSON encoded
+// pb.Value.ClientCommand.
+type KVCommand struct {
+	Op       KVOp   `json:"op"`
+	Key      string `json:"key,omitempty"`      // the key; for scans, the first key of the range
+	Value    string `json:"value,omitempty"`    // new value (put, compare-and-swap)
+	Expected string `json:"expected,omitempty"` // expected current value (compare-and-swap)
+	Absent   bool   `json:"absent,omitempty"`   // expect the key to not exist (compare-and-swap)
+	End      string `json:"end,omitempty"`      // exclusive end of the range, empty for no bound (scan)
+	Limit    int    `json:"limit,omitempty"`    // maximum number of entries, zero for no limit (scan)
+}
+
+// KVEntry is a key-value pair returned by a scan.
+type KVEntry struct {
+	Key   string `json:"key"`
+	Value string `json:"value"`
+}
+
+// KVResult is the result of applying a KVCommand; it is sent as the JSON
+// encoded pb.Response.Result.
+type KVResult struct {
+	Value   string    `json:"value,omitempty"`   // the value before the command (get, delete, compare-and-swap)
+	Found   bool      `json:"found,omitempty"`   // whether the key existed before the command
+	Swapped bool      `json:"swapped,omitempty"` // whether the value was replaced (compare-and-swap)
+	Entries []KVEntry `json:"entries,omitempty"` // entries in the range (scan)
+	Error   string    `json:"error,omitempty"`   // set if the command could not be applied
+}
+
+// KVStateMachine is the state machine of the replicated key-value store.
+type KVStateMachine struct {
+	mu   sync.RWMutex
+	data map[string]string
+}
+
+// NewKVStateMachine returns a new empty key-value state machine.
+func NewKVStateMachine() *KVStateMachine {
+	return &KVStateMachine{data: make(map[string]string)}
+}
+
+// Get returns the value of the key as applied by this replica.
+// The value may be stale; use the KVService for linearizable reads.
+func (sm *KVStateMachine) Get(key string) (string, bool) {
+	sm.mu.RLock()
+	defer sm.mu.RUnlock()
+	value, ok := sm.data[key]
+	return value, ok
+}
+
+// Len returns the number of keys applied by this replica.
+func (sm *KVStateMachine) Len() int {
+	sm.mu.RLock()
+	defer sm.mu.RUnlock()
+	return len(sm.data)
+}
+
+// Apply applies a decided key-value store command.
+func (sm *KVStateMachine) Apply(learn *pb.LearnMsg) string {
+	var cmd KVCommand
+	var result KVResult
+	if err := json.Unmarshal([]byte(learn.GetVal().GetClientCommand()), &cmd); err != nil {
+		result.Error = fmt.Sprintf("invalid command: %v", err)
+	} else {
+		result = sm.apply(cmd)
+	}
+	b, _ := json.Marshal(result)
+	return string(b)
+}
+
+func (sm *KVStateMachine) apply(cmd KVCommand) (result KVResult) {
+	sm.mu.Lock()
+	defer sm.mu.Unlock()
+	if cmd.Op != KVScan {
+		result.Value, result.Found = sm.data[cmd.Key]
+	}
+	switch cmd.Op {
+	case KVGet:
+	case KVPut:
+		sm.data[cmd.Key] = cmd.Value
+	case KVDelete:
+		delete(sm.data, cmd.Key)
+	case KVCompareAndSwap:
+		if cmd.Absent && !result.Found || !cmd.Absent && result.Found && result.Value == cmd.Expected {
+			sm.data[cmd.Key] = cmd.Value
+			result.Swapped = true
+		}
+	case KVScan:
+		result.Entries = sm.scan(cmd.Key, cmd.End, cmd.Limit)
+	default:
+		result.Error = fmt.Sprintf("unknown operation %q", cmd.Op)
+	}
+	return result
+}
+
+// scan returns the entries with keys in [start, end) in key order.
+// The caller must hold sm.mu.
+func (sm *KVStateMachine) scan(start, end string, limit int) []KVEntry {
+	keys := make([]string, 0)
+	for key := range sm.data {
+		if key >= start && (end == "" || key < end) {
+			keys = append(keys, key)
+		}
+	}
+	slices.Sort(keys)
+	if limit > 0 && len(keys) > limit {
+		keys = keys[:limit]
+	}
+	entries := make([]KVEntry, len(keys))
+	for i, key := range keys {
+		entries[i] = KVEntry{Key: key, Value: sm.data[key]}
+	}
+	return entries
+}
+
+// NewKVReplica returns a Paxos replica that applies decided requests to sm
+// and serves the KVService in addition to the services served by a replica
+// returned by NewPaxosReplica.
+func NewKVReplica(myID int, nodeMap map[string]uint32, sm *KVStateMachine, options ...ReplicaOption) *PaxosReplica {
+	r := NewPaxosReplica(myID, nodeMap, append(options, WithStateMachine(sm))...)
+	kvpb.RegisterKVServiceServer(r.srv, &kvServer{proxy: newCommandProxy(r, "kv")})
+	return r
+}
+
+// kvServer serves the KVService of a replica.
+type kvServer struct {
+	proxy *commandProxy
+}
+
+// submit sends the command of a gorums request to the Paxos group and
+// returns its result.
+func (s *kvServer) submit(ctx gorums.ServerCtx, cmd KVCommand) (KVResult, error) {
+	var result KVResult
+	err := s.proxy.submit(ctx, cmd, &result)
+	return kvResult(result, err)
+}
+
+// send sends the command to the Paxos group and returns its result.
+func (s *kvServer) send(ctx context.Context, cmd KVCommand) (KVResult, error) {
+	var result KVResult
+	err := s.proxy.send(ctx, cmd, &result)
+	return kvResult(result, err)
+}
+
+// kvResult returns the result of a command sent to the Paxos group, or the
+// error that sending or applying the command failed with.
+func kvResult(result KVResult, err error) (KVResult, error) {
+	if err != nil {
+		return KVResult{}, err
+	}
+	if result.Error != "" {
+		return KVResult{}, errors.New(result.Error)
+	}
+	return result, nil
+}
+
+// Insert sets the key to the value.
+func (s *kvServer) Insert(ctx gorums.ServerCtx, req *kvpb.InsertRequest) (*kvpb.InsertResponse, error) {
+	if _, err := s.submit(ctx, KVCommand{Op: KVPut, Key: req.GetKey(), Value: req.GetValue()}); err != nil {
+		return nil, err
+	}
+	return &kvpb.InsertResponse{Success: true}, nil
+}
+
+// Lookup returns the value of the key, or an empty value if the key is not found.
+func (s *kvServer) Lookup(ctx gorums.ServerCtx, req *kvpb.LookupRequest) (*kvpb.LookupResponse, error) {
+	result, err := s.submit(ctx, KVCommand{Op: KVGet, Key: req.GetKey()})
+	if err != nil {
+		return nil, err
+	}
+	return &kvpb.LookupResponse{Value: result.Value}, nil
+}
+
+// Keys returns all keys in sorted order.
+func (s *kvServer) Keys(ctx gorums.ServerCtx, req *kvpb.KeysRequest) (*kvpb.KeysResponse, error) {
+	result, err := s.submit(ctx, KVCommand{Op: KVScan})
+	if err != nil {
+		return nil, err
+	}
+	keys := make([]string, len(result.Entries))
+	for i, entry := range result.Entries {
+		keys[i] = entry.Key
+	}
+	return &kvpb.KeysResponse{Keys: keys}, nil
+}
+
+// Get returns the value of the key.
+func (s *kvServer) Get(ctx gorums.ServerCtx, req *kvpb.GetRequest) (*kvpb.GetResponse, error) {
+	result, err := s.submit(ctx, KVCommand{Op: KVGet, Key: req.GetKey()})
+	if err != nil {
+		return nil, err
+	}
+	return &kvpb.GetResponse{Value: result.Value, Found: result.Found}, nil
+}
+
+// Put sets the key to the value.
+func (s *kvServer) Put(ctx gorums.ServerCtx, req *kvpb.PutRequest) (*kvpb.PutResponse, error) {
+	if _, err := s.submit(ctx, KVCommand{Op: KVPut, Key: req.GetKey(), Value: req.GetValue()}); err != nil {
+		return nil, err
+	}
+	return &kvpb.PutResponse{}, nil
+}
+
+// Delete deletes the key.
+func (s *kvServer) Delete(ctx gorums.ServerCtx, req *kvpb.DeleteRequest) (*kvpb.DeleteResponse, error) {
+	result, err := s.submit(ctx, KVCommand{Op: KVDelete, Key: req.GetKey()})
+	if err != nil {
+		return nil, err
+	}
+	return &kvpb.DeleteResponse{Deleted: result.Found}, nil
+}
+
+// CompareAndSwap sets the key to the value if its current value is as expected.
+func (s *kvServer) CompareAndSwap(ctx gorums.ServerCtx, req *kvpb.CompareAndSwapRequest) (*kvpb.CompareAndSwapResponse, error) {
+	cmd := KVCommand{Op: KVCompareAndSwap, Key: req.GetKey(), Expected: req.GetExpected(), Absent: req.GetAbsent(), Value: req.GetValue()}
+	result, err := s.submit(ctx, cmd)
+	if err != nil {
+		return nil, err
+	}
+	return &kvpb.CompareAndSwapResponse{Swapped: result.Swapped, Current: result.Value, Found: result.Found}, nil
+}
+
+// Scan returns the entries in the requested key range in key order.
+func (s *kvServer) Scan(ctx gorums.ServerCtx, req *kvpb.ScanRequest) (*kvpb.ScanResponse, error) {
+	result, err := s.submit(ctx, KVCommand{Op: KVScan, Key: req.GetStart(), End: req.GetEnd(), Limit: int(req.GetLimit())})
+	if err != nil {
+		return nil, err
+	}
+	entries := make([]*kvpb.KeyValue, len(result.Entries))
+	for i, entry := range result.Entries {
+		entries[i] = &kvpb.KeyValue{Key: entry.Key, Value: entry.Value}
+	}
+	return &kvpb.ScanResponse{Entries: entries}, nil
+}
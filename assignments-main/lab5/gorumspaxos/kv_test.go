@@ -0,0 +1,344 @@
+package gorumspaxos
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net"
+	"os"
+	"reflect"
+	"regexp"
+	"strings"
+	"testing"
+	"time"
+
+	pb "dat520/lab5/gorumspaxos/proto"
+	kvpb "dat520/lab5/gorumspaxos/proto/kv"
+
+	"github.com/google/go-cmp/cmp"
+	"github.com/relab/gorums"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/credentials/insecure"
+	"google.golang.org/protobuf/proto"
+	"google.golang.org/protobuf/reflect/protoreflect"
+	"google.golang.org/protobuf/testing/protocmp"
+)
+
+func TestKVStateMachine(t *testing.T) {
+	sm := NewKVStateMachine()
+	tests := []struct {
+		cmd  KVCommand
+		want KVResult
+	}{
+		{cmd: KVCommand{Op: KVGet, Key: "a"}, want: KVResult{}},
+		{cmd: KVCommand{Op: KVPut, Key: "a", Value: "1"}, want: KVResult{}},
+		{cmd: KVCommand{Op: KVPut, Key: "b", Value: "2"}, want: KVResult{}},
+		{cmd: KVCommand{Op: KVPut, Key: "c", Value: "3"}, want: KVResult{}},
+		{cmd: KVCommand{Op: KVGet, Key: "a"}, want: KVResult{Value: "1", Found: true}},
+		{cmd: KVCommand{Op: KVCompareAndSwap, Key: "a", Expected: "0", Value: "10"}, want: KVResult{Value: "1", Found: true}},
+		{cmd: KVCommand{Op: KVCompareAndSwap, Key: "a", Expected: "1", Value: "10"}, want: KVResult{Value: "1", Found: true, Swapped: true}},
+		{cmd: KVCommand{Op: KVCompareAndSwap, Key: "a", Absent: true, Value: "11"}, want: KVResult{Value: "10", Found: true}},
+		{cmd: KVCommand{Op: KVCompareAndSwap, Key: "d", Absent: true, Value: "4"}, want: KVResult{Swapped: true}},
+		{cmd: KVCommand{Op: KVCompareAndSwap, Key: "e", Expected: "", Value: "5"}, want: KVResult{}},
+		{cmd: KVCommand{Op: KVScan, Key: "b", End: "d"}, want: KVResult{Entries: []KVEntry{{"b", "2"}, {"c", "3"}}}},
+		{cmd: KVCommand{Op: KVScan, Key: "b", Limit: 1}, want: KVResult{Entries: []KVEntry{{"b", "2"}}}},
+		{cmd: KVCommand{Op: KVDelete, Key: "b"}, want: KVResult{Value: "2", Found: true}},
+		{cmd: KVCommand{Op: KVDelete, Key: "b"}, want: KVResult{}},
+		{cmd: KVCommand{Op: KVScan}, want: KVResult{Entries: []KVEntry{{"a", "10"}, {"c", "3"}, {"d", "4"}}}},
+		{cmd: KVCommand{Op: "append", Key: "a"}, want: KVResult{Value: "10", Found: true, Error: `unknown operation "append"`}},
+	}
+	for i, test := range tests {
+		b, err := json.Marshal(test.cmd)
+		if err != nil {
+			t.Fatal(err)
+		}
+		learn := &pb.LearnMsg{Slot: Slot(i + 1), Val: &pb.Value{ClientCommand: string(b)}}
+		var got KVResult
+		if err := json.Unmarshal([]byte(sm.Apply(learn)), &got); err != nil {
+			t.Fatal(err)
+		}
+		if diff := cmp.Diff(test.want, got); diff != "" {
+			t.Errorf("Apply(%+v) mismatch (-want +got):\n%s", test.cmd, diff)
+		}
+	}
+	invalid := &pb.LearnMsg{Slot: 100, Val: &pb.Value{ClientCommand: "put a 1"}}
+	var got KVResult
+	if err := json.Unmarshal([]byte(sm.Apply(invalid)), &got); err != nil || got.Error == "" {
+		t.Errorf("Apply(%q) = %+v, %v; want error result", invalid.GetVal().GetClientCommand(), got, err)
+	}
+}
+
+// startKVReplicas starts numServers replicas running the key-value store and
+// returns the replicas and the KVService node of each replica in replica order.
+func startKVReplicas(t *testing.T, numServers int) ([]*PaxosReplica, []*kvpb.Node, []*KVStateMachine) {
+	t.Helper()
+	sms := make([]*KVStateMachine, numServers)
+	nodeMap, replicas := serveReplicas(t, numServers, func(id int, nodeMap map[string]uint32) *PaxosReplica {
+		sms[id] = NewKVStateMachine()
+		return NewKVReplica(id, nodeMap, sms[id])
+	})
+
+	mgr := kvpb.NewManager(gorums.WithDialTimeout(5*time.Second),
+		gorums.WithGrpcDialOptions(
+			grpc.WithBlock(), // block until connections are made
+			grpc.WithTransportCredentials(insecure.NewCredentials()), // disable TLS
+		),
+	)
+	t.Cleanup(mgr.Close)
+	cfg, err := mgr.NewConfiguration(gorums.WithNodeMap(nodeMap))
+	if err != nil {
+		t.Fatal(err)
+	}
+	nodes := make([]*kvpb.Node, numServers)
+	for _, node := range cfg.Nodes() {
+		nodes[node.ID()] = node
+	}
+	return replicas, nodes, sms
+}
+
+func TestKVService(t *testing.T) {
+	_, nodes, sms := startKVReplicas(t, 3)
+	ctx, cancel := context.WithTimeout(context.Background(), 30*time.Second)
+	defer cancel()
+
+	// lab2 KeyValueService API
+	for i, kv := range [][2]string{{"x", "1"}, {"y", "2"}, {"z", "3"}} {
+		rsp, err := nodes[i].Insert(ctx, &kvpb.InsertRequest{Key: kv[0], Value: kv[1]})
+		if err != nil {
+			t.Fatal(err)
+		}
+		if !rsp.GetSuccess() {
+			t.Errorf("Insert(%s=%s) = %v, want success", kv[0], kv[1], rsp)
+		}
+	}
+	lookup, err := nodes[2].Lookup(ctx, &kvpb.LookupRequest{Key: "x"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if lookup.GetValue() != "1" {
+		t.Errorf("Lookup(x) = %q, want %q", lookup.GetValue(), "1")
+	}
+	keys, err := nodes[1].Keys(ctx, &kvpb.KeysRequest{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if diff := cmp.Diff([]string{"x", "y", "z"}, keys.GetKeys()); diff != "" {
+		t.Errorf("Keys() mismatch (-want +got):\n%s", diff)
+	}
+
+	// typed commands, sent to different replicas
+	tests := []struct {
+		name string
+		call func(node *kvpb.Node) (any, error)
+		want any
+	}{
+		{
+			name: "Get",
+			call: func(n *kvpb.Node) (any, error) { return n.Get(ctx, &kvpb.GetRequest{Key: "y"}) },
+			want: &kvpb.GetResponse{Value: "2", Found: true},
+		},
+		{
+			name: "GetMissing",
+			call: func(n *kvpb.Node) (any, error) { return n.Get(ctx, &kvpb.GetRequest{Key: "w"}) },
+			want: &kvpb.GetResponse{},
+		},
+		{
+			name: "Put",
+			call: func(n *kvpb.Node) (any, error) { return n.Put(ctx, &kvpb.PutRequest{Key: "w", Value: "0"}) },
+			want: &kvpb.PutResponse{},
+		},
+		{
+			name: "CompareAndSwapMismatch",
+			call: func(n *kvpb.Node) (any, error) {
+				return n.CompareAndSwap(ctx, &kvpb.CompareAndSwapRequest{Key: "w", Expected: "1", Value: "2"})
+			},
+			want: &kvpb.CompareAndSwapResponse{Current: "0", Found: true},
+		},
+		{
+			name: "CompareAndSwap",
+			call: func(n *kvpb.Node) (any, error) {
+				return n.CompareAndSwap(ctx, &kvpb.CompareAndSwapRequest{Key: "w", Expected: "0", Value: "5"})
+			},
+			want: &kvpb.CompareAndSwapResponse{Swapped: true, Current: "0", Found: true},
+		},
+		{
+			name: "CompareAndSwapAbsent",
+			call: func(n *kvpb.Node) (any, error) {
+				return n.CompareAndSwap(ctx, &kvpb.CompareAndSwapRequest{Key: "v", Absent: true, Value: "9"})
+			},
+			want: &kvpb.CompareAndSwapResponse{Swapped: true},
+		},
+		{
+			name: "Delete",
+			call: func(n *kvpb.Node) (any, error) { return n.Delete(ctx, &kvpb.DeleteRequest{Key: "y"}) },
+			want: &kvpb.DeleteResponse{Deleted: true},
+		},
+		{
+			name: "DeleteMissing",
+			call: func(n *kvpb.Node) (any, error) { return n.Delete(ctx, &kvpb.DeleteRequest{Key: "y"}) },
+			want: &kvpb.DeleteResponse{},
+		},
+		{
+			name: "Scan",
+			call: func(n *kvpb.Node) (any, error) { return n.Scan(ctx, &kvpb.ScanRequest{Start: "w", End: "z"}) },
+			want: &kvpb.ScanResponse{Entries: []*kvpb.KeyValue{{Key: "w", Value: "5"}, {Key: "x", Value: "1"}}},
+		},
+		{
+			name: "ScanLimit",
+			call: func(n *kvpb.Node) (any, error) { return n.Scan(ctx, &kvpb.ScanRequest{Limit: 2}) },
+			want: &kvpb.ScanResponse{Entries: []*kvpb.KeyValue{{Key: "v", Value: "9"}, {Key: "w", Value: "5"}}},
+		},
+	}
+	for i, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			got, err := test.call(nodes[i%len(nodes)])
+			if err != nil {
+				t.Fatal(err)
+			}
+			if diff := cmp.Diff(test.want, got, protocmp.Transform()); diff != "" {
+				t.Errorf("%s mismatch (-want +got):\n%s", test.name, diff)
+			}
+		})
+	}
+
+	// all replicas apply the same commands; the replica outside the quorum may lag behind
+	want := map[string]string{"v": "9", "w": "5", "x": "1", "z": "3"}
+	for i, sm := range sms {
+		for sm.Len() != len(want) && ctx.Err() == nil {
+			time.Sleep(10 * time.Millisecond)
+		}
+		for key, value := range want {
+			if got, ok := sm.Get(key); !ok || got != value {
+				t.Errorf("replica %d: Get(%s) = %q, %t; want %q, true", i, key, got, ok, value)
+			}
+		}
+	}
+}
+
+// TestKeyValueService runs the lab2 KeyValueService on the replicated
+// key-value store, calling it as a lab2 client does.
+func TestKeyValueService(t *testing.T) {
+	replicas, _, sms := startKVReplicas(t, 3)
+	conns := make([]*grpc.ClientConn, len(replicas))
+	for i, replica := range replicas {
+		lis, err := net.Listen("tcp", "127.0.0.1:0")
+		if err != nil {
+			t.Fatal(err)
+		}
+		srv := NewKeyValueServer(replica)
+		go srv.Serve(lis)
+		t.Cleanup(srv.Stop)
+		conn, err := grpc.Dial(lis.Addr().String(), grpc.WithTransportCredentials(insecure.NewCredentials()))
+		if err != nil {
+			t.Fatal(err)
+		}
+		t.Cleanup(func() { conn.Close() })
+		conns[i] = conn
+	}
+	ctx, cancel := context.WithTimeout(context.Background(), 30*time.Second)
+	defer cancel()
+	call := func(conn *grpc.ClientConn, method string, req, rsp any) {
+		t.Helper()
+		if err := conn.Invoke(ctx, "/proto.KeyValueService/"+method, req, rsp); err != nil {
+			t.Fatalf("%s(%v) = %v, want nil", method, req, err)
+		}
+	}
+
+	for i, kv := range [][2]string{{"x", "1"}, {"y", "2"}, {"z", "3"}} {
+		var rsp kvpb.InsertResponse
+		call(conns[i], "Insert", &kvpb.InsertRequest{Key: kv[0], Value: kv[1]}, &rsp)
+		if !rsp.GetSuccess() {
+			t.Errorf("Insert(%s=%s) = %v, want success", kv[0], kv[1], &rsp)
+		}
+	}
+	var lookup kvpb.LookupResponse
+	call(conns[2], "Lookup", &kvpb.LookupRequest{Key: "x"}, &lookup)
+	if lookup.GetValue() != "1" {
+		t.Errorf("Lookup(x) = %q, want %q", lookup.GetValue(), "1")
+	}
+	var keys kvpb.KeysResponse
+	call(conns[1], "Keys", &kvpb.KeysRequest{}, &keys)
+	if diff := cmp.Diff([]string{"x", "y", "z"}, keys.GetKeys()); diff != "" {
+		t.Errorf("Keys() mismatch (-want +got):\n%s", diff)
+	}
+	// the inserts are applied by a quorum of replicas before they are answered
+	applied := 0
+	for _, sm := range sms {
+		if value, ok := sm.Get("z"); ok && value == "3" {
+			applied++
+		}
+	}
+	if applied < 2 {
+		t.Errorf("z=3 applied by %d replicas, want at least 2", applied)
+	}
+}
+
+// lab2KVProto is the lab2 proto file defining the KeyValueService.
+const lab2KVProto = "../../lab2/grpc/proto/kv.proto"
+
+// TestKeyValueServiceDesc checks that keyValueServiceDesc, which is written by
+// hand, matches the KeyValueService and messages of the lab2 proto file.
+func TestKeyValueServiceDesc(t *testing.T) {
+	b, err := os.ReadFile(lab2KVProto)
+	if err != nil {
+		t.Fatal(err)
+	}
+	src := string(b)
+	pkg := regexp.MustCompile(`(?m)^package (\w+);`).FindStringSubmatch(src)
+	service := regexp.MustCompile(`(?s)service (\w+) \{(.*?)\n\}`).FindStringSubmatch(src)
+	if pkg == nil || service == nil {
+		t.Fatalf("%s: no package or service", lab2KVProto)
+	}
+	if got, want := keyValueServiceDesc.ServiceName, pkg[1]+"."+service[1]; got != want {
+		t.Errorf("ServiceName = %q, want %q", got, want)
+	}
+
+	// the methods of the service, and their request and response messages
+	var wantMethods, gotMethods []string
+	for _, rpc := range regexp.MustCompile(`rpc (\w+)\((\w+)\) returns \((\w+)\)`).FindAllStringSubmatch(service[2], -1) {
+		wantMethods = append(wantMethods, strings.Join(rpc[1:], " "))
+	}
+	server := reflect.TypeFor[keyValueServiceServer]()
+	for _, desc := range keyValueServiceDesc.Methods {
+		method, ok := server.MethodByName(desc.MethodName)
+		if !ok {
+			t.Errorf("method %s not in keyValueServiceServer", desc.MethodName)
+			continue
+		}
+		req, rsp := method.Type.In(1).Elem().Name(), method.Type.Out(0).Elem().Name()
+		gotMethods = append(gotMethods, strings.Join([]string{desc.MethodName, req, rsp}, " "))
+	}
+	if diff := cmp.Diff(wantMethods, gotMethods); diff != "" {
+		t.Errorf("methods mismatch (-lab2 +desc):\n%s", diff)
+	}
+
+	// the fields of the messages, which must be wire compatible
+	wantFields := make(map[string][]string)
+	for _, msg := range regexp.MustCompile(`message (\w+) \{([^}]*)\}`).FindAllStringSubmatch(src, -1) {
+		wantFields[msg[1]] = nil
+		for _, field := range regexp.MustCompile(`(repeated )?(\w+) +(\w+) *= *(\d+);`).FindAllStringSubmatch(msg[2], -1) {
+			wantFields[msg[1]] = append(wantFields[msg[1]], fmt.Sprintf("%s%s %s %s", field[1], field[2], field[3], field[4]))
+		}
+	}
+	gotFields := make(map[string][]string)
+	for _, msg := range []proto.Message{
+		&kvpb.InsertRequest{}, &kvpb.InsertResponse{},
+		&kvpb.LookupRequest{}, &kvpb.LookupResponse{},
+		&kvpb.KeysRequest{}, &kvpb.KeysResponse{},
+	} {
+		desc := msg.ProtoReflect().Descriptor()
+		gotFields[string(desc.Name())] = nil
+		for i := range desc.Fields().Len() {
+			field := desc.Fields().Get(i)
+			var repeated string
+			if field.Cardinality() == protoreflect.Repeated {
+				repeated = "repeated "
+			}
+			gotFields[string(desc.Name())] = append(gotFields[string(desc.Name())],
+				fmt.Sprintf("%s%s %s %d", repeated, field.Kind(), field.Name(), field.Number()))
+		}
+	}
+	if diff := cmp.Diff(wantFields, gotFields); diff != "" {
+		t.Errorf("message fields mismatch (-lab2 +kv):\n%s", diff)
+	}
+}
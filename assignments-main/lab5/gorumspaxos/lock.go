@@ -0,0 +1,494 @@
+package gorumspaxos
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"math"
+	"strconv"
+	"sync"
+	"time"
+
+	pb "dat520/lab5/gorumspaxos/proto"
+	lockpb "dat520/lab5/gorumspaxos/proto/lock"
+
+	"github.com/relab/gorums"
+)
+
+// The lock service is a Chubby-style service of named locks, built as an
+// application over the decided log of a PaxosReplica. Locks are held by
+// sessions. A session stays alive as long as its client renews the session's
+// lease; when a lease runs out, the session expires and its locks are released.
+//
+// Each decided command carries the wall clock time of the replica that
+// proposed it, and the state machine's clock is the highest such time applied
+// so far. Expiry is therefore deterministic across replicas, but it is only
+// noticed when the next command is applied. A Watch therefore also returns
+// when the lease of the lock's holder runs out, so that waiting clients can
+// retry, which lets the expiry take effect.
+//
+// The fencing token of a lock is the slot in which it was acquired. Since
+// slots are decided in increasing order, each new holder of a lock gets a
+// higher token than all previous holders, and a resource can reject requests
+// carrying a token lower than the highest token it has seen.
+
+const (
+	// DefaultLockLease is the lease of a session opened without a lease.
+	DefaultLockLease = 10 * time.Second
+	// defaultWatchTimeout is the duration a Watch waits for a lock to change
+	// if the request does not specify a timeout.
+	defaultWatchTimeout = 10 * time.Second
+)
+
+// LockOp is the operation of a lock service command.
+type LockOp string
+
+const (
+	LockOpen    LockOp = "open"
+	LockRenew   LockOp = "renew"
+	LockClose   LockOp = "close"
+	LockAcquire LockOp = "acquire"
+	LockRelease LockOp = "release"
+)
+
+// LockCommand is a lock service command; it is sent as a JSON encoded
+// pb.Value.ClientCommand.
+type LockCommand struct {
+	Op      LockOp        `json:"op"`
+	Session string        `json:"session,omitempty"` // the session (all but open)
+	Name    string        `json:"name,omitempty"`    // the lock (acquire, release)
+	Token   uint64        `json:"token,omitempty"`   // fencing token of the lock to release; zero for any (release)
+	Lease   time.Duration `json:"lease,omitempty"`   // session lease; zero for DefaultLockLease (open)
+	Now     int64         `json:"now"`               // proposing replica's time in Unix nanoseconds
+}
+
+// LockState is the state of a named lock.
+type LockState struct {
+	Name    string `json:"name"`
+	Holder  string `json:"holder,omitempty"`  // session holding the lock; empty if free
+	Token   uint64 `json:"token,omitempty"`   // fencing token of the current holder
+	Version uint64 `json:"version,omitempty"` // slot of the last change to the lock
+}
+
+// LockResult is the result of applying a LockCommand; it is sent as the JSON
+// encoded pb.Response.Result.
+type LockResult struct {
+	Session string    `json:"session,omitempty"` // the session (open, renew, close)
+	Expires int64     `json:"expires,omitempty"` // session expiry time in Unix nanoseconds (open, renew)
+	OK      bool      `json:"ok,omitempty"`      // whether the lock was acquired or released
+	State   LockState `json:"state"`             // state of the lock after the command (acquire, release)
+	Error   string    `json:"error,omitempty"`   // set if the command could not be applied
+}
+
+type lockSession struct {
+	lease   time.Duration
+	expires int64
+}
+
+// LockStateMachine is the state machine of the replicated lock service.
+type LockStateMachine struct {
+	mu       sync.RWMutex
+	now      int64
+	sessions map[string]*lockSession
+	locks    map[string]*LockState
+	changed  chan struct{} // closed and replaced when a lock changes
+}
+
+// NewLockStateMachine returns a new lock state machine without sessions or locks.
+func NewLockStateMachine() *LockStateMachine {
+	return &LockStateMachine{
+		sessions: make(map[string]*lockSession),
+		locks:    make(map[string]*LockState),
+		changed:  make(chan struct{}),
+	}
+}
+
+// State returns the state of the named lock as applied by this replica.
+func (sm *LockStateMachine) State(name string) LockState {
+	sm.mu.RLock()
+	defer sm.mu.RUnlock()
+	return sm.state(name)
+}
+
+// Sessions returns the number of sessions that have not been closed or
+// found to be expired by this replica.
+func (sm *LockStateMachine) Sessions() int {
+	sm.mu.RLock()
+	defer sm.mu.RUnlock()
+	return len(sm.sessions)
+}
+
+// Watch blocks until the version of the named lock is higher than version, or
+// until the lease of the lock's holder runs out according to this replica's
+// clock, and returns the lock's state. If ctx is done first, Watch returns the
+// current state and the context's error.
+func (sm *LockStateMachine) Watch(ctx context.Context, name string, version uint64) (LockState, error) {
+	for {
+		sm.mu.RLock()
+		state, changed := sm.state(name), sm.changed
+		expires := time.Duration(math.MaxInt64)
+		if session, ok := sm.sessions[state.Holder]; ok {
+			expires = time.Until(time.Unix(0, session.expires))
+		}
+		sm.mu.RUnlock()
+		if state.Version > version {
+			return state, nil
+		}
+		timer := time.NewTimer(expires)
+		select {
+		case <-changed:
+			timer.Stop()
+		case <-timer.C:
+			return state, nil
+		case <-ctx.Done():
+			timer.Stop()
+			return state, ctx.Err()
+		}
+	}
+}
+
+// Apply applies a decided lock service command.
+func (sm *LockStateMachine) Apply(learn *pb.LearnMsg) string {
+	var cmd LockCommand
+	var result LockResult
+	if err := json.Unmarshal([]byte(learn.GetVal().GetClientCommand()), &cmd); err != nil {
+		result.Error = fmt.Sprintf("invalid command: %v", err)
+	} else {
+		result = sm.apply(uint64(learn.GetSlot()), cmd)
+	}
+	b, _ := json.Marshal(result)
+	return string(b)
+}
+
+func (sm *LockStateMachine) apply(slot uint64, cmd LockCommand) (result LockResult) {
+	sm.mu.Lock()
+	defer sm.mu.Unlock()
+	sm.now = max(sm.now, cmd.Now)
+	changed := sm.expire(slot)
+	session, found := sm.sessions[cmd.Session]
+	if !found && cmd.Op != LockOpen && cmd.Op != LockRelease {
+		result.Error = fmt.Sprintf("unknown session %q", cmd.Session)
+		cmd.Op = ""
+	}
+	switch cmd.Op {
+	case "":
+	case LockOpen:
+		session = &lockSession{lease: cmd.Lease}
+		if session.lease <= 0 {
+			session.lease = DefaultLockLease
+		}
+		result.Session = "s" + strconv.FormatUint(slot, 10)
+		sm.sessions[result.Session] = session
+		fallthrough
+	case LockRenew:
+		session.expires = sm.now + int64(session.lease)
+		if result.Session == "" {
+			result.Session = cmd.Session
+		}
+		result.Expires = session.expires
+	case LockClose:
+		changed = sm.releaseAll(cmd.Session, slot) || changed
+		delete(sm.sessions, cmd.Session)
+		result.Session = cmd.Session
+	case LockAcquire:
+		lock, ok := sm.locks[cmd.Name]
+		if !ok {
+			lock = &LockState{Name: cmd.Name}
+			sm.locks[cmd.Name] = lock
+		}
+		switch lock.Holder {
+		case "":
+			lock.Holder, lock.Token, lock.Version = cmd.Session, slot, slot
+			changed = true
+			fallthrough
+		case cmd.Session:
+			result.OK = true
+		}
+		result.State = *lock
+	case LockRelease:
+		lock, ok := sm.locks[cmd.Name]
+		if ok && lock.Holder != "" && lock.Holder == cmd.Session && (cmd.Token == 0 || cmd.Token == lock.Token) {
+			lock.Holder, lock.Token, lock.Version = "", 0, slot
+			changed = true
+			result.OK = true
+		}
+		result.State = sm.state(cmd.Name)
+	default:
+		result.Error = fmt.Sprintf("unknown operation %q", cmd.Op)
+	}
+	if changed {
+		close(sm.changed)
+		sm.changed = make(chan struct{})
+	}
+	return result
+}
+
+// state returns the state of the named lock.
+// The caller must hold sm.mu.
+func (sm *LockStateMachine) state(name string) LockState {
+	if lock, ok := sm.locks[name]; ok {
+		return *lock
+	}
+	return LockState{Name: name}
+}
+
+// expire removes the sessions whose lease has run out and releases their
+// locks in the given slot. It returns true if a lock was released.
+// The caller must hold sm.mu.
+func (sm *LockStateMachine) expire(slot uint64) bool {
+	changed := false
+	for id, session := range sm.sessions {
+		if session.expires <= sm.now {
+			changed = sm.releaseAll(id, slot) || changed
+			delete(sm.sessions, id)
+		}
+	}
+	return changed
+}
+
+// releaseAll releases all locks held by the session in the given slot.
+// It returns true if a lock was released.
+// The caller must hold sm.mu.
+func (sm *LockStateMachine) releaseAll(session string, slot uint64) bool {
+	changed := false
+	for _, lock := range sm.locks {
+		if lock.Holder == session {
+			lock.Holder, lock.Token, lock.Version = "", 0, slot
+			changed = true
+		}
+	}
+	return changed
+}
+
+// NewLockReplica returns a Paxos replica that applies decided requests to sm
+// and serves the LockService in addition to the services served by a replica
+// returned by NewPaxosReplica.
+func NewLockReplica(myID int, nodeMap map[string]uint32, sm *LockStateMachine, options ...ReplicaOption) *PaxosReplica {
+	r := NewPaxosReplica(myID, nodeMap, append(options, WithStateMachine(sm))...)
+	lockpb.RegisterLockServiceServer(r.srv, &lockServer{proxy: newCommandProxy(r, "lock"), sm: sm})
+	return r
+}
+
+// lockServer serves the LockService of a replica.
+type lockServer struct {
+	proxy *commandProxy
+	sm    *LockStateMachine
+}
+
+// submit stamps the command with the replica's time, sends it to the Paxos
+// group and returns its result.
+func (s *lockServer) submit(ctx gorums.ServerCtx, cmd LockCommand) (LockResult, error) {
+	cmd.Now = time.Now().UnixNano()
+	var result LockResult
+	if err := s.proxy.submit(ctx, cmd, &result); err != nil {
+		return LockResult{}, err
+	}
+	if result.Error != "" {
+		return LockResult{}, errors.New(result.Error)
+	}
+	return result, nil
+}
+
+// OpenSession opens a new session with the requested lease.
+func (s *lockServer) OpenSession(ctx gorums.ServerCtx, req *lockpb.SessionRequest) (*lockpb.SessionReply, error) {
+	lease := time.Duration(req.GetLeaseMillis()) * time.Millisecond
+	result, err := s.submit(ctx, LockCommand{Op: LockOpen, Lease: lease})
+	if err != nil {
+		return nil, err
+	}
+	return &lockpb.SessionReply{SessionID: result.Session, Expires: result.Expires}, nil
+}
+
+// RenewSession extends the lease of the session.
+func (s *lockServer) RenewSession(ctx gorums.ServerCtx, req *lockpb.SessionRequest) (*lockpb.SessionReply, error) {
+	result, err := s.submit(ctx, LockCommand{Op: LockRenew, Session: req.GetSessionID()})
+	if err != nil {
+		return nil, err
+	}
+	return &lockpb.SessionReply{SessionID: result.Session, Expires: result.Expires}, nil
+}
+
+// CloseSession closes the session and releases its locks.
+func (s *lockServer) CloseSession(ctx gorums.ServerCtx, req *lockpb.SessionRequest) (*lockpb.SessionReply, error) {
+	result, err := s.submit(ctx, LockCommand{Op: LockClose, Session: req.GetSessionID()})
+	if err != nil {
+		return nil, err
+	}
+	return &lockpb.SessionReply{SessionID: result.Session}, nil
+}
+
+// Acquire acquires the lock for the session if the lock is free.
+func (s *lockServer) Acquire(ctx gorums.ServerCtx, req *lockpb.LockRequest) (*lockpb.LockReply, error) {
+	result, err := s.submit(ctx, LockCommand{Op: LockAcquire, Session: req.GetSessionID(), Name: req.GetName()})
+	if err != nil {
+		return nil, err
+	}
+	return &lockpb.LockReply{OK: result.OK, State: result.State.proto()}, nil
+}
+
+// Release releases the lock if it is held by the session with the requested token.
+func (s *lockServer) Release(ctx gorums.ServerCtx, req *lockpb.LockRequest) (*lockpb.LockReply, error) {
+	result, err := s.submit(ctx, LockCommand{Op: LockRelease, Session: req.GetSessionID(), Name: req.GetName(), Token: req.GetToken()})
+	if err != nil {
+		return nil, err
+	}
+	return &lockpb.LockReply{OK: result.OK, State: result.State.proto()}, nil
+}
+
+// Watch returns the state of the lock once its version is higher than the
+// requested version or its holder's lease runs out, or its current state when
+// the request times out.
+// The state is read from this replica's state machine, which may lag behind.
+func (s *lockServer) Watch(ctx gorums.ServerCtx, req *lockpb.WatchRequest) (*lockpb.LockState, error) {
+	ctx.Release()
+	timeout := time.Duration(req.GetTimeoutMillis()) * time.Millisecond
+	if timeout <= 0 {
+		timeout = defaultWatchTimeout
+	}
+	wctx, cancel := context.WithTimeout(ctx, timeout)
+	defer cancel()
+	state, err := s.sm.Watch(wctx, req.GetName(), req.GetVersion())
+	if err != nil && ctx.Err() != nil {
+		return nil, err
+	}
+	return state.proto(), nil
+}
+
+func (l LockState) proto() *lockpb.LockState {
+	return &lockpb.LockState{Name: l.Name, Holder: l.Holder, Token: l.Token, Version: l.Version}
+}
+
+// LockClient is a client of the lock service served by a replica.
+type LockClient struct {
+	node *lockpb.Node
+}
+
+// NewLockClient returns a lock client that sends its requests to the given
+// LockService node.
+func NewLockClient(node *lockpb.Node) *LockClient {
+	return &LockClient{node: node}
+}
+
+// OpenSession opens a new session with the given lease; zero means
+// DefaultLockLease. The session expires unless it is renewed, either by
+// calling Renew or by running KeepAlive.
+func (c *LockClient) OpenSession(ctx context.Context, lease time.Duration) (*Session, error) {
+	rsp, err := c.node.OpenSession(ctx, &lockpb.SessionRequest{LeaseMillis: lease.Milliseconds()})
+	if err != nil {
+		return nil, err
+	}
+	if lease <= 0 {
+		lease = DefaultLockLease
+	}
+	return &Session{client: c, id: rsp.GetSessionID(), lease: lease, expires: time.Unix(0, rsp.GetExpires())}, nil
+}
+
+// Watch blocks until the version of the named lock is higher than version, the
+// lease of the lock's holder runs out, or the timeout expires, and returns the
+// lock's state. A zero timeout means that the replica's default timeout is used.
+func (c *LockClient) Watch(ctx context.Context, name string, version uint64, timeout time.Duration) (*lockpb.LockState, error) {
+	return c.node.Watch(ctx, &lockpb.WatchRequest{Name: name, Version: version, TimeoutMillis: timeout.Milliseconds()})
+}
+
+// Session is a lock service session. Locks are held by sessions.
+type Session struct {
+	client *LockClient
+	id     string
+	lease  time.Duration
+
+	mu      sync.Mutex
+	expires time.Time
+}
+
+// ID returns the session's ID.
+func (s *Session) ID() string {
+	return s.id
+}
+
+// Expires returns the expiry time of the session's lease, as last reported
+// by the lock service.
+func (s *Session) Expires() time.Time {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.expires
+}
+
+// Renew extends the session's lease.
+func (s *Session) Renew(ctx context.Context) error {
+	rsp, err := s.client.node.RenewSession(ctx, &lockpb.SessionRequest{SessionID: s.id})
+	if err != nil {
+		return err
+	}
+	s.mu.Lock()
+	s.expires = time.Unix(0, rsp.GetExpires())
+	s.mu.Unlock()
+	return nil
+}
+
+// KeepAlive renews the session's lease three times per lease until ctx is
+// done or a renewal fails. It returns the error of the failed renewal, or
+// nil if ctx is done.
+func (s *Session) KeepAlive(ctx context.Context) error {
+	ticker := time.NewTicker(s.lease / 3)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-ctx.Done():
+			return nil
+		case <-ticker.C:
+			rctx, cancel := context.WithTimeout(ctx, s.lease)
+			err := s.Renew(rctx)
+			cancel()
+			if err != nil && ctx.Err() == nil {
+				return err
+			}
+		}
+	}
+}
+
+// Close closes the session and releases its locks.
+func (s *Session) Close(ctx context.Context) error {
+	_, err := s.client.node.CloseSession(ctx, &lockpb.SessionRequest{SessionID: s.id})
+	return err
+}
+
+// TryAcquire acquires the named lock if it is free or already held by the
+// session. It returns the lock's state and whether the lock is held by the
+// session; if so, the state's token is the lock's fencing token.
+func (s *Session) TryAcquire(ctx context.Context, name string) (*lockpb.LockState, bool, error) {
+	rsp, err := s.client.node.Acquire(ctx, &lockpb.LockRequest{SessionID: s.id, Name: name})
+	if err != nil {
+		return nil, false, err
+	}
+	return rsp.GetState(), rsp.GetOK(), nil
+}
+
+// Acquire blocks until the session holds the named lock and returns the
+// lock's fencing token. While the lock is held by another session, Acquire
+// watches the lock and retries when it changes or the holder's lease runs out.
+func (s *Session) Acquire(ctx context.Context, name string) (uint64, error) {
+	for {
+		state, ok, err := s.TryAcquire(ctx, name)
+		if err != nil {
+			return 0, err
+		}
+		if ok {
+			return state.GetToken(), nil
+		}
+		if _, err := s.client.Watch(ctx, name, state.GetVersion(), 0); err != nil {
+			return 0, err
+		}
+	}
+}
+
+// Release releases the named lock if it is held by the session with the given
+// fencing token; zero releases the lock regardless of its token. It returns
+// whether the lock was released.
+func (s *Session) Release(ctx context.Context, name string, token uint64) (bool, error) {
+	rsp, err := s.client.node.Release(ctx, &lockpb.LockRequest{SessionID: s.id, Name: name, Token: token})
+	if err != nil {
+		return false, err
+	}
+	return rsp.GetOK(), nil
+}
@@ -0,0 +1,228 @@
+package gorumspaxos
+
+import (
+	"context"
+	"encoding/json"
+	"testing"
+	"time"
+
+	pb "dat520/lab5/gorumspaxos/proto"
+	lockpb "dat520/lab5/gorumspaxos/proto/lock"
+
+	"github.com/google/go-cmp/cmp"
+	"github.com/relab/gorums"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/credentials/insecure"
+	"google.golang.org/protobuf/testing/protocmp"
+)
+
+func TestLockStateMachine(t *testing.T) {
+	sm := NewLockStateMachine()
+	sec := int64(time.Second)
+	tests := []struct {
+		cmd  LockCommand
+		want LockResult
+	}{
+		/* 1 */ {cmd: LockCommand{Op: LockOpen, Lease: time.Second, Now: 10 * sec}, want: LockResult{Session: "s1", Expires: 11 * sec}},
+		/* 2 */ {cmd: LockCommand{Op: LockOpen, Now: 10 * sec}, want: LockResult{Session: "s2", Expires: 10*sec + int64(DefaultLockLease)}},
+		/* 3 */ {cmd: LockCommand{Op: LockAcquire, Session: "s1", Name: "a", Now: 10 * sec}, want: LockResult{OK: true, State: LockState{Name: "a", Holder: "s1", Token: 3, Version: 3}}},
+		/* 4 */ {cmd: LockCommand{Op: LockAcquire, Session: "s1", Name: "a", Now: 10 * sec}, want: LockResult{OK: true, State: LockState{Name: "a", Holder: "s1", Token: 3, Version: 3}}},
+		/* 5 */ {cmd: LockCommand{Op: LockAcquire, Session: "s2", Name: "a", Now: 10 * sec}, want: LockResult{State: LockState{Name: "a", Holder: "s1", Token: 3, Version: 3}}},
+		/* 6 */ {cmd: LockCommand{Op: LockRelease, Session: "s2", Name: "a", Now: 10 * sec}, want: LockResult{State: LockState{Name: "a", Holder: "s1", Token: 3, Version: 3}}},
+		/* 7 */ {cmd: LockCommand{Op: LockRelease, Session: "s1", Name: "a", Token: 2, Now: 10 * sec}, want: LockResult{State: LockState{Name: "a", Holder: "s1", Token: 3, Version: 3}}},
+		/* 8 */ {cmd: LockCommand{Op: LockRelease, Session: "s1", Name: "a", Token: 3, Now: 10 * sec}, want: LockResult{OK: true, State: LockState{Name: "a", Version: 8}}},
+		/* 9 */ {cmd: LockCommand{Op: LockAcquire, Session: "s2", Name: "a", Now: 10 * sec}, want: LockResult{OK: true, State: LockState{Name: "a", Holder: "s2", Token: 9, Version: 9}}},
+		/* 10 */ {cmd: LockCommand{Op: LockAcquire, Session: "s1", Name: "b", Now: 10 * sec}, want: LockResult{OK: true, State: LockState{Name: "b", Holder: "s1", Token: 10, Version: 10}}},
+		/* 11 */ {cmd: LockCommand{Op: LockRenew, Session: "s1", Now: 10*sec + sec/2}, want: LockResult{Session: "s1", Expires: 11*sec + sec/2}},
+		// time runs backwards on this proposer; the state machine's clock does not
+		/* 12 */ {cmd: LockCommand{Op: LockAcquire, Session: "s2", Name: "b", Now: 5 * sec}, want: LockResult{State: LockState{Name: "b", Holder: "s1", Token: 10, Version: 10}}},
+		// s1 expires; its lock is released before the command is applied
+		/* 13 */ {cmd: LockCommand{Op: LockAcquire, Session: "s2", Name: "b", Now: 12 * sec}, want: LockResult{OK: true, State: LockState{Name: "b", Holder: "s2", Token: 13, Version: 13}}},
+		/* 14 */ {cmd: LockCommand{Op: LockRenew, Session: "s1", Now: 12 * sec}, want: LockResult{Error: `unknown session "s1"`}},
+		/* 15 */ {cmd: LockCommand{Op: LockAcquire, Session: "s1", Name: "c", Now: 12 * sec}, want: LockResult{Error: `unknown session "s1"`}},
+		/* 16 */ {cmd: LockCommand{Op: LockClose, Session: "s2", Now: 12 * sec}, want: LockResult{Session: "s2"}},
+		/* 17 */ {cmd: LockCommand{Op: LockRelease, Session: "s2", Name: "a", Now: 12 * sec}, want: LockResult{State: LockState{Name: "a", Version: 16}}},
+		/* 18 */ {cmd: LockCommand{Op: "steal", Name: "a", Now: 12 * sec}, want: LockResult{Error: `unknown session ""`}},
+	}
+	for i, test := range tests {
+		b, err := json.Marshal(test.cmd)
+		if err != nil {
+			t.Fatal(err)
+		}
+		learn := &pb.LearnMsg{Slot: Slot(i + 1), Val: &pb.Value{ClientCommand: string(b)}}
+		var got LockResult
+		if err := json.Unmarshal([]byte(sm.Apply(learn)), &got); err != nil {
+			t.Fatal(err)
+		}
+		if diff := cmp.Diff(test.want, got); diff != "" {
+			t.Errorf("Apply(%+v) in slot %d mismatch (-want +got):\n%s", test.cmd, i+1, diff)
+		}
+	}
+	if got := sm.Sessions(); got != 0 {
+		t.Errorf("Sessions() = %d, want 0", got)
+	}
+	want := []LockState{{Name: "a", Version: 16}, {Name: "b", Version: 16}, {Name: "c"}}
+	for _, w := range want {
+		if diff := cmp.Diff(w, sm.State(w.Name)); diff != "" {
+			t.Errorf("State(%s) mismatch (-want +got):\n%s", w.Name, diff)
+		}
+	}
+}
+
+func TestLockStateMachineWatch(t *testing.T) {
+	sm := NewLockStateMachine()
+	apply := func(slot Slot, cmd LockCommand) {
+		b, _ := json.Marshal(cmd)
+		sm.Apply(&pb.LearnMsg{Slot: slot, Val: &pb.Value{ClientCommand: string(b)}})
+	}
+	apply(1, LockCommand{Op: LockOpen})
+
+	ctx, cancel := context.WithTimeout(context.Background(), 50*time.Millisecond)
+	defer cancel()
+	if state, err := sm.Watch(ctx, "a", 0); err == nil {
+		t.Errorf("Watch(a, 0) = %+v, %v; want timeout", state, err)
+	}
+
+	watched := make(chan LockState)
+	go func() {
+		state, err := sm.Watch(context.Background(), "a", 0)
+		if err != nil {
+			t.Error(err)
+		}
+		watched <- state
+	}()
+	// changes to other locks and sessions do not notify the watcher
+	apply(2, LockCommand{Op: LockOpen})
+	apply(3, LockCommand{Op: LockAcquire, Session: "s1", Name: "b"})
+	apply(4, LockCommand{Op: LockAcquire, Session: "s2", Name: "a"})
+	want := LockState{Name: "a", Holder: "s2", Token: 4, Version: 4}
+	select {
+	case got := <-watched:
+		if diff := cmp.Diff(want, got); diff != "" {
+			t.Errorf("Watch(a, 0) mismatch (-want +got):\n%s", diff)
+		}
+	case <-time.After(time.Second):
+		t.Fatal("Watch(a, 0) was not notified")
+	}
+
+	// watching an older version returns immediately
+	got, err := sm.Watch(context.Background(), "a", 3)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if diff := cmp.Diff(want, got); diff != "" {
+		t.Errorf("Watch(a, 3) mismatch (-want +got):\n%s", diff)
+	}
+}
+
+// startLockReplicas starts numServers replicas running the lock service and
+// returns a lock client for each replica in replica order.
+func startLockReplicas(t *testing.T, numServers int) ([]*LockClient, []*LockStateMachine) {
+	t.Helper()
+	sms := make([]*LockStateMachine, numServers)
+	nodeMap, _ := serveReplicas(t, numServers, func(id int, nodeMap map[string]uint32) *PaxosReplica {
+		sms[id] = NewLockStateMachine()
+		return NewLockReplica(id, nodeMap, sms[id])
+	})
+
+	mgr := lockpb.NewManager(gorums.WithDialTimeout(5*time.Second),
+		gorums.WithGrpcDialOptions(
+			grpc.WithBlock(), // block until connections are made
+			grpc.WithTransportCredentials(insecure.NewCredentials()), // disable TLS
+		),
+	)
+	t.Cleanup(mgr.Close)
+	cfg, err := mgr.NewConfiguration(gorums.WithNodeMap(nodeMap))
+	if err != nil {
+		t.Fatal(err)
+	}
+	clients := make([]*LockClient, numServers)
+	for _, node := range cfg.Nodes() {
+		clients[node.ID()] = NewLockClient(node)
+	}
+	return clients, sms
+}
+
+func TestLockService(t *testing.T) {
+	clients, sms := startLockReplicas(t, 3)
+	ctx, cancel := context.WithTimeout(context.Background(), 60*time.Second)
+	defer cancel()
+
+	alice, err := clients[0].OpenSession(ctx, time.Minute)
+	if err != nil {
+		t.Fatal(err)
+	}
+	bob, err := clients[1].OpenSession(ctx, 3*time.Second)
+	if err != nil {
+		t.Fatal(err)
+	}
+	kctx, stopKeepAlive := context.WithCancel(ctx)
+	go bob.KeepAlive(kctx)
+	if alice.ID() == bob.ID() {
+		t.Fatalf("sessions have the same ID %q", alice.ID())
+	}
+
+	token1, err := alice.Acquire(ctx, "leader")
+	if err != nil {
+		t.Fatal(err)
+	}
+	state, ok, err := bob.TryAcquire(ctx, "leader")
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := &lockpb.LockState{Name: "leader", Holder: alice.ID(), Token: token1, Version: token1}
+	if diff := cmp.Diff(want, state, protocmp.Transform()); ok || diff != "" {
+		t.Errorf("TryAcquire(leader) = %t, mismatch (-want +got):\n%s", ok, diff)
+	}
+
+	// bob blocks until alice releases the lock
+	acquired := make(chan uint64)
+	go func() {
+		token, err := bob.Acquire(ctx, "leader")
+		if err != nil {
+			t.Error(err)
+		}
+		acquired <- token
+	}()
+	time.Sleep(500 * time.Millisecond)
+	if released, err := alice.Release(ctx, "leader", token1); err != nil || !released {
+		t.Fatalf("Release(leader, %d) = %t, %v; want true, nil", token1, released, err)
+	}
+	var token2 uint64
+	select {
+	case token2 = <-acquired:
+	case <-ctx.Done():
+		t.Fatal("Acquire(leader) was not granted after release")
+	}
+	if token2 <= token1 {
+		t.Errorf("fencing token %d of the second holder is not higher than %d", token2, token1)
+	}
+
+	// alice blocks until bob's session expires
+	stopKeepAlive()
+	start := time.Now()
+	token3, err := alice.Acquire(ctx, "leader")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if token3 <= token2 {
+		t.Errorf("fencing token %d of the third holder is not higher than %d", token3, token2)
+	}
+	t.Logf("lock granted %v after bob stopped renewing", time.Since(start))
+	if err := bob.Renew(ctx); err == nil {
+		t.Error("Renew() of expired session succeeded")
+	}
+	if err := alice.Close(ctx); err != nil {
+		t.Fatal(err)
+	}
+
+	// all replicas apply the same commands; the replica outside the quorum may lag behind
+	for i, sm := range sms {
+		for sm.Sessions() != 0 && ctx.Err() == nil {
+			time.Sleep(10 * time.Millisecond)
+		}
+		if got := sm.State("leader"); got.Holder != "" || got.Version <= token3 {
+			t.Errorf("replica %d: State(leader) = %+v, want released after slot %d", i, got, token3)
+		}
+	}
+}
@@ -0,0 +1,20 @@
+package gorumspaxos
+
+// ReplicaOption configures optional behavior of a PaxosReplica.
+type ReplicaOption func(*PaxosReplica)
+
+// WithAdmissionConfig sets the limits used to admit client requests
+// into the replica's request queue.
+func WithAdmissionConfig(cfg AdmissionConfig) ReplicaOption {
+	return func(r *PaxosReplica) {
+		r.SetAdmissionConfig(cfg)
+	}
+}
+
+// WithTracer sets the tracer used to record spans for client requests
+// as they pass through the replica.
+func WithTracer(tracer *Tracer) ReplicaOption {
+	return func(r *PaxosReplica) {
+		r.Proposer.tracer = tracer
+	}
+}
@@ -0,0 +1,203 @@
+package gorumspaxos
+
+import (
+	"sync"
+	"sync/atomic"
+	"time"
+
+	pb "dat520/lab5/gorumspaxos/proto"
+)
+
+const (
+	// decidedResponseTTL is the duration a response to a decided request is
+	// kept for a ClientHandle call that has not yet reached the replica.
+	decidedResponseTTL = 15 * responseTimeout
+	// sweepInterval is the minimum duration between two sweeps of the registry.
+	sweepInterval = time.Second
+)
+
+// pendingRequests matches decided requests with the ClientHandle calls waiting
+// for them. Requests are identified by Value.Hash, so that the calls of a client
+// that retries a request all wait for the same decision.
+//
+// Each waiting call removes itself from the registry when it returns. Waiters
+// that are still registered after their deadline, and responses that no call
+// has claimed within decidedResponseTTL, are removed by sweeps done by the
+// registry's other operations.
+type pendingRequests struct {
+	mu        sync.Mutex
+	waiters   map[uint64][]*pendingWaiter
+	responses map[uint64]decidedResponse
+	lastSweep time.Time
+	now       func() time.Time // returns the current time; replaced in tests
+	counters  pendingCounters
+}
+
+// pendingWaiter is a ClientHandle call waiting for a request to be decided.
+// The response is sent on ch, which is closed if the waiter is swept.
+type pendingWaiter struct {
+	ch       chan *pb.Response
+	deadline time.Time
+}
+
+// decidedResponse is the response to a decided request that no call has waited for.
+type decidedResponse struct {
+	rsp     *pb.Response
+	decided time.Time
+}
+
+// pendingCounters counts the outcomes of the calls waiting for responses.
+type pendingCounters struct {
+	delivered atomic.Uint64 // responses sent to waiting calls
+	stored    atomic.Uint64 // responses to calls that had not yet arrived
+	cancelled atomic.Uint64 // calls whose client went away before the decision
+	timedOut  atomic.Uint64 // calls that were not answered in time
+	expired   atomic.Uint64 // stored responses removed before any call claimed them
+}
+
+// PendingStats is a snapshot of a replica's pending request metrics.
+type PendingStats struct {
+	Requests  int    // distinct requests with waiting calls
+	Waiters   int    // calls waiting for a response
+	Responses int    // responses stored for calls that have not arrived yet
+	Delivered uint64 // responses sent to waiting calls, including stored responses
+	Stored    uint64 // responses stored because no call was waiting
+	Cancelled uint64 // calls cancelled by their client
+	TimedOut  uint64 // calls that were not answered in time
+	Expired   uint64 // stored responses that were never claimed
+}
+
+func newPendingRequests() *pendingRequests {
+	return &pendingRequests{
+		waiters:   make(map[uint64][]*pendingWaiter),
+		responses: make(map[uint64]decidedResponse),
+		now:       time.Now,
+	}
+}
+
+// add registers a call waiting for the request with the given hash until the
+// timeout expires. If the request has already been decided, its response is
+// returned instead, and no waiter is registered.
+func (p *pendingRequests) add(hash uint64, timeout time.Duration) (*pendingWaiter, *pb.Response) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	now := p.now()
+	p.sweep(now)
+	if decided, ok := p.responses[hash]; ok {
+		delete(p.responses, hash)
+		p.counters.delivered.Add(1)
+		return nil, decided.rsp
+	}
+	w := &pendingWaiter{ch: make(chan *pb.Response, 1), deadline: now.Add(timeout)}
+	p.waiters[hash] = append(p.waiters[hash], w)
+	return w, nil
+}
+
+// remove unregisters the waiter, which gave up waiting because its client
+// cancelled the call or, if timedOut is true, because its timeout expired.
+// Removing a waiter that has been answered or swept has no effect.
+func (p *pendingRequests) remove(hash uint64, w *pendingWaiter, timedOut bool) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	if !p.unregister(hash, w) {
+		return
+	}
+	if timedOut {
+		p.counters.timedOut.Add(1)
+	} else {
+		p.counters.cancelled.Add(1)
+	}
+}
+
+// resolve sends the response to all calls waiting for the request with the
+// given hash, or stores it for a later call if none are waiting.
+func (p *pendingRequests) resolve(hash uint64, rsp *pb.Response) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	now := p.now()
+	p.sweep(now)
+	waiters, ok := p.waiters[hash]
+	if !ok {
+		p.responses[hash] = decidedResponse{rsp: rsp, decided: now}
+		p.counters.stored.Add(1)
+		return
+	}
+	delete(p.waiters, hash)
+	for _, w := range waiters {
+		w.ch <- rsp // never blocks; each waiter is answered once
+	}
+	p.counters.delivered.Add(uint64(len(waiters)))
+}
+
+// unregister removes the waiter and returns true if it was registered.
+// The caller must hold p.mu.
+func (p *pendingRequests) unregister(hash uint64, w *pendingWaiter) bool {
+	waiters := p.waiters[hash]
+	for i, other := range waiters {
+		if other != w {
+			continue
+		}
+		waiters = append(waiters[:i:i], waiters[i+1:]...)
+		if len(waiters) == 0 {
+			delete(p.waiters, hash)
+		} else {
+			p.waiters[hash] = waiters
+		}
+		return true
+	}
+	return false
+}
+
+// sweep removes the waiters whose deadline has passed, closing their channels,
+// and the responses that have been stored for longer than decidedResponseTTL.
+// It does nothing if the registry was swept less than sweepInterval ago.
+// The caller must hold p.mu.
+func (p *pendingRequests) sweep(now time.Time) {
+	if now.Sub(p.lastSweep) < sweepInterval {
+		return
+	}
+	p.lastSweep = now
+	for hash, waiters := range p.waiters {
+		for _, w := range waiters {
+			if now.After(w.deadline) {
+				p.unregister(hash, w)
+				close(w.ch)
+				p.counters.timedOut.Add(1)
+			}
+		}
+	}
+	for hash, decided := range p.responses {
+		if now.Sub(decided.decided) > decidedResponseTTL {
+			delete(p.responses, hash)
+			p.counters.expired.Add(1)
+		}
+	}
+}
+
+// ids returns the hashes of the requests with waiting calls or stored responses.
+func (p *pendingRequests) ids() []uint64 {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	return append(Keys(p.waiters), Keys(p.responses)...)
+}
+
+// stats returns a snapshot of the registry's metrics.
+func (p *pendingRequests) stats() PendingStats {
+	p.mu.Lock()
+	stats := PendingStats{Requests: len(p.waiters), Responses: len(p.responses)}
+	for _, waiters := range p.waiters {
+		stats.Waiters += len(waiters)
+	}
+	p.mu.Unlock()
+	stats.Delivered = p.counters.delivered.Load()
+	stats.Stored = p.counters.stored.Load()
+	stats.Cancelled = p.counters.cancelled.Load()
+	stats.TimedOut = p.counters.timedOut.Load()
+	stats.Expired = p.counters.expired.Load()
+	return stats
+}
+
+// PendingStats returns a snapshot of the replica's pending request metrics.
+func (r *PaxosReplica) PendingStats() PendingStats {
+	return r.pending.stats()
+}
@@ -0,0 +1,175 @@
+package gorumspaxos
+
+import (
+	"sync"
+	"testing"
+	"time"
+
+	pb "dat520/lab5/gorumspaxos/proto"
+
+	"github.com/google/go-cmp/cmp"
+	"github.com/relab/gorums"
+	"google.golang.org/protobuf/testing/protocmp"
+)
+
+// newTestPendingRequests returns a registry whose clock is advanced by the returned function.
+func newTestPendingRequests() (*pendingRequests, func(time.Duration)) {
+	now := time.Unix(0, 0)
+	p := newPendingRequests()
+	p.now = func() time.Time { return now }
+	return p, func(d time.Duration) { now = now.Add(d) }
+}
+
+// received returns the response sent to the waiter and whether the waiter's channel is open.
+func received(t *testing.T, w *pendingWaiter) (*pb.Response, bool) {
+	t.Helper()
+	select {
+	case rsp, ok := <-w.ch:
+		return rsp, ok
+	default:
+		t.Fatal("no response sent to waiter")
+		return nil, false
+	}
+}
+
+func TestPendingRequests(t *testing.T) {
+	rsp := &pb.Response{ClientID: "A", ClientSeq: 1, ClientCommand: "cmd"}
+
+	t.Run("MultipleWaiters", func(t *testing.T) {
+		p, _ := newTestPendingRequests()
+		w1, _ := p.add(1, time.Second)
+		w2, _ := p.add(1, time.Second)
+		other, _ := p.add(2, time.Second)
+		p.resolve(1, rsp)
+		for _, w := range []*pendingWaiter{w1, w2} {
+			if got, ok := received(t, w); !ok || got != rsp {
+				t.Errorf("waiter received (%v, %t), want (%v, true)", got, ok, rsp)
+			}
+		}
+		select {
+		case got := <-other.ch:
+			t.Errorf("waiter for another request received %v", got)
+		default:
+		}
+		// removing an answered waiter has no effect
+		p.remove(1, w1, false)
+		want := PendingStats{Requests: 1, Waiters: 1, Delivered: 2}
+		if diff := cmp.Diff(want, p.stats()); diff != "" {
+			t.Errorf("stats() mismatch (-want +got):\n%s", diff)
+		}
+	})
+
+	t.Run("DecidedBeforeWaiting", func(t *testing.T) {
+		p, _ := newTestPendingRequests()
+		p.resolve(1, rsp)
+		if w, got := p.add(1, time.Second); w != nil || got != rsp {
+			t.Errorf("add() = (%v, %v), want (nil, %v)", w, got, rsp)
+		}
+		// the stored response is claimed only once
+		if w, got := p.add(1, time.Second); w == nil || got != nil {
+			t.Errorf("add() = (%v, %v), want a waiter", w, got)
+		}
+		want := PendingStats{Requests: 1, Waiters: 1, Delivered: 1, Stored: 1}
+		if diff := cmp.Diff(want, p.stats()); diff != "" {
+			t.Errorf("stats() mismatch (-want +got):\n%s", diff)
+		}
+	})
+
+	t.Run("Remove", func(t *testing.T) {
+		p, _ := newTestPendingRequests()
+		cancelled, _ := p.add(1, time.Second)
+		timedOut, _ := p.add(1, time.Second)
+		w, _ := p.add(1, time.Second)
+		p.remove(1, cancelled, false)
+		p.remove(1, timedOut, true)
+		p.resolve(1, rsp)
+		if got, ok := received(t, w); !ok || got != rsp {
+			t.Errorf("waiter received (%v, %t), want (%v, true)", got, ok, rsp)
+		}
+		for _, removed := range []*pendingWaiter{cancelled, timedOut} {
+			if len(removed.ch) != 0 {
+				t.Error("response sent to removed waiter")
+			}
+		}
+		want := PendingStats{Delivered: 1, Cancelled: 1, TimedOut: 1}
+		if diff := cmp.Diff(want, p.stats()); diff != "" {
+			t.Errorf("stats() mismatch (-want +got):\n%s", diff)
+		}
+	})
+
+	t.Run("Sweep", func(t *testing.T) {
+		p, advance := newTestPendingRequests()
+		advance(sweepInterval)
+		overdue, _ := p.add(1, time.Second)
+		w, _ := p.add(2, 2*decidedResponseTTL)
+		p.resolve(3, rsp)
+		// the registry is not swept again until sweepInterval has passed
+		advance(sweepInterval / 2)
+		p.add(4, time.Second)
+		if len(p.waiters[1]) != 1 {
+			t.Fatal("waiter swept before sweepInterval")
+		}
+		advance(sweepInterval)
+		p.resolve(5, rsp)
+		if _, ok := received(t, overdue); ok {
+			t.Error("overdue waiter not closed by sweep")
+		}
+		// removing a swept waiter has no effect
+		p.remove(1, overdue, true)
+		select {
+		case <-w.ch:
+			t.Error("waiter swept before its deadline")
+		default:
+		}
+		want := PendingStats{Requests: 2, Waiters: 2, Responses: 2, Stored: 2, TimedOut: 1}
+		if diff := cmp.Diff(want, p.stats()); diff != "" {
+			t.Errorf("stats() mismatch (-want +got):\n%s", diff)
+		}
+
+		// unclaimed responses expire after decidedResponseTTL
+		advance(decidedResponseTTL)
+		p.add(6, time.Minute)
+		want = PendingStats{Requests: 2, Waiters: 2, Responses: 1, Stored: 2, TimedOut: 2, Expired: 1}
+		if diff := cmp.Diff(want, p.stats()); diff != "" {
+			t.Errorf("stats() mismatch (-want +got):\n%s", diff)
+		}
+		if _, ok := p.responses[5]; !ok {
+			t.Error("response expired before decidedResponseTTL")
+		}
+	})
+}
+
+func TestClientHandleMultipleWaiters(t *testing.T) {
+	r := newTestReplicaLeader()
+	val := &pb.Value{ClientID: "A", ClientSeq: 1, ClientCommand: "cmd"}
+	want := &pb.Response{ClientID: "A", ClientSeq: 1, ClientCommand: "cmd"}
+
+	// a client retrying its request has several calls waiting for the same decision
+	const calls = 3
+	var wg sync.WaitGroup
+	rsps := make([]*pb.Response, calls)
+	errs := make([]error, calls)
+	for i := range calls {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			rsps[i], errs[i] = r.ClientHandle(gorums.ServerCtx{}, val)
+		}()
+	}
+	for r.PendingStats().Waiters < calls {
+		time.Sleep(time.Millisecond)
+	}
+	r.Commit(gorums.ServerCtx{}, &pb.LearnMsg{Slot: 1, Val: val})
+	wg.Wait()
+	for i := range calls {
+		if errs[i] != nil {
+			t.Fatalf("ClientHandle() = %v, want nil", errs[i])
+		}
+		if diff := cmp.Diff(want, rsps[i], protocmp.Transform()); diff != "" {
+			t.Errorf("ClientHandle() mismatch (-want +got):\n%s", diff)
+		}
+	}
+	if got := r.remainingResponses(); got != 0 {
+		t.Errorf("remainingResponses() = %d, want 0", got)
+	}
+}
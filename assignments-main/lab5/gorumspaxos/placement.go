@@ -0,0 +1,38 @@
+package gorumspaxos
+
+import (
+	"dat520/lab3/gorumsfd"
+	"dat520/lab3/leaderdetector"
+)
+
+// WithLeaderPlacement makes the replica use a latency-aware leader detector
+// instead of the monarchical leader detector, which elects the highest
+// non-suspected node ID. The leader is placed on the node with the lowest RTTs
+// to a majority of the nodes, as measured by the failure detector's heartbeats,
+// unless the configuration's priority list prefers another node.
+// The groups of a ShardedReplica share the leader detector of group 0.
+func WithLeaderPlacement(cfg leaderdetector.LatencyConfig) ReplicaOption {
+	return func(r *PaxosReplica) {
+		r.placement = &cfg
+	}
+}
+
+// newDetectors returns the leader detector, suspect tracker and failure
+// detector of the replica with the given id. The leader detector is
+// latency-aware if placement is non-nil, and monarchical otherwise.
+func newDetectors(myID int, nodeMap map[string]uint32, placement *leaderdetector.LatencyConfig) (leaderdetector.LeaderDetector, *suspectTracker, *gorumsfd.GorumsFailureDetector) {
+	nodeIDs := make([]int, 0, len(nodeMap))
+	for _, id := range nodeMap {
+		nodeIDs = append(nodeIDs, int(id))
+	}
+	if placement == nil {
+		ld := leaderdetector.NewMonLeaderDetector(nodeIDs)
+		suspects := newSuspectTracker(ld)
+		return ld, suspects, gorumsfd.NewGorumsFailureDetector(uint32(myID), suspects, delta)
+	}
+	ld := leaderdetector.NewLatencyLeaderDetector(nodeIDs, *placement)
+	suspects := newSuspectTracker(ld)
+	failureDetector := gorumsfd.NewGorumsFailureDetector(uint32(myID), suspects, delta)
+	failureDetector.SetLatencyObserver(ld)
+	return ld, suspects, failureDetector
+}
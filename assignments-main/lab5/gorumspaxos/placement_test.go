@@ -0,0 +1,57 @@
+package gorumspaxos
+
+import (
+	"context"
+	"fmt"
+	"testing"
+	"time"
+
+	"dat520/lab3/leaderdetector"
+	pb "dat520/lab5/gorumspaxos/proto"
+)
+
+func TestLeaderPlacement(t *testing.T) {
+	const numReplicas = 3
+	// the operator places the leader on node 0 rather than the highest ID
+	cfg := leaderdetector.LatencyConfig{Priorities: []int{0}}
+	nodeMap, replicas := serveReplicas(t, numReplicas, func(id int, nodeMap map[string]uint32) *PaxosReplica {
+		return NewPaxosReplica(id, nodeMap, WithLeaderPlacement(cfg))
+	})
+
+	config, closeMgr, err := newConfiguration(nodeMap)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer closeMgr()
+	for k := range 3 {
+		ctx, cancel := context.WithTimeout(context.Background(), waitTimeForRequest)
+		req := &pb.Value{ClientID: "placement", ClientSeq: uint32(k), ClientCommand: fmt.Sprint(k)}
+		_, err := config.ClientHandle(ctx, req)
+		cancel()
+		if err != nil {
+			t.Fatalf("ClientHandle(%v) error = %v", req, err)
+		}
+	}
+	for _, r := range replicas {
+		if got := r.leaderDetector.Leader(); got != 0 {
+			t.Errorf("replica %d: Leader() = %d, want 0", r.id, got)
+		}
+		if r.isLeader() != (r.id == 0) {
+			t.Errorf("replica %d: isLeader() = %t, want %t", r.id, r.isLeader(), r.id == 0)
+		}
+	}
+	// the heartbeats measure the RTTs between the replicas
+	ld := replicas[0].leaderDetector.(*leaderdetector.LatencyLeaderDetector)
+	deadline := time.Now().Add(5 * time.Second)
+	for {
+		_, ok01 := ld.RTT(0, 1)
+		_, ok12 := ld.RTT(1, 2)
+		if ok01 && ok12 {
+			break
+		}
+		if time.Now().After(deadline) {
+			t.Fatal("RTTs between the replicas not measured")
+		}
+		time.Sleep(10 * time.Millisecond)
+	}
+}
@@ -1,10 +1,15 @@
 package gorumspaxos
 
 import (
+	"context"
+	"errors"
+	"slices"
 	"sync"
 	"time"
 
 	pb "dat520/lab5/gorumspaxos/proto"
+
+	"github.com/relab/gorums"
 )
 
 const (
@@ -19,16 +24,21 @@ const (
 // Proposer represents a proposer as defined by the Multi-Paxos algorithm.
 type Proposer struct {
 	mu                 sync.RWMutex
-	id                 int               // replica's id.
-	leader             int               // current Paxos leader.
-	crnd               Round             // replica's current round; initially, this replica's id.
-	adu                Slot              // all-decided-up-to is the highest consecutive slot that has been committed.
-	nextSlot           Slot              // slot for the next request, initially 0.
-	phaseOneDone       bool              // indicates if the phase1 is done, initially false.
-	config             MultiPaxosConfig  // configuration used for multipaxos.
-	nodeMap            map[string]uint32 // map of the address to the node id.
-	acceptMsgQueue     []*pb.AcceptMsg   // queue of pending accept messages as part of prepare operation.
-	clientRequestQueue []*pb.AcceptMsg   // queue of pending client requests.
+	id                 int                    // replica's id.
+	leader             int                    // current Paxos leader.
+	crnd               Round                  // replica's current round; initially, this replica's id.
+	adu                Slot                   // all-decided-up-to is the highest consecutive slot that has been committed.
+	nextSlot           Slot                   // slot for the next request, initially 0.
+	phaseOneDone       bool                   // indicates if the phase1 is done, initially false.
+	config             MultiPaxosConfig       // configuration used for multipaxos.
+	nodeMap            map[string]uint32      // map of the address to the node id.
+	acceptMsgQueue     []*pb.AcceptMsg        // queue of pending accept messages as part of prepare operation.
+	clientRequestQueue []*pb.AcceptMsg        // queue of pending client requests.
+	admission          AdmissionConfig        // limits on the clientRequestQueue.
+	admissionMetrics   admissionCounters      // counts of admitted and rejected client requests.
+	tracer             *Tracer                // tracer for proposer spans; nil disables tracing.
+	queueSpans         map[uint64]*ActiveSpan // spans for client requests waiting in the clientRequestQueue.
+	paused             bool                   // indicates if proposing has been paused by an operator.
 }
 
 // NewProposer returns a new Multi-Paxos proposer with the specified
@@ -42,23 +52,49 @@ func NewProposer(myID, leader int, nodeMap map[string]uint32) *Proposer {
 		crnd:               Round(propIdx),
 		acceptMsgQueue:     make([]*pb.AcceptMsg, 0),
 		clientRequestQueue: make([]*pb.AcceptMsg, 0),
+		admission:          DefaultAdmissionConfig,
+		queueSpans:         make(map[uint64]*ActiveSpan),
 	}
 }
 
 // newLeader updates the current leader and crnd.
+// If this replica is the new leader, it moves to its next round
+// and must run phase one again before proposing; otherwise its
+// pending requests are dropped.
 func (p *Proposer) newLeader(leader int) {
-	// TODO(student): complete
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	p.leader = leader
+	p.phaseOneDone = false
+	p.acceptMsgQueue = nil
+	if leader != p.id {
+		// the new leader proposes the requests sent to it by the clients
+		p.clientRequestQueue = nil
+		p.dropQueueSpans("dropped by leader change")
+		return
+	}
+	p.crnd += Round(len(p.nodeMap))
 }
 
 // isLeader returns true if this replica is the leader.
 func (p *Proposer) isLeader() bool {
-	// TODO(student): complete
-	return false
+	p.mu.RLock()
+	defer p.mu.RUnlock()
+	return p.leader == p.id
 }
 
 // advanceAllDecidedUpTo increments the highest consecutive slot that has been committed.
 func (p *Proposer) advanceAllDecidedUpTo() uint32 {
-	// TODO(student): complete
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	p.adu++
+	return p.adu
+}
+
+// allDecidedUpTo returns the highest consecutive slot that has been committed.
+func (p *Proposer) allDecidedUpTo() Slot {
+	p.mu.RLock()
+	defer p.mu.RUnlock()
 	return p.adu
 }
 
@@ -76,7 +112,31 @@ func (p *Proposer) advanceAllDecidedUpTo() uint32 {
 //  5. Advance the nextSlot to adu+1.
 //  6. Set phaseOneDone to true.
 func (p *Proposer) runPhaseOne() error {
-	// TODO(student): complete
+	span := p.tracer.Start("Proposer.PhaseOne", nil)
+	defer span.End()
+	p.mu.RLock()
+	prepare := &pb.PrepareMsg{Slot: p.adu + 1, Crnd: p.crnd}
+	config := p.config
+	p.mu.RUnlock()
+	span.setMessage(prepare.GetSlot(), prepare.GetCrnd(), nil)
+
+	ctx, cancel := context.WithTimeout(context.Background(), promiseTimeout)
+	defer cancel()
+	promise, err := config.Prepare(ctx, prepare)
+	if err != nil {
+		return err
+	}
+
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	if p.crnd != prepare.GetCrnd() {
+		return errors.New("round changed during phase one")
+	}
+	for _, pval := range promise.GetAccepted() {
+		p.acceptMsgQueue = append(p.acceptMsgQueue, &pb.AcceptMsg{Slot: pval.GetSlot(), Rnd: p.crnd, Val: pval.GetVval()})
+	}
+	p.nextSlot = p.adu
+	p.phaseOneDone = true
 	return nil
 }
 
@@ -93,17 +153,83 @@ func (p *Proposer) runPhaseOne() error {
 //	Call performAccept
 //	Call performCommit with the returned learn message
 func (p *Proposer) runMultiPaxos() {
-	// TODO(student): complete
+	if p.isPaused() {
+		time.Sleep(requestWaitTime)
+		return
+	}
+	if !p.isPhaseOneDone() {
+		if err := p.runPhaseOne(); err != nil {
+			// the acceptors may have promised a higher round, for instance
+			// to another leader while this replica was down; try the next one
+			p.Logf("Phase one failed: %v", err)
+			p.mu.Lock()
+			p.crnd += Round(len(p.nodeMap))
+			p.mu.Unlock()
+			time.Sleep(requestWaitTime)
+		}
+		return
+	}
+	accept, fromClient := p.nextAccept()
+	if accept == nil {
+		time.Sleep(requestWaitTime)
+		return
+	}
+	learn, err := p.performAccept(accept)
+	if err != nil {
+		// another proposer may have taken over; start a new round, proposing
+		// the client request again once phase one has recovered the log
+		p.Logf("Accept(%v) failed: %v", accept, err)
+		p.mu.Lock()
+		p.phaseOneDone = false
+		p.crnd += Round(len(p.nodeMap))
+		if fromClient {
+			p.clientRequestQueue = slices.Insert(p.clientRequestQueue, 0, &pb.AcceptMsg{Val: accept.GetVal()})
+		}
+		p.mu.Unlock()
+		return
+	}
+	if err := p.performCommit(learn); err != nil {
+		p.Logf("Commit(%v) failed: %v", learn, err)
+	}
 }
 
 // nextAcceptMsg returns the next accept message to be sent, if any.
 // If there are no pending accept messages or any client requests to process,
 // it returns nil.
-func (p *Proposer) nextAcceptMsg() (accept *pb.AcceptMsg) {
-	// TODO(student): complete
+//
+// Values recovered in phase one keep their slot, and gaps before them are
+// filled with no-ops; client requests are proposed in the next free slot.
+func (p *Proposer) nextAcceptMsg() *pb.AcceptMsg {
+	accept, _ := p.nextAccept()
 	return accept
 }
 
+// nextAccept returns the next accept message to be sent, if any, and
+// whether it proposes a request taken from the clientRequestQueue.
+func (p *Proposer) nextAccept() (accept *pb.AcceptMsg, fromClient bool) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	switch {
+	case len(p.acceptMsgQueue) > 0 && p.acceptMsgQueue[0].GetSlot() > p.nextSlot+1:
+		p.nextSlot++
+		accept = &pb.AcceptMsg{Slot: p.nextSlot, Val: &pb.Value{IsNoop: true}}
+	case len(p.acceptMsgQueue) > 0:
+		accept = p.acceptMsgQueue[0]
+		p.acceptMsgQueue = p.acceptMsgQueue[1:]
+		p.nextSlot = max(p.nextSlot, accept.GetSlot())
+	case len(p.clientRequestQueue) > 0:
+		accept = p.clientRequestQueue[0]
+		p.clientRequestQueue = p.clientRequestQueue[1:]
+		p.nextSlot++
+		accept.Slot = p.nextSlot
+		fromClient = true
+	default:
+		return nil, false
+	}
+	accept.Rnd = p.crnd
+	return accept, fromClient
+}
+
 // Perform the accept quorum call on the replicas.
 //
 //  1. Check if any pending accept requests in the acceptReqQueue to process
@@ -112,14 +238,32 @@ func (p *Proposer) nextAcceptMsg() (accept *pb.AcceptMsg) {
 //     using crnd and nextSlot.
 //  4. Perform accept quorum call on the configuration and return the learnMsg.
 func (p *Proposer) performAccept(accept *pb.AcceptMsg) (*pb.LearnMsg, error) {
-	// TODO(student): complete
-	return nil, nil
+	if accept == nil {
+		return nil, nil
+	}
+	span := p.startAcceptSpan(accept)
+	defer span.End()
+	p.mu.RLock()
+	config := p.config
+	p.mu.RUnlock()
+	ctx, cancel := context.WithTimeout(context.Background(), learnTimeout)
+	defer cancel()
+	return config.Accept(ctx, accept)
 }
 
 // Perform the commit operation using a multicast call.
 func (p *Proposer) performCommit(learn *pb.LearnMsg) error {
-	// TODO(student): complete
-	_ = learn // TODO: remove this line when you implement the method
+	span := p.tracer.Start("Proposer.Commit", learn.GetVal().GetTrace())
+	defer span.End()
+	if learn == nil {
+		return errors.New("no learn message to commit")
+	}
+	span.setMessage(learn.GetSlot(), learn.GetRnd(), learn.GetVal())
+	learn.Trace = span.Context()
+	p.mu.RLock()
+	config := p.config
+	p.mu.RUnlock()
+	config.Commit(context.Background(), learn, gorums.WithNoSendWaiting())
 	return nil
 }
 
@@ -139,12 +283,17 @@ func (p *Proposer) setConfiguration(config MultiPaxosConfig) {
 }
 
 // AddRequestToQ adds the request to the clientRequestQueue.
-func (p *Proposer) AddRequestToQ(request *pb.Value) {
-	if p.isLeader() {
-		p.Logf("Adding request to queue: %v", request)
-		accept := &pb.AcceptMsg{Val: request}
-		p.mu.Lock()
-		p.clientRequestQueue = append(p.clientRequestQueue, accept)
-		p.mu.Unlock()
+// It returns an OverloadedError if the request was rejected by admission control.
+func (p *Proposer) AddRequestToQ(request *pb.Value) error {
+	if !p.isLeader() {
+		return nil
+	}
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	if err := p.enqueueRequest(request); err != nil {
+		p.Logf("Rejecting request %v: %v", request, err)
+		return err
 	}
+	p.Logf("Adding request to queue: %v", request)
+	return nil
 }
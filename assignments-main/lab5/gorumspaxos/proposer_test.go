@@ -1,7 +1,14 @@
 package gorumspaxos
 
 import (
+	"errors"
 	"testing"
+
+	pb "dat520/lab5/gorumspaxos/proto"
+
+	"github.com/google/go-cmp/cmp"
+	"github.com/google/go-cmp/cmp/cmpopts"
+	"google.golang.org/protobuf/testing/protocmp"
 )
 
 func TestPerformAccept(t *testing.T) {
@@ -15,3 +22,49 @@ func TestPerformCommit(t *testing.T) {
 func TestRunPhaseOne(t *testing.T) {
 	testRunPhaseOne(t, func() {})
 }
+
+func TestRunMultiPaxosAcceptFailed(t *testing.T) {
+	tests := []struct {
+		name            string
+		state           *proposerState
+		wantClientQueue []*pb.AcceptMsg
+		wantAcceptQueue []*pb.AcceptMsg
+	}{
+		{
+			name: "ClientRequestRequeued",
+			state: &proposerState{
+				clientMsgQueue: []*pb.Value{valOne, valTwo},
+				config:         &MockConfiguration{ErrOut: errors.New("rejected")},
+				crnd:           1,
+			},
+			wantClientQueue: []*pb.AcceptMsg{{Val: valOne}, {Val: valTwo}},
+		},
+		{
+			// a recovered value is recovered again by the next phase one
+			name: "RecoveredValueNotRequeued",
+			state: &proposerState{
+				acceptMsgQueue: []*pb.AcceptMsg{{Slot: 1, Rnd: 1, Val: valThree}},
+				clientMsgQueue: []*pb.Value{valOne},
+				config:         &MockConfiguration{ErrOut: errors.New("rejected")},
+				crnd:           1,
+			},
+			wantClientQueue: []*pb.AcceptMsg{{Val: valOne}},
+		},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			proposer := newMockProposer(test.state)
+			proposer.phaseOneDone = true
+			proposer.runMultiPaxos()
+			if proposer.phaseOneDone {
+				t.Error("phaseOneDone = true, want false after a failed accept")
+			}
+			if diff := cmp.Diff(test.wantClientQueue, proposer.clientRequestQueue, protocmp.Transform(), cmpopts.EquateEmpty()); diff != "" {
+				t.Errorf("clientRequestQueue mismatch (-want +got):\n%s", diff)
+			}
+			if diff := cmp.Diff(test.wantAcceptQueue, proposer.acceptMsgQueue, protocmp.Transform(), cmpopts.EquateEmpty()); diff != "" {
+				t.Errorf("acceptMsgQueue mismatch (-want +got):\n%s", diff)
+			}
+		})
+	}
+}
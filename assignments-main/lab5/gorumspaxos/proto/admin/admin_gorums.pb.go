@@ -0,0 +1,332 @@
+// Code generated by protoc-gen-gorums. DO NOT EDIT.
+// versions:
+// 	protoc-gen-gorums v0.7.0-devel
+// 	protoc            v4.25.3
+// source: proto/admin/admin.proto
+
+package admin
+
+import (
+	context "context"
+	fmt "fmt"
+	gorums "github.com/relab/gorums"
+	encoding "google.golang.org/grpc/encoding"
+)
+
+const (
+	// Verify that this generated code is sufficiently up-to-date.
+	_ = gorums.EnforceVersion(7 - gorums.MinVersion)
+	// Verify that the gorums runtime is sufficiently up-to-date.
+	_ = gorums.EnforceVersion(gorums.MaxVersion - 7)
+)
+
+// A Configuration represents a static set of nodes on which quorum remote
+// procedure calls may be invoked.
+type Configuration struct {
+	gorums.RawConfiguration
+	nodes []*Node
+	qspec QuorumSpec
+}
+
+// ConfigurationFromRaw returns a new Configuration from the given raw configuration and QuorumSpec.
+//
+// This function may for example be used to "clone" a configuration but install a different QuorumSpec:
+//
+//	cfg1, err := mgr.NewConfiguration(qspec1, opts...)
+//	cfg2 := ConfigurationFromRaw(cfg1.RawConfig, qspec2)
+func ConfigurationFromRaw(rawCfg gorums.RawConfiguration, qspec QuorumSpec) *Configuration {
+	// return an error if the QuorumSpec interface is not empty and no implementation was provided.
+	var test interface{} = struct{}{}
+	if _, empty := test.(QuorumSpec); !empty && qspec == nil {
+		panic("QuorumSpec may not be nil")
+	}
+	return &Configuration{
+		RawConfiguration: rawCfg,
+		qspec:            qspec,
+	}
+}
+
+// Nodes returns a slice of each available node. IDs are returned in the same
+// order as they were provided in the creation of the Manager.
+//
+// NOTE: mutating the returned slice is not supported.
+func (c *Configuration) Nodes() []*Node {
+	if c.nodes == nil {
+		c.nodes = make([]*Node, 0, c.Size())
+		for _, n := range c.RawConfiguration {
+			c.nodes = append(c.nodes, &Node{n})
+		}
+	}
+	return c.nodes
+}
+
+// And returns a NodeListOption that can be used to create a new configuration combining c and d.
+func (c Configuration) And(d *Configuration) gorums.NodeListOption {
+	return c.RawConfiguration.And(d.RawConfiguration)
+}
+
+// Except returns a NodeListOption that can be used to create a new configuration
+// from c without the nodes in rm.
+func (c Configuration) Except(rm *Configuration) gorums.NodeListOption {
+	return c.RawConfiguration.Except(rm.RawConfiguration)
+}
+
+func init() {
+	if encoding.GetCodec(gorums.ContentSubtype) == nil {
+		encoding.RegisterCodec(gorums.NewCodec())
+	}
+}
+
+// Manager maintains a connection pool of nodes on
+// which quorum calls can be performed.
+type Manager struct {
+	*gorums.RawManager
+}
+
+// NewManager returns a new Manager for managing connection to nodes added
+// to the manager. This function accepts manager options used to configure
+// various aspects of the manager.
+func NewManager(opts ...gorums.ManagerOption) (mgr *Manager) {
+	mgr = &Manager{}
+	mgr.RawManager = gorums.NewRawManager(opts...)
+	return mgr
+}
+
+// NewConfiguration returns a configuration based on the provided list of nodes (required)
+// and an optional quorum specification. The QuorumSpec is necessary for call types that
+// must process replies. For configurations only used for unicast or multicast call types,
+// a QuorumSpec is not needed. The QuorumSpec interface is also a ConfigOption.
+// Nodes can be supplied using WithNodeMap or WithNodeList, or WithNodeIDs.
+// A new configuration can also be created from an existing configuration,
+// using the And, WithNewNodes, Except, and WithoutNodes methods.
+func (m *Manager) NewConfiguration(opts ...gorums.ConfigOption) (c *Configuration, err error) {
+	if len(opts) < 1 || len(opts) > 2 {
+		return nil, fmt.Errorf("wrong number of options: %d", len(opts))
+	}
+	c = &Configuration{}
+	for _, opt := range opts {
+		switch v := opt.(type) {
+		case gorums.NodeListOption:
+			c.RawConfiguration, err = gorums.NewRawConfiguration(m.RawManager, v)
+			if err != nil {
+				return nil, err
+			}
+		case QuorumSpec:
+			// Must be last since v may match QuorumSpec if it is interface{}
+			c.qspec = v
+		default:
+			return nil, fmt.Errorf("unknown option type: %v", v)
+		}
+	}
+	// return an error if the QuorumSpec interface is not empty and no implementation was provided.
+	var test interface{} = struct{}{}
+	if _, empty := test.(QuorumSpec); !empty && c.qspec == nil {
+		return nil, fmt.Errorf("missing required QuorumSpec")
+	}
+	return c, nil
+}
+
+// Nodes returns a slice of available nodes on this manager.
+// IDs are returned in the order they were added at creation of the manager.
+func (m *Manager) Nodes() []*Node {
+	gorumsNodes := m.RawManager.Nodes()
+	nodes := make([]*Node, 0, len(gorumsNodes))
+	for _, n := range gorumsNodes {
+		nodes = append(nodes, &Node{n})
+	}
+	return nodes
+}
+
+// Node encapsulates the state of a node on which a remote procedure call
+// can be performed.
+type Node struct {
+	*gorums.RawNode
+}
+
+// QuorumSpec is the interface of quorum functions for Admin.
+type QuorumSpec interface {
+	gorums.ConfigOption
+}
+
+// ReplicaStatus is a quorum call invoked on all nodes in configuration c,
+// with the same argument in, and returns a combined result.
+func (n *Node) ReplicaStatus(ctx context.Context, in *StatusRequest) (resp *StatusReply, err error) {
+	cd := gorums.CallData{
+		Message: in,
+		Method:  "admin.Admin.ReplicaStatus",
+	}
+
+	res, err := n.RawNode.RPCCall(ctx, cd)
+	if err != nil {
+		return nil, err
+	}
+	return res.(*StatusReply), err
+}
+
+// DumpLog is a quorum call invoked on all nodes in configuration c,
+// with the same argument in, and returns a combined result.
+func (n *Node) DumpLog(ctx context.Context, in *LogRequest) (resp *LogReply, err error) {
+	cd := gorums.CallData{
+		Message: in,
+		Method:  "admin.Admin.DumpLog",
+	}
+
+	res, err := n.RawNode.RPCCall(ctx, cd)
+	if err != nil {
+		return nil, err
+	}
+	return res.(*LogReply), err
+}
+
+// ForceElection is a quorum call invoked on all nodes in configuration c,
+// with the same argument in, and returns a combined result.
+func (n *Node) ForceElection(ctx context.Context, in *StatusRequest) (resp *StatusReply, err error) {
+	cd := gorums.CallData{
+		Message: in,
+		Method:  "admin.Admin.ForceElection",
+	}
+
+	res, err := n.RawNode.RPCCall(ctx, cd)
+	if err != nil {
+		return nil, err
+	}
+	return res.(*StatusReply), err
+}
+
+// SetProposing is a quorum call invoked on all nodes in configuration c,
+// with the same argument in, and returns a combined result.
+func (n *Node) SetProposing(ctx context.Context, in *ProposingRequest) (resp *StatusReply, err error) {
+	cd := gorums.CallData{
+		Message: in,
+		Method:  "admin.Admin.SetProposing",
+	}
+
+	res, err := n.RawNode.RPCCall(ctx, cd)
+	if err != nil {
+		return nil, err
+	}
+	return res.(*StatusReply), err
+}
+
+// SuspectNode is a quorum call invoked on all nodes in configuration c,
+// with the same argument in, and returns a combined result.
+func (n *Node) SuspectNode(ctx context.Context, in *NodeRequest) (resp *StatusReply, err error) {
+	cd := gorums.CallData{
+		Message: in,
+		Method:  "admin.Admin.SuspectNode",
+	}
+
+	res, err := n.RawNode.RPCCall(ctx, cd)
+	if err != nil {
+		return nil, err
+	}
+	return res.(*StatusReply), err
+}
+
+// RestoreNode is a quorum call invoked on all nodes in configuration c,
+// with the same argument in, and returns a combined result.
+func (n *Node) RestoreNode(ctx context.Context, in *NodeRequest) (resp *StatusReply, err error) {
+	cd := gorums.CallData{
+		Message: in,
+		Method:  "admin.Admin.RestoreNode",
+	}
+
+	res, err := n.RawNode.RPCCall(ctx, cd)
+	if err != nil {
+		return nil, err
+	}
+	return res.(*StatusReply), err
+}
+
+// SetFaults is a quorum call invoked on all nodes in configuration c,
+// with the same argument in, and returns a combined result.
+func (n *Node) SetFaults(ctx context.Context, in *FaultsRequest) (resp *FaultsReply, err error) {
+	cd := gorums.CallData{
+		Message: in,
+		Method:  "admin.Admin.SetFaults",
+	}
+
+	res, err := n.RawNode.RPCCall(ctx, cd)
+	if err != nil {
+		return nil, err
+	}
+	return res.(*FaultsReply), err
+}
+
+// Faults is a quorum call invoked on all nodes in configuration c,
+// with the same argument in, and returns a combined result.
+func (n *Node) Faults(ctx context.Context, in *StatusRequest) (resp *FaultsReply, err error) {
+	cd := gorums.CallData{
+		Message: in,
+		Method:  "admin.Admin.Faults",
+	}
+
+	res, err := n.RawNode.RPCCall(ctx, cd)
+	if err != nil {
+		return nil, err
+	}
+	return res.(*FaultsReply), err
+}
+
+// Admin is the server-side API for the Admin Service
+type Admin interface {
+	ReplicaStatus(ctx gorums.ServerCtx, request *StatusRequest) (response *StatusReply, err error)
+	DumpLog(ctx gorums.ServerCtx, request *LogRequest) (response *LogReply, err error)
+	ForceElection(ctx gorums.ServerCtx, request *StatusRequest) (response *StatusReply, err error)
+	SetProposing(ctx gorums.ServerCtx, request *ProposingRequest) (response *StatusReply, err error)
+	SuspectNode(ctx gorums.ServerCtx, request *NodeRequest) (response *StatusReply, err error)
+	RestoreNode(ctx gorums.ServerCtx, request *NodeRequest) (response *StatusReply, err error)
+	SetFaults(ctx gorums.ServerCtx, request *FaultsRequest) (response *FaultsReply, err error)
+	Faults(ctx gorums.ServerCtx, request *StatusRequest) (response *FaultsReply, err error)
+}
+
+func RegisterAdminServer(srv *gorums.Server, impl Admin) {
+	srv.RegisterHandler("admin.Admin.ReplicaStatus", func(ctx gorums.ServerCtx, in *gorums.Message, finished chan<- *gorums.Message) {
+		req := in.Message.(*StatusRequest)
+		defer ctx.Release()
+		resp, err := impl.ReplicaStatus(ctx, req)
+		gorums.SendMessage(ctx, finished, gorums.WrapMessage(in.Metadata, resp, err))
+	})
+	srv.RegisterHandler("admin.Admin.DumpLog", func(ctx gorums.ServerCtx, in *gorums.Message, finished chan<- *gorums.Message) {
+		req := in.Message.(*LogRequest)
+		defer ctx.Release()
+		resp, err := impl.DumpLog(ctx, req)
+		gorums.SendMessage(ctx, finished, gorums.WrapMessage(in.Metadata, resp, err))
+	})
+	srv.RegisterHandler("admin.Admin.ForceElection", func(ctx gorums.ServerCtx, in *gorums.Message, finished chan<- *gorums.Message) {
+		req := in.Message.(*StatusRequest)
+		defer ctx.Release()
+		resp, err := impl.ForceElection(ctx, req)
+		gorums.SendMessage(ctx, finished, gorums.WrapMessage(in.Metadata, resp, err))
+	})
+	srv.RegisterHandler("admin.Admin.SetProposing", func(ctx gorums.ServerCtx, in *gorums.Message, finished chan<- *gorums.Message) {
+		req := in.Message.(*ProposingRequest)
+		defer ctx.Release()
+		resp, err := impl.SetProposing(ctx, req)
+		gorums.SendMessage(ctx, finished, gorums.WrapMessage(in.Metadata, resp, err))
+	})
+	srv.RegisterHandler("admin.Admin.SuspectNode", func(ctx gorums.ServerCtx, in *gorums.Message, finished chan<- *gorums.Message) {
+		req := in.Message.(*NodeRequest)
+		defer ctx.Release()
+		resp, err := impl.SuspectNode(ctx, req)
+		gorums.SendMessage(ctx, finished, gorums.WrapMessage(in.Metadata, resp, err))
+	})
+	srv.RegisterHandler("admin.Admin.RestoreNode", func(ctx gorums.ServerCtx, in *gorums.Message, finished chan<- *gorums.Message) {
+		req := in.Message.(*NodeRequest)
+		defer ctx.Release()
+		resp, err := impl.RestoreNode(ctx, req)
+		gorums.SendMessage(ctx, finished, gorums.WrapMessage(in.Metadata, resp, err))
+	})
+	srv.RegisterHandler("admin.Admin.SetFaults", func(ctx gorums.ServerCtx, in *gorums.Message, finished chan<- *gorums.Message) {
+		req := in.Message.(*FaultsRequest)
+		defer ctx.Release()
+		resp, err := impl.SetFaults(ctx, req)
+		gorums.SendMessage(ctx, finished, gorums.WrapMessage(in.Metadata, resp, err))
+	})
+	srv.RegisterHandler("admin.Admin.Faults", func(ctx gorums.ServerCtx, in *gorums.Message, finished chan<- *gorums.Message) {
+		req := in.Message.(*StatusRequest)
+		defer ctx.Release()
+		resp, err := impl.Faults(ctx, req)
+		gorums.SendMessage(ctx, finished, gorums.WrapMessage(in.Metadata, resp, err))
+	})
+}
@@ -0,0 +1,332 @@
+// Code generated by protoc-gen-gorums. DO NOT EDIT.
+// versions:
+// 	protoc-gen-gorums v0.7.0-devel
+// 	protoc            v4.25.3
+// source: proto/kv/kv.proto
+
+package kv
+
+import (
+	context "context"
+	fmt "fmt"
+	gorums "github.com/relab/gorums"
+	encoding "google.golang.org/grpc/encoding"
+)
+
+const (
+	// Verify that this generated code is sufficiently up-to-date.
+	_ = gorums.EnforceVersion(7 - gorums.MinVersion)
+	// Verify that the gorums runtime is sufficiently up-to-date.
+	_ = gorums.EnforceVersion(gorums.MaxVersion - 7)
+)
+
+// A Configuration represents a static set of nodes on which quorum remote
+// procedure calls may be invoked.
+type Configuration struct {
+	gorums.RawConfiguration
+	nodes []*Node
+	qspec QuorumSpec
+}
+
+// ConfigurationFromRaw returns a new Configuration from the given raw configuration and QuorumSpec.
+//
+// This function may for example be used to "clone" a configuration but install a different QuorumSpec:
+//
+//	cfg1, err := mgr.NewConfiguration(qspec1, opts...)
+//	cfg2 := ConfigurationFromRaw(cfg1.RawConfig, qspec2)
+func ConfigurationFromRaw(rawCfg gorums.RawConfiguration, qspec QuorumSpec) *Configuration {
+	// return an error if the QuorumSpec interface is not empty and no implementation was provided.
+	var test interface{} = struct{}{}
+	if _, empty := test.(QuorumSpec); !empty && qspec == nil {
+		panic("QuorumSpec may not be nil")
+	}
+	return &Configuration{
+		RawConfiguration: rawCfg,
+		qspec:            qspec,
+	}
+}
+
+// Nodes returns a slice of each available node. IDs are returned in the same
+// order as they were provided in the creation of the Manager.
+//
+// NOTE: mutating the returned slice is not supported.
+func (c *Configuration) Nodes() []*Node {
+	if c.nodes == nil {
+		c.nodes = make([]*Node, 0, c.Size())
+		for _, n := range c.RawConfiguration {
+			c.nodes = append(c.nodes, &Node{n})
+		}
+	}
+	return c.nodes
+}
+
+// And returns a NodeListOption that can be used to create a new configuration combining c and d.
+func (c Configuration) And(d *Configuration) gorums.NodeListOption {
+	return c.RawConfiguration.And(d.RawConfiguration)
+}
+
+// Except returns a NodeListOption that can be used to create a new configuration
+// from c without the nodes in rm.
+func (c Configuration) Except(rm *Configuration) gorums.NodeListOption {
+	return c.RawConfiguration.Except(rm.RawConfiguration)
+}
+
+func init() {
+	if encoding.GetCodec(gorums.ContentSubtype) == nil {
+		encoding.RegisterCodec(gorums.NewCodec())
+	}
+}
+
+// Manager maintains a connection pool of nodes on
+// which quorum calls can be performed.
+type Manager struct {
+	*gorums.RawManager
+}
+
+// NewManager returns a new Manager for managing connection to nodes added
+// to the manager. This function accepts manager options used to configure
+// various aspects of the manager.
+func NewManager(opts ...gorums.ManagerOption) (mgr *Manager) {
+	mgr = &Manager{}
+	mgr.RawManager = gorums.NewRawManager(opts...)
+	return mgr
+}
+
+// NewConfiguration returns a configuration based on the provided list of nodes (required)
+// and an optional quorum specification. The QuorumSpec is necessary for call types that
+// must process replies. For configurations only used for unicast or multicast call types,
+// a QuorumSpec is not needed. The QuorumSpec interface is also a ConfigOption.
+// Nodes can be supplied using WithNodeMap or WithNodeList, or WithNodeIDs.
+// A new configuration can also be created from an existing configuration,
+// using the And, WithNewNodes, Except, and WithoutNodes methods.
+func (m *Manager) NewConfiguration(opts ...gorums.ConfigOption) (c *Configuration, err error) {
+	if len(opts) < 1 || len(opts) > 2 {
+		return nil, fmt.Errorf("wrong number of options: %d", len(opts))
+	}
+	c = &Configuration{}
+	for _, opt := range opts {
+		switch v := opt.(type) {
+		case gorums.NodeListOption:
+			c.RawConfiguration, err = gorums.NewRawConfiguration(m.RawManager, v)
+			if err != nil {
+				return nil, err
+			}
+		case QuorumSpec:
+			// Must be last since v may match QuorumSpec if it is interface{}
+			c.qspec = v
+		default:
+			return nil, fmt.Errorf("unknown option type: %v", v)
+		}
+	}
+	// return an error if the QuorumSpec interface is not empty and no implementation was provided.
+	var test interface{} = struct{}{}
+	if _, empty := test.(QuorumSpec); !empty && c.qspec == nil {
+		return nil, fmt.Errorf("missing required QuorumSpec")
+	}
+	return c, nil
+}
+
+// Nodes returns a slice of available nodes on this manager.
+// IDs are returned in the order they were added at creation of the manager.
+func (m *Manager) Nodes() []*Node {
+	gorumsNodes := m.RawManager.Nodes()
+	nodes := make([]*Node, 0, len(gorumsNodes))
+	for _, n := range gorumsNodes {
+		nodes = append(nodes, &Node{n})
+	}
+	return nodes
+}
+
+// Node encapsulates the state of a node on which a remote procedure call
+// can be performed.
+type Node struct {
+	*gorums.RawNode
+}
+
+// QuorumSpec is the interface of quorum functions for KVService.
+type QuorumSpec interface {
+	gorums.ConfigOption
+}
+
+// Insert is a quorum call invoked on all nodes in configuration c,
+// with the same argument in, and returns a combined result.
+func (n *Node) Insert(ctx context.Context, in *InsertRequest) (resp *InsertResponse, err error) {
+	cd := gorums.CallData{
+		Message: in,
+		Method:  "kv.KVService.Insert",
+	}
+
+	res, err := n.RawNode.RPCCall(ctx, cd)
+	if err != nil {
+		return nil, err
+	}
+	return res.(*InsertResponse), err
+}
+
+// Lookup is a quorum call invoked on all nodes in configuration c,
+// with the same argument in, and returns a combined result.
+func (n *Node) Lookup(ctx context.Context, in *LookupRequest) (resp *LookupResponse, err error) {
+	cd := gorums.CallData{
+		Message: in,
+		Method:  "kv.KVService.Lookup",
+	}
+
+	res, err := n.RawNode.RPCCall(ctx, cd)
+	if err != nil {
+		return nil, err
+	}
+	return res.(*LookupResponse), err
+}
+
+// Keys is a quorum call invoked on all nodes in configuration c,
+// with the same argument in, and returns a combined result.
+func (n *Node) Keys(ctx context.Context, in *KeysRequest) (resp *KeysResponse, err error) {
+	cd := gorums.CallData{
+		Message: in,
+		Method:  "kv.KVService.Keys",
+	}
+
+	res, err := n.RawNode.RPCCall(ctx, cd)
+	if err != nil {
+		return nil, err
+	}
+	return res.(*KeysResponse), err
+}
+
+// Get is a quorum call invoked on all nodes in configuration c,
+// with the same argument in, and returns a combined result.
+func (n *Node) Get(ctx context.Context, in *GetRequest) (resp *GetResponse, err error) {
+	cd := gorums.CallData{
+		Message: in,
+		Method:  "kv.KVService.Get",
+	}
+
+	res, err := n.RawNode.RPCCall(ctx, cd)
+	if err != nil {
+		return nil, err
+	}
+	return res.(*GetResponse), err
+}
+
+// Put is a quorum call invoked on all nodes in configuration c,
+// with the same argument in, and returns a combined result.
+func (n *Node) Put(ctx context.Context, in *PutRequest) (resp *PutResponse, err error) {
+	cd := gorums.CallData{
+		Message: in,
+		Method:  "kv.KVService.Put",
+	}
+
+	res, err := n.RawNode.RPCCall(ctx, cd)
+	if err != nil {
+		return nil, err
+	}
+	return res.(*PutResponse), err
+}
+
+// Delete is a quorum call invoked on all nodes in configuration c,
+// with the same argument in, and returns a combined result.
+func (n *Node) Delete(ctx context.Context, in *DeleteRequest) (resp *DeleteResponse, err error) {
+	cd := gorums.CallData{
+		Message: in,
+		Method:  "kv.KVService.Delete",
+	}
+
+	res, err := n.RawNode.RPCCall(ctx, cd)
+	if err != nil {
+		return nil, err
+	}
+	return res.(*DeleteResponse), err
+}
+
+// CompareAndSwap is a quorum call invoked on all nodes in configuration c,
+// with the same argument in, and returns a combined result.
+func (n *Node) CompareAndSwap(ctx context.Context, in *CompareAndSwapRequest) (resp *CompareAndSwapResponse, err error) {
+	cd := gorums.CallData{
+		Message: in,
+		Method:  "kv.KVService.CompareAndSwap",
+	}
+
+	res, err := n.RawNode.RPCCall(ctx, cd)
+	if err != nil {
+		return nil, err
+	}
+	return res.(*CompareAndSwapResponse), err
+}
+
+// Scan is a quorum call invoked on all nodes in configuration c,
+// with the same argument in, and returns a combined result.
+func (n *Node) Scan(ctx context.Context, in *ScanRequest) (resp *ScanResponse, err error) {
+	cd := gorums.CallData{
+		Message: in,
+		Method:  "kv.KVService.Scan",
+	}
+
+	res, err := n.RawNode.RPCCall(ctx, cd)
+	if err != nil {
+		return nil, err
+	}
+	return res.(*ScanResponse), err
+}
+
+// KVService is the server-side API for the KVService Service
+type KVService interface {
+	Insert(ctx gorums.ServerCtx, request *InsertRequest) (response *InsertResponse, err error)
+	Lookup(ctx gorums.ServerCtx, request *LookupRequest) (response *LookupResponse, err error)
+	Keys(ctx gorums.ServerCtx, request *KeysRequest) (response *KeysResponse, err error)
+	Get(ctx gorums.ServerCtx, request *GetRequest) (response *GetResponse, err error)
+	Put(ctx gorums.ServerCtx, request *PutRequest) (response *PutResponse, err error)
+	Delete(ctx gorums.ServerCtx, request *DeleteRequest) (response *DeleteResponse, err error)
+	CompareAndSwap(ctx gorums.ServerCtx, request *CompareAndSwapRequest) (response *CompareAndSwapResponse, err error)
+	Scan(ctx gorums.ServerCtx, request *ScanRequest) (response *ScanResponse, err error)
+}
+
+func RegisterKVServiceServer(srv *gorums.Server, impl KVService) {
+	srv.RegisterHandler("kv.KVService.Insert", func(ctx gorums.ServerCtx, in *gorums.Message, finished chan<- *gorums.Message) {
+		req := in.Message.(*InsertRequest)
+		defer ctx.Release()
+		resp, err := impl.Insert(ctx, req)
+		gorums.SendMessage(ctx, finished, gorums.WrapMessage(in.Metadata, resp, err))
+	})
+	srv.RegisterHandler("kv.KVService.Lookup", func(ctx gorums.ServerCtx, in *gorums.Message, finished chan<- *gorums.Message) {
+		req := in.Message.(*LookupRequest)
+		defer ctx.Release()
+		resp, err := impl.Lookup(ctx, req)
+		gorums.SendMessage(ctx, finished, gorums.WrapMessage(in.Metadata, resp, err))
+	})
+	srv.RegisterHandler("kv.KVService.Keys", func(ctx gorums.ServerCtx, in *gorums.Message, finished chan<- *gorums.Message) {
+		req := in.Message.(*KeysRequest)
+		defer ctx.Release()
+		resp, err := impl.Keys(ctx, req)
+		gorums.SendMessage(ctx, finished, gorums.WrapMessage(in.Metadata, resp, err))
+	})
+	srv.RegisterHandler("kv.KVService.Get", func(ctx gorums.ServerCtx, in *gorums.Message, finished chan<- *gorums.Message) {
+		req := in.Message.(*GetRequest)
+		defer ctx.Release()
+		resp, err := impl.Get(ctx, req)
+		gorums.SendMessage(ctx, finished, gorums.WrapMessage(in.Metadata, resp, err))
+	})
+	srv.RegisterHandler("kv.KVService.Put", func(ctx gorums.ServerCtx, in *gorums.Message, finished chan<- *gorums.Message) {
+		req := in.Message.(*PutRequest)
+		defer ctx.Release()
+		resp, err := impl.Put(ctx, req)
+		gorums.SendMessage(ctx, finished, gorums.WrapMessage(in.Metadata, resp, err))
+	})
+	srv.RegisterHandler("kv.KVService.Delete", func(ctx gorums.ServerCtx, in *gorums.Message, finished chan<- *gorums.Message) {
+		req := in.Message.(*DeleteRequest)
+		defer ctx.Release()
+		resp, err := impl.Delete(ctx, req)
+		gorums.SendMessage(ctx, finished, gorums.WrapMessage(in.Metadata, resp, err))
+	})
+	srv.RegisterHandler("kv.KVService.CompareAndSwap", func(ctx gorums.ServerCtx, in *gorums.Message, finished chan<- *gorums.Message) {
+		req := in.Message.(*CompareAndSwapRequest)
+		defer ctx.Release()
+		resp, err := impl.CompareAndSwap(ctx, req)
+		gorums.SendMessage(ctx, finished, gorums.WrapMessage(in.Metadata, resp, err))
+	})
+	srv.RegisterHandler("kv.KVService.Scan", func(ctx gorums.ServerCtx, in *gorums.Message, finished chan<- *gorums.Message) {
+		req := in.Message.(*ScanRequest)
+		defer ctx.Release()
+		resp, err := impl.Scan(ctx, req)
+		gorums.SendMessage(ctx, finished, gorums.WrapMessage(in.Metadata, resp, err))
+	})
+}
@@ -0,0 +1,288 @@
+// Code generated by protoc-gen-gorums. DO NOT EDIT.
+// versions:
+// 	protoc-gen-gorums v0.7.0-devel
+// 	protoc            v4.25.3
+// source: proto/lock/lock.proto
+
+package lock
+
+import (
+	context "context"
+	fmt "fmt"
+	gorums "github.com/relab/gorums"
+	encoding "google.golang.org/grpc/encoding"
+)
+
+const (
+	// Verify that this generated code is sufficiently up-to-date.
+	_ = gorums.EnforceVersion(7 - gorums.MinVersion)
+	// Verify that the gorums runtime is sufficiently up-to-date.
+	_ = gorums.EnforceVersion(gorums.MaxVersion - 7)
+)
+
+// A Configuration represents a static set of nodes on which quorum remote
+// procedure calls may be invoked.
+type Configuration struct {
+	gorums.RawConfiguration
+	nodes []*Node
+	qspec QuorumSpec
+}
+
+// ConfigurationFromRaw returns a new Configuration from the given raw configuration and QuorumSpec.
+//
+// This function may for example be used to "clone" a configuration but install a different QuorumSpec:
+//
+//	cfg1, err := mgr.NewConfiguration(qspec1, opts...)
+//	cfg2 := ConfigurationFromRaw(cfg1.RawConfig, qspec2)
+func ConfigurationFromRaw(rawCfg gorums.RawConfiguration, qspec QuorumSpec) *Configuration {
+	// return an error if the QuorumSpec interface is not empty and no implementation was provided.
+	var test interface{} = struct{}{}
+	if _, empty := test.(QuorumSpec); !empty && qspec == nil {
+		panic("QuorumSpec may not be nil")
+	}
+	return &Configuration{
+		RawConfiguration: rawCfg,
+		qspec:            qspec,
+	}
+}
+
+// Nodes returns a slice of each available node. IDs are returned in the same
+// order as they were provided in the creation of the Manager.
+//
+// NOTE: mutating the returned slice is not supported.
+func (c *Configuration) Nodes() []*Node {
+	if c.nodes == nil {
+		c.nodes = make([]*Node, 0, c.Size())
+		for _, n := range c.RawConfiguration {
+			c.nodes = append(c.nodes, &Node{n})
+		}
+	}
+	return c.nodes
+}
+
+// And returns a NodeListOption that can be used to create a new configuration combining c and d.
+func (c Configuration) And(d *Configuration) gorums.NodeListOption {
+	return c.RawConfiguration.And(d.RawConfiguration)
+}
+
+// Except returns a NodeListOption that can be used to create a new configuration
+// from c without the nodes in rm.
+func (c Configuration) Except(rm *Configuration) gorums.NodeListOption {
+	return c.RawConfiguration.Except(rm.RawConfiguration)
+}
+
+func init() {
+	if encoding.GetCodec(gorums.ContentSubtype) == nil {
+		encoding.RegisterCodec(gorums.NewCodec())
+	}
+}
+
+// Manager maintains a connection pool of nodes on
+// which quorum calls can be performed.
+type Manager struct {
+	*gorums.RawManager
+}
+
+// NewManager returns a new Manager for managing connection to nodes added
+// to the manager. This function accepts manager options used to configure
+// various aspects of the manager.
+func NewManager(opts ...gorums.ManagerOption) (mgr *Manager) {
+	mgr = &Manager{}
+	mgr.RawManager = gorums.NewRawManager(opts...)
+	return mgr
+}
+
+// NewConfiguration returns a configuration based on the provided list of nodes (required)
+// and an optional quorum specification. The QuorumSpec is necessary for call types that
+// must process replies. For configurations only used for unicast or multicast call types,
+// a QuorumSpec is not needed. The QuorumSpec interface is also a ConfigOption.
+// Nodes can be supplied using WithNodeMap or WithNodeList, or WithNodeIDs.
+// A new configuration can also be created from an existing configuration,
+// using the And, WithNewNodes, Except, and WithoutNodes methods.
+func (m *Manager) NewConfiguration(opts ...gorums.ConfigOption) (c *Configuration, err error) {
+	if len(opts) < 1 || len(opts) > 2 {
+		return nil, fmt.Errorf("wrong number of options: %d", len(opts))
+	}
+	c = &Configuration{}
+	for _, opt := range opts {
+		switch v := opt.(type) {
+		case gorums.NodeListOption:
+			c.RawConfiguration, err = gorums.NewRawConfiguration(m.RawManager, v)
+			if err != nil {
+				return nil, err
+			}
+		case QuorumSpec:
+			// Must be last since v may match QuorumSpec if it is interface{}
+			c.qspec = v
+		default:
+			return nil, fmt.Errorf("unknown option type: %v", v)
+		}
+	}
+	// return an error if the QuorumSpec interface is not empty and no implementation was provided.
+	var test interface{} = struct{}{}
+	if _, empty := test.(QuorumSpec); !empty && c.qspec == nil {
+		return nil, fmt.Errorf("missing required QuorumSpec")
+	}
+	return c, nil
+}
+
+// Nodes returns a slice of available nodes on this manager.
+// IDs are returned in the order they were added at creation of the manager.
+func (m *Manager) Nodes() []*Node {
+	gorumsNodes := m.RawManager.Nodes()
+	nodes := make([]*Node, 0, len(gorumsNodes))
+	for _, n := range gorumsNodes {
+		nodes = append(nodes, &Node{n})
+	}
+	return nodes
+}
+
+// Node encapsulates the state of a node on which a remote procedure call
+// can be performed.
+type Node struct {
+	*gorums.RawNode
+}
+
+// QuorumSpec is the interface of quorum functions for LockService.
+type QuorumSpec interface {
+	gorums.ConfigOption
+}
+
+// OpenSession is a quorum call invoked on all nodes in configuration c,
+// with the same argument in, and returns a combined result.
+func (n *Node) OpenSession(ctx context.Context, in *SessionRequest) (resp *SessionReply, err error) {
+	cd := gorums.CallData{
+		Message: in,
+		Method:  "lock.LockService.OpenSession",
+	}
+
+	res, err := n.RawNode.RPCCall(ctx, cd)
+	if err != nil {
+		return nil, err
+	}
+	return res.(*SessionReply), err
+}
+
+// RenewSession is a quorum call invoked on all nodes in configuration c,
+// with the same argument in, and returns a combined result.
+func (n *Node) RenewSession(ctx context.Context, in *SessionRequest) (resp *SessionReply, err error) {
+	cd := gorums.CallData{
+		Message: in,
+		Method:  "lock.LockService.RenewSession",
+	}
+
+	res, err := n.RawNode.RPCCall(ctx, cd)
+	if err != nil {
+		return nil, err
+	}
+	return res.(*SessionReply), err
+}
+
+// CloseSession is a quorum call invoked on all nodes in configuration c,
+// with the same argument in, and returns a combined result.
+func (n *Node) CloseSession(ctx context.Context, in *SessionRequest) (resp *SessionReply, err error) {
+	cd := gorums.CallData{
+		Message: in,
+		Method:  "lock.LockService.CloseSession",
+	}
+
+	res, err := n.RawNode.RPCCall(ctx, cd)
+	if err != nil {
+		return nil, err
+	}
+	return res.(*SessionReply), err
+}
+
+// Acquire is a quorum call invoked on all nodes in configuration c,
+// with the same argument in, and returns a combined result.
+func (n *Node) Acquire(ctx context.Context, in *LockRequest) (resp *LockReply, err error) {
+	cd := gorums.CallData{
+		Message: in,
+		Method:  "lock.LockService.Acquire",
+	}
+
+	res, err := n.RawNode.RPCCall(ctx, cd)
+	if err != nil {
+		return nil, err
+	}
+	return res.(*LockReply), err
+}
+
+// Release is a quorum call invoked on all nodes in configuration c,
+// with the same argument in, and returns a combined result.
+func (n *Node) Release(ctx context.Context, in *LockRequest) (resp *LockReply, err error) {
+	cd := gorums.CallData{
+		Message: in,
+		Method:  "lock.LockService.Release",
+	}
+
+	res, err := n.RawNode.RPCCall(ctx, cd)
+	if err != nil {
+		return nil, err
+	}
+	return res.(*LockReply), err
+}
+
+// Watch returns the state of the lock once its version is higher than
+// the requested version, or its current state when the call times out.
+func (n *Node) Watch(ctx context.Context, in *WatchRequest) (resp *LockState, err error) {
+	cd := gorums.CallData{
+		Message: in,
+		Method:  "lock.LockService.Watch",
+	}
+
+	res, err := n.RawNode.RPCCall(ctx, cd)
+	if err != nil {
+		return nil, err
+	}
+	return res.(*LockState), err
+}
+
+// LockService is the server-side API for the LockService Service
+type LockService interface {
+	OpenSession(ctx gorums.ServerCtx, request *SessionRequest) (response *SessionReply, err error)
+	RenewSession(ctx gorums.ServerCtx, request *SessionRequest) (response *SessionReply, err error)
+	CloseSession(ctx gorums.ServerCtx, request *SessionRequest) (response *SessionReply, err error)
+	Acquire(ctx gorums.ServerCtx, request *LockRequest) (response *LockReply, err error)
+	Release(ctx gorums.ServerCtx, request *LockRequest) (response *LockReply, err error)
+	Watch(ctx gorums.ServerCtx, request *WatchRequest) (response *LockState, err error)
+}
+
+func RegisterLockServiceServer(srv *gorums.Server, impl LockService) {
+	srv.RegisterHandler("lock.LockService.OpenSession", func(ctx gorums.ServerCtx, in *gorums.Message, finished chan<- *gorums.Message) {
+		req := in.Message.(*SessionRequest)
+		defer ctx.Release()
+		resp, err := impl.OpenSession(ctx, req)
+		gorums.SendMessage(ctx, finished, gorums.WrapMessage(in.Metadata, resp, err))
+	})
+	srv.RegisterHandler("lock.LockService.RenewSession", func(ctx gorums.ServerCtx, in *gorums.Message, finished chan<- *gorums.Message) {
+		req := in.Message.(*SessionRequest)
+		defer ctx.Release()
+		resp, err := impl.RenewSession(ctx, req)
+		gorums.SendMessage(ctx, finished, gorums.WrapMessage(in.Metadata, resp, err))
+	})
+	srv.RegisterHandler("lock.LockService.CloseSession", func(ctx gorums.ServerCtx, in *gorums.Message, finished chan<- *gorums.Message) {
+		req := in.Message.(*SessionRequest)
+		defer ctx.Release()
+		resp, err := impl.CloseSession(ctx, req)
+		gorums.SendMessage(ctx, finished, gorums.WrapMessage(in.Metadata, resp, err))
+	})
+	srv.RegisterHandler("lock.LockService.Acquire", func(ctx gorums.ServerCtx, in *gorums.Message, finished chan<- *gorums.Message) {
+		req := in.Message.(*LockRequest)
+		defer ctx.Release()
+		resp, err := impl.Acquire(ctx, req)
+		gorums.SendMessage(ctx, finished, gorums.WrapMessage(in.Metadata, resp, err))
+	})
+	srv.RegisterHandler("lock.LockService.Release", func(ctx gorums.ServerCtx, in *gorums.Message, finished chan<- *gorums.Message) {
+		req := in.Message.(*LockRequest)
+		defer ctx.Release()
+		resp, err := impl.Release(ctx, req)
+		gorums.SendMessage(ctx, finished, gorums.WrapMessage(in.Metadata, resp, err))
+	})
+	srv.RegisterHandler("lock.LockService.Watch", func(ctx gorums.ServerCtx, in *gorums.Message, finished chan<- *gorums.Message) {
+		req := in.Message.(*WatchRequest)
+		defer ctx.Release()
+		resp, err := impl.Watch(ctx, req)
+		gorums.SendMessage(ctx, finished, gorums.WrapMessage(in.Metadata, resp, err))
+	})
+}
@@ -1,6 +1,6 @@
 // Code generated by protoc-gen-go. DO NOT EDIT.
 // versions:
-// 	protoc-gen-go v1.32.0
+// 	protoc-gen-go v1.33.0
 // 	protoc        v4.25.3
 // source: proto/multipaxos.proto
 
@@ -21,15 +21,25 @@ const (
 	_ = protoimpl.EnforceVersion(protoimpl.MaxVersion - 20)
 )
 
+// Value is a client request. GroupID identifies the Paxos group that should
+// order the request when a replica runs several groups (see ShardedReplica);
+// it is zero for a replica that runs a single group.
+//
+// A command is either a string in ClientCommand, or an encoded Payload whose
+// ContentType names the codec that decodes it (see CodecRegistry).
 type Value struct {
 	state         protoimpl.MessageState
 	sizeCache     protoimpl.SizeCache
 	unknownFields protoimpl.UnknownFields
 
-	ClientID      string `protobuf:"bytes,1,opt,name=ClientID,proto3" json:"ClientID,omitempty"`
-	ClientSeq     uint32 `protobuf:"varint,2,opt,name=ClientSeq,proto3" json:"ClientSeq,omitempty"`
-	IsNoop        bool   `protobuf:"varint,3,opt,name=isNoop,proto3" json:"isNoop,omitempty"`
-	ClientCommand string `protobuf:"bytes,4,opt,name=ClientCommand,proto3" json:"ClientCommand,omitempty"`
+	ClientID      string        `protobuf:"bytes,1,opt,name=ClientID,proto3" json:"ClientID,omitempty"`
+	ClientSeq     uint32        `protobuf:"varint,2,opt,name=ClientSeq,proto3" json:"ClientSeq,omitempty"`
+	IsNoop        bool          `protobuf:"varint,3,opt,name=isNoop,proto3" json:"isNoop,omitempty"`
+	ClientCommand string        `protobuf:"bytes,4,opt,name=ClientCommand,proto3" json:"ClientCommand,omitempty"`
+	Trace         *TraceContext `protobuf:"bytes,5,opt,name=Trace,proto3" json:"Trace,omitempty"`
+	GroupID       uint32        `protobuf:"varint,6,opt,name=GroupID,proto3" json:"GroupID,omitempty"`
+	Payload       []byte        `protobuf:"bytes,7,opt,name=Payload,proto3" json:"Payload,omitempty"`
+	ContentType   string        `protobuf:"bytes,8,opt,name=ContentType,proto3" json:"ContentType,omitempty"`
 }
 
 func (x *Value) Reset() {
@@ -92,6 +102,36 @@ func (x *Value) GetClientCommand() string {
 	return ""
 }
 
+func (x *Value) GetTrace() *TraceContext {
+	if x != nil {
+		return x.Trace
+	}
+	return nil
+}
+
+func (x *Value) GetGroupID() uint32 {
+	if x != nil {
+		return x.GroupID
+	}
+	return 0
+}
+
+func (x *Value) GetPayload() []byte {
+	if x != nil {
+		return x.Payload
+	}
+	return nil
+}
+
+func (x *Value) GetContentType() string {
+	if x != nil {
+		return x.ContentType
+	}
+	return ""
+}
+
+// Response is the reply to a client request once it has been decided.
+// Result holds the output of applying the request to the replica's state machine.
 type Response struct {
 	state         protoimpl.MessageState
 	sizeCache     protoimpl.SizeCache
@@ -100,6 +140,7 @@ type Response struct {
 	ClientID      string `protobuf:"bytes,1,opt,name=ClientID,proto3" json:"ClientID,omitempty"`
 	ClientSeq     uint32 `protobuf:"varint,2,opt,name=ClientSeq,proto3" json:"ClientSeq,omitempty"`
 	ClientCommand string `protobuf:"bytes,3,opt,name=ClientCommand,proto3" json:"ClientCommand,omitempty"`
+	Result        string `protobuf:"bytes,4,opt,name=Result,proto3" json:"Result,omitempty"`
 }
 
 func (x *Response) Reset() {
@@ -155,13 +196,22 @@ func (x *Response) GetClientCommand() string {
 	return ""
 }
 
+func (x *Response) GetResult() string {
+	if x != nil {
+		return x.Result
+	}
+	return ""
+}
+
 type PrepareMsg struct {
 	state         protoimpl.MessageState
 	sizeCache     protoimpl.SizeCache
 	unknownFields protoimpl.UnknownFields
 
-	Slot uint32 `protobuf:"varint,1,opt,name=Slot,proto3" json:"Slot,omitempty"`
-	Crnd int32  `protobuf:"varint,2,opt,name=Crnd,proto3" json:"Crnd,omitempty"`
+	Slot      uint32 `protobuf:"varint,1,opt,name=Slot,proto3" json:"Slot,omitempty"`
+	Crnd      int32  `protobuf:"varint,2,opt,name=Crnd,proto3" json:"Crnd,omitempty"`
+	GroupID   uint32 `protobuf:"varint,3,opt,name=GroupID,proto3" json:"GroupID,omitempty"`
+	Signature []byte `protobuf:"bytes,4,opt,name=Signature,proto3" json:"Signature,omitempty"` // the proposer's signature in BFT mode
 }
 
 func (x *PrepareMsg) Reset() {
@@ -210,15 +260,32 @@ func (x *PrepareMsg) GetCrnd() int32 {
 	return 0
 }
 
+func (x *PrepareMsg) GetGroupID() uint32 {
+	if x != nil {
+		return x.GroupID
+	}
+	return 0
+}
+
+func (x *PrepareMsg) GetSignature() []byte {
+	if x != nil {
+		return x.Signature
+	}
+	return nil
+}
+
 // PromiseMsg is the reply from an Acceptor to the Proposer in response to a PrepareMsg.
 // The Acceptor will only respond if the PrepareMsg.Rnd > Acceptor.Rnd.
+// In BFT mode, the promise is signed by the Acceptor, and each accepted PValue
+// carries the signature of the AcceptMsg in which it was accepted as proof.
 type PromiseMsg struct {
 	state         protoimpl.MessageState
 	sizeCache     protoimpl.SizeCache
 	unknownFields protoimpl.UnknownFields
 
-	Rnd      int32     `protobuf:"varint,1,opt,name=Rnd,proto3" json:"Rnd,omitempty"`
-	Accepted []*PValue `protobuf:"bytes,2,rep,name=Accepted,proto3" json:"Accepted,omitempty"`
+	Rnd       int32     `protobuf:"varint,1,opt,name=Rnd,proto3" json:"Rnd,omitempty"`
+	Accepted  []*PValue `protobuf:"bytes,2,rep,name=Accepted,proto3" json:"Accepted,omitempty"`
+	Signature []byte    `protobuf:"bytes,3,opt,name=Signature,proto3" json:"Signature,omitempty"`
 }
 
 func (x *PromiseMsg) Reset() {
@@ -267,6 +334,13 @@ func (x *PromiseMsg) GetAccepted() []*PValue {
 	return nil
 }
 
+func (x *PromiseMsg) GetSignature() []byte {
+	if x != nil {
+		return x.Signature
+	}
+	return nil
+}
+
 // AcceptMsg is sent by the Proposer, asking the Acceptors to lock-in the value, val.
 // If AcceptMsg.rnd < Acceptor.rnd, the message will be ignored.
 type AcceptMsg struct {
@@ -274,9 +348,12 @@ type AcceptMsg struct {
 	sizeCache     protoimpl.SizeCache
 	unknownFields protoimpl.UnknownFields
 
-	Slot uint32 `protobuf:"varint,1,opt,name=Slot,proto3" json:"Slot,omitempty"`
-	Rnd  int32  `protobuf:"varint,2,opt,name=Rnd,proto3" json:"Rnd,omitempty"`
-	Val  *Value `protobuf:"bytes,3,opt,name=Val,proto3" json:"Val,omitempty"`
+	Slot      uint32        `protobuf:"varint,1,opt,name=Slot,proto3" json:"Slot,omitempty"`
+	Rnd       int32         `protobuf:"varint,2,opt,name=Rnd,proto3" json:"Rnd,omitempty"`
+	Val       *Value        `protobuf:"bytes,3,opt,name=Val,proto3" json:"Val,omitempty"`
+	Trace     *TraceContext `protobuf:"bytes,4,opt,name=Trace,proto3" json:"Trace,omitempty"`
+	GroupID   uint32        `protobuf:"varint,5,opt,name=GroupID,proto3" json:"GroupID,omitempty"`
+	Signature []byte        `protobuf:"bytes,6,opt,name=Signature,proto3" json:"Signature,omitempty"` // the proposer's signature in BFT mode
 }
 
 func (x *AcceptMsg) Reset() {
@@ -332,16 +409,43 @@ func (x *AcceptMsg) GetVal() *Value {
 	return nil
 }
 
+func (x *AcceptMsg) GetTrace() *TraceContext {
+	if x != nil {
+		return x.Trace
+	}
+	return nil
+}
+
+func (x *AcceptMsg) GetGroupID() uint32 {
+	if x != nil {
+		return x.GroupID
+	}
+	return 0
+}
+
+func (x *AcceptMsg) GetSignature() []byte {
+	if x != nil {
+		return x.Signature
+	}
+	return nil
+}
+
 // LearnMsg is sent by an Acceptor to the Proposer, if the Acceptor agreed to lock-in the value, val.
 // The LearnMsg is also sent by the Proposer in a Commit.
+// In BFT mode, the Acceptor signs its LearnMsg, and the Proposer's Commit carries
+// the signatures of a quorum of Acceptors as a certificate that the value was decided.
 type LearnMsg struct {
 	state         protoimpl.MessageState
 	sizeCache     protoimpl.SizeCache
 	unknownFields protoimpl.UnknownFields
 
-	Slot uint32 `protobuf:"varint,1,opt,name=Slot,proto3" json:"Slot,omitempty"`
-	Rnd  int32  `protobuf:"varint,2,opt,name=Rnd,proto3" json:"Rnd,omitempty"`
-	Val  *Value `protobuf:"bytes,3,opt,name=Val,proto3" json:"Val,omitempty"`
+	Slot        uint32        `protobuf:"varint,1,opt,name=Slot,proto3" json:"Slot,omitempty"`
+	Rnd         int32         `protobuf:"varint,2,opt,name=Rnd,proto3" json:"Rnd,omitempty"`
+	Val         *Value        `protobuf:"bytes,3,opt,name=Val,proto3" json:"Val,omitempty"`
+	Trace       *TraceContext `protobuf:"bytes,4,opt,name=Trace,proto3" json:"Trace,omitempty"`
+	GroupID     uint32        `protobuf:"varint,5,opt,name=GroupID,proto3" json:"GroupID,omitempty"`
+	Signature   []byte        `protobuf:"bytes,6,opt,name=Signature,proto3" json:"Signature,omitempty"`
+	Certificate []*Vote       `protobuf:"bytes,7,rep,name=Certificate,proto3" json:"Certificate,omitempty"`
 }
 
 func (x *LearnMsg) Reset() {
@@ -397,20 +501,105 @@ func (x *LearnMsg) GetVal() *Value {
 	return nil
 }
 
+func (x *LearnMsg) GetTrace() *TraceContext {
+	if x != nil {
+		return x.Trace
+	}
+	return nil
+}
+
+func (x *LearnMsg) GetGroupID() uint32 {
+	if x != nil {
+		return x.GroupID
+	}
+	return 0
+}
+
+func (x *LearnMsg) GetSignature() []byte {
+	if x != nil {
+		return x.Signature
+	}
+	return nil
+}
+
+func (x *LearnMsg) GetCertificate() []*Vote {
+	if x != nil {
+		return x.Certificate
+	}
+	return nil
+}
+
+// Vote is an Acceptor's signature of a LearnMsg.
+type Vote struct {
+	state         protoimpl.MessageState
+	sizeCache     protoimpl.SizeCache
+	unknownFields protoimpl.UnknownFields
+
+	AcceptorID uint32 `protobuf:"varint,1,opt,name=AcceptorID,proto3" json:"AcceptorID,omitempty"`
+	Signature  []byte `protobuf:"bytes,2,opt,name=Signature,proto3" json:"Signature,omitempty"`
+}
+
+func (x *Vote) Reset() {
+	*x = Vote{}
+	if protoimpl.UnsafeEnabled {
+		mi := &file_proto_multipaxos_proto_msgTypes[6]
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		ms.StoreMessageInfo(mi)
+	}
+}
+
+func (x *Vote) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*Vote) ProtoMessage() {}
+
+func (x *Vote) ProtoReflect() protoreflect.Message {
+	mi := &file_proto_multipaxos_proto_msgTypes[6]
+	if protoimpl.UnsafeEnabled && x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use Vote.ProtoReflect.Descriptor instead.
+func (*Vote) Descriptor() ([]byte, []int) {
+	return file_proto_multipaxos_proto_rawDescGZIP(), []int{6}
+}
+
+func (x *Vote) GetAcceptorID() uint32 {
+	if x != nil {
+		return x.AcceptorID
+	}
+	return 0
+}
+
+func (x *Vote) GetSignature() []byte {
+	if x != nil {
+		return x.Signature
+	}
+	return nil
+}
+
 type PValue struct {
 	state         protoimpl.MessageState
 	sizeCache     protoimpl.SizeCache
 	unknownFields protoimpl.UnknownFields
 
-	Slot uint32 `protobuf:"varint,1,opt,name=Slot,proto3" json:"Slot,omitempty"`
-	Vrnd int32  `protobuf:"varint,2,opt,name=Vrnd,proto3" json:"Vrnd,omitempty"`
-	Vval *Value `protobuf:"bytes,3,opt,name=Vval,proto3" json:"Vval,omitempty"`
+	Slot  uint32 `protobuf:"varint,1,opt,name=Slot,proto3" json:"Slot,omitempty"`
+	Vrnd  int32  `protobuf:"varint,2,opt,name=Vrnd,proto3" json:"Vrnd,omitempty"`
+	Vval  *Value `protobuf:"bytes,3,opt,name=Vval,proto3" json:"Vval,omitempty"`
+	Proof []byte `protobuf:"bytes,4,opt,name=Proof,proto3" json:"Proof,omitempty"` // signature of the AcceptMsg in which the value was accepted (BFT mode)
 }
 
 func (x *PValue) Reset() {
 	*x = PValue{}
 	if protoimpl.UnsafeEnabled {
-		mi := &file_proto_multipaxos_proto_msgTypes[6]
+		mi := &file_proto_multipaxos_proto_msgTypes[7]
 		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 		ms.StoreMessageInfo(mi)
 	}
@@ -423,7 +612,7 @@ func (x *PValue) String() string {
 func (*PValue) ProtoMessage() {}
 
 func (x *PValue) ProtoReflect() protoreflect.Message {
-	mi := &file_proto_multipaxos_proto_msgTypes[6]
+	mi := &file_proto_multipaxos_proto_msgTypes[7]
 	if protoimpl.UnsafeEnabled && x != nil {
 		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 		if ms.LoadMessageInfo() == nil {
@@ -436,7 +625,7 @@ func (x *PValue) ProtoReflect() protoreflect.Message {
 
 // Deprecated: Use PValue.ProtoReflect.Descriptor instead.
 func (*PValue) Descriptor() ([]byte, []int) {
-	return file_proto_multipaxos_proto_rawDescGZIP(), []int{6}
+	return file_proto_multipaxos_proto_rawDescGZIP(), []int{7}
 }
 
 func (x *PValue) GetSlot() uint32 {
@@ -460,6 +649,71 @@ func (x *PValue) GetVval() *Value {
 	return nil
 }
 
+func (x *PValue) GetProof() []byte {
+	if x != nil {
+		return x.Proof
+	}
+	return nil
+}
+
+// TraceContext identifies the span that caused a message to be sent.
+// Gorums does not support per-call metadata, so the trace context is
+// carried in the messages themselves.
+type TraceContext struct {
+	state         protoimpl.MessageState
+	sizeCache     protoimpl.SizeCache
+	unknownFields protoimpl.UnknownFields
+
+	TraceID string `protobuf:"bytes,1,opt,name=TraceID,proto3" json:"TraceID,omitempty"`
+	SpanID  string `protobuf:"bytes,2,opt,name=SpanID,proto3" json:"SpanID,omitempty"`
+}
+
+func (x *TraceContext) Reset() {
+	*x = TraceContext{}
+	if protoimpl.UnsafeEnabled {
+		mi := &file_proto_multipaxos_proto_msgTypes[8]
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		ms.StoreMessageInfo(mi)
+	}
+}
+
+func (x *TraceContext) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*TraceContext) ProtoMessage() {}
+
+func (x *TraceContext) ProtoReflect() protoreflect.Message {
+	mi := &file_proto_multipaxos_proto_msgTypes[8]
+	if protoimpl.UnsafeEnabled && x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use TraceContext.ProtoReflect.Descriptor instead.
+func (*TraceContext) Descriptor() ([]byte, []int) {
+	return file_proto_multipaxos_proto_rawDescGZIP(), []int{8}
+}
+
+func (x *TraceContext) GetTraceID() string {
+	if x != nil {
+		return x.TraceID
+	}
+	return ""
+}
+
+func (x *TraceContext) GetSpanID() string {
+	if x != nil {
+		return x.SpanID
+	}
+	return ""
+}
+
 type Empty struct {
 	state         protoimpl.MessageState
 	sizeCache     protoimpl.SizeCache
@@ -469,7 +723,7 @@ type Empty struct {
 func (x *Empty) Reset() {
 	*x = Empty{}
 	if protoimpl.UnsafeEnabled {
-		mi := &file_proto_multipaxos_proto_msgTypes[7]
+		mi := &file_proto_multipaxos_proto_msgTypes[9]
 		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 		ms.StoreMessageInfo(mi)
 	}
@@ -482,7 +736,7 @@ func (x *Empty) String() string {
 func (*Empty) ProtoMessage() {}
 
 func (x *Empty) ProtoReflect() protoreflect.Message {
-	mi := &file_proto_multipaxos_proto_msgTypes[7]
+	mi := &file_proto_multipaxos_proto_msgTypes[9]
 	if protoimpl.UnsafeEnabled && x != nil {
 		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 		if ms.LoadMessageInfo() == nil {
@@ -495,7 +749,7 @@ func (x *Empty) ProtoReflect() protoreflect.Message {
 
 // Deprecated: Use Empty.ProtoReflect.Descriptor instead.
 func (*Empty) Descriptor() ([]byte, []int) {
-	return file_proto_multipaxos_proto_rawDescGZIP(), []int{7}
+	return file_proto_multipaxos_proto_rawDescGZIP(), []int{9}
 }
 
 var File_proto_multipaxos_proto protoreflect.FileDescriptor
@@ -503,63 +757,103 @@ var File_proto_multipaxos_proto protoreflect.FileDescriptor
 var file_proto_multipaxos_proto_rawDesc = []byte{
 	0x0a, 0x16, 0x70, 0x72, 0x6f, 0x74, 0x6f, 0x2f, 0x6d, 0x75, 0x6c, 0x74, 0x69, 0x70, 0x61, 0x78,
 	0x6f, 0x73, 0x2e, 0x70, 0x72, 0x6f, 0x74, 0x6f, 0x12, 0x05, 0x70, 0x72, 0x6f, 0x74, 0x6f, 0x1a,
-	0x0c, 0x67, 0x6f, 0x72, 0x75, 0x6d, 0x73, 0x2e, 0x70, 0x72, 0x6f, 0x74, 0x6f, 0x22, 0x7f, 0x0a,
-	0x05, 0x56, 0x61, 0x6c, 0x75, 0x65, 0x12, 0x1a, 0x0a, 0x08, 0x43, 0x6c, 0x69, 0x65, 0x6e, 0x74,
-	0x49, 0x44, 0x18, 0x01, 0x20, 0x01, 0x28, 0x09, 0x52, 0x08, 0x43, 0x6c, 0x69, 0x65, 0x6e, 0x74,
-	0x49, 0x44, 0x12, 0x1c, 0x0a, 0x09, 0x43, 0x6c, 0x69, 0x65, 0x6e, 0x74, 0x53, 0x65, 0x71, 0x18,
-	0x02, 0x20, 0x01, 0x28, 0x0d, 0x52, 0x09, 0x43, 0x6c, 0x69, 0x65, 0x6e, 0x74, 0x53, 0x65, 0x71,
-	0x12, 0x16, 0x0a, 0x06, 0x69, 0x73, 0x4e, 0x6f, 0x6f, 0x70, 0x18, 0x03, 0x20, 0x01, 0x28, 0x08,
-	0x52, 0x06, 0x69, 0x73, 0x4e, 0x6f, 0x6f, 0x70, 0x12, 0x24, 0x0a, 0x0d, 0x43, 0x6c, 0x69, 0x65,
-	0x6e, 0x74, 0x43, 0x6f, 0x6d, 0x6d, 0x61, 0x6e, 0x64, 0x18, 0x04, 0x20, 0x01, 0x28, 0x09, 0x52,
-	0x0d, 0x43, 0x6c, 0x69, 0x65, 0x6e, 0x74, 0x43, 0x6f, 0x6d, 0x6d, 0x61, 0x6e, 0x64, 0x22, 0x6a,
-	0x0a, 0x08, 0x52, 0x65, 0x73, 0x70, 0x6f, 0x6e, 0x73, 0x65, 0x12, 0x1a, 0x0a, 0x08, 0x43, 0x6c,
-	0x69, 0x65, 0x6e, 0x74, 0x49, 0x44, 0x18, 0x01, 0x20, 0x01, 0x28, 0x09, 0x52, 0x08, 0x43, 0x6c,
-	0x69, 0x65, 0x6e, 0x74, 0x49, 0x44, 0x12, 0x1c, 0x0a, 0x09, 0x43, 0x6c, 0x69, 0x65, 0x6e, 0x74,
-	0x53, 0x65, 0x71, 0x18, 0x02, 0x20, 0x01, 0x28, 0x0d, 0x52, 0x09, 0x43, 0x6c, 0x69, 0x65, 0x6e,
-	0x74, 0x53, 0x65, 0x71, 0x12, 0x24, 0x0a, 0x0d, 0x43, 0x6c, 0x69, 0x65, 0x6e, 0x74, 0x43, 0x6f,
-	0x6d, 0x6d, 0x61, 0x6e, 0x64, 0x18, 0x03, 0x20, 0x01, 0x28, 0x09, 0x52, 0x0d, 0x43, 0x6c, 0x69,
-	0x65, 0x6e, 0x74, 0x43, 0x6f, 0x6d, 0x6d, 0x61, 0x6e, 0x64, 0x22, 0x34, 0x0a, 0x0a, 0x50, 0x72,
-	0x65, 0x70, 0x61, 0x72, 0x65, 0x4d, 0x73, 0x67, 0x12, 0x12, 0x0a, 0x04, 0x53, 0x6c, 0x6f, 0x74,
-	0x18, 0x01, 0x20, 0x01, 0x28, 0x0d, 0x52, 0x04, 0x53, 0x6c, 0x6f, 0x74, 0x12, 0x12, 0x0a, 0x04,
-	0x43, 0x72, 0x6e, 0x64, 0x18, 0x02, 0x20, 0x01, 0x28, 0x05, 0x52, 0x04, 0x43, 0x72, 0x6e, 0x64,
-	0x22, 0x49, 0x0a, 0x0a, 0x50, 0x72, 0x6f, 0x6d, 0x69, 0x73, 0x65, 0x4d, 0x73, 0x67, 0x12, 0x10,
-	0x0a, 0x03, 0x52, 0x6e, 0x64, 0x18, 0x01, 0x20, 0x01, 0x28, 0x05, 0x52, 0x03, 0x52, 0x6e, 0x64,
-	0x12, 0x29, 0x0a, 0x08, 0x41, 0x63, 0x63, 0x65, 0x70, 0x74, 0x65, 0x64, 0x18, 0x02, 0x20, 0x03,
-	0x28, 0x0b, 0x32, 0x0d, 0x2e, 0x70, 0x72, 0x6f, 0x74, 0x6f, 0x2e, 0x50, 0x56, 0x61, 0x6c, 0x75,
-	0x65, 0x52, 0x08, 0x41, 0x63, 0x63, 0x65, 0x70, 0x74, 0x65, 0x64, 0x22, 0x51, 0x0a, 0x09, 0x41,
-	0x63, 0x63, 0x65, 0x70, 0x74, 0x4d, 0x73, 0x67, 0x12, 0x12, 0x0a, 0x04, 0x53, 0x6c, 0x6f, 0x74,
-	0x18, 0x01, 0x20, 0x01, 0x28, 0x0d, 0x52, 0x04, 0x53, 0x6c, 0x6f, 0x74, 0x12, 0x10, 0x0a, 0x03,
-	0x52, 0x6e, 0x64, 0x18, 0x02, 0x20, 0x01, 0x28, 0x05, 0x52, 0x03, 0x52, 0x6e, 0x64, 0x12, 0x1e,
-	0x0a, 0x03, 0x56, 0x61, 0x6c, 0x18, 0x03, 0x20, 0x01, 0x28, 0x0b, 0x32, 0x0c, 0x2e, 0x70, 0x72,
-	0x6f, 0x74, 0x6f, 0x2e, 0x56, 0x61, 0x6c, 0x75, 0x65, 0x52, 0x03, 0x56, 0x61, 0x6c, 0x22, 0x50,
-	0x0a, 0x08, 0x4c, 0x65, 0x61, 0x72, 0x6e, 0x4d, 0x73, 0x67, 0x12, 0x12, 0x0a, 0x04, 0x53, 0x6c,
+	0x0c, 0x67, 0x6f, 0x72, 0x75, 0x6d, 0x73, 0x2e, 0x70, 0x72, 0x6f, 0x74, 0x6f, 0x22, 0x80, 0x02,
+	0x0a, 0x05, 0x56, 0x61, 0x6c, 0x75, 0x65, 0x12, 0x1a, 0x0a, 0x08, 0x43, 0x6c, 0x69, 0x65, 0x6e,
+	0x74, 0x49, 0x44, 0x18, 0x01, 0x20, 0x01, 0x28, 0x09, 0x52, 0x08, 0x43, 0x6c, 0x69, 0x65, 0x6e,
+	0x74, 0x49, 0x44, 0x12, 0x1c, 0x0a, 0x09, 0x43, 0x6c, 0x69, 0x65, 0x6e, 0x74, 0x53, 0x65, 0x71,
+	0x18, 0x02, 0x20, 0x01, 0x28, 0x0d, 0x52, 0x09, 0x43, 0x6c, 0x69, 0x65, 0x6e, 0x74, 0x53, 0x65,
+	0x71, 0x12, 0x16, 0x0a, 0x06, 0x69, 0x73, 0x4e, 0x6f, 0x6f, 0x70, 0x18, 0x03, 0x20, 0x01, 0x28,
+	0x08, 0x52, 0x06, 0x69, 0x73, 0x4e, 0x6f, 0x6f, 0x70, 0x12, 0x24, 0x0a, 0x0d, 0x43, 0x6c, 0x69,
+	0x65, 0x6e, 0x74, 0x43, 0x6f, 0x6d, 0x6d, 0x61, 0x6e, 0x64, 0x18, 0x04, 0x20, 0x01, 0x28, 0x09,
+	0x52, 0x0d, 0x43, 0x6c, 0x69, 0x65, 0x6e, 0x74, 0x43, 0x6f, 0x6d, 0x6d, 0x61, 0x6e, 0x64, 0x12,
+	0x29, 0x0a, 0x05, 0x54, 0x72, 0x61, 0x63, 0x65, 0x18, 0x05, 0x20, 0x01, 0x28, 0x0b, 0x32, 0x13,
+	0x2e, 0x70, 0x72, 0x6f, 0x74, 0x6f, 0x2e, 0x54, 0x72, 0x61, 0x63, 0x65, 0x43, 0x6f, 0x6e, 0x74,
+	0x65, 0x78, 0x74, 0x52, 0x05, 0x54, 0x72, 0x61, 0x63, 0x65, 0x12, 0x18, 0x0a, 0x07, 0x47, 0x72,
+	0x6f, 0x75, 0x70, 0x49, 0x44, 0x18, 0x06, 0x20, 0x01, 0x28, 0x0d, 0x52, 0x07, 0x47, 0x72, 0x6f,
+	0x75, 0x70, 0x49, 0x44, 0x12, 0x18, 0x0a, 0x07, 0x50, 0x61, 0x79, 0x6c, 0x6f, 0x61, 0x64, 0x18,
+	0x07, 0x20, 0x01, 0x28, 0x0c, 0x52, 0x07, 0x50, 0x61, 0x79, 0x6c, 0x6f, 0x61, 0x64, 0x12, 0x20,
+	0x0a, 0x0b, 0x43, 0x6f, 0x6e, 0x74, 0x65, 0x6e, 0x74, 0x54, 0x79, 0x70, 0x65, 0x18, 0x08, 0x20,
+	0x01, 0x28, 0x09, 0x52, 0x0b, 0x43, 0x6f, 0x6e, 0x74, 0x65, 0x6e, 0x74, 0x54, 0x79, 0x70, 0x65,
+	0x22, 0x82, 0x01, 0x0a, 0x08, 0x52, 0x65, 0x73, 0x70, 0x6f, 0x6e, 0x73, 0x65, 0x12, 0x1a, 0x0a,
+	0x08, 0x43, 0x6c, 0x69, 0x65, 0x6e, 0x74, 0x49, 0x44, 0x18, 0x01, 0x20, 0x01, 0x28, 0x09, 0x52,
+	0x08, 0x43, 0x6c, 0x69, 0x65, 0x6e, 0x74, 0x49, 0x44, 0x12, 0x1c, 0x0a, 0x09, 0x43, 0x6c, 0x69,
+	0x65, 0x6e, 0x74, 0x53, 0x65, 0x71, 0x18, 0x02, 0x20, 0x01, 0x28, 0x0d, 0x52, 0x09, 0x43, 0x6c,
+	0x69, 0x65, 0x6e, 0x74, 0x53, 0x65, 0x71, 0x12, 0x24, 0x0a, 0x0d, 0x43, 0x6c, 0x69, 0x65, 0x6e,
+	0x74, 0x43, 0x6f, 0x6d, 0x6d, 0x61, 0x6e, 0x64, 0x18, 0x03, 0x20, 0x01, 0x28, 0x09, 0x52, 0x0d,
+	0x43, 0x6c, 0x69, 0x65, 0x6e, 0x74, 0x43, 0x6f, 0x6d, 0x6d, 0x61, 0x6e, 0x64, 0x12, 0x16, 0x0a,
+	0x06, 0x52, 0x65, 0x73, 0x75, 0x6c, 0x74, 0x18, 0x04, 0x20, 0x01, 0x28, 0x09, 0x52, 0x06, 0x52,
+	0x65, 0x73, 0x75, 0x6c, 0x74, 0x22, 0x6c, 0x0a, 0x0a, 0x50, 0x72, 0x65, 0x70, 0x61, 0x72, 0x65,
+	0x4d, 0x73, 0x67, 0x12, 0x12, 0x0a, 0x04, 0x53, 0x6c, 0x6f, 0x74, 0x18, 0x01, 0x20, 0x01, 0x28,
+	0x0d, 0x52, 0x04, 0x53, 0x6c, 0x6f, 0x74, 0x12, 0x12, 0x0a, 0x04, 0x43, 0x72, 0x6e, 0x64, 0x18,
+	0x02, 0x20, 0x01, 0x28, 0x05, 0x52, 0x04, 0x43, 0x72, 0x6e, 0x64, 0x12, 0x18, 0x0a, 0x07, 0x47,
+	0x72, 0x6f, 0x75, 0x70, 0x49, 0x44, 0x18, 0x03, 0x20, 0x01, 0x28, 0x0d, 0x52, 0x07, 0x47, 0x72,
+	0x6f, 0x75, 0x70, 0x49, 0x44, 0x12, 0x1c, 0x0a, 0x09, 0x53, 0x69, 0x67, 0x6e, 0x61, 0x74, 0x75,
+	0x72, 0x65, 0x18, 0x04, 0x20, 0x01, 0x28, 0x0c, 0x52, 0x09, 0x53, 0x69, 0x67, 0x6e, 0x61, 0x74,
+	0x75, 0x72, 0x65, 0x22, 0x67, 0x0a, 0x0a, 0x50, 0x72, 0x6f, 0x6d, 0x69, 0x73, 0x65, 0x4d, 0x73,
+	0x67, 0x12, 0x10, 0x0a, 0x03, 0x52, 0x6e, 0x64, 0x18, 0x01, 0x20, 0x01, 0x28, 0x05, 0x52, 0x03,
+	0x52, 0x6e, 0x64, 0x12, 0x29, 0x0a, 0x08, 0x41, 0x63, 0x63, 0x65, 0x70, 0x74, 0x65, 0x64, 0x18,
+	0x02, 0x20, 0x03, 0x28, 0x0b, 0x32, 0x0d, 0x2e, 0x70, 0x72, 0x6f, 0x74, 0x6f, 0x2e, 0x50, 0x56,
+	0x61, 0x6c, 0x75, 0x65, 0x52, 0x08, 0x41, 0x63, 0x63, 0x65, 0x70, 0x74, 0x65, 0x64, 0x12, 0x1c,
+	0x0a, 0x09, 0x53, 0x69, 0x67, 0x6e, 0x61, 0x74, 0x75, 0x72, 0x65, 0x18, 0x03, 0x20, 0x01, 0x28,
+	0x0c, 0x52, 0x09, 0x53, 0x69, 0x67, 0x6e, 0x61, 0x74, 0x75, 0x72, 0x65, 0x22, 0xb4, 0x01, 0x0a,
+	0x09, 0x41, 0x63, 0x63, 0x65, 0x70, 0x74, 0x4d, 0x73, 0x67, 0x12, 0x12, 0x0a, 0x04, 0x53, 0x6c,
 	0x6f, 0x74, 0x18, 0x01, 0x20, 0x01, 0x28, 0x0d, 0x52, 0x04, 0x53, 0x6c, 0x6f, 0x74, 0x12, 0x10,
 	0x0a, 0x03, 0x52, 0x6e, 0x64, 0x18, 0x02, 0x20, 0x01, 0x28, 0x05, 0x52, 0x03, 0x52, 0x6e, 0x64,
 	0x12, 0x1e, 0x0a, 0x03, 0x56, 0x61, 0x6c, 0x18, 0x03, 0x20, 0x01, 0x28, 0x0b, 0x32, 0x0c, 0x2e,
 	0x70, 0x72, 0x6f, 0x74, 0x6f, 0x2e, 0x56, 0x61, 0x6c, 0x75, 0x65, 0x52, 0x03, 0x56, 0x61, 0x6c,
-	0x22, 0x52, 0x0a, 0x06, 0x50, 0x56, 0x61, 0x6c, 0x75, 0x65, 0x12, 0x12, 0x0a, 0x04, 0x53, 0x6c,
-	0x6f, 0x74, 0x18, 0x01, 0x20, 0x01, 0x28, 0x0d, 0x52, 0x04, 0x53, 0x6c, 0x6f, 0x74, 0x12, 0x12,
-	0x0a, 0x04, 0x56, 0x72, 0x6e, 0x64, 0x18, 0x02, 0x20, 0x01, 0x28, 0x05, 0x52, 0x04, 0x56, 0x72,
-	0x6e, 0x64, 0x12, 0x20, 0x0a, 0x04, 0x56, 0x76, 0x61, 0x6c, 0x18, 0x03, 0x20, 0x01, 0x28, 0x0b,
-	0x32, 0x0c, 0x2e, 0x70, 0x72, 0x6f, 0x74, 0x6f, 0x2e, 0x56, 0x61, 0x6c, 0x75, 0x65, 0x52, 0x04,
-	0x56, 0x76, 0x61, 0x6c, 0x22, 0x07, 0x0a, 0x05, 0x45, 0x6d, 0x70, 0x74, 0x79, 0x32, 0xda, 0x01,
-	0x0a, 0x0a, 0x4d, 0x75, 0x6c, 0x74, 0x69, 0x50, 0x61, 0x78, 0x6f, 0x73, 0x12, 0x35, 0x0a, 0x07,
-	0x50, 0x72, 0x65, 0x70, 0x61, 0x72, 0x65, 0x12, 0x11, 0x2e, 0x70, 0x72, 0x6f, 0x74, 0x6f, 0x2e,
-	0x50, 0x72, 0x65, 0x70, 0x61, 0x72, 0x65, 0x4d, 0x73, 0x67, 0x1a, 0x11, 0x2e, 0x70, 0x72, 0x6f,
-	0x74, 0x6f, 0x2e, 0x50, 0x72, 0x6f, 0x6d, 0x69, 0x73, 0x65, 0x4d, 0x73, 0x67, 0x22, 0x04, 0xa0,
-	0xb5, 0x18, 0x01, 0x12, 0x31, 0x0a, 0x06, 0x41, 0x63, 0x63, 0x65, 0x70, 0x74, 0x12, 0x10, 0x2e,
-	0x70, 0x72, 0x6f, 0x74, 0x6f, 0x2e, 0x41, 0x63, 0x63, 0x65, 0x70, 0x74, 0x4d, 0x73, 0x67, 0x1a,
-	0x0f, 0x2e, 0x70, 0x72, 0x6f, 0x74, 0x6f, 0x2e, 0x4c, 0x65, 0x61, 0x72, 0x6e, 0x4d, 0x73, 0x67,
-	0x22, 0x04, 0xa0, 0xb5, 0x18, 0x01, 0x12, 0x2d, 0x0a, 0x06, 0x43, 0x6f, 0x6d, 0x6d, 0x69, 0x74,
-	0x12, 0x0f, 0x2e, 0x70, 0x72, 0x6f, 0x74, 0x6f, 0x2e, 0x4c, 0x65, 0x61, 0x72, 0x6e, 0x4d, 0x73,
-	0x67, 0x1a, 0x0c, 0x2e, 0x70, 0x72, 0x6f, 0x74, 0x6f, 0x2e, 0x45, 0x6d, 0x70, 0x74, 0x79, 0x22,
-	0x04, 0x98, 0xb5, 0x18, 0x01, 0x12, 0x33, 0x0a, 0x0c, 0x43, 0x6c, 0x69, 0x65, 0x6e, 0x74, 0x48,
-	0x61, 0x6e, 0x64, 0x6c, 0x65, 0x12, 0x0c, 0x2e, 0x70, 0x72, 0x6f, 0x74, 0x6f, 0x2e, 0x56, 0x61,
-	0x6c, 0x75, 0x65, 0x1a, 0x0f, 0x2e, 0x70, 0x72, 0x6f, 0x74, 0x6f, 0x2e, 0x52, 0x65, 0x73, 0x70,
-	0x6f, 0x6e, 0x73, 0x65, 0x22, 0x04, 0xa0, 0xb5, 0x18, 0x01, 0x42, 0x1f, 0x5a, 0x1d, 0x64, 0x61,
-	0x74, 0x35, 0x32, 0x30, 0x2f, 0x6c, 0x61, 0x62, 0x35, 0x2f, 0x67, 0x6f, 0x72, 0x75, 0x6d, 0x73,
-	0x70, 0x61, 0x78, 0x6f, 0x73, 0x2f, 0x70, 0x72, 0x6f, 0x74, 0x6f, 0x62, 0x06, 0x70, 0x72, 0x6f,
-	0x74, 0x6f, 0x33,
+	0x12, 0x29, 0x0a, 0x05, 0x54, 0x72, 0x61, 0x63, 0x65, 0x18, 0x04, 0x20, 0x01, 0x28, 0x0b, 0x32,
+	0x13, 0x2e, 0x70, 0x72, 0x6f, 0x74, 0x6f, 0x2e, 0x54, 0x72, 0x61, 0x63, 0x65, 0x43, 0x6f, 0x6e,
+	0x74, 0x65, 0x78, 0x74, 0x52, 0x05, 0x54, 0x72, 0x61, 0x63, 0x65, 0x12, 0x18, 0x0a, 0x07, 0x47,
+	0x72, 0x6f, 0x75, 0x70, 0x49, 0x44, 0x18, 0x05, 0x20, 0x01, 0x28, 0x0d, 0x52, 0x07, 0x47, 0x72,
+	0x6f, 0x75, 0x70, 0x49, 0x44, 0x12, 0x1c, 0x0a, 0x09, 0x53, 0x69, 0x67, 0x6e, 0x61, 0x74, 0x75,
+	0x72, 0x65, 0x18, 0x06, 0x20, 0x01, 0x28, 0x0c, 0x52, 0x09, 0x53, 0x69, 0x67, 0x6e, 0x61, 0x74,
+	0x75, 0x72, 0x65, 0x22, 0xe2, 0x01, 0x0a, 0x08, 0x4c, 0x65, 0x61, 0x72, 0x6e, 0x4d, 0x73, 0x67,
+	0x12, 0x12, 0x0a, 0x04, 0x53, 0x6c, 0x6f, 0x74, 0x18, 0x01, 0x20, 0x01, 0x28, 0x0d, 0x52, 0x04,
+	0x53, 0x6c, 0x6f, 0x74, 0x12, 0x10, 0x0a, 0x03, 0x52, 0x6e, 0x64, 0x18, 0x02, 0x20, 0x01, 0x28,
+	0x05, 0x52, 0x03, 0x52, 0x6e, 0x64, 0x12, 0x1e, 0x0a, 0x03, 0x56, 0x61, 0x6c, 0x18, 0x03, 0x20,
+	0x01, 0x28, 0x0b, 0x32, 0x0c, 0x2e, 0x70, 0x72, 0x6f, 0x74, 0x6f, 0x2e, 0x56, 0x61, 0x6c, 0x75,
+	0x65, 0x52, 0x03, 0x56, 0x61, 0x6c, 0x12, 0x29, 0x0a, 0x05, 0x54, 0x72, 0x61, 0x63, 0x65, 0x18,
+	0x04, 0x20, 0x01, 0x28, 0x0b, 0x32, 0x13, 0x2e, 0x70, 0x72, 0x6f, 0x74, 0x6f, 0x2e, 0x54, 0x72,
+	0x61, 0x63, 0x65, 0x43, 0x6f, 0x6e, 0x74, 0x65, 0x78, 0x74, 0x52, 0x05, 0x54, 0x72, 0x61, 0x63,
+	0x65, 0x12, 0x18, 0x0a, 0x07, 0x47, 0x72, 0x6f, 0x75, 0x70, 0x49, 0x44, 0x18, 0x05, 0x20, 0x01,
+	0x28, 0x0d, 0x52, 0x07, 0x47, 0x72, 0x6f, 0x75, 0x70, 0x49, 0x44, 0x12, 0x1c, 0x0a, 0x09, 0x53,
+	0x69, 0x67, 0x6e, 0x61, 0x74, 0x75, 0x72, 0x65, 0x18, 0x06, 0x20, 0x01, 0x28, 0x0c, 0x52, 0x09,
+	0x53, 0x69, 0x67, 0x6e, 0x61, 0x74, 0x75, 0x72, 0x65, 0x12, 0x2d, 0x0a, 0x0b, 0x43, 0x65, 0x72,
+	0x74, 0x69, 0x66, 0x69, 0x63, 0x61, 0x74, 0x65, 0x18, 0x07, 0x20, 0x03, 0x28, 0x0b, 0x32, 0x0b,
+	0x2e, 0x70, 0x72, 0x6f, 0x74, 0x6f, 0x2e, 0x56, 0x6f, 0x74, 0x65, 0x52, 0x0b, 0x43, 0x65, 0x72,
+	0x74, 0x69, 0x66, 0x69, 0x63, 0x61, 0x74, 0x65, 0x22, 0x44, 0x0a, 0x04, 0x56, 0x6f, 0x74, 0x65,
+	0x12, 0x1e, 0x0a, 0x0a, 0x41, 0x63, 0x63, 0x65, 0x70, 0x74, 0x6f, 0x72, 0x49, 0x44, 0x18, 0x01,
+	0x20, 0x01, 0x28, 0x0d, 0x52, 0x0a, 0x41, 0x63, 0x63, 0x65, 0x70, 0x74, 0x6f, 0x72, 0x49, 0x44,
+	0x12, 0x1c, 0x0a, 0x09, 0x53, 0x69, 0x67, 0x6e, 0x61, 0x74, 0x75, 0x72, 0x65, 0x18, 0x02, 0x20,
+	0x01, 0x28, 0x0c, 0x52, 0x09, 0x53, 0x69, 0x67, 0x6e, 0x61, 0x74, 0x75, 0x72, 0x65, 0x22, 0x68,
+	0x0a, 0x06, 0x50, 0x56, 0x61, 0x6c, 0x75, 0x65, 0x12, 0x12, 0x0a, 0x04, 0x53, 0x6c, 0x6f, 0x74,
+	0x18, 0x01, 0x20, 0x01, 0x28, 0x0d, 0x52, 0x04, 0x53, 0x6c, 0x6f, 0x74, 0x12, 0x12, 0x0a, 0x04,
+	0x56, 0x72, 0x6e, 0x64, 0x18, 0x02, 0x20, 0x01, 0x28, 0x05, 0x52, 0x04, 0x56, 0x72, 0x6e, 0x64,
+	0x12, 0x20, 0x0a, 0x04, 0x56, 0x76, 0x61, 0x6c, 0x18, 0x03, 0x20, 0x01, 0x28, 0x0b, 0x32, 0x0c,
+	0x2e, 0x70, 0x72, 0x6f, 0x74, 0x6f, 0x2e, 0x56, 0x61, 0x6c, 0x75, 0x65, 0x52, 0x04, 0x56, 0x76,
+	0x61, 0x6c, 0x12, 0x14, 0x0a, 0x05, 0x50, 0x72, 0x6f, 0x6f, 0x66, 0x18, 0x04, 0x20, 0x01, 0x28,
+	0x0c, 0x52, 0x05, 0x50, 0x72, 0x6f, 0x6f, 0x66, 0x22, 0x40, 0x0a, 0x0c, 0x54, 0x72, 0x61, 0x63,
+	0x65, 0x43, 0x6f, 0x6e, 0x74, 0x65, 0x78, 0x74, 0x12, 0x18, 0x0a, 0x07, 0x54, 0x72, 0x61, 0x63,
+	0x65, 0x49, 0x44, 0x18, 0x01, 0x20, 0x01, 0x28, 0x09, 0x52, 0x07, 0x54, 0x72, 0x61, 0x63, 0x65,
+	0x49, 0x44, 0x12, 0x16, 0x0a, 0x06, 0x53, 0x70, 0x61, 0x6e, 0x49, 0x44, 0x18, 0x02, 0x20, 0x01,
+	0x28, 0x09, 0x52, 0x06, 0x53, 0x70, 0x61, 0x6e, 0x49, 0x44, 0x22, 0x07, 0x0a, 0x05, 0x45, 0x6d,
+	0x70, 0x74, 0x79, 0x32, 0xda, 0x01, 0x0a, 0x0a, 0x4d, 0x75, 0x6c, 0x74, 0x69, 0x50, 0x61, 0x78,
+	0x6f, 0x73, 0x12, 0x35, 0x0a, 0x07, 0x50, 0x72, 0x65, 0x70, 0x61, 0x72, 0x65, 0x12, 0x11, 0x2e,
+	0x70, 0x72, 0x6f, 0x74, 0x6f, 0x2e, 0x50, 0x72, 0x65, 0x70, 0x61, 0x72, 0x65, 0x4d, 0x73, 0x67,
+	0x1a, 0x11, 0x2e, 0x70, 0x72, 0x6f, 0x74, 0x6f, 0x2e, 0x50, 0x72, 0x6f, 0x6d, 0x69, 0x73, 0x65,
+	0x4d, 0x73, 0x67, 0x22, 0x04, 0xa0, 0xb5, 0x18, 0x01, 0x12, 0x31, 0x0a, 0x06, 0x41, 0x63, 0x63,
+	0x65, 0x70, 0x74, 0x12, 0x10, 0x2e, 0x70, 0x72, 0x6f, 0x74, 0x6f, 0x2e, 0x41, 0x63, 0x63, 0x65,
+	0x70, 0x74, 0x4d, 0x73, 0x67, 0x1a, 0x0f, 0x2e, 0x70, 0x72, 0x6f, 0x74, 0x6f, 0x2e, 0x4c, 0x65,
+	0x61, 0x72, 0x6e, 0x4d, 0x73, 0x67, 0x22, 0x04, 0xa0, 0xb5, 0x18, 0x01, 0x12, 0x2d, 0x0a, 0x06,
+	0x43, 0x6f, 0x6d, 0x6d, 0x69, 0x74, 0x12, 0x0f, 0x2e, 0x70, 0x72, 0x6f, 0x74, 0x6f, 0x2e, 0x4c,
+	0x65, 0x61, 0x72, 0x6e, 0x4d, 0x73, 0x67, 0x1a, 0x0c, 0x2e, 0x70, 0x72, 0x6f, 0x74, 0x6f, 0x2e,
+	0x45, 0x6d, 0x70, 0x74, 0x79, 0x22, 0x04, 0x98, 0xb5, 0x18, 0x01, 0x12, 0x33, 0x0a, 0x0c, 0x43,
+	0x6c, 0x69, 0x65, 0x6e, 0x74, 0x48, 0x61, 0x6e, 0x64, 0x6c, 0x65, 0x12, 0x0c, 0x2e, 0x70, 0x72,
+	0x6f, 0x74, 0x6f, 0x2e, 0x56, 0x61, 0x6c, 0x75, 0x65, 0x1a, 0x0f, 0x2e, 0x70, 0x72, 0x6f, 0x74,
+	0x6f, 0x2e, 0x52, 0x65, 0x73, 0x70, 0x6f, 0x6e, 0x73, 0x65, 0x22, 0x04, 0xa0, 0xb5, 0x18, 0x01,
+	0x42, 0x1f, 0x5a, 0x1d, 0x64, 0x61, 0x74, 0x35, 0x32, 0x30, 0x2f, 0x6c, 0x61, 0x62, 0x35, 0x2f,
+	0x67, 0x6f, 0x72, 0x75, 0x6d, 0x73, 0x70, 0x61, 0x78, 0x6f, 0x73, 0x2f, 0x70, 0x72, 0x6f, 0x74,
+	0x6f, 0x62, 0x06, 0x70, 0x72, 0x6f, 0x74, 0x6f, 0x33,
 }
 
 var (
@@ -574,35 +868,41 @@ func file_proto_multipaxos_proto_rawDescGZIP() []byte {
 	return file_proto_multipaxos_proto_rawDescData
 }
 
-var file_proto_multipaxos_proto_msgTypes = make([]protoimpl.MessageInfo, 8)
+var file_proto_multipaxos_proto_msgTypes = make([]protoimpl.MessageInfo, 10)
 var file_proto_multipaxos_proto_goTypes = []interface{}{
-	(*Value)(nil),      // 0: proto.Value
-	(*Response)(nil),   // 1: proto.Response
-	(*PrepareMsg)(nil), // 2: proto.PrepareMsg
-	(*PromiseMsg)(nil), // 3: proto.PromiseMsg
-	(*AcceptMsg)(nil),  // 4: proto.AcceptMsg
-	(*LearnMsg)(nil),   // 5: proto.LearnMsg
-	(*PValue)(nil),     // 6: proto.PValue
-	(*Empty)(nil),      // 7: proto.Empty
+	(*Value)(nil),        // 0: proto.Value
+	(*Response)(nil),     // 1: proto.Response
+	(*PrepareMsg)(nil),   // 2: proto.PrepareMsg
+	(*PromiseMsg)(nil),   // 3: proto.PromiseMsg
+	(*AcceptMsg)(nil),    // 4: proto.AcceptMsg
+	(*LearnMsg)(nil),     // 5: proto.LearnMsg
+	(*Vote)(nil),         // 6: proto.Vote
+	(*PValue)(nil),       // 7: proto.PValue
+	(*TraceContext)(nil), // 8: proto.TraceContext
+	(*Empty)(nil),        // 9: proto.Empty
 }
 var file_proto_multipaxos_proto_depIdxs = []int32{
-	6, // 0: proto.PromiseMsg.Accepted:type_name -> proto.PValue
-	0, // 1: proto.AcceptMsg.Val:type_name -> proto.Value
-	0, // 2: proto.LearnMsg.Val:type_name -> proto.Value
-	0, // 3: proto.PValue.Vval:type_name -> proto.Value
-	2, // 4: proto.MultiPaxos.Prepare:input_type -> proto.PrepareMsg
-	4, // 5: proto.MultiPaxos.Accept:input_type -> proto.AcceptMsg
-	5, // 6: proto.MultiPaxos.Commit:input_type -> proto.LearnMsg
-	0, // 7: proto.MultiPaxos.ClientHandle:input_type -> proto.Value
-	3, // 8: proto.MultiPaxos.Prepare:output_type -> proto.PromiseMsg
-	5, // 9: proto.MultiPaxos.Accept:output_type -> proto.LearnMsg
-	7, // 10: proto.MultiPaxos.Commit:output_type -> proto.Empty
-	1, // 11: proto.MultiPaxos.ClientHandle:output_type -> proto.Response
-	8, // [8:12] is the sub-list for method output_type
-	4, // [4:8] is the sub-list for method input_type
-	4, // [4:4] is the sub-list for extension type_name
-	4, // [4:4] is the sub-list for extension extendee
-	0, // [0:4] is the sub-list for field type_name
+	8,  // 0: proto.Value.Trace:type_name -> proto.TraceContext
+	7,  // 1: proto.PromiseMsg.Accepted:type_name -> proto.PValue
+	0,  // 2: proto.AcceptMsg.Val:type_name -> proto.Value
+	8,  // 3: proto.AcceptMsg.Trace:type_name -> proto.TraceContext
+	0,  // 4: proto.LearnMsg.Val:type_name -> proto.Value
+	8,  // 5: proto.LearnMsg.Trace:type_name -> proto.TraceContext
+	6,  // 6: proto.LearnMsg.Certificate:type_name -> proto.Vote
+	0,  // 7: proto.PValue.Vval:type_name -> proto.Value
+	2,  // 8: proto.MultiPaxos.Prepare:input_type -> proto.PrepareMsg
+	4,  // 9: proto.MultiPaxos.Accept:input_type -> proto.AcceptMsg
+	5,  // 10: proto.MultiPaxos.Commit:input_type -> proto.LearnMsg
+	0,  // 11: proto.MultiPaxos.ClientHandle:input_type -> proto.Value
+	3,  // 12: proto.MultiPaxos.Prepare:output_type -> proto.PromiseMsg
+	5,  // 13: proto.MultiPaxos.Accept:output_type -> proto.LearnMsg
+	9,  // 14: proto.MultiPaxos.Commit:output_type -> proto.Empty
+	1,  // 15: proto.MultiPaxos.ClientHandle:output_type -> proto.Response
+	12, // [12:16] is the sub-list for method output_type
+	8,  // [8:12] is the sub-list for method input_type
+	8,  // [8:8] is the sub-list for extension type_name
+	8,  // [8:8] is the sub-list for extension extendee
+	0,  // [0:8] is the sub-list for field type_name
 }
 
 func init() { file_proto_multipaxos_proto_init() }
@@ -684,7 +984,7 @@ func file_proto_multipaxos_proto_init() {
 			}
 		}
 		file_proto_multipaxos_proto_msgTypes[6].Exporter = func(v interface{}, i int) interface{} {
-			switch v := v.(*PValue); i {
+			switch v := v.(*Vote); i {
 			case 0:
 				return &v.state
 			case 1:
@@ -696,6 +996,30 @@ func file_proto_multipaxos_proto_init() {
 			}
 		}
 		file_proto_multipaxos_proto_msgTypes[7].Exporter = func(v interface{}, i int) interface{} {
+			switch v := v.(*PValue); i {
+			case 0:
+				return &v.state
+			case 1:
+				return &v.sizeCache
+			case 2:
+				return &v.unknownFields
+			default:
+				return nil
+			}
+		}
+		file_proto_multipaxos_proto_msgTypes[8].Exporter = func(v interface{}, i int) interface{} {
+			switch v := v.(*TraceContext); i {
+			case 0:
+				return &v.state
+			case 1:
+				return &v.sizeCache
+			case 2:
+				return &v.unknownFields
+			default:
+				return nil
+			}
+		}
+		file_proto_multipaxos_proto_msgTypes[9].Exporter = func(v interface{}, i int) interface{} {
 			switch v := v.(*Empty); i {
 			case 0:
 				return &v.state
@@ -714,7 +1038,7 @@ func file_proto_multipaxos_proto_init() {
 			GoPackagePath: reflect.TypeOf(x{}).PkgPath(),
 			RawDescriptor: file_proto_multipaxos_proto_rawDesc,
 			NumEnums:      0,
-			NumMessages:   8,
+			NumMessages:   10,
 			NumExtensions: 0,
 			NumServices:   1,
 		},
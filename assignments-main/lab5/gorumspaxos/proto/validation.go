@@ -103,6 +103,13 @@ func (request *Value) Hash() uint64 {
 	binary.LittleEndian.PutUint32(b, request.ClientSeq)
 	h.Write([]byte(b))
 	h.Write([]byte(request.ClientCommand))
+	if request.ContentType != "" || len(request.Payload) > 0 {
+		// length-prefixed, so that payloads cannot be confused with commands
+		binary.LittleEndian.PutUint32(b, uint32(len(request.ContentType)))
+		h.Write(b)
+		h.Write([]byte(request.ContentType))
+		h.Write(request.Payload)
+	}
 	bHash := h.Sum(nil)
 	return binary.LittleEndian.Uint64(bHash)
 }
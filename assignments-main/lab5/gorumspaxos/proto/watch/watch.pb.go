@@ -0,0 +1,165 @@
+// Code generated by protoc-gen-go. DO NOT EDIT.
+// versions:
+// 	protoc-gen-go v1.33.0
+// 	protoc        v4.25.3
+// source: proto/watch/watch.proto
+
+package watch
+
+import (
+	proto "dat520/lab5/gorumspaxos/proto"
+	_ "github.com/relab/gorums"
+	protoreflect "google.golang.org/protobuf/reflect/protoreflect"
+	protoimpl "google.golang.org/protobuf/runtime/protoimpl"
+	reflect "reflect"
+	sync "sync"
+)
+
+const (
+	// Verify that this generated code is sufficiently up-to-date.
+	_ = protoimpl.EnforceVersion(20 - protoimpl.MinVersion)
+	// Verify that runtime/protoimpl is sufficiently up-to-date.
+	_ = protoimpl.EnforceVersion(protoimpl.MaxVersion - 20)
+)
+
+type WatchRequest struct {
+	state         protoimpl.MessageState
+	sizeCache     protoimpl.SizeCache
+	unknownFields protoimpl.UnknownFields
+
+	FromSlot uint32 `protobuf:"varint,1,opt,name=FromSlot,proto3" json:"FromSlot,omitempty"` // first slot to send; zero is the same as one
+	GroupID  uint32 `protobuf:"varint,2,opt,name=GroupID,proto3" json:"GroupID,omitempty"`
+}
+
+func (x *WatchRequest) Reset() {
+	*x = WatchRequest{}
+	if protoimpl.UnsafeEnabled {
+		mi := &file_proto_watch_watch_proto_msgTypes[0]
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		ms.StoreMessageInfo(mi)
+	}
+}
+
+func (x *WatchRequest) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*WatchRequest) ProtoMessage() {}
+
+func (x *WatchRequest) ProtoReflect() protoreflect.Message {
+	mi := &file_proto_watch_watch_proto_msgTypes[0]
+	if protoimpl.UnsafeEnabled && x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use WatchRequest.ProtoReflect.Descriptor instead.
+func (*WatchRequest) Descriptor() ([]byte, []int) {
+	return file_proto_watch_watch_proto_rawDescGZIP(), []int{0}
+}
+
+func (x *WatchRequest) GetFromSlot() uint32 {
+	if x != nil {
+		return x.FromSlot
+	}
+	return 0
+}
+
+func (x *WatchRequest) GetGroupID() uint32 {
+	if x != nil {
+		return x.GroupID
+	}
+	return 0
+}
+
+var File_proto_watch_watch_proto protoreflect.FileDescriptor
+
+var file_proto_watch_watch_proto_rawDesc = []byte{
+	0x0a, 0x17, 0x70, 0x72, 0x6f, 0x74, 0x6f, 0x2f, 0x77, 0x61, 0x74, 0x63, 0x68, 0x2f, 0x77, 0x61,
+	0x74, 0x63, 0x68, 0x2e, 0x70, 0x72, 0x6f, 0x74, 0x6f, 0x12, 0x05, 0x77, 0x61, 0x74, 0x63, 0x68,
+	0x1a, 0x0c, 0x67, 0x6f, 0x72, 0x75, 0x6d, 0x73, 0x2e, 0x70, 0x72, 0x6f, 0x74, 0x6f, 0x1a, 0x16,
+	0x70, 0x72, 0x6f, 0x74, 0x6f, 0x2f, 0x6d, 0x75, 0x6c, 0x74, 0x69, 0x70, 0x61, 0x78, 0x6f, 0x73,
+	0x2e, 0x70, 0x72, 0x6f, 0x74, 0x6f, 0x22, 0x44, 0x0a, 0x0c, 0x57, 0x61, 0x74, 0x63, 0x68, 0x52,
+	0x65, 0x71, 0x75, 0x65, 0x73, 0x74, 0x12, 0x1a, 0x0a, 0x08, 0x46, 0x72, 0x6f, 0x6d, 0x53, 0x6c,
+	0x6f, 0x74, 0x18, 0x01, 0x20, 0x01, 0x28, 0x0d, 0x52, 0x08, 0x46, 0x72, 0x6f, 0x6d, 0x53, 0x6c,
+	0x6f, 0x74, 0x12, 0x18, 0x0a, 0x07, 0x47, 0x72, 0x6f, 0x75, 0x70, 0x49, 0x44, 0x18, 0x02, 0x20,
+	0x01, 0x28, 0x0d, 0x52, 0x07, 0x47, 0x72, 0x6f, 0x75, 0x70, 0x49, 0x44, 0x32, 0x41, 0x0a, 0x08,
+	0x4c, 0x6f, 0x67, 0x57, 0x61, 0x74, 0x63, 0x68, 0x12, 0x35, 0x0a, 0x05, 0x57, 0x61, 0x74, 0x63,
+	0x68, 0x12, 0x13, 0x2e, 0x77, 0x61, 0x74, 0x63, 0x68, 0x2e, 0x57, 0x61, 0x74, 0x63, 0x68, 0x52,
+	0x65, 0x71, 0x75, 0x65, 0x73, 0x74, 0x1a, 0x0f, 0x2e, 0x70, 0x72, 0x6f, 0x74, 0x6f, 0x2e, 0x4c,
+	0x65, 0x61, 0x72, 0x6e, 0x4d, 0x73, 0x67, 0x22, 0x04, 0xa8, 0xb5, 0x18, 0x01, 0x30, 0x01, 0x42,
+	0x25, 0x5a, 0x23, 0x64, 0x61, 0x74, 0x35, 0x32, 0x30, 0x2f, 0x6c, 0x61, 0x62, 0x35, 0x2f, 0x67,
+	0x6f, 0x72, 0x75, 0x6d, 0x73, 0x70, 0x61, 0x78, 0x6f, 0x73, 0x2f, 0x70, 0x72, 0x6f, 0x74, 0x6f,
+	0x2f, 0x77, 0x61, 0x74, 0x63, 0x68, 0x62, 0x06, 0x70, 0x72, 0x6f, 0x74, 0x6f, 0x33,
+}
+
+var (
+	file_proto_watch_watch_proto_rawDescOnce sync.Once
+	file_proto_watch_watch_proto_rawDescData = file_proto_watch_watch_proto_rawDesc
+)
+
+func file_proto_watch_watch_proto_rawDescGZIP() []byte {
+	file_proto_watch_watch_proto_rawDescOnce.Do(func() {
+		file_proto_watch_watch_proto_rawDescData = protoimpl.X.CompressGZIP(file_proto_watch_watch_proto_rawDescData)
+	})
+	return file_proto_watch_watch_proto_rawDescData
+}
+
+var file_proto_watch_watch_proto_msgTypes = make([]protoimpl.MessageInfo, 1)
+var file_proto_watch_watch_proto_goTypes = []interface{}{
+	(*WatchRequest)(nil),   // 0: watch.WatchRequest
+	(*proto.LearnMsg)(nil), // 1: proto.LearnMsg
+}
+var file_proto_watch_watch_proto_depIdxs = []int32{
+	0, // 0: watch.LogWatch.Watch:input_type -> watch.WatchRequest
+	1, // 1: watch.LogWatch.Watch:output_type -> proto.LearnMsg
+	1, // [1:2] is the sub-list for method output_type
+	0, // [0:1] is the sub-list for method input_type
+	0, // [0:0] is the sub-list for extension type_name
+	0, // [0:0] is the sub-list for extension extendee
+	0, // [0:0] is the sub-list for field type_name
+}
+
+func init() { file_proto_watch_watch_proto_init() }
+func file_proto_watch_watch_proto_init() {
+	if File_proto_watch_watch_proto != nil {
+		return
+	}
+	if !protoimpl.UnsafeEnabled {
+		file_proto_watch_watch_proto_msgTypes[0].Exporter = func(v interface{}, i int) interface{} {
+			switch v := v.(*WatchRequest); i {
+			case 0:
+				return &v.state
+			case 1:
+				return &v.sizeCache
+			case 2:
+				return &v.unknownFields
+			default:
+				return nil
+			}
+		}
+	}
+	type x struct{}
+	out := protoimpl.TypeBuilder{
+		File: protoimpl.DescBuilder{
+			GoPackagePath: reflect.TypeOf(x{}).PkgPath(),
+			RawDescriptor: file_proto_watch_watch_proto_rawDesc,
+			NumEnums:      0,
+			NumMessages:   1,
+			NumExtensions: 0,
+			NumServices:   1,
+		},
+		GoTypes:           file_proto_watch_watch_proto_goTypes,
+		DependencyIndexes: file_proto_watch_watch_proto_depIdxs,
+		MessageInfos:      file_proto_watch_watch_proto_msgTypes,
+	}.Build()
+	File_proto_watch_watch_proto = out.File
+	file_proto_watch_watch_proto_rawDesc = nil
+	file_proto_watch_watch_proto_goTypes = nil
+	file_proto_watch_watch_proto_depIdxs = nil
+}
@@ -0,0 +1,226 @@
+// Code generated by protoc-gen-gorums. DO NOT EDIT.
+// versions:
+// 	protoc-gen-gorums v0.7.0-devel
+// 	protoc            v4.25.3
+// source: proto/watch/watch.proto
+
+package watch
+
+import (
+	context "context"
+	proto "dat520/lab5/gorumspaxos/proto"
+	fmt "fmt"
+	gorums "github.com/relab/gorums"
+	ordering "github.com/relab/gorums/ordering"
+	encoding "google.golang.org/grpc/encoding"
+	proto1 "google.golang.org/protobuf/proto"
+	protoreflect "google.golang.org/protobuf/reflect/protoreflect"
+)
+
+const (
+	// Verify that this generated code is sufficiently up-to-date.
+	_ = gorums.EnforceVersion(7 - gorums.MinVersion)
+	// Verify that the gorums runtime is sufficiently up-to-date.
+	_ = gorums.EnforceVersion(gorums.MaxVersion - 7)
+)
+
+// A Configuration represents a static set of nodes on which quorum remote
+// procedure calls may be invoked.
+type Configuration struct {
+	gorums.RawConfiguration
+	nodes []*Node
+	qspec QuorumSpec
+}
+
+// ConfigurationFromRaw returns a new Configuration from the given raw configuration and QuorumSpec.
+//
+// This function may for example be used to "clone" a configuration but install a different QuorumSpec:
+//
+//	cfg1, err := mgr.NewConfiguration(qspec1, opts...)
+//	cfg2 := ConfigurationFromRaw(cfg1.RawConfig, qspec2)
+func ConfigurationFromRaw(rawCfg gorums.RawConfiguration, qspec QuorumSpec) *Configuration {
+	// return an error if the QuorumSpec interface is not empty and no implementation was provided.
+	var test interface{} = struct{}{}
+	if _, empty := test.(QuorumSpec); !empty && qspec == nil {
+		panic("QuorumSpec may not be nil")
+	}
+	return &Configuration{
+		RawConfiguration: rawCfg,
+		qspec:            qspec,
+	}
+}
+
+// Nodes returns a slice of each available node. IDs are returned in the same
+// order as they were provided in the creation of the Manager.
+//
+// NOTE: mutating the returned slice is not supported.
+func (c *Configuration) Nodes() []*Node {
+	if c.nodes == nil {
+		c.nodes = make([]*Node, 0, c.Size())
+		for _, n := range c.RawConfiguration {
+			c.nodes = append(c.nodes, &Node{n})
+		}
+	}
+	return c.nodes
+}
+
+// And returns a NodeListOption that can be used to create a new configuration combining c and d.
+func (c Configuration) And(d *Configuration) gorums.NodeListOption {
+	return c.RawConfiguration.And(d.RawConfiguration)
+}
+
+// Except returns a NodeListOption that can be used to create a new configuration
+// from c without the nodes in rm.
+func (c Configuration) Except(rm *Configuration) gorums.NodeListOption {
+	return c.RawConfiguration.Except(rm.RawConfiguration)
+}
+
+func init() {
+	if encoding.GetCodec(gorums.ContentSubtype) == nil {
+		encoding.RegisterCodec(gorums.NewCodec())
+	}
+}
+
+// Manager maintains a connection pool of nodes on
+// which quorum calls can be performed.
+type Manager struct {
+	*gorums.RawManager
+}
+
+// NewManager returns a new Manager for managing connection to nodes added
+// to the manager. This function accepts manager options used to configure
+// various aspects of the manager.
+func NewManager(opts ...gorums.ManagerOption) (mgr *Manager) {
+	mgr = &Manager{}
+	mgr.RawManager = gorums.NewRawManager(opts...)
+	return mgr
+}
+
+// NewConfiguration returns a configuration based on the provided list of nodes (required)
+// and an optional quorum specification. The QuorumSpec is necessary for call types that
+// must process replies. For configurations only used for unicast or multicast call types,
+// a QuorumSpec is not needed. The QuorumSpec interface is also a ConfigOption.
+// Nodes can be supplied using WithNodeMap or WithNodeList, or WithNodeIDs.
+// A new configuration can also be created from an existing configuration,
+// using the And, WithNewNodes, Except, and WithoutNodes methods.
+func (m *Manager) NewConfiguration(opts ...gorums.ConfigOption) (c *Configuration, err error) {
+	if len(opts) < 1 || len(opts) > 2 {
+		return nil, fmt.Errorf("wrong number of options: %d", len(opts))
+	}
+	c = &Configuration{}
+	for _, opt := range opts {
+		switch v := opt.(type) {
+		case gorums.NodeListOption:
+			c.RawConfiguration, err = gorums.NewRawConfiguration(m.RawManager, v)
+			if err != nil {
+				return nil, err
+			}
+		case QuorumSpec:
+			// Must be last since v may match QuorumSpec if it is interface{}
+			c.qspec = v
+		default:
+			return nil, fmt.Errorf("unknown option type: %v", v)
+		}
+	}
+	// return an error if the QuorumSpec interface is not empty and no implementation was provided.
+	var test interface{} = struct{}{}
+	if _, empty := test.(QuorumSpec); !empty && c.qspec == nil {
+		return nil, fmt.Errorf("missing required QuorumSpec")
+	}
+	return c, nil
+}
+
+// Nodes returns a slice of available nodes on this manager.
+// IDs are returned in the order they were added at creation of the manager.
+func (m *Manager) Nodes() []*Node {
+	gorumsNodes := m.RawManager.Nodes()
+	nodes := make([]*Node, 0, len(gorumsNodes))
+	for _, n := range gorumsNodes {
+		nodes = append(nodes, &Node{n})
+	}
+	return nodes
+}
+
+// Node encapsulates the state of a node on which a remote procedure call
+// can be performed.
+type Node struct {
+	*gorums.RawNode
+}
+
+// Watch streams the decided log entries of the replica's Paxos group
+// GroupID in slot order, starting at FromSlot. Entries that are already
+// decided are sent first, followed by entries as they are decided.
+// No-ops are included, so that the stream has no gaps.
+func (c *Configuration) Watch(ctx context.Context, in *WatchRequest) *CorrectableStreamLearnMsg {
+	cd := gorums.CorrectableCallData{
+		Message:      in,
+		Method:       "watch.LogWatch.Watch",
+		ServerStream: true,
+	}
+	cd.QuorumFunction = func(req protoreflect.ProtoMessage, replies map[uint32]protoreflect.ProtoMessage) (protoreflect.ProtoMessage, int, bool) {
+		r := make(map[uint32]*proto.LearnMsg, len(replies))
+		for k, v := range replies {
+			r[k] = v.(*proto.LearnMsg)
+		}
+		return c.qspec.WatchQF(req.(*WatchRequest), r)
+	}
+
+	corr := c.RawConfiguration.CorrectableCall(ctx, cd)
+	return &CorrectableStreamLearnMsg{corr}
+}
+
+// QuorumSpec is the interface of quorum functions for LogWatch.
+type QuorumSpec interface {
+	gorums.ConfigOption
+
+	// WatchQF is the quorum function for the Watch
+	// correctable stream quorum call method. The in parameter is the request object
+	// supplied to the Watch method at call time, and may or may not
+	// be used by the quorum function. If the in parameter is not needed
+	// you should implement your quorum function with '_ *WatchRequest'.
+	WatchQF(in *WatchRequest, replies map[uint32]*proto.LearnMsg) (*proto.LearnMsg, int, bool)
+}
+
+// LogWatch is the server-side API for the LogWatch Service
+type LogWatch interface {
+	Watch(ctx gorums.ServerCtx, request *WatchRequest, send func(response *proto.LearnMsg) error) error
+}
+
+func RegisterLogWatchServer(srv *gorums.Server, impl LogWatch) {
+	srv.RegisterHandler("watch.LogWatch.Watch", func(ctx gorums.ServerCtx, in *gorums.Message, finished chan<- *gorums.Message) {
+		req := in.Message.(*WatchRequest)
+		defer ctx.Release()
+		err := impl.Watch(ctx, req, func(resp *proto.LearnMsg) error {
+			// create a copy of the metadata, to avoid a data race between WrapMessage and SendMsg
+			md := proto1.Clone(in.Metadata)
+			return gorums.SendMessage(ctx, finished, gorums.WrapMessage(md.(*ordering.Metadata), resp, nil))
+		})
+		if err != nil {
+			gorums.SendMessage(ctx, finished, gorums.WrapMessage(in.Metadata, nil, err))
+		}
+	})
+}
+
+type internalLearnMsg struct {
+	nid   uint32
+	reply *proto.LearnMsg
+	err   error
+}
+
+// CorrectableStreamLearnMsg is a correctable object for processing replies.
+type CorrectableStreamLearnMsg struct {
+	*gorums.Correctable
+}
+
+// Get returns the reply, level and any error associated with the
+// called method. The method does not block until a (possibly
+// intermediate) reply or error is available. Level is set to LevelNotSet if no
+// reply has yet been received. The Done or Watch methods should be used to
+// ensure that a reply is available.
+func (c *CorrectableStreamLearnMsg) Get() (*proto.LearnMsg, int, error) {
+	resp, level, err := c.Correctable.Get()
+	if err != nil {
+		return nil, level, err
+	}
+	return resp.(*proto.LearnMsg), level, err
+}
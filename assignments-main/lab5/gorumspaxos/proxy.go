@@ -0,0 +1,64 @@
+package gorumspaxos
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"sync/atomic"
+	"time"
+
+	pb "dat520/lab5/gorumspaxos/proto"
+
+	"github.com/relab/gorums"
+)
+
+// proxyRequestTimeout is the duration a proxied request waits to be decided.
+const proxyRequestTimeout = responseTimeout + time.Second
+
+// commandProxy lets an application service served by a replica act as a
+// client of the replica's Paxos group. Each command is sent to all replicas
+// as a JSON encoded client request, and its result is returned once a quorum
+// of replicas has applied it.
+type commandProxy struct {
+	replica  *PaxosReplica
+	clientID string
+	seq      atomic.Uint32
+}
+
+// newCommandProxy returns a proxy for the replica. The proxy's client ID is
+// unique to this process, so that requests of a restarted replica are not
+// mistaken for requests decided before the restart.
+func newCommandProxy(r *PaxosReplica, service string) *commandProxy {
+	return &commandProxy{replica: r, clientID: fmt.Sprintf("%s/%d/%s", service, r.id, randomID(4))}
+}
+
+// submit sends the command of a gorums request to the Paxos group and
+// decodes its result into result.
+func (p *commandProxy) submit(ctx gorums.ServerCtx, cmd any, result any) error {
+	// allow the client's next request to be handled while this one is decided
+	ctx.Release()
+	return p.send(ctx, cmd, result)
+}
+
+// send sends the command to the Paxos group and decodes its result into result.
+func (p *commandProxy) send(ctx context.Context, cmd any, result any) error {
+	b, err := json.Marshal(cmd)
+	if err != nil {
+		return err
+	}
+	config, err := p.replica.newPaxosConfiguration()
+	if err != nil {
+		return err
+	}
+	request := &pb.Value{ClientID: p.clientID, ClientSeq: p.seq.Add(1), ClientCommand: string(b)}
+	cctx, cancel := context.WithTimeout(ctx, proxyRequestTimeout)
+	defer cancel()
+	rsp, err := config.ClientHandle(cctx, request)
+	if err != nil {
+		return err
+	}
+	if err := json.Unmarshal([]byte(rsp.GetResult()), result); err != nil {
+		return fmt.Errorf("invalid result %q: %w", rsp.GetResult(), err)
+	}
+	return nil
+}
@@ -130,7 +130,8 @@ var prepareQFTests = []struct {
 			{Rnd: 6, Accepted: []*pb.PValue{{Slot: 2, Vrnd: 1, Vval: oneVal}}},
 			{Rnd: 6, Accepted: []*pb.PValue{{Slot: 2, Vrnd: 1, Vval: oneVal}}},
 		},
-		wantPromise: &pb.PromiseMsg{Rnd: 6},
+		// the prepared slot is undecided, and its accepted value may have been chosen
+		wantPromise: &pb.PromiseMsg{Rnd: 6, Accepted: []*pb.PValue{{Slot: 2, Vrnd: 1, Vval: oneVal}}},
 		wantQuorum:  true,
 	},
 	{
@@ -188,6 +189,7 @@ var prepareQFTests = []struct {
 		wantPromise: &pb.PromiseMsg{
 			Rnd: 6,
 			Accepted: []*pb.PValue{
+				{Slot: 1, Vrnd: 5, Vval: oneVal},
 				{Slot: 2, Vrnd: 5, Vval: oneVal},
 				{Slot: 3, Vrnd: 6, Vval: noopVal}, // fill the gap with a noop
 				{Slot: 4, Vrnd: 5, Vval: twoVal},
@@ -206,6 +208,7 @@ var prepareQFTests = []struct {
 		wantPromise: &pb.PromiseMsg{
 			Rnd: 6,
 			Accepted: []*pb.PValue{
+				{Slot: 1, Vrnd: 4, Vval: oneVal},
 				{Slot: 2, Vrnd: 3, Vval: oneVal},
 				{Slot: 3, Vrnd: 4, Vval: twoVal},
 				{Slot: 4, Vrnd: 3, Vval: twoVal},
@@ -1,19 +1,33 @@
 package gorumspaxos
 
 import (
+	"slices"
+
 	pb "dat520/lab5/gorumspaxos/proto"
 )
 
 // PaxosQSpec is a quorum specification object for Paxos.
-// It only holds the quorum size.
+// It holds the quorum sizes and, in BFT mode, the keys used to verify replies.
 type PaxosQSpec struct {
-	quorum int
+	quorum      int      // replies needed by Prepare and Accept
+	replyQuorum int      // matching replies needed by ClientHandle
+	keys        *Keyring // verifies the acceptors' signatures in BFT mode; nil otherwise
 }
 
 // NewPaxosQSpec returns a quorum specification object for Paxos
 // for the given configuration size n.
 func NewPaxosQSpec(n int) PaxosQSpec {
-	return PaxosQSpec{}
+	quorum := (n + 1) / 2
+	return PaxosQSpec{quorum: quorum, replyQuorum: quorum}
+}
+
+// NewBFTPaxosQSpec returns a quorum specification object for Paxos in BFT
+// mode for the given configuration size n. It only counts promises and learns
+// that are signed by the replying acceptor and whose accepted values carry a
+// valid proof. Clients that only use ClientHandle may pass nil keys.
+func NewBFTPaxosQSpec(n int, keys *Keyring) PaxosQSpec {
+	quorum, f := bftQuorum(n)
+	return PaxosQSpec{quorum: quorum, replyQuorum: f + 1, keys: keys}
 }
 
 // PrepareQF is the quorum function to process the replies from the Prepare quorum call.
@@ -25,8 +39,47 @@ func NewPaxosQSpec(n int) PaxosQSpec {
 // returns true if a quorum of valid promises was found, and the combined PromiseMsg.
 // Nil and false is returned if no quorum of valid promises was found.
 func (qs PaxosQSpec) PrepareQF(prepare *pb.PrepareMsg, replies map[uint32]*pb.PromiseMsg) (*pb.PromiseMsg, bool) {
-	// TODO(student) complete the PrepareQF
-	return nil, false
+	valid := 0
+	highest := make(map[Slot]*pb.PValue)
+	for _, id := range sortedIDs(replies) {
+		promise := replies[id]
+		if !prepare.IsValid(promise) {
+			continue
+		}
+		if qs.keys != nil && !qs.verifyPromise(id, promise) {
+			// the promise is forged or carries a forged accepted value
+			continue
+		}
+		valid++
+		for _, pval := range promise.GetAccepted() {
+			if pval.GetSlot() < prepare.GetSlot() {
+				// decided slots; prepare.Slot itself is the first undecided
+				// slot, and its accepted value may already have been chosen
+				continue
+			}
+			if prev, ok := highest[pval.GetSlot()]; !ok || pval.GetVrnd() > prev.GetVrnd() {
+				highest[pval.GetSlot()] = pval
+			}
+		}
+	}
+	if valid < qs.quorum {
+		return nil, false
+	}
+	promise := &pb.PromiseMsg{Rnd: prepare.GetCrnd()}
+	slots := Keys(highest)
+	if len(slots) == 0 {
+		return promise, true
+	}
+	first, last := slices.Min(slots), slices.Max(slots)
+	for slot := first; slot <= last; slot++ {
+		pval, ok := highest[slot]
+		if !ok {
+			// fill the gap with a no-op
+			pval = &pb.PValue{Slot: slot, Vrnd: prepare.GetCrnd(), Vval: &pb.Value{IsNoop: true}}
+		}
+		promise.Accepted = append(promise.Accepted, pval)
+	}
+	return promise, true
 }
 
 // AcceptQF is the quorum function to process the replies from the Accept quorum call.
@@ -35,8 +88,47 @@ func (qs PaxosQSpec) PrepareQF(prepare *pb.PrepareMsg, replies map[uint32]*pb.Pr
 // the corresponding LearnMsg holds the slot, round number and value that was decided.
 // Nil and false is returned if no value was decided.
 func (qs PaxosQSpec) AcceptQF(accept *pb.AcceptMsg, replies map[uint32]*pb.LearnMsg) (*pb.LearnMsg, bool) {
-	// TODO (student) complete the AcceptQF
-	return nil, false
+	matching := 0
+	var learn *pb.LearnMsg
+	var votes []*pb.Vote
+	for _, id := range sortedIDs(replies) {
+		reply := replies[id]
+		if !accept.Match(reply) {
+			continue
+		}
+		if qs.keys != nil {
+			if !qs.keys.verify(id, learnDigest(reply.GetSlot(), reply.GetRnd(), reply.GetVal()), reply.GetSignature()) {
+				continue
+			}
+			votes = append(votes, &pb.Vote{AcceptorID: id, Signature: reply.GetSignature()})
+		}
+		matching++
+		learn = reply
+	}
+	if matching < qs.quorum {
+		return nil, false
+	}
+	if qs.keys != nil {
+		// the committed learn carries the acceptors' votes as a certificate
+		learn = &pb.LearnMsg{Slot: learn.GetSlot(), Rnd: learn.GetRnd(), Val: learn.GetVal(), Certificate: votes}
+	}
+	return learn, true
+}
+
+// verifyPromise returns true if the promise is signed by acceptor id and each
+// of its accepted values carries a valid proof, that is, the signature of the
+// owner of the value's round on the AcceptMsg in which it was accepted.
+func (qs PaxosQSpec) verifyPromise(id uint32, promise *pb.PromiseMsg) bool {
+	if !qs.keys.verify(id, promiseDigest(promise), promise.GetSignature()) {
+		return false
+	}
+	for _, pval := range promise.GetAccepted() {
+		digest := acceptDigest(pval.GetSlot(), pval.GetVrnd(), pval.GetVval())
+		if !qs.keys.verifyRound(pval.GetVrnd(), digest, pval.GetProof()) {
+			return false
+		}
+	}
+	return true
 }
 
 // ClientHandleQF is the quorum function to process the replies from the ClientHandle quorum call.
@@ -45,6 +137,25 @@ func (qs PaxosQSpec) AcceptQF(accept *pb.AcceptMsg, replies map[uint32]*pb.Learn
 // The quorum function returns true if a quorum of the replicas replied with the same response,
 // and a single response is returned. Nil and false is returned if no quorum of valid replies was found.
 func (qs PaxosQSpec) ClientHandleQF(request *pb.Value, replies map[uint32]*pb.Response) (*pb.Response, bool) {
-	// TODO (student) complete the ClientHandleQF
+	// replicas may only disagree on the result if they apply requests to
+	// diverging state machines; count matching replies per result
+	results := make(map[string]int)
+	for _, id := range sortedIDs(replies) {
+		rsp := replies[id]
+		if !request.Match(rsp) {
+			continue
+		}
+		results[rsp.GetResult()]++
+		if results[rsp.GetResult()] >= qs.replyQuorum {
+			return rsp, true
+		}
+	}
 	return nil, false
 }
+
+// sortedIDs returns the node IDs of the replies in increasing order.
+func sortedIDs[T any](replies map[uint32]T) []uint32 {
+	ids := Keys(replies)
+	slices.Sort(ids)
+	return ids
+}
@@ -1,6 +1,7 @@
 package gorumspaxos
 
 import (
+	"bytes"
 	"context"
 	"dat520/lab3/gorumsfd"
 	"dat520/lab3/leaderdetector"
@@ -11,46 +12,81 @@ import (
 
 	fd "dat520/lab3/gorumsfd/proto"
 	pb "dat520/lab5/gorumspaxos/proto"
+	apb "dat520/lab5/gorumspaxos/proto/admin"
+	wpb "dat520/lab5/gorumspaxos/proto/watch"
 
 	"github.com/relab/gorums"
 	"google.golang.org/grpc"
 	"google.golang.org/grpc/credentials/insecure"
+	"google.golang.org/protobuf/proto"
 )
 
 const (
-	// responseTimeout is the duration to wait for a response before cancelling
-	responseTimeout = 1 * time.Second
+	// responseTimeout is the duration to wait for a response before cancelling;
+	// it exceeds the time to detect a new leader, so that requests recovered
+	// by the new leader in phase one are answered.
+	responseTimeout = 4 * time.Second
 	// managerDialTimeout is the default timeout for dialing a manager
 	managerDialTimeout = 5 * time.Second
 	// delta is the failure detector's default timeout value
 	delta = 1 * time.Second
+	// forcedSuspicion is how long ForceElection suspects the replaced leader
+	forcedSuspicion = 30 * time.Second
+	// recommitWindow is the number of decided slots committed again by a new leader
+	recommitWindow = 16
 )
 
+// errIgnored is returned by the acceptor's handlers when a message
+// is ignored, so that it is not counted in the quorum function.
+var errIgnored = errors.New("ignored by acceptor")
+
 // PaxosReplica is the structure composing the Proposer and Acceptor.
 type PaxosReplica struct {
 	pb.MultiPaxos
-	mu sync.Mutex
+	mu         sync.Mutex
+	acceptorMu sync.Mutex // serializes access to the acceptor
 	*Acceptor
 	*Proposer
 	leaderDetector  leaderdetector.LeaderDetector
+	suspects        *suspectTracker // records suspected nodes on behalf of the leader detector
 	failureDetector gorumsfd.FailureDetector
-	fdManager       *fd.Manager             // gorums failure detector manager (from generated code)
-	paxosManager    *pb.Manager             // gorums paxos manager (from generated code)
-	id              int                     // id is the id of the node
-	srv             *gorums.Server          // the gorums.Server that the replica is registered to
-	stop            chan struct{}           // channel for stopping the replica's run loop.
-	learntVal       map[uint32]*pb.LearnMsg // Stores all received learn messages
+	fdManager       *fd.Manager                       // gorums failure detector manager (from generated code)
+	paxosManager    *pb.Manager                       // gorums paxos manager (from generated code)
+	paxosConfig     func() (*pb.Configuration, error) // returns the configuration shared by the replica's groups
+	id              int                               // id is the id of the node
+	srv             *gorums.Server                    // the gorums.Server that the replica is registered to
+	stop            chan struct{}                     // channel for stopping the replica's run loop.
+	learntVal       map[uint32]*pb.LearnMsg           // Stores all received learn messages
+	pending         *pendingRequests                  // client requests waiting for a response, by request hash
+	sessions        map[string]*session               // responses to the applied requests, by client ID
+	decided         chan struct{}                     // closed and replaced when allDecidedUpTo advances
+	group           uint32                            // the Paxos group this replica belongs to
+	stateMachine    StateMachine                      // application that decided requests are applied to; may be nil
+	keys            *Keyring                          // signs and verifies messages in BFT mode; nil otherwise
+	faults          *FaultInjector                    // injects faults into the gorums calls of the replica's managers and server
+	placement       *leaderdetector.LatencyConfig     // leader placement of a latency-aware leader detector; nil if monarchical
+	storage         *AcceptorStorage                  // persists the acceptor's state; nil if kept in memory only
 	stopped         bool
 }
 
 // NewPaxosReplica returns a new Paxos replica with a nodeMap configuration.
-func NewPaxosReplica(myID int, nodeMap map[string]uint32) *PaxosReplica {
-	nodeIds := make([]int, 0)
-	for _, id := range nodeMap {
-		nodeIds = append(nodeIds, int(id))
-	}
-	ld := leaderdetector.NewMonLeaderDetector(nodeIds)
-	failureDetector := gorumsfd.NewGorumsFailureDetector(uint32(myID), ld, delta)
+// The replica's optional behavior can be configured with options.
+func NewPaxosReplica(myID int, nodeMap map[string]uint32, options ...ReplicaOption) *PaxosReplica {
+	r := newPaxosReplica(myID, nodeMap, options...)
+	fd.RegisterFailureDetectorServer(r.srv, r.failureDetector)
+	pb.RegisterMultiPaxosServer(r.srv, r)
+	apb.RegisterAdminServer(r.srv, r)
+	wpb.RegisterLogWatchServer(r.srv, r)
+	r.run()
+	r.startFailureDetector()
+	return r
+}
+
+// newPaxosReplica returns a new Paxos replica for the default group with
+// its own failure detector, leader detector, managers and gorums server.
+// The services are not registered with the server and the replica is not started.
+func newPaxosReplica(myID int, nodeMap map[string]uint32, options ...ReplicaOption) *PaxosReplica {
+	faults := NewFaultInjector(myID)
 
 	opts := []gorums.ManagerOption{
 		gorums.WithDialTimeout(managerDialTimeout),
@@ -58,21 +94,30 @@ func NewPaxosReplica(myID int, nodeMap map[string]uint32) *PaxosReplica {
 			grpc.WithTransportCredentials(insecure.NewCredentials()), // disable TLS
 		),
 	}
+	opts = append(opts, faults.managerOptions()...)
 	r := &PaxosReplica{
-		Acceptor:        NewAcceptor(),
-		Proposer:        NewProposer(myID, ld.Leader(), nodeMap),
-		leaderDetector:  ld,
-		failureDetector: failureDetector,
-		fdManager:       fd.NewManager(opts...),
-		paxosManager:    pb.NewManager(opts...),
-		id:              myID,
-		srv:             gorums.NewServer(),
-		stop:            make(chan struct{}),
-		learntVal:       make(map[uint32]*pb.LearnMsg),
+		Acceptor:     NewAcceptor(),
+		Proposer:     NewProposer(myID, leaderdetector.UnknownID, nodeMap),
+		fdManager:    fd.NewManager(opts...),
+		paxosManager: pb.NewManager(opts...),
+		id:           myID,
+		srv:          gorums.NewServer(faults.serverOptions()...),
+		stop:         make(chan struct{}),
+		learntVal:    make(map[uint32]*pb.LearnMsg),
+		pending:      newPendingRequests(),
+		sessions:     make(map[string]*session),
+		decided:      make(chan struct{}),
+		faults:       faults,
 	}
-	fd.RegisterFailureDetectorServer(r.srv, r.failureDetector)
-	pb.RegisterMultiPaxosServer(r.srv, r)
-	r.run()
+	r.paxosConfig = sync.OnceValues(func() (*pb.Configuration, error) {
+		return r.paxosManager.NewConfiguration(r.newQSpec(), gorums.WithNodeMap(r.nodeMap))
+	})
+	for _, opt := range options {
+		opt(r)
+	}
+	// the options choose the leader detector
+	r.leaderDetector, r.suspects, r.failureDetector = newDetectors(myID, nodeMap, r.placement)
+	r.Proposer.leader = r.leaderDetector.Leader()
 	return r
 }
 
@@ -82,6 +127,9 @@ func newTestReplicaLeader() *PaxosReplica {
 		Proposer:  NewProposer(myID, myID, map[string]uint32{"0": 0}),
 		id:        myID,
 		learntVal: make(map[uint32]*pb.LearnMsg),
+		pending:   newPendingRequests(),
+		sessions:  make(map[string]*session),
+		decided:   make(chan struct{}),
 	}
 	replica.Proposer.phaseOneDone = true
 	replica.adu = 0
@@ -118,18 +166,67 @@ func (replica *PaxosReplica) ServerStart(lis net.Listener) {
 	// TODO(student) Implement the function
 }
 
+// newPaxosConfiguration returns the configuration used by the proposer to
+// communicate with the other replicas of its Paxos group.
+func (r *PaxosReplica) newPaxosConfiguration() (MultiPaxosConfig, error) {
+	cfg, err := r.paxosConfig()
+	if err != nil {
+		return nil, err
+	}
+	var config MultiPaxosConfig = cfg
+	if r.keys != nil {
+		// sign last, since groupConfig modifies the messages
+		config = signingConfig{MultiPaxosConfig: cfg, keys: r.keys}
+	}
+	return &groupConfig{MultiPaxosConfig: config, group: r.group}, nil
+}
+
+// newQSpec returns the quorum specification of the replica's configuration.
+func (r *PaxosReplica) newQSpec() PaxosQSpec {
+	if r.keys != nil {
+		return NewBFTPaxosQSpec(len(r.nodeMap), r.keys)
+	}
+	return NewPaxosQSpec(len(r.nodeMap))
+}
+
 // run starts the replica's run loop.
 // It subscribes to the leader detector's trust messages and signals the proposer when a new leader is detected.
-// It also starts the failure detector, which is necessary to get leader detections.
 func (r *PaxosReplica) run() {
 	trustMsgs := r.leaderDetector.Subscribe()
 	go func() {
-		// TODO(student) create Paxos configuration and set the proposer's configuration
-		// TODO(student) implement Paxos replica run loop
-		_ = trustMsgs // TODO: remove this line when you start using trustMsgs
-		<-r.stop      // TODO: remove this line when you implement the method
+		config, err := r.newPaxosConfiguration()
+		if err != nil {
+			r.Logf("Failed to create Paxos configuration: %v", err)
+			<-r.stop
+			return
+		}
+		r.setConfiguration(config)
+		for {
+			select {
+			case <-r.stop:
+				return
+			case leader := <-trustMsgs:
+				r.leaderChange(leader)
+				continue
+			default:
+			}
+			if r.isLeader() {
+				r.runMultiPaxos()
+				continue
+			}
+			// wait for a leader change before doing anything
+			select {
+			case <-r.stop:
+				return
+			case leader := <-trustMsgs:
+				r.leaderChange(leader)
+			}
+		}
 	}()
+}
 
+// startFailureDetector starts the failure detector, which is necessary to get leader detections.
+func (r *PaxosReplica) startFailureDetector() {
 	go func() {
 		cfg, err := r.fdManager.NewConfiguration(gorums.WithNodeMap(r.nodeMap))
 		if err != nil {
@@ -137,18 +234,60 @@ func (r *PaxosReplica) run() {
 			return
 		}
 		hbSender := func(hb *fd.HeartBeat) {
-			cfg.Heartbeat(context.Background(), &fd.HeartBeat{ID: uint32(r.id)})
+			cfg.Heartbeat(context.Background(), hb)
 		}
 		r.failureDetector.Start(hbSender)
 	}()
 }
 
+// leaderChange updates the proposer with the new leader. If this replica is
+// the new leader, it commits its most recently decided slots again, since the
+// previous leader may have crashed before its last commits reached all replicas.
+func (r *PaxosReplica) leaderChange(leader int) {
+	r.newLeader(leader)
+	if !r.isLeader() {
+		return
+	}
+	r.mu.Lock()
+	adu := r.allDecidedUpTo()
+	learns := make([]*pb.LearnMsg, 0, recommitWindow)
+	for slot := max(adu, recommitWindow) - recommitWindow + 1; slot <= adu; slot++ {
+		learns = append(learns, proto.Clone(r.learntVal[slot]).(*pb.LearnMsg))
+	}
+	r.mu.Unlock()
+	for _, learn := range learns {
+		if err := r.performCommit(learn); err != nil {
+			r.Logf("Recommit(%v) failed: %v", learn, err)
+		}
+	}
+}
+
 // Prepare handles the prepare quorum calls from the proposer by passing the received messages to its acceptor.
 // It receives prepare massages and pass them to handlePrepare method of acceptor.
 // It returns promise messages back to the proposer by its acceptor.
 func (r *PaxosReplica) Prepare(ctx gorums.ServerCtx, prepare *pb.PrepareMsg) (*pb.PromiseMsg, error) {
 	r.Logf("Acceptor: Prepare(%v) received", prepare)
-	return r.handlePrepare(prepare), nil
+	span := r.tracer.Start("Acceptor.Prepare", nil)
+	defer span.End()
+	span.setMessage(prepare.GetSlot(), prepare.GetCrnd(), nil)
+	if r.keys != nil && !r.keys.verifyRound(prepare.GetCrnd(), prepareDigest(prepare), prepare.GetSignature()) {
+		return nil, span.ignore(errInvalidSignature)
+	}
+	r.acceptorMu.Lock()
+	defer r.acceptorMu.Unlock()
+	promise := r.handlePrepare(prepare)
+	if promise == nil {
+		return nil, span.ignore(errIgnored)
+	}
+	if r.storage != nil {
+		if err := r.storage.store(r.group, r.Acceptor); err != nil {
+			return nil, err
+		}
+	}
+	if r.keys != nil {
+		promise.Signature = r.keys.sign(promiseDigest(promise))
+	}
+	return promise, nil
 }
 
 // Accept handles the accept quorum calls from the proposer by passing the received messages to its acceptor.
@@ -156,7 +295,41 @@ func (r *PaxosReplica) Prepare(ctx gorums.ServerCtx, prepare *pb.PrepareMsg) (*p
 // It returns learn massages back to the proposer by its acceptor
 func (r *PaxosReplica) Accept(ctx gorums.ServerCtx, accept *pb.AcceptMsg) (*pb.LearnMsg, error) {
 	r.Logf("Acceptor: Accept(%v) received", accept)
-	return r.handleAccept(accept), nil
+	span := r.tracer.Start("Acceptor.Accept", accept.GetTrace())
+	defer span.End()
+	span.setMessage(accept.GetSlot(), accept.GetRnd(), accept.GetVal())
+	if r.keys != nil && !r.keys.verifyRound(accept.GetRnd(), acceptDigest(accept.GetSlot(), accept.GetRnd(), accept.GetVal()), accept.GetSignature()) {
+		return nil, span.ignore(errInvalidSignature)
+	}
+	r.acceptorMu.Lock()
+	defer r.acceptorMu.Unlock()
+	if r.keys != nil && r.equivocates(accept) {
+		return nil, span.ignore(errIgnored)
+	}
+	learn := r.handleAccept(accept)
+	if learn == nil {
+		return nil, span.ignore(errIgnored)
+	}
+	if r.storage != nil {
+		if err := r.storage.store(r.group, r.Acceptor, r.accepted[accept.GetSlot()]); err != nil {
+			return nil, err
+		}
+	}
+	if r.keys != nil {
+		learn.Signature = r.keys.sign(learnDigest(learn.GetSlot(), learn.GetRnd(), learn.GetVal()))
+	}
+	return learn, nil
+}
+
+// equivocates returns true if the acceptor has already accepted a different
+// value in the accept's slot and round, which a correct proposer never sends.
+// The caller must hold r.acceptorMu.
+func (r *PaxosReplica) equivocates(accept *pb.AcceptMsg) bool {
+	pval, ok := r.accepted[accept.GetSlot()]
+	if !ok || pval.GetVrnd() != accept.GetRnd() {
+		return false
+	}
+	return !bytes.Equal(acceptDigest(pval.GetSlot(), pval.GetVrnd(), pval.GetVval()), acceptDigest(accept.GetSlot(), accept.GetRnd(), accept.GetVal()))
 }
 
 // Commit is invoked by the proposer as part of the commit phase of the MultiPaxos algorithm.
@@ -168,19 +341,42 @@ func (r *PaxosReplica) Accept(ctx gorums.ServerCtx, accept *pb.AcceptMsg) (*pb.L
 // If the received slot is less than the next slot, the message should be ignored.
 // If the received slot is greater than the next slot, the message should be buffered.
 // If the received slot is equal to the next slot, the message should be delivered.
+// Delivering a message means applying it to the state machine with deliver.
 //
 // This method is also responsible for communicating the decided value to the ClientHandle
 // method, which is responsible for returning the response to the client.
 func (r *PaxosReplica) Commit(ctx gorums.ServerCtx, learn *pb.LearnMsg) {
 	r.Logf("Replica: Commit(%v) received", learn)
+	span := r.tracer.Start("Replica.Commit", learn.GetTrace())
+	defer span.End()
+	span.setMessage(learn.GetSlot(), learn.GetRnd(), learn.GetVal())
+	if r.keys != nil && !r.keys.verifyCertificate(learn) {
+		r.Logf("Replica: Commit(%v) ignored: invalid certificate", learn)
+		return
+	}
 	r.mu.Lock()
-	adu := r.adu + 1
+	defer r.mu.Unlock()
 	if _, ok := r.learntVal[learn.Slot]; !ok {
 		r.learntVal[learn.Slot] = learn
 	}
-	r.mu.Unlock()
-	_ = adu // TODO: remove this line when you implement the method
-	// TODO(student) complete
+	adu := r.allDecidedUpTo()
+	for {
+		next, ok := r.learntVal[r.allDecidedUpTo()+1]
+		if !ok {
+			break
+		}
+		r.advanceAllDecidedUpTo()
+		rsp := r.deliver(next)
+		if next.GetVal().GetIsNoop() {
+			continue
+		}
+		r.pending.resolve(next.GetVal().Hash(), rsp)
+	}
+	if r.allDecidedUpTo() > adu {
+		// wake up the watchers of the decided log
+		close(r.decided)
+		r.decided = make(chan struct{})
+	}
 }
 
 // ClientHandle is invoked by the client to send a request to the replicas via a quorum call and get a response.
@@ -193,24 +389,60 @@ func (r *PaxosReplica) Commit(ctx gorums.ServerCtx, learn *pb.LearnMsg) {
 // to the client. However, while waiting for M1 to get committed, M2 may be proposed and committed by the replicas.
 // Thus, M2 should not be returned to the client that sent M1.
 func (r *PaxosReplica) ClientHandle(ctx gorums.ServerCtx, req *pb.Value) (rsp *pb.Response, err error) {
-	r.AddRequestToQ(req)
-	// TODO(student) complete
+	span := r.tracer.Start("ClientHandle", req.GetTrace())
+	defer func() {
+		if err != nil {
+			span.SetAttribute("error", err.Error())
+		}
+		span.End()
+	}()
+	if span != nil {
+		// requests queued by the proposer are traced as children of this span
+		req.Trace = span.Context()
+	}
+	hash := req.Hash()
+	w, rsp := r.pending.add(hash, responseTimeout)
+	if rsp != nil {
+		// the request was decided before the client's request reached this replica
+		return rsp, nil
+	}
+	// the request is queued even if other calls are already waiting for it,
+	// since a client retries a request when the proposal carrying it was lost;
+	// if both proposals are decided, deliver only applies the first one
+	if err = r.AddRequestToQ(req); err != nil {
+		r.pending.remove(hash, w, false)
+		return nil, err
+	}
+	var done <-chan struct{}
+	if ctx.Context != nil {
+		// let the server handle the sender's next message, e.g., an Accept
+		// from a leader sharing its manager with a client, while waiting
+		ctx.Release()
+		done = ctx.Done()
+	}
+	timer := time.NewTimer(responseTimeout)
+	defer timer.Stop()
+	select {
+	case rsp, ok := <-w.ch:
+		if ok {
+			return rsp, nil
+		}
+		// the waiter was swept after its deadline
+	case <-timer.C:
+		r.pending.remove(hash, w, true)
+	case <-done:
+		r.pending.remove(hash, w, false)
+	}
 	return nil, errors.New("unable to get the response")
 }
 
 // remainingResponses returns the number of responses that are still pending.
 func (r *PaxosReplica) remainingResponses() int {
-	r.mu.Lock()
-	defer r.mu.Unlock()
-	// TODO(student) complete
-	return 0
+	stats := r.pending.stats()
+	return stats.Requests + stats.Responses
 }
 
 // responseIDs returns the IDs of the responses that are still pending.
 func (r *PaxosReplica) responseIDs() []uint64 {
-	r.mu.Lock()
-	defer r.mu.Unlock()
-	ids := make([]uint64, 0)
-	// TODO(student) complete
-	return ids
+	return r.pending.ids()
 }
@@ -0,0 +1,120 @@
+package gorumspaxos
+
+import (
+	"cmp"
+	"crypto/sha256"
+	"encoding/binary"
+	"slices"
+	"strconv"
+	"sync"
+
+	pb "dat520/lab5/gorumspaxos/proto"
+)
+
+// DefaultVirtualNodes is the default number of points each group is given on
+// the ShardRouter's hash ring.
+const DefaultVirtualNodes = 64
+
+// ShardRouter maps keys to Paxos groups by consistent hashing. Each group is
+// placed at several points (virtual nodes) on a hash ring, and a key belongs
+// to the group owning the first point at or after the key's hash. Adding or
+// removing a group therefore only moves the keys of that group's ring segments.
+type ShardRouter struct {
+	mu           sync.RWMutex
+	virtualNodes int         // number of ring points per group.
+	ring         []ringPoint // ring points sorted by hash.
+}
+
+type ringPoint struct {
+	hash  uint64
+	group uint32
+}
+
+// NewShardRouter returns a router for the given groups, placing each group at
+// virtualNodes points on the ring. If virtualNodes is less than one,
+// DefaultVirtualNodes is used.
+func NewShardRouter(virtualNodes int, groups ...uint32) *ShardRouter {
+	if virtualNodes < 1 {
+		virtualNodes = DefaultVirtualNodes
+	}
+	r := &ShardRouter{virtualNodes: virtualNodes}
+	for _, group := range groups {
+		r.AddGroup(group)
+	}
+	return r
+}
+
+// NewShardRouterN returns a router for the groups 0 to numGroups-1, matching
+// the groups run by NewShardedReplica.
+func NewShardRouterN(numGroups int) *ShardRouter {
+	groups := make([]uint32, numGroups)
+	for i := range groups {
+		groups[i] = uint32(i)
+	}
+	return NewShardRouter(DefaultVirtualNodes, groups...)
+}
+
+// AddGroup adds the group to the ring; adding a group twice has no effect.
+func (r *ShardRouter) AddGroup(group uint32) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if slices.ContainsFunc(r.ring, func(p ringPoint) bool { return p.group == group }) {
+		return
+	}
+	for i := 0; i < r.virtualNodes; i++ {
+		r.ring = append(r.ring, ringPoint{hash: hashKey(strconv.Itoa(int(group)) + "#" + strconv.Itoa(i)), group: group})
+	}
+	slices.SortFunc(r.ring, func(a, b ringPoint) int {
+		return cmp.Or(cmp.Compare(a.hash, b.hash), cmp.Compare(a.group, b.group))
+	})
+}
+
+// RemoveGroup removes the group from the ring.
+func (r *ShardRouter) RemoveGroup(group uint32) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.ring = slices.DeleteFunc(r.ring, func(p ringPoint) bool { return p.group == group })
+}
+
+// Groups returns the sorted IDs of the groups on the ring.
+func (r *ShardRouter) Groups() []uint32 {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+	groups := make([]uint32, 0)
+	for _, p := range r.ring {
+		if !slices.Contains(groups, p.group) {
+			groups = append(groups, p.group)
+		}
+	}
+	slices.Sort(groups)
+	return groups
+}
+
+// Group returns the group responsible for the key.
+// If the ring is empty, the default group 0 is returned.
+func (r *ShardRouter) Group(key string) uint32 {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+	if len(r.ring) == 0 {
+		return 0
+	}
+	h := hashKey(key)
+	i, _ := slices.BinarySearchFunc(r.ring, h, func(p ringPoint, h uint64) int { return cmp.Compare(p.hash, h) })
+	if i == len(r.ring) {
+		i = 0 // wrap around the ring
+	}
+	return r.ring[i].group
+}
+
+// Route sets the request's GroupID to the group responsible for the key
+// and returns the request.
+func (r *ShardRouter) Route(key string, request *pb.Value) *pb.Value {
+	request.GroupID = r.Group(key)
+	return request
+}
+
+// hashKey returns the position of the key on the hash ring.
+func hashKey(key string) uint64 {
+	sum := sha256.Sum256([]byte(key))
+	return binary.LittleEndian.Uint64(sum[:8])
+}
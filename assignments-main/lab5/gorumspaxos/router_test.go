@@ -0,0 +1,89 @@
+package gorumspaxos
+
+import (
+	"fmt"
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+)
+
+func routeKeys(r *ShardRouter, n int) map[string]uint32 {
+	groups := make(map[string]uint32, n)
+	for i := 0; i < n; i++ {
+		key := fmt.Sprintf("key-%d", i)
+		groups[key] = r.Group(key)
+	}
+	return groups
+}
+
+func TestShardRouterBalance(t *testing.T) {
+	const numKeys = 10000
+	for _, numGroups := range []int{1, 2, 4, 8} {
+		t.Run(fmt.Sprintf("Groups=%d", numGroups), func(t *testing.T) {
+			r := NewShardRouterN(numGroups)
+			counts := make(map[uint32]int)
+			for _, group := range routeKeys(r, numKeys) {
+				counts[group]++
+			}
+			if len(counts) != numGroups {
+				t.Fatalf("keys mapped to %d groups, want %d", len(counts), numGroups)
+			}
+			fair := numKeys / numGroups
+			for group, count := range counts {
+				if count < fair/2 || count > fair*2 {
+					t.Errorf("group %d owns %d keys, want close to %d", group, count, fair)
+				}
+			}
+		})
+	}
+}
+
+func TestShardRouterStable(t *testing.T) {
+	const numKeys = 10000
+	r := NewShardRouter(DefaultVirtualNodes, 0, 1, 2)
+	before := routeKeys(r, numKeys)
+	if diff := cmp.Diff(before, routeKeys(NewShardRouter(DefaultVirtualNodes, 2, 1, 0), numKeys)); diff != "" {
+		t.Errorf("routing depends on the order groups are added (-want +got):\n%s", diff)
+	}
+
+	r.AddGroup(3)
+	added := routeKeys(r, numKeys)
+	moved := 0
+	for key, group := range added {
+		if group == before[key] {
+			continue
+		}
+		moved++
+		if group != 3 {
+			t.Errorf("Group(%q) moved from %d to %d, want only moves to the new group 3", key, before[key], group)
+		}
+	}
+	if moved == 0 || moved > numKeys/2 {
+		t.Errorf("adding a group moved %d of %d keys, want about %d", moved, numKeys, numKeys/4)
+	}
+
+	r.RemoveGroup(3)
+	if diff := cmp.Diff(before, routeKeys(r, numKeys)); diff != "" {
+		t.Errorf("removing the added group did not restore routing (-want +got):\n%s", diff)
+	}
+	r.RemoveGroup(1)
+	for key, group := range routeKeys(r, numKeys) {
+		if before[key] != 1 && group != before[key] {
+			t.Errorf("Group(%q) moved from %d to %d, want only keys of removed group 1 to move", key, before[key], group)
+		}
+	}
+	if diff := cmp.Diff([]uint32{0, 2}, r.Groups()); diff != "" {
+		t.Errorf("Groups() mismatch (-want +got):\n%s", diff)
+	}
+}
+
+func TestShardRouterRoute(t *testing.T) {
+	if got := NewShardRouter(0).Group("key"); got != 0 {
+		t.Errorf("Group(key) on empty router = %d, want 0", got)
+	}
+	r := NewShardRouterN(4)
+	request := r.Route("key", req("A", 1))
+	if want := r.Group("key"); request.GetGroupID() != want {
+		t.Errorf("Route(key).GroupID = %d, want %d", request.GetGroupID(), want)
+	}
+}
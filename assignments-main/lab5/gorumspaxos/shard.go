@@ -0,0 +1,232 @@
+package gorumspaxos
+
+import (
+	"context"
+	"fmt"
+	"net"
+
+	fd "dat520/lab3/gorumsfd/proto"
+	pb "dat520/lab5/gorumspaxos/proto"
+	apb "dat520/lab5/gorumspaxos/proto/admin"
+	wpb "dat520/lab5/gorumspaxos/proto/watch"
+
+	"github.com/relab/gorums"
+)
+
+// groupConfig is a MultiPaxosConfig that stamps the ID of its Paxos group
+// on every message sent to the replicas.
+type groupConfig struct {
+	MultiPaxosConfig
+	group uint32
+}
+
+func (c *groupConfig) Prepare(ctx context.Context, request *pb.PrepareMsg) (*pb.PromiseMsg, error) {
+	request.GroupID = c.group
+	return c.MultiPaxosConfig.Prepare(ctx, request)
+}
+
+func (c *groupConfig) Accept(ctx context.Context, request *pb.AcceptMsg) (*pb.LearnMsg, error) {
+	request.GroupID = c.group
+	return c.MultiPaxosConfig.Accept(ctx, request)
+}
+
+func (c *groupConfig) Commit(ctx context.Context, request *pb.LearnMsg, opts ...gorums.CallOption) {
+	request.GroupID = c.group
+	c.MultiPaxosConfig.Commit(ctx, request, opts...)
+}
+
+func (c *groupConfig) ClientHandle(ctx context.Context, request *pb.Value) (*pb.Response, error) {
+	request.GroupID = c.group
+	return c.MultiPaxosConfig.ClientHandle(ctx, request)
+}
+
+// ShardedReplica runs several independent Multi-Paxos groups in one process.
+// Each group has its own Acceptor, Proposer and log, while all groups share
+// the failure detector, leader detector and gorums server of the process.
+// Incoming messages are dispatched to a group by their GroupID.
+type ShardedReplica struct {
+	pb.MultiPaxos
+	groups []*PaxosReplica // the replica of each group, indexed by group ID
+}
+
+// NewShardedReplica returns a replica running numGroups Paxos groups with IDs
+// 0 to numGroups-1 on the nodes in nodeMap. The options apply to every group.
+func NewShardedReplica(myID int, nodeMap map[string]uint32, numGroups int, options ...ReplicaOption) *ShardedReplica {
+	node := newPaxosReplica(myID, nodeMap, options...)
+	s := &ShardedReplica{groups: []*PaxosReplica{node}}
+	for group := 1; group < numGroups; group++ {
+		s.groups = append(s.groups, node.newGroupReplica(uint32(group), options...))
+	}
+	fd.RegisterFailureDetectorServer(node.srv, node.failureDetector)
+	pb.RegisterMultiPaxosServer(node.srv, s)
+	apb.RegisterAdminServer(node.srv, s)
+	wpb.RegisterLogWatchServer(node.srv, s)
+	for _, r := range s.groups {
+		r.run()
+	}
+	node.startFailureDetector()
+	return s
+}
+
+// newGroupReplica returns a replica for the given Paxos group that shares
+// r's failure detector, leader detector, managers and gorums server.
+func (r *PaxosReplica) newGroupReplica(group uint32, options ...ReplicaOption) *PaxosReplica {
+	g := &PaxosReplica{
+		Acceptor:        NewAcceptor(),
+		Proposer:        NewProposer(r.id, r.leaderDetector.Leader(), r.nodeMap),
+		leaderDetector:  r.leaderDetector,
+		suspects:        r.suspects,
+		failureDetector: r.failureDetector,
+		fdManager:       r.fdManager,
+		paxosManager:    r.paxosManager,
+		paxosConfig:     r.paxosConfig,
+		id:              r.id,
+		srv:             r.srv,
+		stop:            make(chan struct{}),
+		learntVal:       make(map[uint32]*pb.LearnMsg),
+		pending:         newPendingRequests(),
+		sessions:        make(map[string]*session),
+		decided:         make(chan struct{}),
+		group:           group,
+		faults:          r.faults,
+	}
+	for _, opt := range options {
+		opt(g)
+	}
+	return g
+}
+
+// Groups returns the number of Paxos groups run by the replica.
+func (s *ShardedReplica) Groups() int {
+	return len(s.groups)
+}
+
+// Group returns the replica of the given Paxos group.
+func (s *ShardedReplica) Group(group uint32) (*PaxosReplica, error) {
+	if int(group) >= len(s.groups) {
+		return nil, fmt.Errorf("unknown Paxos group %d", group)
+	}
+	return s.groups[group], nil
+}
+
+// Stop stops the run loops of all groups, the failure detector and the gorums server.
+func (s *ShardedReplica) Stop() {
+	node := s.groups[0]
+	if node.stopped {
+		return
+	}
+	for _, r := range s.groups[1:] {
+		r.stop <- struct{}{} // stop the group's run loop
+	}
+	node.Stop()
+}
+
+// Serve starts the server and blocks until the server is stopped.
+func (s *ShardedReplica) Serve(lis net.Listener) {
+	s.groups[0].Serve(lis)
+}
+
+// Prepare passes the prepare to the replica of the message's group.
+func (s *ShardedReplica) Prepare(ctx gorums.ServerCtx, prepare *pb.PrepareMsg) (*pb.PromiseMsg, error) {
+	r, err := s.Group(prepare.GetGroupID())
+	if err != nil {
+		return nil, err
+	}
+	return r.Prepare(ctx, prepare)
+}
+
+// Accept passes the accept to the replica of the message's group.
+func (s *ShardedReplica) Accept(ctx gorums.ServerCtx, accept *pb.AcceptMsg) (*pb.LearnMsg, error) {
+	r, err := s.Group(accept.GetGroupID())
+	if err != nil {
+		return nil, err
+	}
+	return r.Accept(ctx, accept)
+}
+
+// Commit passes the learn to the replica of the message's group.
+// Learns for unknown groups are dropped.
+func (s *ShardedReplica) Commit(ctx gorums.ServerCtx, learn *pb.LearnMsg) {
+	r, err := s.Group(learn.GetGroupID())
+	if err != nil {
+		s.groups[0].Logf("Dropping Commit(%v): %v", learn, err)
+		return
+	}
+	r.Commit(ctx, learn)
+}
+
+// ClientHandle passes the client request to the replica of the request's group.
+func (s *ShardedReplica) ClientHandle(ctx gorums.ServerCtx, req *pb.Value) (*pb.Response, error) {
+	r, err := s.Group(req.GetGroupID())
+	if err != nil {
+		return nil, err
+	}
+	return r.ClientHandle(ctx, req)
+}
+
+// ReplicaStatus returns the state of the requested group's replica.
+func (s *ShardedReplica) ReplicaStatus(ctx gorums.ServerCtx, req *apb.StatusRequest) (*apb.StatusReply, error) {
+	r, err := s.Group(req.GetGroupID())
+	if err != nil {
+		return nil, err
+	}
+	return r.ReplicaStatus(ctx, req)
+}
+
+// DumpLog returns the log entries of the requested group.
+func (s *ShardedReplica) DumpLog(ctx gorums.ServerCtx, req *apb.LogRequest) (*apb.LogReply, error) {
+	r, err := s.Group(req.GetGroupID())
+	if err != nil {
+		return nil, err
+	}
+	return r.DumpLog(ctx, req)
+}
+
+// Watch streams the decided log of the requested group.
+func (s *ShardedReplica) Watch(ctx gorums.ServerCtx, req *wpb.WatchRequest, send func(*pb.LearnMsg) error) error {
+	r, err := s.Group(req.GetGroupID())
+	if err != nil {
+		return err
+	}
+	return r.Watch(ctx, req, send)
+}
+
+// ForceElection forces a leader election; since the groups share the leader
+// detector, the leader of every group changes.
+func (s *ShardedReplica) ForceElection(ctx gorums.ServerCtx, req *apb.StatusRequest) (*apb.StatusReply, error) {
+	r, err := s.Group(req.GetGroupID())
+	if err != nil {
+		return nil, err
+	}
+	return r.ForceElection(ctx, req)
+}
+
+// SetProposing pauses or resumes the proposer of the requested group.
+func (s *ShardedReplica) SetProposing(ctx gorums.ServerCtx, req *apb.ProposingRequest) (*apb.StatusReply, error) {
+	r, err := s.Group(req.GetGroupID())
+	if err != nil {
+		return nil, err
+	}
+	return r.SetProposing(ctx, req)
+}
+
+// SuspectNode makes the shared leader detector suspect the given node.
+func (s *ShardedReplica) SuspectNode(ctx gorums.ServerCtx, req *apb.NodeRequest) (*apb.StatusReply, error) {
+	return s.groups[0].SuspectNode(ctx, req)
+}
+
+// RestoreNode makes the shared leader detector restore the given node.
+func (s *ShardedReplica) RestoreNode(ctx gorums.ServerCtx, req *apb.NodeRequest) (*apb.StatusReply, error) {
+	return s.groups[0].RestoreNode(ctx, req)
+}
+
+// SetFaults replaces the fault rules of the replica's managers and server,
+// which are shared by all groups.
+func (s *ShardedReplica) SetFaults(ctx gorums.ServerCtx, req *apb.FaultsRequest) (*apb.FaultsReply, error) {
+	return s.groups[0].SetFaults(ctx, req)
+}
+
+// Faults returns the fault rules shared by all groups.
+func (s *ShardedReplica) Faults(ctx gorums.ServerCtx, req *apb.StatusRequest) (*apb.FaultsReply, error) {
+	return s.groups[0].Faults(ctx, req)
+}
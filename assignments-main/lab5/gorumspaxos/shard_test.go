@@ -0,0 +1,109 @@
+package gorumspaxos
+
+import (
+	"context"
+	"net"
+	"testing"
+	"time"
+
+	pb "dat520/lab5/gorumspaxos/proto"
+	apb "dat520/lab5/gorumspaxos/proto/admin"
+
+	"github.com/relab/gorums"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/credentials/insecure"
+)
+
+func TestGroupConfig(t *testing.T) {
+	mock := &MockConfiguration{}
+	cfg := &groupConfig{MultiPaxosConfig: mock, group: 3}
+	ctx := context.Background()
+	_, _ = cfg.Prepare(ctx, &pb.PrepareMsg{Slot: 1, Crnd: 2})
+	_, _ = cfg.Accept(ctx, &pb.AcceptMsg{Slot: 1, Rnd: 2, Val: valOne})
+	cfg.Commit(ctx, &pb.LearnMsg{Slot: 1, Rnd: 2, Val: valOne})
+	_, _ = cfg.ClientHandle(ctx, &pb.Value{ClientID: "1", ClientSeq: 1})
+	got := []uint32{mock.PrpIn.GetGroupID(), mock.AccIn.GetGroupID(), mock.LrnIn.GetGroupID(), mock.ValIn.GetGroupID()}
+	for i, group := range got {
+		if group != 3 {
+			t.Errorf("message %d sent with GroupID %d, want 3", i, group)
+		}
+	}
+}
+
+func TestShardedReplica(t *testing.T) {
+	const numGroups = 3
+	lis, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	addr := lis.Addr().String()
+	replica := NewShardedReplica(0, map[string]uint32{addr: 0}, numGroups)
+	go replica.Serve(lis)
+	defer replica.Stop()
+
+	dialOpts := []gorums.ManagerOption{
+		gorums.WithDialTimeout(5 * time.Second),
+		gorums.WithGrpcDialOptions(
+			grpc.WithBlock(), // block until connections are made
+			grpc.WithTransportCredentials(insecure.NewCredentials()), // disable TLS
+		),
+	}
+	paxosMgr := pb.NewManager(dialOpts...)
+	defer paxosMgr.Close()
+	paxosCfg, err := paxosMgr.NewConfiguration(NewPaxosQSpec(1), gorums.WithNodeList([]string{addr}))
+	if err != nil {
+		t.Fatal(err)
+	}
+	adminMgr := apb.NewManager(dialOpts...)
+	defer adminMgr.Close()
+	adminCfg, err := adminMgr.NewConfiguration(gorums.WithNodeList([]string{addr}))
+	if err != nil {
+		t.Fatal(err)
+	}
+	admin := adminCfg.Nodes()[0]
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	// commit a different number of slots to each group
+	for group := uint32(0); group < numGroups; group++ {
+		for slot := Slot(1); slot <= Slot(group+1); slot++ {
+			paxosCfg.Commit(ctx, &pb.LearnMsg{GroupID: group, Slot: slot, Rnd: 1, Val: valOne})
+		}
+	}
+	for group := uint32(0); group < numGroups; group++ {
+		var logReply *apb.LogReply
+		for {
+			logReply, err = admin.DumpLog(ctx, &apb.LogRequest{GroupID: group})
+			if err != nil {
+				t.Fatal(err)
+			}
+			if len(logReply.GetDecided()) >= int(group+1) || ctx.Err() != nil {
+				break
+			}
+			time.Sleep(10 * time.Millisecond)
+		}
+		if got := len(logReply.GetDecided()); got != int(group+1) {
+			t.Errorf("group %d decided %d slots, want %d", group, got, group+1)
+		}
+		status, err := admin.ReplicaStatus(ctx, &apb.StatusRequest{GroupID: group})
+		if err != nil {
+			t.Fatal(err)
+		}
+		if status.GetGroupID() != group {
+			t.Errorf("ReplicaStatus(GroupID: %d).GroupID = %d", group, status.GetGroupID())
+		}
+	}
+
+	if _, err := admin.ReplicaStatus(ctx, &apb.StatusRequest{GroupID: numGroups}); err == nil {
+		t.Errorf("ReplicaStatus(GroupID: %d) = nil error, want error for unknown group", numGroups)
+	}
+	if _, err := admin.SetProposing(ctx, &apb.ProposingRequest{GroupID: 1, Paused: true}); err != nil {
+		t.Fatal(err)
+	}
+	for group := uint32(0); group < numGroups; group++ {
+		r, _ := replica.Group(group)
+		if want := group == 1; r.isPaused() != want {
+			t.Errorf("group %d paused = %t, want %t", group, r.isPaused(), want)
+		}
+	}
+}
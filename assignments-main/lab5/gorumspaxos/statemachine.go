@@ -0,0 +1,106 @@
+package gorumspaxos
+
+import (
+	pb "dat520/lab5/gorumspaxos/proto"
+)
+
+// StateMachine is an application replicated by applying the decided client
+// requests in slot order. A replica running several Paxos groups applies the
+// requests of all groups to the same state machine, concurrently across groups;
+// learn.GroupID identifies the group that decided the request.
+type StateMachine interface {
+	// Apply applies the request decided in learn and returns the result
+	// that is sent back to the client in the Response.
+	Apply(learn *pb.LearnMsg) (result string)
+}
+
+// WithStateMachine sets the state machine that decided requests are applied to.
+func WithStateMachine(sm StateMachine) ReplicaOption {
+	return func(r *PaxosReplica) {
+		r.stateMachine = sm
+	}
+}
+
+// sessionWindow is the number of a client's most recent sequence numbers
+// whose responses are kept to answer retried requests.
+const sessionWindow = 64
+
+// session records the responses to a client's most recently applied requests,
+// so that a retried request is answered without being applied again.
+type session struct {
+	latest    uint32                    // the client's highest applied sequence number
+	responses map[uint32]appliedRequest // by sequence number, in (latest-sessionWindow, latest]
+}
+
+// appliedRequest is the response to an applied request, and the request's hash.
+type appliedRequest struct {
+	hash uint64
+	rsp  *pb.Response
+}
+
+// lookup returns the response to the request with the given sequence number
+// and hash, if it was applied.
+func (s *session) lookup(seq uint32, hash uint64) (*pb.Response, bool) {
+	applied, ok := s.responses[seq]
+	if !ok || applied.hash != hash {
+		return nil, false
+	}
+	return applied.rsp, true
+}
+
+// record records the response to an applied request. A sequence number that
+// falls behind the window means that the client restarted its numbering, and
+// the responses to its previous requests are discarded.
+func (s *session) record(seq uint32, hash uint64, rsp *pb.Response) {
+	switch {
+	case seq > s.latest:
+		s.latest = seq
+		for old := range s.responses {
+			if s.latest-old >= sessionWindow {
+				delete(s.responses, old)
+			}
+		}
+	case s.latest-seq >= sessionWindow:
+		s.latest = seq
+		clear(s.responses)
+	}
+	s.responses[seq] = appliedRequest{hash: hash, rsp: rsp}
+}
+
+// deliver applies the decided request to the replica's state machine, if any,
+// and returns the response for the client that sent the request.
+// It must be called exactly once for each slot, in slot order, with r.mu held.
+// No-ops are not applied to the state machine.
+//
+// A request that a client retried may be decided in several slots; it is only
+// applied in the first one, and the later slots return the same response.
+// All replicas decide the same slots, so they skip the same duplicates.
+// A request is identified by its client ID, sequence number and command, and
+// only the client's last sessionWindow sequence numbers are remembered; a
+// client must therefore not reuse a sequence number for the same command,
+// also after a restart, while it is in the window.
+func (r *PaxosReplica) deliver(learn *pb.LearnMsg) *pb.Response {
+	val := learn.GetVal()
+	rsp := &pb.Response{
+		ClientID:      val.GetClientID(),
+		ClientSeq:     val.GetClientSeq(),
+		ClientCommand: val.GetClientCommand(),
+	}
+	if val.GetIsNoop() {
+		return rsp
+	}
+	s, ok := r.sessions[val.GetClientID()]
+	if !ok {
+		s = &session{responses: make(map[uint32]appliedRequest)}
+		r.sessions[val.GetClientID()] = s
+	}
+	hash := val.Hash()
+	if applied, ok := s.lookup(val.GetClientSeq(), hash); ok {
+		return applied
+	}
+	if r.stateMachine != nil {
+		rsp.Result = r.stateMachine.Apply(learn)
+	}
+	s.record(val.GetClientSeq(), hash, rsp)
+	return rsp
+}
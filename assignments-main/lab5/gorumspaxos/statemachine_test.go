@@ -0,0 +1,142 @@
+package gorumspaxos
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	pb "dat520/lab5/gorumspaxos/proto"
+
+	"github.com/google/go-cmp/cmp"
+	"google.golang.org/protobuf/testing/protocmp"
+)
+
+// countingSM records the slots of the applied requests.
+type countingSM struct{ applied []Slot }
+
+func (sm *countingSM) Apply(learn *pb.LearnMsg) string {
+	sm.applied = append(sm.applied, learn.GetSlot())
+	return learn.GetVal().GetClientCommand() + " done"
+}
+
+func TestDeliver(t *testing.T) {
+	sm := &countingSM{}
+	replica := newTestReplicaLeader()
+	WithStateMachine(sm)(replica)
+
+	noop := &pb.Value{IsNoop: true}
+	tests := []struct {
+		learn *pb.LearnMsg
+		want  *pb.Response
+	}{
+		{learn: &pb.LearnMsg{Slot: 1, Rnd: 1, Val: valOne}, want: &pb.Response{ClientID: "1234", ClientSeq: 42, ClientCommand: "ls", Result: "ls done"}},
+		{learn: &pb.LearnMsg{Slot: 2, Rnd: 1, Val: noop}, want: &pb.Response{}},
+		{learn: &pb.LearnMsg{Slot: 3, Rnd: 1, Val: valTwo}, want: &pb.Response{ClientID: "5678", ClientSeq: 99, ClientCommand: "rm", Result: "rm done"}},
+		// a retried request decided again is not applied again
+		{learn: &pb.LearnMsg{Slot: 4, Rnd: 1, Val: valOne}, want: &pb.Response{ClientID: "1234", ClientSeq: 42, ClientCommand: "ls", Result: "ls done"}},
+	}
+	for _, test := range tests {
+		if diff := cmp.Diff(test.want, replica.deliver(test.learn), protocmp.Transform()); diff != "" {
+			t.Errorf("deliver(%v) mismatch (-want +got):\n%s", test.learn, diff)
+		}
+	}
+	if diff := cmp.Diff([]Slot{1, 3}, sm.applied); diff != "" {
+		t.Errorf("applied slots mismatch (-want +got):\n%s", diff)
+	}
+}
+
+func TestDeliverClientRestart(t *testing.T) {
+	sm := &countingSM{}
+	replica := newTestReplicaLeader()
+	WithStateMachine(sm)(replica)
+
+	request := func(seq uint32, cmd string) *pb.Value {
+		return &pb.Value{ClientID: "1", ClientSeq: seq, ClientCommand: cmd}
+	}
+	tests := []struct {
+		name        string
+		val         *pb.Value
+		wantApplied bool
+	}{
+		{name: "First", val: request(100, "ls"), wantApplied: true},
+		{name: "Retry", val: request(100, "ls"), wantApplied: false},
+		{name: "Concurrent", val: request(99, "ls"), wantApplied: true},
+		{name: "RetryConcurrent", val: request(99, "ls"), wantApplied: false},
+		// the client restarts its numbering at 0, behind the window of seq 100
+		{name: "Restarted", val: request(0, "ls"), wantApplied: true},
+		{name: "RetryRestarted", val: request(0, "ls"), wantApplied: false},
+		// the earlier requests are forgotten with the client's old numbering
+		{name: "RestartedAgain", val: request(100, "ls"), wantApplied: true},
+		// a sequence number reused with another command is not a retry
+		{name: "OtherCommand", val: request(100, "rm"), wantApplied: true},
+	}
+	for i, test := range tests {
+		slot := Slot(i + 1)
+		rsp := replica.deliver(&pb.LearnMsg{Slot: slot, Rnd: 1, Val: test.val})
+		gotApplied := len(sm.applied) > 0 && sm.applied[len(sm.applied)-1] == slot
+		if gotApplied != test.wantApplied {
+			t.Errorf("%s: deliver(%v) applied = %t, want %t", test.name, test.val, gotApplied, test.wantApplied)
+		}
+		if !test.val.Match(rsp) {
+			t.Errorf("%s: deliver(%v) = %v, want matching response", test.name, test.val, rsp)
+		}
+	}
+}
+
+func TestDeliverSessionWindow(t *testing.T) {
+	replica := newTestReplicaLeader()
+	for seq := uint32(1); seq <= 10*sessionWindow; seq++ {
+		replica.deliver(&pb.LearnMsg{Slot: seq, Rnd: 1, Val: &pb.Value{ClientID: "1", ClientSeq: seq, ClientCommand: "ls"}})
+	}
+	if got := len(replica.sessions["1"].responses); got != sessionWindow {
+		t.Errorf("len(responses) = %d, want %d", got, sessionWindow)
+	}
+}
+
+func TestClientHandleDuplicate(t *testing.T) {
+	nodeMap, stop, _, replicas := startReplicas(t, 3)
+	defer stop()
+	sms := make([]*countingSM, len(replicas))
+	for i, replica := range replicas {
+		sms[i] = &countingSM{}
+		replica.mu.Lock()
+		replica.stateMachine = sms[i]
+		replica.mu.Unlock()
+	}
+	config, closeMgr, err := newConfiguration(nodeMap)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer closeMgr()
+
+	// the client sends the same request twice, e.g., retrying after a timeout;
+	// the second call is proposed again and decided in the next slot
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+	req := &pb.Value{ClientID: "1", ClientSeq: 1, ClientCommand: "ls"}
+	var rsps []*pb.Response
+	for range 2 {
+		rsp, err := config.ClientHandle(ctx, req)
+		if err != nil {
+			t.Fatalf("ClientHandle(%v) = %v, want nil", req, err)
+		}
+		rsps = append(rsps, rsp)
+	}
+	if diff := cmp.Diff(rsps[0], rsps[1], protocmp.Transform()); diff != "" {
+		t.Errorf("responses mismatch (-first +second):\n%s", diff)
+	}
+
+	for i, replica := range replicas {
+		for deadline := time.Now().Add(5 * time.Second); replica.allDecidedUpTo() < 2; time.Sleep(10 * time.Millisecond) {
+			if time.Now().After(deadline) {
+				t.Fatalf("replica %d: allDecidedUpTo = %d, want 2", replica.id, replica.allDecidedUpTo())
+			}
+		}
+		replica.mu.Lock()
+		applied := sms[i].applied
+		replica.mu.Unlock()
+		if diff := cmp.Diff([]Slot{1}, applied); diff != "" {
+			t.Errorf("replica %d: applied slots mismatch (-want +got):\n%s", replica.id, diff)
+		}
+	}
+}
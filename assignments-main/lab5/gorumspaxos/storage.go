@@ -0,0 +1,214 @@
+package gorumspaxos
+
+import (
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"sync"
+
+	pb "dat520/lab5/gorumspaxos/proto"
+
+	"google.golang.org/protobuf/proto"
+)
+
+// AcceptorStorage persists the state of the acceptors of a replica in a
+// directory, one file per Paxos group, so that a replica restarted after a
+// crash keeps the promises and accepted values of its acceptors. Without it,
+// a restarted acceptor could promise a round lower than it promised before,
+// or forget a value accepted by a quorum that the proposer of a higher round
+// must recover.
+//
+// An acceptor's state is appended to its file before it replies to a Prepare
+// or Accept. The writes are not synced, so the state survives a crash of the
+// replica's process, but not of its machine. Once a file holds more records
+// than a snapshot of the acceptor's state would, and at least compactRecords,
+// it is replaced by the snapshot.
+type AcceptorStorage struct {
+	dir     string
+	mu      sync.Mutex
+	states  map[uint32]*pb.PromiseMsg // the loaded state of each group's acceptor
+	files   map[uint32]*os.File       // the opened file of each group's acceptor
+	records map[uint32]int            // the number of records in each opened file
+}
+
+// compactRecords is the minimum number of records in an acceptor's file
+// before it is replaced by a snapshot.
+const compactRecords = 1024
+
+// NewAcceptorStorage returns an acceptor storage in dir, creating the
+// directory if needed, and loads the acceptor states stored in it.
+func NewAcceptorStorage(dir string) (*AcceptorStorage, error) {
+	if err := os.MkdirAll(dir, 0o755); err != nil {
+		return nil, err
+	}
+	s := &AcceptorStorage{
+		dir:     dir,
+		states:  make(map[uint32]*pb.PromiseMsg),
+		files:   make(map[uint32]*os.File),
+		records: make(map[uint32]int),
+	}
+	names, err := filepath.Glob(filepath.Join(dir, "acceptor-*.log"))
+	if err != nil {
+		return nil, err
+	}
+	for _, name := range names {
+		group, err := strconv.ParseUint(strings.TrimSuffix(strings.TrimPrefix(filepath.Base(name), "acceptor-"), ".log"), 10, 32)
+		if err != nil {
+			continue
+		}
+		state, err := loadAcceptorState(name)
+		if err != nil {
+			return nil, fmt.Errorf("loading %s: %w", name, err)
+		}
+		s.states[uint32(group)] = state
+	}
+	return s, nil
+}
+
+// WithAcceptorStorage restores the replica's acceptor from the storage
+// and persists the acceptor's state in it.
+func WithAcceptorStorage(s *AcceptorStorage) ReplicaOption {
+	return func(r *PaxosReplica) {
+		r.storage = s
+		s.restore(r.group, r.Acceptor)
+	}
+}
+
+// restore sets the acceptor's state to the state loaded for the group, if any.
+func (s *AcceptorStorage) restore(group uint32, a *Acceptor) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	state, ok := s.states[group]
+	if !ok {
+		return
+	}
+	a.rnd = state.GetRnd()
+	for _, pval := range state.GetAccepted() {
+		a.accepted[pval.GetSlot()] = pval
+	}
+}
+
+// store appends the acceptor's round and the given accepted values to the
+// group's file, and replaces the file with a snapshot of the acceptor's state
+// if it holds too many records. The caller must hold the acceptor's lock.
+func (s *AcceptorStorage) store(group uint32, a *Acceptor, accepted ...*pb.PValue) error {
+	record, err := acceptorRecord(&pb.PromiseMsg{Rnd: a.rnd, Accepted: accepted})
+	if err != nil {
+		return err
+	}
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	f, ok := s.files[group]
+	if !ok {
+		f, err = os.OpenFile(s.name(group), os.O_CREATE|os.O_WRONLY|os.O_APPEND, 0o644)
+		if err != nil {
+			return err
+		}
+		s.files[group] = f
+	}
+	if _, err := f.Write(record); err != nil {
+		return err
+	}
+	s.records[group]++
+	if s.records[group] < max(compactRecords, len(a.accepted)) {
+		return nil
+	}
+	return s.compact(group, a)
+}
+
+// compact replaces the group's file with a snapshot of the acceptor's state,
+// a single record holding its round and accepted values. The snapshot is
+// written to a temporary file that is renamed to the group's file, so that a
+// crash leaves either the old file or the snapshot. The caller must hold the
+// acceptor's lock and s.mu.
+func (s *AcceptorStorage) compact(group uint32, a *Acceptor) error {
+	record, err := acceptorRecord(&pb.PromiseMsg{Rnd: a.rnd, Accepted: Values(a.accepted)})
+	if err != nil {
+		return err
+	}
+	name := s.name(group)
+	if err := os.WriteFile(name+".tmp", record, 0o644); err != nil {
+		return err
+	}
+	if err := s.files[group].Close(); err != nil {
+		return err
+	}
+	delete(s.files, group)
+	if err := os.Rename(name+".tmp", name); err != nil {
+		return err
+	}
+	f, err := os.OpenFile(name, os.O_WRONLY|os.O_APPEND, 0)
+	if err != nil {
+		return err
+	}
+	s.files[group] = f
+	s.records[group] = 1
+	return nil
+}
+
+// name returns the name of the group's file.
+func (s *AcceptorStorage) name(group uint32) string {
+	return filepath.Join(s.dir, fmt.Sprintf("acceptor-%d.log", group))
+}
+
+// acceptorRecord returns the length-prefixed encoding of an acceptor state.
+func acceptorRecord(state *pb.PromiseMsg) ([]byte, error) {
+	b, err := proto.Marshal(state)
+	if err != nil {
+		return nil, err
+	}
+	record := binary.AppendUvarint(nil, uint64(len(b)))
+	return append(record, b...), nil
+}
+
+// Close closes the storage's files.
+func (s *AcceptorStorage) Close() error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	var errs []error
+	for group, f := range s.files {
+		errs = append(errs, f.Close())
+		delete(s.files, group)
+	}
+	return errors.Join(errs...)
+}
+
+// loadAcceptorState returns the acceptor state stored in the named file,
+// which is a sequence of length-prefixed PromiseMsg records, each holding the
+// acceptor's round and the values it accepted since the previous record.
+// A record cut short by a crash is removed from the file.
+func loadAcceptorState(name string) (*pb.PromiseMsg, error) {
+	b, err := os.ReadFile(name)
+	if err != nil {
+		return nil, err
+	}
+	state := &pb.PromiseMsg{Rnd: NoRound}
+	accepted := make(map[Slot]*pb.PValue)
+	valid := 0
+	for valid < len(b) {
+		n, size := binary.Uvarint(b[valid:])
+		if size <= 0 || uint64(len(b)-valid-size) < n {
+			break // the last record was cut short
+		}
+		record := &pb.PromiseMsg{}
+		if err := proto.Unmarshal(b[valid+size:valid+size+int(n)], record); err != nil {
+			return nil, err
+		}
+		state.Rnd = max(state.Rnd, record.GetRnd())
+		for _, pval := range record.GetAccepted() {
+			accepted[pval.GetSlot()] = pval
+		}
+		valid += size + int(n)
+	}
+	if valid < len(b) {
+		if err := os.Truncate(name, int64(valid)); err != nil {
+			return nil, err
+		}
+	}
+	state.Accepted = Values(accepted)
+	return state, nil
+}
@@ -0,0 +1,118 @@
+package gorumspaxos
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	pb "dat520/lab5/gorumspaxos/proto"
+
+	"github.com/google/go-cmp/cmp"
+	"github.com/relab/gorums"
+	"google.golang.org/protobuf/testing/protocmp"
+)
+
+func TestAcceptorStorage(t *testing.T) {
+	dir := t.TempDir()
+	nodeMap := map[string]uint32{"127.0.0.1:0": 0}
+	newReplica := func() (*PaxosReplica, *AcceptorStorage) {
+		t.Helper()
+		storage, err := NewAcceptorStorage(dir)
+		if err != nil {
+			t.Fatal(err)
+		}
+		return newPaxosReplica(0, nodeMap, WithAcceptorStorage(storage)), storage
+	}
+
+	r, storage := newReplica()
+	if _, err := r.Prepare(gorums.ServerCtx{}, &pb.PrepareMsg{Slot: 1, Crnd: 2}); err != nil {
+		t.Fatal(err)
+	}
+	for _, accept := range []*pb.AcceptMsg{
+		{Slot: 1, Rnd: 2, Val: valOne},
+		{Slot: 2, Rnd: 2, Val: valTwo},
+		{Slot: 1, Rnd: 5, Val: valTwo},
+	} {
+		if _, err := r.Accept(gorums.ServerCtx{}, accept); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if err := storage.Close(); err != nil {
+		t.Fatal(err)
+	}
+
+	// a record cut short by a crash is ignored
+	name := filepath.Join(dir, "acceptor-0.log")
+	f, err := os.OpenFile(name, os.O_WRONLY|os.O_APPEND, 0)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := f.Write([]byte{100, 1, 2}); err != nil {
+		t.Fatal(err)
+	}
+	f.Close()
+
+	restarted, storage := newReplica()
+	defer storage.Close()
+	if restarted.rnd != 5 {
+		t.Errorf("rnd = %d, want 5", restarted.rnd)
+	}
+	want := map[Slot]*pb.PValue{
+		1: {Slot: 1, Vrnd: 5, Vval: valTwo},
+		2: {Slot: 2, Vrnd: 2, Vval: valTwo},
+	}
+	if diff := cmp.Diff(want, restarted.accepted, protocmp.Transform()); diff != "" {
+		t.Errorf("accepted mismatch (-want +got):\n%s", diff)
+	}
+	// the restored promise rejects lower rounds, and new records follow the valid ones
+	if _, err := restarted.Prepare(gorums.ServerCtx{}, &pb.PrepareMsg{Slot: 1, Crnd: 3}); err == nil {
+		t.Error("Prepare(3) = nil, want error for a round lower than the restored promise")
+	}
+	if _, err := restarted.Prepare(gorums.ServerCtx{}, &pb.PrepareMsg{Slot: 1, Crnd: 7}); err != nil {
+		t.Fatal(err)
+	}
+	state, err := loadAcceptorState(name)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if state.GetRnd() != 7 {
+		t.Errorf("stored rnd = %d, want 7", state.GetRnd())
+	}
+}
+
+func TestAcceptorStorageCompaction(t *testing.T) {
+	dir := t.TempDir()
+	nodeMap := map[string]uint32{"127.0.0.1:0": 0}
+	storage, err := NewAcceptorStorage(dir)
+	if err != nil {
+		t.Fatal(err)
+	}
+	r := newPaxosReplica(0, nodeMap, WithAcceptorStorage(storage))
+	if _, err := r.Prepare(gorums.ServerCtx{}, &pb.PrepareMsg{Slot: 1, Crnd: 2}); err != nil {
+		t.Fatal(err)
+	}
+	const numSlots = compactRecords + 100
+	for slot := Slot(1); slot <= numSlots; slot++ {
+		if _, err := r.Accept(gorums.ServerCtx{}, &pb.AcceptMsg{Slot: slot, Rnd: 2, Val: valOne}); err != nil {
+			t.Fatal(err)
+		}
+	}
+	// the file was replaced by a snapshot after compactRecords records
+	if got := storage.records[0]; got >= compactRecords {
+		t.Errorf("records = %d, want fewer than %d after compaction", got, compactRecords)
+	}
+	if err := storage.Close(); err != nil {
+		t.Fatal(err)
+	}
+
+	state, err := loadAcceptorState(filepath.Join(dir, "acceptor-0.log"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if state.GetRnd() != 2 || len(state.GetAccepted()) != numSlots {
+		t.Errorf("stored rnd = %d, accepted = %d; want 2, %d", state.GetRnd(), len(state.GetAccepted()), numSlots)
+	}
+	if _, err := os.Stat(filepath.Join(dir, "acceptor-0.log.tmp")); !os.IsNotExist(err) {
+		t.Errorf("Stat(acceptor-0.log.tmp) = %v, want not exist", err)
+	}
+}
@@ -0,0 +1,268 @@
+package gorumspaxos
+
+import (
+	"crypto/rand"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"os"
+	"slices"
+	"strconv"
+	"sync"
+	"time"
+
+	pb "dat520/lab5/gorumspaxos/proto"
+)
+
+// Span is a finished, timed operation that belongs to a trace.
+// Spans are modeled after OpenTelemetry spans; a span whose ParentID is
+// empty is the root of its trace.
+type Span struct {
+	Name       string            `json:"name"`
+	TraceID    string            `json:"trace_id"`
+	SpanID     string            `json:"span_id"`
+	ParentID   string            `json:"parent_id,omitempty"`
+	NodeID     int               `json:"node_id"`
+	Start      time.Time         `json:"start"`
+	End        time.Time         `json:"end"`
+	Attributes map[string]string `json:"attributes,omitempty"`
+}
+
+// Duration returns the duration of the span.
+func (s Span) Duration() time.Duration {
+	return s.End.Sub(s.Start)
+}
+
+// SpanExporter receives spans as they are ended.
+type SpanExporter interface {
+	ExportSpan(Span)
+}
+
+// InMemoryExporter stores exported spans in memory; it is intended for tests.
+type InMemoryExporter struct {
+	mu    sync.Mutex
+	spans []Span
+}
+
+// NewInMemoryExporter returns a new in-memory span exporter.
+func NewInMemoryExporter() *InMemoryExporter {
+	return &InMemoryExporter{}
+}
+
+// ExportSpan stores the span.
+func (e *InMemoryExporter) ExportSpan(span Span) {
+	e.mu.Lock()
+	e.spans = append(e.spans, span)
+	e.mu.Unlock()
+}
+
+// Spans returns the exported spans in the order they were ended.
+func (e *InMemoryExporter) Spans() []Span {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+	return slices.Clone(e.spans)
+}
+
+// Trace returns the exported spans belonging to the given trace.
+func (e *InMemoryExporter) Trace(traceID string) []Span {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+	var spans []Span
+	for _, span := range e.spans {
+		if span.TraceID == traceID {
+			spans = append(spans, span)
+		}
+	}
+	return spans
+}
+
+// FileExporter writes exported spans to a file, one JSON object per line.
+type FileExporter struct {
+	mu   sync.Mutex
+	file *os.File
+	enc  *json.Encoder
+}
+
+// NewFileExporter returns a span exporter that appends spans to the named file.
+func NewFileExporter(name string) (*FileExporter, error) {
+	f, err := os.OpenFile(name, os.O_CREATE|os.O_WRONLY|os.O_APPEND, 0o644)
+	if err != nil {
+		return nil, err
+	}
+	return &FileExporter{file: f, enc: json.NewEncoder(f)}, nil
+}
+
+// ExportSpan writes the span to the file.
+func (e *FileExporter) ExportSpan(span Span) {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+	_ = e.enc.Encode(span)
+}
+
+// Close closes the underlying file.
+func (e *FileExporter) Close() error {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+	return e.file.Close()
+}
+
+// Tracer creates spans for a node and hands them to an exporter when ended.
+// A nil *Tracer is valid and creates no spans.
+type Tracer struct {
+	nodeID   int
+	exporter SpanExporter
+}
+
+// NewTracer returns a tracer for the given node that exports spans to exp.
+func NewTracer(nodeID int, exp SpanExporter) *Tracer {
+	return &Tracer{nodeID: nodeID, exporter: exp}
+}
+
+// Start starts a new span with the given name. The span is a child of parent,
+// or the root of a new trace if parent is nil.
+func (t *Tracer) Start(name string, parent *pb.TraceContext) *ActiveSpan {
+	if t == nil {
+		return nil
+	}
+	span := &ActiveSpan{
+		tracer: t,
+		span: Span{
+			Name:    name,
+			TraceID: parent.GetTraceID(),
+			SpanID:  randomID(8),
+			NodeID:  t.nodeID,
+			Start:   time.Now(),
+		},
+	}
+	if span.span.TraceID == "" {
+		span.span.TraceID = randomID(16)
+	} else {
+		span.span.ParentID = parent.GetSpanID()
+	}
+	return span
+}
+
+// ActiveSpan is a span that has been started but not yet ended.
+// A nil *ActiveSpan is valid and ignores all calls.
+type ActiveSpan struct {
+	tracer *Tracer
+	mu     sync.Mutex
+	span   Span
+	ended  bool
+}
+
+// Context returns the trace context to propagate to children of the span.
+func (s *ActiveSpan) Context() *pb.TraceContext {
+	if s == nil {
+		return nil
+	}
+	return &pb.TraceContext{TraceID: s.span.TraceID, SpanID: s.span.SpanID}
+}
+
+// SetAttribute records a key-value attribute on the span.
+func (s *ActiveSpan) SetAttribute(key, value string) {
+	if s == nil {
+		return
+	}
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if s.span.Attributes == nil {
+		s.span.Attributes = make(map[string]string)
+	}
+	s.span.Attributes[key] = value
+}
+
+// End ends the span and exports it. Only the first call to End has any effect.
+func (s *ActiveSpan) End() {
+	if s == nil {
+		return
+	}
+	s.mu.Lock()
+	if s.ended {
+		s.mu.Unlock()
+		return
+	}
+	s.ended = true
+	s.span.End = time.Now()
+	span := s.span
+	s.mu.Unlock()
+	if s.tracer.exporter != nil {
+		s.tracer.exporter.ExportSpan(span)
+	}
+}
+
+// randomID returns a random hex-encoded identifier of n bytes.
+func randomID(n int) string {
+	b := make([]byte, n)
+	_, _ = rand.Read(b)
+	return hex.EncodeToString(b)
+}
+
+// startAcceptSpan starts the span for the accept phase of a client request,
+// ending the span for the time the request spent in the clientRequestQueue.
+// The accept message is updated to carry the new span's trace context.
+func (p *Proposer) startAcceptSpan(accept *pb.AcceptMsg) *ActiveSpan {
+	if p.tracer == nil || accept == nil {
+		return nil
+	}
+	p.mu.Lock()
+	if queued, ok := p.queueSpans[accept.GetVal().Hash()]; ok {
+		delete(p.queueSpans, accept.GetVal().Hash())
+		queued.End()
+	}
+	p.mu.Unlock()
+	span := p.tracer.Start("Proposer.Accept", accept.GetVal().GetTrace())
+	span.setMessage(accept.GetSlot(), accept.GetRnd(), accept.GetVal())
+	accept.Trace = span.Context()
+	return span
+}
+
+// dropQueueSpans ends the spans of the requests in the clientRequestQueue,
+// recording that they were dropped for the given reason. It is called when
+// the queue is dropped; the caller must hold p.mu.
+func (p *Proposer) dropQueueSpans(reason string) {
+	for hash, span := range p.queueSpans {
+		span.SetAttribute(AttrError, reason)
+		span.End()
+		delete(p.queueSpans, hash)
+	}
+}
+
+// AttrError is the attribute of a span whose operation failed, describing why.
+const AttrError = "error"
+
+// The attributes of the spans that handle a Paxos message, describing the
+// message; the paxosviz tool draws the messages from these attributes.
+const (
+	AttrSlot    = "slot"    // the message's slot, or first slot for a prepare
+	AttrRound   = "rnd"     // the message's round
+	AttrValue   = "val"     // the message's value, if any
+	AttrIgnored = "ignored" // why the acceptor ignored the message, if it did
+)
+
+// setMessage records the slot, round and value of the span's message.
+func (s *ActiveSpan) setMessage(slot uint32, rnd int32, val *pb.Value) {
+	if s == nil {
+		return
+	}
+	s.SetAttribute(AttrSlot, strconv.FormatUint(uint64(slot), 10))
+	s.SetAttribute(AttrRound, strconv.FormatInt(int64(rnd), 10))
+	if val != nil {
+		s.SetAttribute(AttrValue, valueLabel(val))
+	}
+}
+
+// ignore records that the acceptor ignored the span's message because of
+// err, and returns err.
+func (s *ActiveSpan) ignore(err error) error {
+	s.SetAttribute(AttrIgnored, err.Error())
+	return err
+}
+
+// valueLabel returns a short description of val for span attributes.
+func valueLabel(val *pb.Value) string {
+	if val.GetIsNoop() {
+		return "noop"
+	}
+	return fmt.Sprintf("%s/%d %s", val.GetClientID(), val.GetClientSeq(), val.GetClientCommand())
+}
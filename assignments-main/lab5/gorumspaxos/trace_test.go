@@ -0,0 +1,204 @@
+package gorumspaxos
+
+import (
+	"bufio"
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"testing"
+
+	pb "dat520/lab5/gorumspaxos/proto"
+
+	"github.com/google/go-cmp/cmp"
+	"github.com/relab/gorums"
+)
+
+func TestTracePropagation(t *testing.T) {
+	exporter := NewInMemoryExporter()
+	client := NewTracer(-1, exporter)
+	tracer := NewTracer(0, exporter)
+
+	replica := newTestReplicaLeader()
+	replica.Acceptor = NewAcceptor()
+	replica.Proposer.tracer = tracer
+	replica.Proposer.config = &MockConfiguration{}
+
+	root := client.Start("paxosclient.Request", nil)
+	request := &pb.Value{ClientID: "1", ClientSeq: 1, ClientCommand: "ls", Trace: root.Context()}
+
+	if err := replica.AddRequestToQ(request); err != nil {
+		t.Fatal(err)
+	}
+	accept := replica.clientRequestQueue[0]
+	_, _ = replica.performAccept(accept)
+	_, _ = replica.Accept(gorums.ServerCtx{}, accept)
+	learn := &pb.LearnMsg{Slot: 1, Rnd: accept.Rnd, Val: accept.Val}
+	_ = replica.performCommit(learn)
+	replica.Commit(gorums.ServerCtx{}, learn)
+	root.End()
+
+	spans := exporter.Trace(root.Context().GetTraceID())
+	byName := make(map[string]Span)
+	for _, span := range spans {
+		byName[span.Name] = span
+	}
+	// the parent of each span in the trace
+	wantParents := map[string]string{
+		"paxosclient.Request": "",
+		"Proposer.Queue":      "paxosclient.Request",
+		"Proposer.Accept":     "paxosclient.Request",
+		"Acceptor.Accept":     "Proposer.Accept",
+		"Proposer.Commit":     "paxosclient.Request",
+		"Replica.Commit":      "Proposer.Commit",
+	}
+	gotParents := make(map[string]string)
+	for name, span := range byName {
+		gotParents[name] = ""
+		for parentName, parent := range byName {
+			if span.ParentID == parent.SpanID {
+				gotParents[name] = parentName
+			}
+		}
+	}
+	if diff := cmp.Diff(wantParents, gotParents); diff != "" {
+		t.Errorf("span parents mismatch (-want +got):\n%s", diff)
+	}
+	if got := byName["Proposer.Queue"].NodeID; got != 0 {
+		t.Errorf("Proposer.Queue NodeID = %d, want 0", got)
+	}
+	if len(replica.queueSpans) != 0 {
+		t.Errorf("len(queueSpans) = %d, want 0", len(replica.queueSpans))
+	}
+}
+
+func TestTraceDroppedQueue(t *testing.T) {
+	exporter := NewInMemoryExporter()
+	replica := newTestReplicaLeader()
+	replica.Proposer.tracer = NewTracer(0, exporter)
+
+	for seq := uint32(1); seq <= 2; seq++ {
+		if err := replica.AddRequestToQ(&pb.Value{ClientID: "1", ClientSeq: seq, ClientCommand: "ls"}); err != nil {
+			t.Fatal(err)
+		}
+	}
+	// another replica becomes the leader, and the queued requests are dropped
+	replica.newLeader(1)
+
+	var got []string
+	for _, span := range exporter.Spans() {
+		if span.Name == "Proposer.Queue" {
+			got = append(got, span.Attributes[AttrError])
+		}
+	}
+	want := []string{"dropped by leader change", "dropped by leader change"}
+	if diff := cmp.Diff(want, got); diff != "" {
+		t.Errorf("Proposer.Queue span errors mismatch (-want +got):\n%s", diff)
+	}
+	if len(replica.queueSpans) != 0 {
+		t.Errorf("len(queueSpans) = %d, want 0", len(replica.queueSpans))
+	}
+}
+
+func TestTraceRetriedRequest(t *testing.T) {
+	exporter := NewInMemoryExporter()
+	replica := newTestReplicaLeader()
+	replica.Proposer.tracer = NewTracer(0, exporter)
+
+	request := &pb.Value{ClientID: "1", ClientSeq: 1, ClientCommand: "ls"}
+	for range 2 {
+		if err := replica.AddRequestToQ(request); err != nil {
+			t.Fatal(err)
+		}
+	}
+	replica.newLeader(1)
+
+	var got []string
+	for _, span := range exporter.Spans() {
+		if span.Name == "Proposer.Queue" {
+			got = append(got, span.Attributes[AttrError])
+		}
+	}
+	want := []string{"superseded by a retried request", "dropped by leader change"}
+	if diff := cmp.Diff(want, got); diff != "" {
+		t.Errorf("Proposer.Queue span errors mismatch (-want +got):\n%s", diff)
+	}
+}
+
+func TestNilTracer(t *testing.T) {
+	var tracer *Tracer
+	span := tracer.Start("noop", nil)
+	span.SetAttribute("key", "value")
+	span.End()
+	if ctx := span.Context(); ctx != nil {
+		t.Errorf("Context() = %v, want nil", ctx)
+	}
+}
+
+func TestFileExporter(t *testing.T) {
+	name := filepath.Join(t.TempDir(), "trace.jsonl")
+	exporter, err := NewFileExporter(name)
+	if err != nil {
+		t.Fatal(err)
+	}
+	tracer := NewTracer(3, exporter)
+	parent := tracer.Start("parent", nil)
+	child := tracer.Start("child", parent.Context())
+	child.SetAttribute("slot", "1")
+	child.End()
+	child.End() // only the first End is exported
+	parent.End()
+	if err := exporter.Close(); err != nil {
+		t.Fatal(err)
+	}
+
+	f, err := os.Open(name)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer f.Close()
+	var got []Span
+	scanner := bufio.NewScanner(f)
+	for scanner.Scan() {
+		var span Span
+		if err := json.Unmarshal(scanner.Bytes(), &span); err != nil {
+			t.Fatal(err)
+		}
+		got = append(got, span)
+	}
+	if len(got) != 2 {
+		t.Fatalf("got %d spans, want 2", len(got))
+	}
+	if got[0].Name != "child" || got[0].ParentID != got[1].SpanID || got[0].TraceID != got[1].TraceID {
+		t.Errorf("child span %+v is not a child of %+v", got[0], got[1])
+	}
+	if got[0].Attributes["slot"] != "1" || got[0].NodeID != 3 {
+		t.Errorf("child span %+v: want attribute slot=1 and NodeID 3", got[0])
+	}
+}
+
+func TestTraceMessageAttributes(t *testing.T) {
+	exporter := NewInMemoryExporter()
+	replica := newTestReplicaLeader()
+	replica.Acceptor = NewAcceptor()
+	replica.Proposer.tracer = NewTracer(1, exporter)
+
+	_, _ = replica.Prepare(gorums.ServerCtx{}, &pb.PrepareMsg{Slot: 1, Crnd: 5})
+	_, _ = replica.Prepare(gorums.ServerCtx{}, &pb.PrepareMsg{Slot: 1, Crnd: 2})
+	val := &pb.Value{ClientID: "1", ClientSeq: 7, ClientCommand: "ls"}
+	_, _ = replica.Accept(gorums.ServerCtx{}, &pb.AcceptMsg{Slot: 1, Rnd: 5, Val: val})
+	_, _ = replica.Accept(gorums.ServerCtx{}, &pb.AcceptMsg{Slot: 2, Rnd: 3, Val: &pb.Value{IsNoop: true}})
+
+	var got []map[string]string
+	for _, span := range exporter.Spans() {
+		got = append(got, span.Attributes)
+	}
+	want := []map[string]string{
+		{AttrSlot: "1", AttrRound: "5"},
+		{AttrSlot: "1", AttrRound: "2", AttrIgnored: errIgnored.Error()},
+		{AttrSlot: "1", AttrRound: "5", AttrValue: "1/7 ls"},
+		{AttrSlot: "2", AttrRound: "3", AttrValue: "noop", AttrIgnored: errIgnored.Error()},
+	}
+	if diff := cmp.Diff(want, got); diff != "" {
+		t.Errorf("span attributes mismatch (-want +got):\n%s", diff)
+	}
+}
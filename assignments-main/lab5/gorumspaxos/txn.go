@@ -0,0 +1,358 @@
+package gorumspaxos
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"slices"
+	"strconv"
+	"sync"
+	"sync/atomic"
+
+	pb "dat520/lab5/gorumspaxos/proto"
+
+	"golang.org/x/sync/errgroup"
+)
+
+// Cross-shard transactions use two-phase commit where both the coordinator
+// and the participants are Paxos groups. Every step of the protocol is a
+// request decided in the log of the group it is sent to:
+//
+//  1. The client sends a prepare with the transaction's writes to each
+//     participant group. The participant locks the written keys and votes
+//     yes, or votes no if another transaction holds one of the locks.
+//  2. The client proposes the outcome (commit if all voted yes) to the
+//     coordinator group. The first decision decided in the coordinator's log
+//     is final; later decisions for the same transaction return it.
+//  3. The client sends the outcome to each participant group, which applies
+//     or discards the staged writes and releases the locks.
+//
+// Since the decision is replicated, any client can finish a transaction
+// whose client failed after step 1 by calling TxnClient.Resolve.
+
+// TxnOp is the operation of a transaction command.
+type TxnOp string
+
+const (
+	TxnPrepare TxnOp = "prepare" // sent to participants: stage writes and vote
+	TxnCommit  TxnOp = "commit"  // sent to participants: apply staged writes
+	TxnAbort   TxnOp = "abort"   // sent to participants: discard staged writes
+	TxnDecide  TxnOp = "decide"  // sent to the coordinator: record the outcome
+)
+
+// Results of applying transaction commands.
+const (
+	TxnVoteYes   = "yes"     // prepare: the participant is prepared to commit
+	TxnVoteNo    = "no"      // prepare: the participant cannot commit
+	TxnCommitted = "commit"  // decide: the transaction commits
+	TxnAborted   = "abort"   // decide: the transaction aborts
+	TxnOK        = "ok"      // commit, abort: the outcome has been applied
+	TxnInvalid   = "invalid" // the command could not be decoded
+)
+
+// ErrTxnAborted is returned when a transaction is aborted.
+var ErrTxnAborted = errors.New("transaction aborted")
+
+// TxnWrite is a write to a single key in a transaction.
+type TxnWrite struct {
+	Key    string `json:"key"`
+	Value  string `json:"value,omitempty"`
+	Delete bool   `json:"delete,omitempty"`
+}
+
+// TxnCommand is a transaction command; it is sent as a JSON encoded
+// pb.Value.ClientCommand.
+type TxnCommand struct {
+	Op     TxnOp      `json:"op"`
+	ID     string     `json:"txn"`
+	Writes []TxnWrite `json:"writes,omitempty"` // writes to stage at a participant (prepare)
+	Commit bool       `json:"commit,omitempty"` // proposed outcome (decide)
+}
+
+// TxnStateMachine is the state machine of the participant and coordinator
+// groups. It holds the committed key-value data, the locks and staged writes
+// of prepared transactions, and the outcomes recorded by coordinators.
+// Outcomes are kept forever so that duplicate and late commands are handled
+// consistently.
+type TxnStateMachine struct {
+	mu        sync.Mutex
+	data      map[string]string     // committed values
+	locks     map[string]string     // key -> transaction holding the key's lock
+	staged    map[txnKey][]TxnWrite // writes staged by prepare
+	finished  map[txnKey]bool       // committed or not, for outcomes applied by participants
+	decisions map[txnKey]bool       // committed or not, for outcomes decided by coordinators
+}
+
+// txnKey identifies a transaction at one of the groups applying commands to
+// the state machine; a group may be both participant and coordinator.
+type txnKey struct {
+	group uint32
+	txn   string
+}
+
+// NewTxnStateMachine returns a new empty transaction state machine.
+func NewTxnStateMachine() *TxnStateMachine {
+	return &TxnStateMachine{
+		data:      make(map[string]string),
+		locks:     make(map[string]string),
+		staged:    make(map[txnKey][]TxnWrite),
+		finished:  make(map[txnKey]bool),
+		decisions: make(map[txnKey]bool),
+	}
+}
+
+// Get returns the committed value of the key.
+func (sm *TxnStateMachine) Get(key string) (string, bool) {
+	sm.mu.Lock()
+	defer sm.mu.Unlock()
+	value, ok := sm.data[key]
+	return value, ok
+}
+
+// Apply applies a decided transaction command.
+func (sm *TxnStateMachine) Apply(learn *pb.LearnMsg) string {
+	var cmd TxnCommand
+	if err := json.Unmarshal([]byte(learn.GetVal().GetClientCommand()), &cmd); err != nil || cmd.ID == "" {
+		return TxnInvalid
+	}
+	id := txnKey{group: learn.GetGroupID(), txn: cmd.ID}
+	sm.mu.Lock()
+	defer sm.mu.Unlock()
+	switch cmd.Op {
+	case TxnPrepare:
+		return sm.prepare(id, cmd.Writes)
+	case TxnCommit:
+		sm.finish(id, true)
+		return TxnOK
+	case TxnAbort:
+		sm.finish(id, false)
+		return TxnOK
+	case TxnDecide:
+		if _, ok := sm.decisions[id]; !ok {
+			sm.decisions[id] = cmd.Commit
+		}
+		return outcome(sm.decisions[id])
+	}
+	return TxnInvalid
+}
+
+// prepare locks the written keys and stages the writes of the transaction.
+func (sm *TxnStateMachine) prepare(id txnKey, writes []TxnWrite) string {
+	if _, ok := sm.staged[id]; ok {
+		return TxnVoteYes // duplicate prepare
+	}
+	if _, ok := sm.finished[id]; ok {
+		return TxnVoteNo // the transaction was aborted before it was prepared here
+	}
+	for _, w := range writes {
+		if holder, ok := sm.locks[w.Key]; ok && holder != id.txn {
+			return TxnVoteNo
+		}
+	}
+	for _, w := range writes {
+		sm.locks[w.Key] = id.txn
+	}
+	sm.staged[id] = writes
+	return TxnVoteYes
+}
+
+// finish applies the outcome of the transaction, releasing its locks.
+func (sm *TxnStateMachine) finish(id txnKey, commit bool) {
+	if _, ok := sm.finished[id]; ok {
+		return // duplicate outcome
+	}
+	sm.finished[id] = commit
+	for _, w := range sm.staged[id] {
+		if commit {
+			if w.Delete {
+				delete(sm.data, w.Key)
+			} else {
+				sm.data[w.Key] = w.Value
+			}
+		}
+		delete(sm.locks, w.Key)
+	}
+	delete(sm.staged, id)
+}
+
+// locked returns the transaction holding the key's lock, if any.
+func (sm *TxnStateMachine) locked(key string) (string, bool) {
+	sm.mu.Lock()
+	defer sm.mu.Unlock()
+	txn, ok := sm.locks[key]
+	return txn, ok
+}
+
+func outcome(commit bool) string {
+	if commit {
+		return TxnCommitted
+	}
+	return TxnAborted
+}
+
+// ClientHandler sends client requests to the replicas and returns the
+// response once the request has been decided; pb.Configuration implements it.
+type ClientHandler interface {
+	ClientHandle(ctx context.Context, request *pb.Value) (*pb.Response, error)
+}
+
+// TxnClient runs cross-shard transactions against replicas running a
+// TxnStateMachine, using router to map keys to participant groups.
+// The coordinator group of a transaction is the group its ID maps to.
+type TxnClient struct {
+	clientID string
+	seq      atomic.Uint32
+	config   ClientHandler
+	router   *ShardRouter
+}
+
+// NewTxnClient returns a transaction client that sends requests as clientID.
+func NewTxnClient(clientID string, config ClientHandler, router *ShardRouter) *TxnClient {
+	return &TxnClient{clientID: clientID, config: config, router: router}
+}
+
+// Txn is a transaction; its writes are buffered until Commit is called.
+type Txn struct {
+	client *TxnClient
+	id     string
+	writes map[string]TxnWrite
+}
+
+// Begin starts a new transaction.
+func (c *TxnClient) Begin() *Txn {
+	return &Txn{
+		client: c,
+		id:     c.clientID + "/" + strconv.FormatUint(uint64(c.seq.Add(1)), 10),
+		writes: make(map[string]TxnWrite),
+	}
+}
+
+// ID returns the transaction's ID.
+func (t *Txn) ID() string {
+	return t.id
+}
+
+// Put sets the key to value when the transaction commits.
+func (t *Txn) Put(key, value string) {
+	t.writes[key] = TxnWrite{Key: key, Value: value}
+}
+
+// Delete deletes the key when the transaction commits.
+func (t *Txn) Delete(key string) {
+	t.writes[key] = TxnWrite{Key: key, Delete: true}
+}
+
+// Participants returns the sorted IDs of the groups written by the transaction.
+func (t *Txn) Participants() []uint32 {
+	participants := Keys(t.writesByGroup())
+	slices.Sort(participants)
+	return participants
+}
+
+func (t *Txn) writesByGroup() map[uint32][]TxnWrite {
+	keys := Keys(t.writes)
+	slices.Sort(keys)
+	groups := make(map[uint32][]TxnWrite)
+	for _, key := range keys {
+		group := t.client.router.Group(key)
+		groups[group] = append(groups[group], t.writes[key])
+	}
+	return groups
+}
+
+// Commit runs two-phase commit for the transaction. It returns ErrTxnAborted
+// if the transaction was aborted. Any other error means that the outcome may
+// be unknown; the transaction can then be finished with TxnClient.Resolve.
+func (t *Txn) Commit(ctx context.Context) error {
+	c := t.client
+	byGroup := t.writesByGroup()
+	participants := t.Participants()
+
+	votes := make([]bool, len(participants))
+	var wg errgroup.Group
+	for i, group := range participants {
+		wg.Go(func() error {
+			vote, err := c.submit(ctx, group, TxnCommand{Op: TxnPrepare, ID: t.id, Writes: byGroup[group]})
+			votes[i] = err == nil && vote == TxnVoteYes
+			return nil
+		})
+	}
+	_ = wg.Wait()
+
+	committed, err := c.decide(ctx, t.id, !slices.Contains(votes, false))
+	if err != nil {
+		return fmt.Errorf("transaction %s: %w", t.id, err)
+	}
+	if err := c.finish(ctx, t.id, participants, committed); err != nil {
+		return fmt.Errorf("transaction %s: %w", t.id, err)
+	}
+	if !committed {
+		return ErrTxnAborted
+	}
+	return nil
+}
+
+// Resolve finishes the transaction with the given ID and participant groups,
+// aborting it unless a commit decision has already been decided.
+// It returns true if the transaction committed.
+func (c *TxnClient) Resolve(ctx context.Context, txnID string, participants []uint32) (bool, error) {
+	committed, err := c.decide(ctx, txnID, false)
+	if err != nil {
+		return false, err
+	}
+	return committed, c.finish(ctx, txnID, participants, committed)
+}
+
+// decide proposes the outcome to the transaction's coordinator group and
+// returns the outcome decided by the coordinator.
+func (c *TxnClient) decide(ctx context.Context, txnID string, commit bool) (bool, error) {
+	result, err := c.submit(ctx, c.router.Group(txnID), TxnCommand{Op: TxnDecide, ID: txnID, Commit: commit})
+	if err != nil {
+		return false, fmt.Errorf("decide: %w", err)
+	}
+	switch result {
+	case TxnCommitted:
+		return true, nil
+	case TxnAborted:
+		return false, nil
+	}
+	return false, fmt.Errorf("decide: unexpected result %q", result)
+}
+
+// finish sends the outcome to all participant groups.
+func (c *TxnClient) finish(ctx context.Context, txnID string, participants []uint32, commit bool) error {
+	op := TxnAbort
+	if commit {
+		op = TxnCommit
+	}
+	var wg errgroup.Group
+	for _, group := range participants {
+		wg.Go(func() error {
+			if _, err := c.submit(ctx, group, TxnCommand{Op: op, ID: txnID}); err != nil {
+				return fmt.Errorf("%s at group %d: %w", op, group, err)
+			}
+			return nil
+		})
+	}
+	return wg.Wait()
+}
+
+// submit sends the command to the group and returns the result.
+func (c *TxnClient) submit(ctx context.Context, group uint32, cmd TxnCommand) (string, error) {
+	b, err := json.Marshal(cmd)
+	if err != nil {
+		return "", err
+	}
+	request := &pb.Value{
+		ClientID:      c.clientID,
+		ClientSeq:     c.seq.Add(1),
+		ClientCommand: string(b),
+		GroupID:       group,
+	}
+	rsp, err := c.config.ClientHandle(ctx, request)
+	if err != nil {
+		return "", err
+	}
+	return rsp.GetResult(), nil
+}
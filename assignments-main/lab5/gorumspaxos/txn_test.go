@@ -0,0 +1,244 @@
+package gorumspaxos
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"sync"
+	"testing"
+	"time"
+
+	pb "dat520/lab5/gorumspaxos/proto"
+
+	"github.com/google/go-cmp/cmp"
+	"github.com/relab/gorums"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/credentials/insecure"
+)
+
+// txnCluster is an in-memory stand-in for replicas running several Paxos
+// groups: each request is decided in the next slot of its group and applied
+// to the state machine of every replica, which must all return the same result.
+type txnCluster struct {
+	t        *testing.T
+	mu       sync.Mutex
+	replicas []*TxnStateMachine
+	slots    map[uint32]Slot
+	drop     func(cmd string) bool // requests for which drop returns true fail
+}
+
+func newTxnCluster(t *testing.T, numReplicas int) *txnCluster {
+	c := &txnCluster{t: t, slots: make(map[uint32]Slot)}
+	for i := 0; i < numReplicas; i++ {
+		c.replicas = append(c.replicas, NewTxnStateMachine())
+	}
+	return c
+}
+
+func (c *txnCluster) ClientHandle(ctx context.Context, request *pb.Value) (*pb.Response, error) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if c.drop != nil && c.drop(request.GetClientCommand()) {
+		return nil, errors.New("request dropped")
+	}
+	c.slots[request.GetGroupID()]++
+	learn := &pb.LearnMsg{GroupID: request.GetGroupID(), Slot: c.slots[request.GetGroupID()], Rnd: 1, Val: request}
+	result := c.replicas[0].Apply(learn)
+	for i, sm := range c.replicas[1:] {
+		if got := sm.Apply(learn); got != result {
+			c.t.Errorf("replica %d: Apply(%v) = %q, want %q", i+1, learn, got, result)
+		}
+	}
+	return &pb.Response{ClientID: request.GetClientID(), ClientSeq: request.GetClientSeq(), ClientCommand: request.GetClientCommand(), Result: result}, nil
+}
+
+// check verifies that every replica has the wanted data and holds no locks on its keys.
+func (c *txnCluster) check(want map[string]string) {
+	c.t.Helper()
+	for i, sm := range c.replicas {
+		if diff := cmp.Diff(want, sm.data); diff != "" {
+			c.t.Errorf("replica %d: data mismatch (-want +got):\n%s", i, diff)
+		}
+		for key := range want {
+			if txn, ok := sm.locked(key); ok {
+				c.t.Errorf("replica %d: key %q still locked by %s", i, key, txn)
+			}
+		}
+	}
+}
+
+func TestTxnCommitAcrossGroups(t *testing.T) {
+	cluster := newTxnCluster(t, 3)
+	client := NewTxnClient("A", cluster, NewShardRouterN(4))
+	ctx := context.Background()
+
+	want := make(map[string]string)
+	txn := client.Begin()
+	for i := 0; i < 20; i++ {
+		key := fmt.Sprintf("key-%d", i)
+		txn.Put(key, "v1")
+		want[key] = "v1"
+	}
+	if got := len(txn.Participants()); got < 2 {
+		t.Fatalf("transaction spans %d groups, want at least 2", got)
+	}
+	if err := txn.Commit(ctx); err != nil {
+		t.Fatalf("Commit() = %v, want nil", err)
+	}
+	cluster.check(want)
+
+	txn = client.Begin()
+	txn.Delete("key-0")
+	txn.Put("key-1", "v2")
+	delete(want, "key-0")
+	want["key-1"] = "v2"
+	if err := txn.Commit(ctx); err != nil {
+		t.Fatalf("Commit() = %v, want nil", err)
+	}
+	cluster.check(want)
+}
+
+func TestTxnConflictAborts(t *testing.T) {
+	cluster := newTxnCluster(t, 3)
+	router := NewShardRouterN(4)
+	alice := NewTxnClient("A", cluster, router)
+	bob := NewTxnClient("B", cluster, router)
+	ctx := context.Background()
+
+	// alice's transaction is prepared at x's group, but her client has not decided yet
+	first := alice.Begin()
+	first.Put("x", "alice")
+	if vote, err := alice.submit(ctx, router.Group("x"), TxnCommand{Op: TxnPrepare, ID: first.ID(), Writes: []TxnWrite{{Key: "x", Value: "alice"}}}); err != nil || vote != TxnVoteYes {
+		t.Fatalf("prepare = (%q, %v), want (%q, nil)", vote, err, TxnVoteYes)
+	}
+
+	second := bob.Begin()
+	for _, key := range []string{"x", "y", "z"} {
+		second.Put(key, "bob")
+	}
+	if err := second.Commit(ctx); !errors.Is(err, ErrTxnAborted) {
+		t.Fatalf("Commit() = %v, want %v", err, ErrTxnAborted)
+	}
+	// none of bob's writes are applied, and only alice's lock remains
+	cluster.check(map[string]string{})
+	if txn, ok := cluster.replicas[0].locked("x"); !ok || txn != first.ID() {
+		t.Errorf("key x locked by (%q, %t), want (%q, true)", txn, ok, first.ID())
+	}
+
+	if err := first.Commit(ctx); err != nil {
+		t.Fatalf("Commit() = %v, want nil", err)
+	}
+	cluster.check(map[string]string{"x": "alice"})
+}
+
+func TestTxnResolve(t *testing.T) {
+	cluster := newTxnCluster(t, 3)
+	router := NewShardRouterN(4)
+	alice := NewTxnClient("A", cluster, router)
+	bob := NewTxnClient("B", cluster, router)
+	ctx := context.Background()
+
+	// alice's client fails after the participants have prepared
+	cluster.drop = func(cmd string) bool { return !cmdHasOp(cmd, TxnPrepare) }
+	txn := alice.Begin()
+	txn.Put("x", "alice")
+	txn.Put("y", "alice")
+	if err := txn.Commit(ctx); err == nil || errors.Is(err, ErrTxnAborted) {
+		t.Fatalf("Commit() = %v, want outcome unknown error", err)
+	}
+	if _, ok := cluster.replicas[0].locked("x"); !ok {
+		t.Fatal("key x is not locked by the prepared transaction")
+	}
+	cluster.drop = nil
+
+	committed, err := bob.Resolve(ctx, txn.ID(), txn.Participants())
+	if err != nil || committed {
+		t.Fatalf("Resolve() = (%t, %v), want (false, nil)", committed, err)
+	}
+	cluster.check(map[string]string{})
+
+	// a retried commit from alice's client must respect the decided abort
+	if err := txn.Commit(ctx); !errors.Is(err, ErrTxnAborted) {
+		t.Fatalf("Commit() after Resolve = %v, want %v", err, ErrTxnAborted)
+	}
+
+	// once a commit is decided, Resolve cannot abort the transaction
+	cluster.drop = func(cmd string) bool { return cmdHasOp(cmd, TxnCommit) }
+	txn = alice.Begin()
+	txn.Put("x", "alice")
+	if err := txn.Commit(ctx); err == nil || errors.Is(err, ErrTxnAborted) {
+		t.Fatalf("Commit() = %v, want error from dropped commit", err)
+	}
+	cluster.drop = nil
+	committed, err = bob.Resolve(ctx, txn.ID(), txn.Participants())
+	if err != nil || !committed {
+		t.Fatalf("Resolve() = (%t, %v), want (true, nil)", committed, err)
+	}
+	cluster.check(map[string]string{"x": "alice"})
+}
+
+func cmdHasOp(cmd string, op TxnOp) bool {
+	var c TxnCommand
+	_ = json.Unmarshal([]byte(cmd), &c)
+	return c.Op == op
+}
+
+// TestTxnReplicas runs transactions on three replicas running four Paxos
+// groups each, with a transaction state machine per replica.
+func TestTxnReplicas(t *testing.T) {
+	const numServers, numGroups = 3, 4
+	sms := make([]*TxnStateMachine, numServers)
+	nodeMap, _ := serveReplicas(t, numServers, func(id int, nodeMap map[string]uint32) *ShardedReplica {
+		sms[id] = NewTxnStateMachine()
+		return NewShardedReplica(id, nodeMap, numGroups, WithStateMachine(sms[id]))
+	})
+	mgr := pb.NewManager(gorums.WithDialTimeout(5*time.Second),
+		gorums.WithGrpcDialOptions(
+			grpc.WithBlock(), // block until connections are made
+			grpc.WithTransportCredentials(insecure.NewCredentials()), // disable TLS
+		),
+	)
+	t.Cleanup(mgr.Close)
+	cfg, err := mgr.NewConfiguration(NewPaxosQSpec(numServers), gorums.WithNodeMap(nodeMap))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	client := NewTxnClient("A", cfg, NewShardRouterN(numGroups))
+	ctx, cancel := context.WithTimeout(context.Background(), 30*time.Second)
+	defer cancel()
+	txn := client.Begin()
+	txn.Put("x", "1")
+	txn.Put("y", "2")
+	txn.Put("z", "3")
+	if got := len(txn.Participants()); got < 2 {
+		t.Fatalf("transaction spans %d groups, want at least 2", got)
+	}
+	if err := txn.Commit(ctx); err != nil {
+		t.Fatalf("Commit() = %v, want nil", err)
+	}
+	txn = client.Begin()
+	txn.Delete("x")
+	txn.Put("y", "4")
+	if err := txn.Commit(ctx); err != nil {
+		t.Fatalf("Commit() = %v, want nil", err)
+	}
+
+	// a replica that is not part of the quorum may apply the commits later
+	want := map[string]string{"y": "4", "z": "3"}
+	for i, sm := range sms {
+		for deadline := time.Now().Add(5 * time.Second); ; time.Sleep(10 * time.Millisecond) {
+			sm.mu.Lock()
+			diff := cmp.Diff(want, sm.data)
+			sm.mu.Unlock()
+			if diff == "" {
+				break
+			}
+			if time.Now().After(deadline) {
+				t.Errorf("replica %d: data mismatch (-want +got):\n%s", i, diff)
+				break
+			}
+		}
+	}
+}
@@ -0,0 +1,123 @@
+package gorumspaxos
+
+import (
+	"context"
+
+	pb "dat520/lab5/gorumspaxos/proto"
+	wpb "dat520/lab5/gorumspaxos/proto/watch"
+
+	"github.com/relab/gorums"
+)
+
+// Watch streams the replica's decided log entries in slot order, starting at
+// the requested slot. Entries already decided are sent from the stored log,
+// followed by new entries as they are decided. The stream ends when the
+// client's connection is closed.
+func (r *PaxosReplica) Watch(ctx gorums.ServerCtx, req *wpb.WatchRequest, send func(*pb.LearnMsg) error) error {
+	// let the server handle the client's other requests while streaming
+	ctx.Release()
+	return r.watch(ctx, req.GetFromSlot(), send)
+}
+
+// watch sends the decided log entries from slot from onwards to send, in slot
+// order, until ctx is done or send fails.
+func (r *PaxosReplica) watch(ctx context.Context, from Slot, send func(*pb.LearnMsg) error) error {
+	next := max(from, 1)
+	for {
+		r.mu.Lock()
+		learns := make([]*pb.LearnMsg, 0)
+		for ; next <= r.allDecidedUpTo(); next++ {
+			learns = append(learns, r.learntVal[next])
+		}
+		decided := r.decided
+		r.mu.Unlock()
+		for _, learn := range learns {
+			if err := send(learn); err != nil {
+				return err
+			}
+		}
+		select {
+		case <-decided:
+		case <-ctx.Done():
+			return ctx.Err()
+		}
+	}
+}
+
+// LogWatcher follows the decided log of a replica's Paxos group.
+type LogWatcher struct {
+	mgr     *wpb.Manager
+	corr    *wpb.CorrectableStreamLearnMsg
+	entries chan *pb.LearnMsg
+	cancel  context.CancelFunc
+}
+
+// WatchLog starts streaming the decided log of the given Paxos group from the
+// replica at addr, starting at slot from. The manager options are the same as
+// for a gorums manager. Each watcher uses its own connection, which is closed
+// when ctx is done or Close is called; this also ends the stream at the replica.
+func WatchLog(ctx context.Context, addr string, group uint32, from Slot, opts ...gorums.ManagerOption) (*LogWatcher, error) {
+	ctx, cancel := context.WithCancel(ctx)
+	w := &LogWatcher{
+		mgr:     wpb.NewManager(opts...),
+		entries: make(chan *pb.LearnMsg),
+		cancel:  cancel,
+	}
+	cfg, err := w.mgr.NewConfiguration(&watchQSpec{ctx: ctx, entries: w.entries}, gorums.WithNodeList([]string{addr}))
+	if err != nil {
+		cancel()
+		w.mgr.Close()
+		return nil, err
+	}
+	w.corr = cfg.Watch(ctx, &wpb.WatchRequest{FromSlot: from, GroupID: group})
+	go func() {
+		// the quorum function is not called after the call is done
+		<-w.corr.Done()
+		close(w.entries)
+		w.mgr.Close()
+	}()
+	return w, nil
+}
+
+// Entries returns the channel of decided log entries, in slot order.
+// The channel is closed when the watch ends; Err then returns the reason.
+func (w *LogWatcher) Entries() <-chan *pb.LearnMsg {
+	return w.entries
+}
+
+// Err returns the reason the watch ended, or nil if it has not ended.
+func (w *LogWatcher) Err() error {
+	select {
+	case <-w.corr.Done():
+		_, _, err := w.corr.Get()
+		return err
+	default:
+		return nil
+	}
+}
+
+// Close ends the watch and closes its connection.
+func (w *LogWatcher) Close() {
+	w.cancel()
+}
+
+// watchQSpec passes each log entry streamed by the replica to the watcher's
+// entries channel. The watch never reaches a quorum, so that it lasts until
+// its context is done or the stream fails.
+type watchQSpec struct {
+	ctx     context.Context
+	entries chan<- *pb.LearnMsg
+}
+
+// WatchQF is called with the latest entry streamed by the replica.
+// It blocks until the entry is received from the entries channel,
+// so that a slow consumer slows down the stream rather than losing entries.
+func (q *watchQSpec) WatchQF(_ *wpb.WatchRequest, replies map[uint32]*pb.LearnMsg) (*pb.LearnMsg, int, bool) {
+	for _, learn := range replies {
+		select {
+		case q.entries <- learn:
+		case <-q.ctx.Done():
+		}
+	}
+	return nil, gorums.LevelNotSet, false
+}
@@ -0,0 +1,172 @@
+package gorumspaxos
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"testing"
+	"time"
+
+	pb "dat520/lab5/gorumspaxos/proto"
+
+	"github.com/google/go-cmp/cmp"
+	"github.com/relab/gorums"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/credentials/insecure"
+	"google.golang.org/protobuf/testing/protocmp"
+)
+
+func TestReplicaWatch(t *testing.T) {
+	r := newTestReplicaLeader()
+	learn := func(slot Slot) *pb.LearnMsg {
+		return &pb.LearnMsg{Slot: slot, Val: &pb.Value{ClientID: "c", ClientSeq: slot, ClientCommand: fmt.Sprint(slot)}}
+	}
+	for _, slot := range []Slot{1, 2, 4} {
+		r.Commit(gorums.ServerCtx{}, learn(slot))
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	got := make(chan *pb.LearnMsg)
+	done := make(chan error)
+	go func() {
+		done <- r.watch(ctx, 2, func(learn *pb.LearnMsg) error {
+			got <- learn
+			return nil
+		})
+	}()
+	recv := func(want Slot) {
+		t.Helper()
+		select {
+		case l := <-got:
+			if diff := cmp.Diff(learn(want), l, protocmp.Transform()); diff != "" {
+				t.Errorf("watch() mismatch (-want +got):\n%s", diff)
+			}
+		case <-time.After(time.Second):
+			t.Fatalf("watch() did not send slot %d", want)
+		}
+	}
+	// slot 2 is backfilled; slot 4 is not sent until slot 3 is decided
+	recv(2)
+	select {
+	case l := <-got:
+		t.Fatalf("watch() sent %v before slot 3 was decided", l)
+	case <-time.After(50 * time.Millisecond):
+	}
+	r.Commit(gorums.ServerCtx{}, learn(3))
+	recv(3)
+	recv(4)
+	r.Commit(gorums.ServerCtx{}, learn(5))
+	recv(5)
+	cancel()
+	if err := <-done; !errors.Is(err, context.Canceled) {
+		t.Errorf("watch() = %v, want %v", err, context.Canceled)
+	}
+
+	// a failing send ends the watch
+	errSend := errors.New("send failed")
+	err := r.watch(context.Background(), 0, func(*pb.LearnMsg) error { return errSend })
+	if !errors.Is(err, errSend) {
+		t.Errorf("watch() = %v, want %v", err, errSend)
+	}
+}
+
+func TestWatchLog(t *testing.T) {
+	nodeMap, teardown, _, _ := startReplicas(t, 3)
+	defer teardown()
+	config, closeMgr, err := newConfiguration(nodeMap)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer closeMgr()
+	ctx, cancel := context.WithTimeout(context.Background(), 30*time.Second)
+	defer cancel()
+
+	sent := make([]*pb.Value, 0)
+	submit := func(n int) {
+		t.Helper()
+		for range n {
+			req := &pb.Value{ClientID: "watch", ClientSeq: uint32(len(sent)), ClientCommand: fmt.Sprint(len(sent))}
+			if _, err := config.ClientHandle(ctx, req); err != nil {
+				t.Fatal(err)
+			}
+			sent = append(sent, req)
+		}
+	}
+	// recv receives entries from the watcher until it has received n client
+	// requests, checking that the slots are consecutive from the first slot.
+	recv := func(w *LogWatcher, slot Slot, n int) []*pb.Value {
+		t.Helper()
+		vals := make([]*pb.Value, 0, n)
+		for len(vals) < n {
+			select {
+			case learn, ok := <-w.Entries():
+				if !ok {
+					t.Fatalf("watch ended: %v", w.Err())
+				}
+				if learn.GetSlot() != slot {
+					t.Fatalf("got slot %d, want %d", learn.GetSlot(), slot)
+				}
+				slot++
+				if !learn.GetVal().GetIsNoop() {
+					vals = append(vals, learn.GetVal())
+				}
+			case <-ctx.Done():
+				t.Fatalf("received %d of %d entries", len(vals), n)
+			}
+		}
+		return vals
+	}
+	submit(3)
+
+	opts := []gorums.ManagerOption{
+		gorums.WithDialTimeout(5 * time.Second),
+		gorums.WithGrpcDialOptions(
+			grpc.WithBlock(), // block until connections are made
+			grpc.WithTransportCredentials(insecure.NewCredentials()), // disable TLS
+		),
+	}
+	addrs := Keys(nodeMap)
+	watchers := make([]*LogWatcher, len(addrs))
+	for i, addr := range addrs {
+		w, err := WatchLog(ctx, addr, 0, 1, opts...)
+		if err != nil {
+			t.Fatal(err)
+		}
+		defer w.Close()
+		watchers[i] = w
+	}
+	// each replica backfills the decided requests, then streams new ones
+	for i, w := range watchers {
+		if diff := cmp.Diff(sent, recv(w, 1, 3), protocmp.Transform()); diff != "" {
+			t.Errorf("replica %s: backfilled entries mismatch (-want +got):\n%s", addrs[i], diff)
+		}
+	}
+	submit(2)
+	for i, w := range watchers {
+		if diff := cmp.Diff(sent[3:], recv(w, 4, 2), protocmp.Transform()); diff != "" {
+			t.Errorf("replica %s: streamed entries mismatch (-want +got):\n%s", addrs[i], diff)
+		}
+	}
+
+	// a watch can start in the middle of the log
+	w, err := WatchLog(ctx, addrs[0], 0, 4, opts...)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if diff := cmp.Diff(sent[3:], recv(w, 4, 2), protocmp.Transform()); diff != "" {
+		t.Errorf("watch from slot 4 mismatch (-want +got):\n%s", diff)
+	}
+	w.Close()
+	select {
+	case _, ok := <-w.Entries():
+		if ok {
+			t.Error("entry received after Close")
+		}
+	case <-time.After(5 * time.Second):
+		t.Error("entries not closed after Close")
+	}
+	if w.Err() == nil {
+		t.Error("Err() = nil after Close")
+	}
+}